@@ -0,0 +1,409 @@
+// Package archive exports daily partitions to gzipped CSV files before they
+// are dropped, keeps a manifest of archived partitions and restores archives
+// as standalone tables.
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/seuros/kaunta/internal/database"
+)
+
+// Format is the archive file format recorded in the manifest
+const Format = "csv.gz"
+
+// FileName returns the archive file name of a partition
+func FileName(p database.Partition) string {
+	return p.Name() + "." + Format
+}
+
+// ParseFileName returns the partition an archive file name belongs to
+func ParseFileName(name string) (database.Partition, error) {
+	return database.ParsePartition(strings.TrimSuffix(filepath.Base(name), "."+Format))
+}
+
+// Entry is an archived partition in the manifest
+type Entry struct {
+	Partition  database.Partition
+	Location   string
+	Format     string
+	Rows       int64
+	SizeBytes  int64
+	SHA256     string
+	ArchivedAt time.Time
+}
+
+// columns returns the stored columns of table in order
+func columns(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT attname
+		FROM pg_attribute
+		WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped AND attgenerated = ''
+		ORDER BY attnum
+	`, pq.QuoteIdentifier(table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var cols []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, err
+		}
+		cols = append(cols, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %s has no columns", table)
+	}
+	return cols, nil
+}
+
+// Export writes table as gzipped CSV with a header line to w and returns
+// the number of rows written. Values are exported in their text form, so
+// they load back into the same column types.
+func Export(ctx context.Context, db *sql.DB, table string, w io.Writer) (int64, error) {
+	cols, err := columns(ctx, db, table)
+	if err != nil {
+		return 0, err
+	}
+
+	selects := make([]string, len(cols))
+	for i, col := range cols {
+		selects[i] = pq.QuoteIdentifier(col) + "::text"
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s",
+		strings.Join(selects, ", "), pq.QuoteIdentifier(table)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	gz := gzip.NewWriter(w)
+	bw := bufio.NewWriterSize(gz, 64*1024)
+
+	header := make([]*string, len(cols))
+	for i := range cols {
+		header[i] = &cols[i]
+	}
+	if err := writeRecord(bw, header); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	values := make([]sql.NullString, len(cols))
+	dest := make([]any, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	fields := make([]*string, len(cols))
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return count, err
+		}
+		for i, v := range values {
+			fields[i] = nil
+			if v.Valid {
+				fields[i] = &values[i].String
+			}
+		}
+		if err := writeRecord(bw, fields); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return count, err
+	}
+	return count, gz.Close()
+}
+
+// Import copies a gzipped CSV archive read from r into table within tx and
+// returns the number of rows loaded. The header must only name columns of
+// the table.
+func Import(ctx context.Context, tx *sql.Tx, table string, r io.Reader) (int64, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, fmt.Errorf("not a gzipped archive: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+	br := bufio.NewReaderSize(gz, 64*1024)
+
+	header, err := readRecord(br)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read archive header: %w", err)
+	}
+	cols := make([]string, len(header))
+	for i, h := range header {
+		if h == nil || *h == "" {
+			return 0, fmt.Errorf("archive header has an empty column name")
+		}
+		cols[i] = *h
+	}
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, cols...))
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	args := make([]any, len(cols))
+	for {
+		record, err := readRecord(br)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = stmt.Close()
+			return count, fmt.Errorf("line %d: %w", count+2, err)
+		}
+		if len(record) != len(cols) {
+			_ = stmt.Close()
+			return count, fmt.Errorf("line %d: expected %d fields, got %d", count+2, len(cols), len(record))
+		}
+		for i, f := range record {
+			args[i] = nil
+			if f != nil {
+				args[i] = *f
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			_ = stmt.Close()
+			return count, err
+		}
+		count++
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		_ = stmt.Close()
+		return count, err
+	}
+	return count, stmt.Close()
+}
+
+// Archiver exports partitions to a Store and records them in the manifest
+type Archiver struct {
+	DB    *sql.DB
+	Store Store
+}
+
+// NewArchiver returns an archiver writing to the store configured in opts
+func NewArchiver(db *sql.DB, opts Options) (*Archiver, error) {
+	store, err := NewStore(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Archiver{DB: db, Store: store}, nil
+}
+
+// Archive exports the partition and records it in the manifest. The export
+// is staged in a temporary file, so nothing is stored unless it completed.
+func (a *Archiver) Archive(ctx context.Context, p database.Partition) (*Entry, error) {
+	tmp, err := os.CreateTemp("", "kaunta-archive-*."+Format)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	h := sha256.New()
+	rows, err := Export(ctx, a.DB, p.Name(), io.MultiWriter(tmp, h))
+	if err != nil {
+		return nil, fmt.Errorf("failed to export %s: %w", p.Name(), err)
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	location, err := a.Store.Put(ctx, FileName(p), tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", FileName(p), err)
+	}
+
+	entry := &Entry{
+		Partition: p,
+		Location:  location,
+		Format:    Format,
+		Rows:      rows,
+		SizeBytes: size,
+		SHA256:    hex.EncodeToString(h.Sum(nil)),
+	}
+	if err := RecordEntry(ctx, a.DB, entry); err != nil {
+		return nil, fmt.Errorf("%s was stored at %s but not recorded: %w", p.Name(), location, err)
+	}
+	return entry, nil
+}
+
+// EnsureArchived archives the partition unless the manifest already has it
+// and returns its manifest entry
+func (a *Archiver) EnsureArchived(ctx context.Context, p database.Partition) (*Entry, error) {
+	entry, err := GetEntry(ctx, a.DB, p.Name())
+	if err != nil || entry != nil {
+		return entry, err
+	}
+	return a.Archive(ctx, p)
+}
+
+// RecordLocation records the configured archive location, or clears it when
+// location is empty. While a location is recorded, cleanup_old_partitions()
+// keeps partitions that were never archived.
+func RecordLocation(ctx context.Context, db *sql.DB, location string) error {
+	if location == "" {
+		_, err := db.ExecContext(ctx, `DELETE FROM partition_archive_location`)
+		return err
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO partition_archive_location (location) VALUES ($1)
+		ON CONFLICT (singleton) DO UPDATE SET location = EXCLUDED.location, updated_at = NOW()
+	`, location)
+	return err
+}
+
+// RecordEntry adds the entry to the manifest, replacing an earlier archive
+// of the same partition
+func RecordEntry(ctx context.Context, db *sql.DB, e *Entry) error {
+	p := e.Partition
+	return db.QueryRowContext(ctx, `
+		INSERT INTO partition_archive (partition_name, table_name, range_start, range_end, location, format, row_count, size_bytes, sha256)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (partition_name) DO UPDATE SET
+			location = EXCLUDED.location,
+			format = EXCLUDED.format,
+			row_count = EXCLUDED.row_count,
+			size_bytes = EXCLUDED.size_bytes,
+			sha256 = EXCLUDED.sha256,
+			archived_at = NOW()
+		RETURNING archived_at
+	`, p.Name(), p.Table, p.Date.Format("2006-01-02"), p.Date.AddDate(0, 0, 1).Format("2006-01-02"),
+		e.Location, e.Format, e.Rows, e.SizeBytes, e.SHA256).Scan(&e.ArchivedAt)
+}
+
+const entryColumns = `partition_name, location, format, row_count, size_bytes, sha256, archived_at`
+
+func scanEntry(scan func(dest ...any) error) (*Entry, error) {
+	var e Entry
+	var name string
+	if err := scan(&name, &e.Location, &e.Format, &e.Rows, &e.SizeBytes, &e.SHA256, &e.ArchivedAt); err != nil {
+		return nil, err
+	}
+	p, err := database.ParsePartition(name)
+	if err != nil {
+		return nil, err
+	}
+	e.Partition = p
+	return &e, nil
+}
+
+// ListEntries returns the manifest for table (all tables when empty),
+// newest partition first
+func ListEntries(ctx context.Context, db *sql.DB, table string) ([]Entry, error) {
+	if table != "" && !slices.Contains(database.PartitionedTables, table) {
+		return nil, fmt.Errorf("%s is not a partitioned table (use one of %v)", table, database.PartitionedTables)
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+entryColumns+`
+		FROM partition_archive
+		WHERE $1 = '' OR table_name = $1
+		ORDER BY range_start DESC, table_name
+	`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the archive manifest: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []Entry
+	for rows.Next() {
+		e, err := scanEntry(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+// GetEntry returns the manifest entry of a partition, or nil if it was never
+// archived
+func GetEntry(ctx context.Context, db *sql.DB, partition string) (*Entry, error) {
+	row := db.QueryRowContext(ctx, `SELECT `+entryColumns+` FROM partition_archive WHERE partition_name = $1`, partition)
+	e, err := scanEntry(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return e, err
+}
+
+// ErrTableExists is returned when restoring over an existing table
+var ErrTableExists = errors.New("table already exists")
+
+// Restore loads an archive of partition p, read from r, into a new
+// standalone table named after the partition and returns the number of rows
+// restored. When wantSHA256 is set the archive must match it. The table is
+// created like the parent, including its generated columns and indexes, so
+// it can be attached, but is not attached.
+func Restore(ctx context.Context, db *sql.DB, p database.Partition, r io.Reader, wantSHA256 string) (int64, error) {
+	name := p.Name()
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, pq.QuoteIdentifier(name)).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists {
+		return 0, fmt.Errorf("%s: %w", name, ErrTableExists)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (LIKE %s INCLUDING ALL)",
+		pq.QuoteIdentifier(name), pq.QuoteIdentifier(p.Table))); err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	h := sha256.New()
+	tee := io.TeeReader(r, h)
+	rows, err := Import(ctx, tx, name, tee)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load %s: %w", name, err)
+	}
+	// Hash any trailing bytes gzip didn't need
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return 0, err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); wantSHA256 != "" && got != wantSHA256 {
+		return 0, fmt.Errorf("archive checksum %s does not match the manifest (%s)", got, wantSHA256)
+	}
+
+	return rows, tx.Commit()
+}
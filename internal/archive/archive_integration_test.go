@@ -0,0 +1,73 @@
+//go:build integration
+
+package archive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/test"
+)
+
+// TestArchiveRestoreAttach_Integration round-trips a website_event partition
+// through the real schema: archive, drop, restore and attach it again
+func TestArchiveRestoreAttach_Integration(t *testing.T) {
+	testDB := test.NewTestDB(t)
+	defer func() { _ = testDB.Close() }()
+
+	originalDB := database.DB
+	database.DB = testDB.DB
+	t.Cleanup(func() {
+		database.DB = originalDB
+	})
+
+	ctx := context.Background()
+	websiteID, sessionID, eventID := uuid.New(), uuid.New(), uuid.New()
+	createdAt := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	require.NoError(t, testDB.Exec(ctx, `INSERT INTO website (website_id, domain) VALUES ($1, 'example.com')`, websiteID))
+	require.NoError(t, testDB.Exec(ctx, `INSERT INTO session (session_id, website_id) VALUES ($1, $2)`, sessionID, websiteID))
+
+	p := database.Partition{Table: database.EventTable, Date: createdAt}
+	require.NoError(t, p.Ensure())
+	require.NoError(t, testDB.Exec(ctx, `
+		INSERT INTO website_event (event_id, website_id, session_id, visit_id, created_at, url_path, event_type, event_name)
+		VALUES ($1, $2, $3, $4, $5, '/signup', 2, 'signup')
+	`, eventID, websiteID, sessionID, uuid.New(), createdAt))
+
+	archiver := &Archiver{DB: testDB.DB, Store: &LocalStore{Dir: t.TempDir()}}
+	entry, err := archiver.Archive(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), entry.Rows)
+
+	info, err := database.GetPartition(ctx, p.Name())
+	require.NoError(t, err)
+	require.NoError(t, info.Drop(ctx, 5*time.Second))
+
+	f, err := OpenLocation(ctx, entry.Location, Options{})
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	rows, err := Restore(ctx, testDB.DB, p, f, entry.SHA256)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), rows)
+
+	info, err = database.GetPartition(ctx, p.Name())
+	require.NoError(t, err)
+	require.NoError(t, info.Attach(ctx, 5*time.Second))
+
+	var eventDate time.Time
+	var eventHour int
+	var isCustom bool
+	require.NoError(t, testDB.QueryRow(ctx, `
+		SELECT event_date, event_hour, is_custom_event FROM website_event WHERE event_id = $1
+	`, eventID).Scan(&eventDate, &eventHour, &isCustom))
+	assert.Equal(t, "2026-01-15", eventDate.Format("2006-01-02"))
+	assert.Equal(t, 10, eventHour)
+	assert.True(t, isCustom)
+}
@@ -0,0 +1,223 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/database"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestCSVRoundTrip(t *testing.T) {
+	records := [][]*string{
+		{ptr("event_id"), ptr("url_path"), ptr("referrer_domain")},
+		{ptr("1"), ptr(`/search?q="kaunta", fast`), nil},
+		{ptr("2"), ptr(""), ptr("line\nbreak")},
+	}
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	for _, r := range records {
+		require.NoError(t, writeRecord(w, r))
+	}
+	require.NoError(t, w.Flush())
+	assert.Equal(t, `"1","/search?q=""kaunta"", fast",`, strings.Split(buf.String(), "\n")[1])
+
+	r := bufio.NewReader(&buf)
+	for _, want := range records {
+		got, err := readRecord(r)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := readRecord(r)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadRecordRejectsUnterminatedQuote(t *testing.T) {
+	_, err := readRecord(bufio.NewReader(strings.NewReader(`"1","open`)))
+	assert.ErrorContains(t, err, "unterminated")
+}
+
+func TestParseFileName(t *testing.T) {
+	p, err := ParseFileName("s3://kaunta-archive/events/website_event_2026_01_15.csv.gz")
+	require.NoError(t, err)
+	assert.Equal(t, database.EventTable, p.Table)
+	assert.Equal(t, "website_event_2026_01_15", p.Name())
+	assert.Equal(t, "website_event_2026_01_15.csv.gz", FileName(p))
+
+	_, err = ParseFileName("/tmp/session.csv.gz")
+	assert.Error(t, err)
+}
+
+func newMock(t *testing.T) (sqlmock.Sqlmock, *Archiver, *LocalStore) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	store := &LocalStore{Dir: t.TempDir()}
+	return mock, &Archiver{DB: db, Store: store}, store
+}
+
+func TestArchiveExportsAndRecordsPartition(t *testing.T) {
+	mock, archiver, store := newMock(t)
+	p := database.Partition{Table: database.EventTable, Date: time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)}
+
+	mock.ExpectQuery("FROM pg_attribute").
+		WithArgs(`"website_event_2026_01_15"`).
+		WillReturnRows(sqlmock.NewRows([]string{"attname"}).AddRow("event_id").AddRow("url_path"))
+	mock.ExpectQuery(`SELECT "event_id"::text, "url_path"::text FROM "website_event_2026_01_15"`).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "url_path"}).
+			AddRow("a", "/").
+			AddRow("b", nil))
+	mock.ExpectQuery("INSERT INTO partition_archive").
+		WithArgs("website_event_2026_01_15", "website_event", "2026-01-15", "2026-01-16",
+			sqlmock.AnyArg(), Format, int64(2), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"archived_at"}).AddRow(time.Now()))
+
+	entry, err := archiver.Archive(context.Background(), p)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, int64(2), entry.Rows)
+	assert.Contains(t, entry.Location, store.Dir)
+	assert.True(t, strings.HasSuffix(entry.Location, "website_event_2026_01_15.csv.gz"))
+
+	f, err := OpenLocation(context.Background(), entry.Location, Options{})
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), entry.SHA256)
+	assert.Equal(t, int64(len(data)), entry.SizeBytes)
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	csv, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "\"event_id\",\"url_path\"\n\"a\",\"/\"\n\"b\",\n", string(csv))
+}
+
+func TestEnsureArchivedSkipsArchivedPartition(t *testing.T) {
+	mock, archiver, _ := newMock(t)
+	p, err := database.ParsePartition("website_event_2026_01_15")
+	require.NoError(t, err)
+
+	mock.ExpectQuery("FROM partition_archive WHERE partition_name").
+		WithArgs("website_event_2026_01_15").
+		WillReturnRows(sqlmock.NewRows([]string{"partition_name", "location", "format", "row_count", "size_bytes", "sha256", "archived_at"}).
+			AddRow("website_event_2026_01_15", "/backups/website_event_2026_01_15.csv.gz", Format, 2, 100, strings.Repeat("0", 64), time.Now()))
+
+	entry, err := archiver.EnsureArchived(context.Background(), p)
+	require.NoError(t, err)
+	assert.Equal(t, "/backups/website_event_2026_01_15.csv.gz", entry.Location)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRecordLocation(t *testing.T) {
+	mock, archiver, _ := newMock(t)
+
+	mock.ExpectExec("INSERT INTO partition_archive_location").
+		WithArgs("s3://kaunta-archive/events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM partition_archive_location").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, RecordLocation(context.Background(), archiver.DB, "s3://kaunta-archive/events"))
+	require.NoError(t, RecordLocation(context.Background(), archiver.DB, ""))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestRestoreLoadsArchiveIntoNewTable(t *testing.T) {
+	mock, archiver, _ := newMock(t)
+	p, err := database.ParsePartition("website_event_2026_01_15")
+	require.NoError(t, err)
+	data := gzipped(t, "\"event_id\",\"url_path\"\n\"a\",\"/\"\n\"b\",\n")
+	sum := sha256.Sum256(data)
+
+	mock.ExpectQuery("SELECT to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE "website_event_2026_01_15" \(LIKE "website_event" INCLUDING ALL\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	copyIn := mock.ExpectPrepare(`COPY "website_event_2026_01_15" \("event_id", "url_path"\) FROM STDIN`)
+	copyIn.ExpectExec().WithArgs("a", "/").WillReturnResult(sqlmock.NewResult(0, 1))
+	copyIn.ExpectExec().WithArgs("b", nil).WillReturnResult(sqlmock.NewResult(0, 1))
+	copyIn.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	rows, err := Restore(context.Background(), archiver.DB, p, bytes.NewReader(data), hex.EncodeToString(sum[:]))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), rows)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreRejectsChecksumMismatch(t *testing.T) {
+	mock, archiver, _ := newMock(t)
+	p, err := database.ParsePartition("website_event_2026_01_15")
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+	copyIn := mock.ExpectPrepare("COPY")
+	copyIn.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err = Restore(context.Background(), archiver.DB, p, bytes.NewReader(gzipped(t, "\"event_id\"\n")), strings.Repeat("0", 64))
+	assert.ErrorContains(t, err, "does not match the manifest")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreRefusesExistingTable(t *testing.T) {
+	mock, archiver, _ := newMock(t)
+	p, err := database.ParsePartition("website_event_2026_01_15")
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT to_regclass").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	_, err = Restore(context.Background(), archiver.DB, p, strings.NewReader(""), "")
+	assert.ErrorIs(t, err, ErrTableExists)
+}
+
+func TestNewStore(t *testing.T) {
+	_, err := NewStore(Options{})
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	store, err := NewStore(Options{Location: "/var/backups/kaunta"})
+	require.NoError(t, err)
+	assert.IsType(t, &LocalStore{}, store)
+
+	store, err = NewStore(Options{Location: "s3://kaunta-archive/events/", Endpoint: "http://localhost:9000/"})
+	require.NoError(t, err)
+	s3, ok := store.(*S3Store)
+	require.True(t, ok)
+	assert.Equal(t, "kaunta-archive", s3.Bucket)
+	assert.Equal(t, "events", s3.Prefix)
+	assert.Equal(t, "http://localhost:9000", s3.Endpoint)
+
+	_, err = NewStore(Options{Location: "s3://"})
+	assert.ErrorContains(t, err, "missing bucket")
+}
@@ -0,0 +1,100 @@
+package archive
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Archives use PostgreSQL's CSV conventions so they can also be loaded with
+// psql's \copy ... CSV HEADER: every value is quoted and NULL is an unquoted
+// empty field. encoding/csv can't tell the two apart, hence this codec.
+
+// writeRecord writes one CSV line; nil fields are NULL
+func writeRecord(w *bufio.Writer, fields []*string) error {
+	for i, f := range fields {
+		if i > 0 {
+			if err := w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if f == nil {
+			continue
+		}
+		if _, err := w.WriteString(`"` + strings.ReplaceAll(*f, `"`, `""`) + `"`); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte('\n')
+}
+
+// readRecord reads one CSV line; unquoted empty fields are returned as nil.
+// It returns io.EOF when there are no more records.
+func readRecord(r *bufio.Reader) ([]*string, error) {
+	var fields []*string
+	var field strings.Builder
+	quoted, inQuotes, started := false, false, false
+
+	emit := func() {
+		if quoted || field.Len() > 0 {
+			s := field.String()
+			fields = append(fields, &s)
+		} else {
+			fields = append(fields, nil)
+		}
+		field.Reset()
+		quoted = false
+	}
+
+	for {
+		c, err := r.ReadByte()
+		if err == io.EOF {
+			if inQuotes {
+				return nil, errors.New("unterminated quoted field")
+			}
+			if !started {
+				return nil, io.EOF
+			}
+			emit()
+			return fields, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		started = true
+
+		if inQuotes {
+			if c != '"' {
+				field.WriteByte(c)
+				continue
+			}
+			next, err := r.Peek(1)
+			if err == nil && next[0] == '"' {
+				_, _ = r.ReadByte()
+				field.WriteByte('"')
+				continue
+			}
+			inQuotes = false
+			continue
+		}
+
+		switch c {
+		case '"':
+			if field.Len() > 0 {
+				return nil, fmt.Errorf("unexpected quote in field %d", len(fields)+1)
+			}
+			inQuotes, quoted = true, true
+		case ',':
+			emit()
+		case '\r':
+			// Tolerate CRLF line endings
+		case '\n':
+			emit()
+			return fields, nil
+		default:
+			field.WriteByte(c)
+		}
+	}
+}
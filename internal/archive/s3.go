@@ -0,0 +1,191 @@
+package archive
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Store stores archives in an S3-compatible bucket (AWS S3, MinIO, R2, ...)
+// using path-style requests signed with AWS Signature Version 4.
+type S3Store struct {
+	Endpoint  string // e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	Region    string
+	Bucket    string
+	Prefix    string
+	AccessKey string
+	SecretKey string
+
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// emptySHA256 is the payload hash of requests without a body
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func newS3Store(bucket, prefix string, opts Options) *S3Store {
+	region := opts.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	return &S3Store{
+		Endpoint:   strings.TrimRight(endpoint, "/"),
+		Region:     region,
+		Bucket:     bucket,
+		Prefix:     strings.Trim(prefix, "/"),
+		AccessKey:  opts.AccessKey,
+		SecretKey:  opts.SecretKey,
+		httpClient: &http.Client{Timeout: 30 * time.Minute},
+		now:        time.Now,
+	}
+}
+
+func (s *S3Store) key(name string) string {
+	if s.Prefix == "" {
+		return name
+	}
+	return s.Prefix + "/" + name
+}
+
+// Put uploads body as name and returns its s3:// location
+func (s *S3Store) Put(ctx context.Context, name string, body io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	size, err := io.Copy(h, body)
+	if err != nil {
+		return "", err
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	key := s.key(name)
+	req, err := s.newRequest(ctx, http.MethodPut, key, body, hex.EncodeToString(h.Sum(nil)))
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", s.responseError("upload", resp)
+	}
+	return fmt.Sprintf("s3://%s/%s", s.Bucket, key), nil
+}
+
+// Open downloads the object at key
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil, emptySHA256)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, s.responseError("download", resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3Store) responseError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s failed: %s: %s", op, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// newRequest builds a signed path-style request for key
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader, payloadHash string) (*http.Request, error) {
+	base, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	path := strings.TrimRight(base.Path, "/") + "/" + s.Bucket + "/" + key
+	u := *base
+	u.Path = path
+	u.RawPath = uriEncodePath(path)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	s.sign(req, payloadHash)
+	return req, nil
+}
+
+// sign adds SigV4 headers for the request
+func (s *S3Store) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.SecretKey, date, s.Region, "s3"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=%s",
+		s.AccessKey, scope, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// signingKey derives the SigV4 signing key for a day, region and service
+func signingKey(secret, date, region, service string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), date)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, service)
+	return hmacSHA256(k, "aws4_request")
+}
+
+// uriEncodePath escapes a path as SigV4 expects: everything but unreserved
+// characters and '/'
+func uriEncodePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			(c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package archive
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+	assert.Equal(t, "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9", hex.EncodeToString(key))
+}
+
+func TestURIEncodePath(t *testing.T) {
+	assert.Equal(t, "/bucket/a%20b/c%2Bd~e.csv.gz", uriEncodePath("/bucket/a b/c+d~e.csv.gz"))
+}
+
+// fakeS3 is an in-memory bucket checking that requests are signed
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=minio/20260115/us-east-1/s3/aws4_request") ||
+		r.Header.Get("X-Amz-Content-Sha256") == "" {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(body)
+	}
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	opts := Options{Location: "s3://kaunta-archive/events", Endpoint: srv.URL, AccessKey: "minio", SecretKey: "minio123"}
+	store, err := NewStore(opts)
+	require.NoError(t, err)
+	s3 := store.(*S3Store)
+	s3.now = func() time.Time { return time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC) }
+
+	location, err := s3.Put(context.Background(), "website_event_2026_01_15.csv.gz", strings.NewReader("archive"))
+	require.NoError(t, err)
+	assert.Equal(t, "s3://kaunta-archive/events/website_event_2026_01_15.csv.gz", location)
+	assert.Equal(t, []byte("archive"), fake.objects["/kaunta-archive/events/website_event_2026_01_15.csv.gz"])
+
+	r, err := s3.Open(context.Background(), "events/website_event_2026_01_15.csv.gz")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	_ = r.Close()
+	assert.Equal(t, "archive", string(data))
+
+	_, err = s3.Open(context.Background(), "events/missing.csv.gz")
+	assert.ErrorContains(t, err, "404")
+}
@@ -0,0 +1,104 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options configures where archives are written
+type Options struct {
+	// Location is a local directory or s3://bucket/prefix
+	Location string
+	// Endpoint of the S3-compatible service, e.g. http://localhost:9000 for
+	// MinIO; defaults to AWS S3 in Region
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// ErrNotConfigured is returned when no archive location is set
+var ErrNotConfigured = errors.New("no archive location configured (set ARCHIVE_LOCATION)")
+
+// Store saves archive files
+type Store interface {
+	// Put saves body as name and returns the location it was written to
+	Put(ctx context.Context, name string, body io.ReadSeeker) (string, error)
+}
+
+// LocalStore writes archives to a directory
+type LocalStore struct {
+	Dir string
+}
+
+// Put writes body to Dir/name through a temporary file, so a partial
+// archive never has the final name
+func (s *LocalStore) Put(ctx context.Context, name string, body io.ReadSeeker) (string, error) {
+	if err := os.MkdirAll(s.Dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	path := filepath.Join(s.Dir, name)
+	tmp, err := os.CreateTemp(s.Dir, "."+name+".*")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		return "", err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// NewStore returns the store for opts.Location
+func NewStore(opts Options) (Store, error) {
+	if opts.Location == "" {
+		return nil, ErrNotConfigured
+	}
+	if bucket, prefix, ok := parseS3URL(opts.Location); ok {
+		if bucket == "" {
+			return nil, fmt.Errorf("invalid archive location %q: missing bucket", opts.Location)
+		}
+		return newS3Store(bucket, prefix, opts), nil
+	}
+	return &LocalStore{Dir: opts.Location}, nil
+}
+
+// OpenLocation opens an archive by file path or s3:// URL. S3 credentials
+// and endpoint are taken from opts.
+func OpenLocation(ctx context.Context, location string, opts Options) (io.ReadCloser, error) {
+	bucket, key, ok := parseS3URL(location)
+	if !ok {
+		return os.Open(location)
+	}
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid archive location %q", location)
+	}
+	return newS3Store(bucket, "", opts).Open(ctx, key)
+}
+
+// parseS3URL splits s3://bucket/key into bucket and key
+func parseS3URL(location string) (bucket, key string, ok bool) {
+	rest, ok := strings.CutPrefix(location, "s3://")
+	if !ok {
+		return "", "", false
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	return bucket, strings.Trim(key, "/"), true
+}
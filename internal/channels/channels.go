@@ -0,0 +1,281 @@
+// Package channels normalizes referrers to source names and groups traffic into
+// marketing channels (Direct, Organic Search, Paid Search, Social, Email,
+// Referral, AI assistants).
+package channels
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Channel names
+const (
+	Direct        = "Direct"
+	OrganicSearch = "Organic Search"
+	PaidSearch    = "Paid Search"
+	Social        = "Social"
+	Email         = "Email"
+	Referral      = "Referral"
+	AIAssistants  = "AI assistants"
+)
+
+// All lists the channels in display order
+var All = []string{Direct, OrganicSearch, PaidSearch, Social, Email, Referral, AIAssistants}
+
+// Rule maps referrers matching Pattern to a source name and channel.
+//
+// Pattern is a host: "facebook.com" matches facebook.com and its subdomains
+// (l.facebook.com), "google.*" matches the google label under any suffix
+// (google.de, news.google.co.uk). Rules also match utm_source values equal to
+// the pattern's name or the source name, case-insensitively.
+type Rule struct {
+	Pattern string `json:"pattern"`
+	Source  string `json:"source"`
+	Channel string `json:"channel"`
+}
+
+// Builtin is the default rule set; per-website rules are checked first
+var Builtin = []Rule{
+	// Search engines
+	{"google.*", "Google", OrganicSearch},
+	{"bing.com", "Bing", OrganicSearch},
+	{"duckduckgo.com", "DuckDuckGo", OrganicSearch},
+	{"search.yahoo.com", "Yahoo", OrganicSearch},
+	{"yandex.*", "Yandex", OrganicSearch},
+	{"baidu.com", "Baidu", OrganicSearch},
+	{"ecosia.org", "Ecosia", OrganicSearch},
+	{"search.brave.com", "Brave Search", OrganicSearch},
+	{"qwant.com", "Qwant", OrganicSearch},
+	{"startpage.com", "Startpage", OrganicSearch},
+	{"kagi.com", "Kagi", OrganicSearch},
+	{"naver.com", "Naver", OrganicSearch},
+	{"seznam.cz", "Seznam", OrganicSearch},
+
+	// AI assistants
+	{"chatgpt.com", "ChatGPT", AIAssistants},
+	{"chat.openai.com", "ChatGPT", AIAssistants},
+	{"perplexity.ai", "Perplexity", AIAssistants},
+	{"claude.ai", "Claude", AIAssistants},
+	{"gemini.google.com", "Gemini", AIAssistants},
+	{"copilot.microsoft.com", "Copilot", AIAssistants},
+	{"phind.com", "Phind", AIAssistants},
+	{"you.com", "You.com", AIAssistants},
+
+	// Social
+	{"facebook.com", "Facebook", Social},
+	{"fb.me", "Facebook", Social},
+	{"instagram.com", "Instagram", Social},
+	{"t.co", "X", Social},
+	{"x.com", "X", Social},
+	{"twitter.com", "X", Social},
+	{"linkedin.com", "LinkedIn", Social},
+	{"lnkd.in", "LinkedIn", Social},
+	{"reddit.com", "Reddit", Social},
+	{"news.ycombinator.com", "Hacker News", Social},
+	{"youtube.com", "YouTube", Social},
+	{"youtu.be", "YouTube", Social},
+	{"pinterest.*", "Pinterest", Social},
+	{"tiktok.com", "TikTok", Social},
+	{"mastodon.social", "Mastodon", Social},
+	{"bsky.app", "Bluesky", Social},
+	{"threads.net", "Threads", Social},
+	{"com.slack", "Slack", Social},
+	{"slack.com", "Slack", Social},
+	{"discord.com", "Discord", Social},
+	{"t.me", "Telegram", Social},
+	{"org.telegram.messenger", "Telegram", Social},
+	{"whatsapp.com", "WhatsApp", Social},
+	{"vk.com", "VK", Social},
+
+	// Webmail
+	{"mail.google.com", "Gmail", Email},
+	{"outlook.live.com", "Outlook", Email},
+	{"outlook.office.com", "Outlook", Email},
+	{"mail.yahoo.com", "Yahoo Mail", Email},
+	{"com.google.android.gm", "Gmail", Email},
+}
+
+var (
+	paidMedium   = regexp.MustCompile(`^(.*cp.*|ppc|retargeting|paid.*)$`)
+	emailMedium  = regexp.MustCompile(`^(email|e-mail|e_mail|e mail|newsletter)$`)
+	socialMedium = regexp.MustCompile(`^(social|social-network|social-media|sm|social network|social media)$`)
+)
+
+// Touch is the traffic information of a session's first event
+type Touch struct {
+	ReferrerDomain string // as stored in website_event.referrer_domain
+	UTMSource      string
+	UTMMedium      string
+}
+
+// Result is the normalized source and channel of a touch
+type Result struct {
+	Source  string `json:"source"`
+	Channel string `json:"channel"`
+}
+
+// IsValid reports whether channel is one of All
+func IsValid(channel string) bool {
+	for _, c := range All {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse resolves a channel name case-insensitively, accepting "-" or "_" for
+// spaces ("organic-search" -> "Organic Search")
+func Parse(name string) (string, bool) {
+	name = strings.NewReplacer("-", " ", "_", " ").Replace(strings.TrimSpace(name))
+	for _, c := range All {
+		if strings.EqualFold(c, name) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// ValidateRule checks a custom rule before it is stored
+func ValidateRule(r Rule) error {
+	pattern := strings.ToLower(strings.TrimSpace(r.Pattern))
+	if pattern == "" || strings.ContainsAny(pattern, " /") {
+		return fmt.Errorf("invalid pattern %q (use a host like l.facebook.com or google.*)", r.Pattern)
+	}
+	if strings.Contains(strings.TrimSuffix(pattern, ".*"), "*") {
+		return fmt.Errorf("invalid pattern %q: only a trailing .* wildcard is supported", r.Pattern)
+	}
+	if strings.TrimSpace(r.Source) == "" {
+		return fmt.Errorf("source name is required")
+	}
+	if !IsValid(r.Channel) {
+		return fmt.Errorf("invalid channel %q (valid: %s)", r.Channel, strings.Join(All, ", "))
+	}
+	return nil
+}
+
+// Classify normalizes the touch's source with custom rules first, then the
+// built-in ones, and derives its channel from utm_medium and the matched rule
+func Classify(t Touch, custom []Rule) Result {
+	host := NormalizeHost(t.ReferrerDomain)
+	source := strings.TrimSpace(t.UTMSource)
+	medium := strings.ToLower(strings.TrimSpace(t.UTMMedium))
+
+	if host == "" && source == "" && medium == "" {
+		return Result{Source: Direct, Channel: Direct}
+	}
+
+	rule, matched := match(host, source, custom)
+
+	result := Result{Source: host}
+	switch {
+	case matched:
+		result.Source = rule.Source
+	case source != "":
+		result.Source = source
+	case host == "":
+		result.Source = Direct
+	}
+
+	switch {
+	case paidMedium.MatchString(medium):
+		if matched && rule.Channel == Social {
+			result.Channel = Social
+		} else {
+			result.Channel = PaidSearch
+		}
+	case emailMedium.MatchString(medium):
+		result.Channel = Email
+	case socialMedium.MatchString(medium):
+		result.Channel = Social
+	case medium == "organic":
+		result.Channel = OrganicSearch
+	case matched:
+		result.Channel = rule.Channel
+	case host == "" && source == "":
+		// utm_medium alone says nothing about where the visit came from
+		result.Channel = Direct
+	default:
+		result.Channel = Referral
+	}
+	return result
+}
+
+// NormalizeHost lowercases a referrer domain (or URL) and strips "www."
+func NormalizeHost(referrer string) string {
+	referrer = strings.ToLower(strings.TrimSpace(referrer))
+	if strings.Contains(referrer, "://") {
+		if u, err := url.Parse(referrer); err == nil {
+			referrer = u.Hostname()
+		}
+	} else if host, _, found := strings.Cut(referrer, "/"); found {
+		referrer = host
+	}
+	return strings.TrimPrefix(referrer, "www.")
+}
+
+func match(host, utmSource string, custom []Rule) (Rule, bool) {
+	utmSource = strings.ToLower(utmSource)
+	for _, rules := range [][]Rule{custom, Builtin} {
+		if host != "" {
+			if r, ok := mostSpecific(host, rules); ok {
+				return r, true
+			}
+		}
+		if utmSource != "" {
+			for _, r := range rules {
+				if sourceMatches(utmSource, r) {
+					return r, true
+				}
+			}
+		}
+	}
+	return Rule{}, false
+}
+
+// mostSpecific returns the matching rule with the longest host pattern, so
+// gemini.google.com wins over google.* regardless of rule order
+func mostSpecific(host string, rules []Rule) (Rule, bool) {
+	var best Rule
+	bestScore := -1
+	for _, r := range rules {
+		pattern := strings.ToLower(r.Pattern)
+		if !hostMatches(host, pattern) {
+			continue
+		}
+		score := len(pattern)
+		if !strings.HasSuffix(pattern, ".*") {
+			score += 1000
+		}
+		if score > bestScore {
+			best, bestScore = r, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+func hostMatches(host, pattern string) bool {
+	if label, ok := strings.CutSuffix(pattern, ".*"); ok {
+		// "google.*": the label followed by at least one more label
+		return strings.HasPrefix(host, label+".") || strings.Contains(host, "."+label+".")
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+func sourceMatches(utmSource string, r Rule) bool {
+	if utmSource == strings.ToLower(r.Source) {
+		return true
+	}
+	pattern := strings.ToLower(r.Pattern)
+	if hostMatches(utmSource, pattern) {
+		return true
+	}
+	// utm_source=facebook matches facebook.com, utm_source=google matches google.*
+	name := strings.TrimSuffix(pattern, ".*")
+	if i := strings.LastIndex(name, "."); i > 0 && !strings.HasSuffix(pattern, ".*") {
+		name = name[:i]
+	}
+	return !strings.Contains(name, ".") && utmSource == name
+}
@@ -0,0 +1,72 @@
+package channels
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name  string
+		touch Touch
+		want  Result
+	}{
+		{"no referrer", Touch{}, Result{Direct, Direct}},
+		{"google ccTLD", Touch{ReferrerDomain: "www.google.de"}, Result{"Google", OrganicSearch}},
+		{"google subdomain", Touch{ReferrerDomain: "news.google.co.uk"}, Result{"Google", OrganicSearch}},
+		{"specific beats wildcard", Touch{ReferrerDomain: "gemini.google.com"}, Result{"Gemini", AIAssistants}},
+		{"webmail", Touch{ReferrerDomain: "mail.google.com"}, Result{"Gmail", Email}},
+		{"facebook subdomain", Touch{ReferrerDomain: "l.facebook.com"}, Result{"Facebook", Social}},
+		{"slack app", Touch{ReferrerDomain: "com.slack"}, Result{"Slack", Social}},
+		{"ai assistant", Touch{ReferrerDomain: "chatgpt.com"}, Result{"ChatGPT", AIAssistants}},
+		{"unknown referrer", Touch{ReferrerDomain: "blog.example.org"}, Result{"blog.example.org", Referral}},
+		{"paid search", Touch{ReferrerDomain: "google.com", UTMMedium: "cpc"}, Result{"Google", PaidSearch}},
+		{"paid social", Touch{UTMSource: "facebook", UTMMedium: "paid"}, Result{"Facebook", Social}},
+		{"email medium", Touch{UTMSource: "newsletter", UTMMedium: "email"}, Result{"newsletter", Email}},
+		{"social medium", Touch{UTMSource: "partner", UTMMedium: "social"}, Result{"partner", Social}},
+		{"utm source matches rule", Touch{UTMSource: "LinkedIn"}, Result{"LinkedIn", Social}},
+		{"utm source label", Touch{UTMSource: "google"}, Result{"Google", OrganicSearch}},
+		{"unknown utm source", Touch{UTMSource: "podcast"}, Result{"podcast", Referral}},
+		{"medium only", Touch{UTMMedium: "banner"}, Result{Direct, Direct}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.touch, nil))
+		})
+	}
+}
+
+func TestClassifyCustomRules(t *testing.T) {
+	custom := []Rule{
+		{Pattern: "google.com", Source: "Google Ads", Channel: PaidSearch},
+		{Pattern: "partner.example", Source: "Partner", Channel: Email},
+	}
+
+	assert.Equal(t, Result{"Google Ads", PaidSearch}, Classify(Touch{ReferrerDomain: "google.com"}, custom))
+	assert.Equal(t, Result{"Partner", Email}, Classify(Touch{ReferrerDomain: "app.partner.example"}, custom))
+	assert.Equal(t, Result{"Google", OrganicSearch}, Classify(Touch{ReferrerDomain: "google.fr"}, custom))
+}
+
+func TestParse(t *testing.T) {
+	name, ok := Parse("organic-search")
+	assert.True(t, ok)
+	assert.Equal(t, OrganicSearch, name)
+
+	name, ok = Parse("ai_assistants")
+	assert.True(t, ok)
+	assert.Equal(t, AIAssistants, name)
+
+	_, ok = Parse("television")
+	assert.False(t, ok)
+}
+
+func TestValidateRule(t *testing.T) {
+	assert.NoError(t, ValidateRule(Rule{Pattern: "google.*", Source: "Google", Channel: OrganicSearch}))
+	assert.Error(t, ValidateRule(Rule{Pattern: "", Source: "X", Channel: Social}))
+	assert.Error(t, ValidateRule(Rule{Pattern: "*.example.com", Source: "X", Channel: Social}))
+	assert.Error(t, ValidateRule(Rule{Pattern: "example.com/path", Source: "X", Channel: Social}))
+	assert.Error(t, ValidateRule(Rule{Pattern: "example.com", Source: "", Channel: Social}))
+	assert.Error(t, ValidateRule(Rule{Pattern: "example.com", Source: "X", Channel: "TV"}))
+}
@@ -14,7 +14,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/seuros/kaunta/internal/channels"
 	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/handlers"
 	"github.com/spf13/cobra"
 )
 
@@ -50,6 +52,17 @@ type BreakdownStat struct {
 	Items     []map[string]interface{} `json:"items"`
 }
 
+type ErrorStat struct {
+	Message     string    `json:"message"`
+	Source      string    `json:"source,omitempty"`
+	Line        *int64    `json:"line,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	Browser     string    `json:"browser,omitempty"`
+	Occurrences int64     `json:"occurrences"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
 type LiveStatsData struct {
 	Timestamp           time.Time                `json:"timestamp"`
 	ActiveVisitorsNow   int64                    `json:"active_visitors_now"`
@@ -77,6 +90,9 @@ var (
 	getTopPagesFn          = GetTopPages
 	getBreakdownStatsFn    = GetBreakdownStats
 	getLiveStatsFn         = GetLiveStats
+	getErrorStatsFn        = GetErrorStats
+	getPathsFn             = GetPaths
+	getBotsFn              = GetBots
 	tickerFactory          = func(d time.Duration) (<-chan time.Time, func()) {
 		ticker := time.NewTicker(d)
 		return ticker.C, ticker.Stop
@@ -116,6 +132,18 @@ Options:
 	},
 }
 
+// SessionFilter narrows pages and breakdown queries to a subset of sessions
+type SessionFilter struct {
+	WithErrors bool   // only sessions that reported a frontend error
+	Channel    string // only sessions from this traffic channel
+}
+
+// statsWithErrors backs the --with-errors flag of the pages and breakdown commands
+var statsWithErrors bool
+
+// statsChannel backs the --channel flag of the pages and breakdown commands
+var statsChannel string
+
 // Pages command flags
 var (
 	pagesDays   int
@@ -133,10 +161,12 @@ Columns: URL Path, Pageviews, Unique Visitors, Bounce Rate, Avg Time
 Options:
   --days N      Time period in days (1-365, default 7)
   --top N       Number of pages to show (1-100, default 10)
-  --format      Output format: json, table, csv (default table)`,
+  --format      Output format: json, table, csv (default table)
+  --with-errors Only count sessions that reported a JavaScript error
+  --channel     Only count sessions from this traffic channel`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runStatsPages(args[0], pagesDays, pagesTop, pagesFormat)
+		return runStatsPages(args[0], pagesDays, pagesTop, pagesFormat, SessionFilter{WithErrors: statsWithErrors, Channel: statsChannel})
 	},
 }
 
@@ -159,19 +189,145 @@ Valid dimensions:
   device   - Device Type, Visitors, Pageviews, Bounce Rate
   referrer - Referrer Domain, Visitors, Pageviews, Bounce Rate
   os       - OS, Visitors, Pageviews, Bounce Rate
+  channel  - Traffic Channel (Organic Search, Social, ...), Visitors, Pageviews, Bounce Rate
+  source   - Normalized Source (Google, Facebook, ...), Visitors, Pageviews, Bounce Rate
+  content_group - Content Group from URL rules (Docs, Blog, ...), Visitors, Pageviews, Bounce Rate
+  asn      - Network (AS16509 Amazon.com, Inc.), Visitors, Pageviews, Bounce Rate; needs an ASN database
 
 Options:
   --by          Dimension to break down by (required)
   --days N      Time period in days (1-365, default 7)
   --top N       Number of items to show (1-100, default 10)
   --format      Output format: json, table, csv (default table)
+  --with-errors Only count sessions that reported a JavaScript error
+  --channel     Only count sessions from this traffic channel
 
 Examples:
   kaunta stats breakdown mysite.com --by country
-  kaunta stats breakdown mysite.com --by browser --top 5 --days 30`,
+  kaunta stats breakdown mysite.com --by browser --top 5 --days 30
+  kaunta stats breakdown mysite.com --by browser --with-errors
+  kaunta stats breakdown mysite.com --by source --channel social
+  kaunta stats breakdown mysite.com --by asn --days 30`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStatsBreakdown(args[0], breakdownDimension, breakdownDays, breakdownTop, breakdownFormat,
+			SessionFilter{WithErrors: statsWithErrors, Channel: statsChannel})
+	},
+}
+
+// Errors command flags
+var (
+	errorsDays   int
+	errorsTop    int
+	errorsFormat string
+)
+
+var statsErrorsCmd = &cobra.Command{
+	Use:   "errors <website-domain> [--days <N>] [--top <N>] [--format json|table|csv]",
+	Short: "Show frontend JavaScript errors",
+	Long: `Display JavaScript errors reported by the tracker, grouped by fingerprint.
+
+Errors with the same normalized message and script location are counted together.
+
+Columns: Message, Location, Page, Browser, Occurrences, First Seen, Last Seen
+
+Options:
+  --days N      Only errors seen in the last N days (1-365, default 7)
+  --top N       Number of error groups to show (1-100, default 10)
+  --format      Output format: json, table, csv (default table)`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStatsErrors(args[0], errorsDays, errorsTop, errorsFormat)
+	},
+}
+
+// Paths command flags
+var (
+	pathsFrom      string
+	pathsDirection string
+	pathsDepth     int
+	pathsTop       int
+	pathsDays      int
+	pathsCountry   string
+	pathsBrowser   string
+	pathsDevice    string
+	pathsFormat    string
+)
+
+var statsPathsCmd = &cobra.Command{
+	Use:   "paths <website-domain> [--from <page|event:name|entry>] [--direction next|previous] [--depth <N>] [--format json|table]",
+	Short: "Show visitor paths from or to a page",
+	Long: `Explore how visitors move through the site, starting from a page,
+a custom event or the entry page of each visit.
+
+Steps are built from pageviews and custom events ordered by time within a
+visit; a page reloaded several times in a row counts once. Each level shows
+the top steps with visits and drop-off (visits ending there). Remaining
+steps are grouped as "(other)".
+
+Options:
+  --from X       Page path (/pricing), custom event (event:signup) or entry (default)
+  --direction D  next or previous (default next; entry only supports next)
+  --depth N      Levels to follow (1-10, default 3)
+  --top N        Steps kept per level (1-50, default 5)
+  --days N       Time period in days (1-365, default 7)
+  --country, --browser, --device  Standard filters
+  --format       Output format: json, table (default table)
+
+JSON output is a Sankey-ready list of nodes and links.
+
+Examples:
+  kaunta stats paths example.com --from /pricing --depth 4
+  kaunta stats paths example.com --from event:signup --direction previous --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runStatsPaths(args[0], handlers.PathsQuery{
+			From:      pathsFrom,
+			Direction: pathsDirection,
+			Depth:     pathsDepth,
+			Top:       pathsTop,
+			Days:      pathsDays,
+			Country:   pathsCountry,
+			Browser:   pathsBrowser,
+			Device:    pathsDevice,
+		}, pathsFormat)
+	},
+}
+
+// Bots command flags
+var (
+	botsDays   int
+	botsType   string
+	botsTop    int
+	botsFormat string
+)
+
+var statsBotsCmd = &cobra.Command{
+	Use:   "bots <website-domain> [--days <N>] [--type <bot-type>] [--top <N>] [--format json|table]",
+	Short: "Show crawler and AI agent hits",
+	Long: `Show hits from crawlers and AI agents detected on a website, by bot
+type, matched user agent pattern, page and day.
+
+Bot hits are never recorded as pageviews; they are logged separately and
+kept for 30 days. Most AI crawlers don't run JavaScript, so importing
+access logs (kaunta import logs) gives the most complete picture.
+
+Options:
+  --days N     Time period in days (1-30, default 7)
+  --type T     Only this bot type: ai (llm_crawler), generic_bot, scraper, headless_browser, datacenter
+  --top N      Patterns and pages to show (1-100, default 10)
+  --format     Output format: json, table (default table)
+
+Examples:
+  kaunta stats bots example.com
+  kaunta stats bots example.com --type ai --days 30 --format json`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runStatsBreakdown(args[0], breakdownDimension, breakdownDays, breakdownTop, breakdownFormat)
+		return runStatsBots(args[0], handlers.BotsQuery{
+			Days:    botsDays,
+			BotType: botsType,
+			Top:     botsTop,
+		}, botsFormat)
 	},
 }
 
@@ -248,7 +404,7 @@ func runStatsOverview(domain string, days int, format string) error {
 	}
 }
 
-func runStatsPages(domain string, days int, top int, format string) error {
+func runStatsPages(domain string, days int, top int, format string, filter SessionFilter) error {
 	if days < 1 || days > 365 {
 		return fmt.Errorf("days must be between 1 and 365")
 	}
@@ -257,6 +413,10 @@ func runStatsPages(domain string, days int, top int, format string) error {
 		return fmt.Errorf("top must be between 1 and 100")
 	}
 
+	if err := filter.normalizeChannel(); err != nil {
+		return err
+	}
+
 	if format == "" {
 		format = "table"
 	}
@@ -276,7 +436,7 @@ func runStatsPages(domain string, days int, top int, format string) error {
 		return err
 	}
 
-	pages, err := getTopPagesFn(ctx, database.DB, websiteID, days, top)
+	pages, err := getTopPagesFn(ctx, database.DB, websiteID, days, top, filter)
 	if err != nil {
 		return err
 	}
@@ -293,21 +453,29 @@ func runStatsPages(domain string, days int, top int, format string) error {
 	}
 }
 
-func runStatsBreakdown(domain string, dimension string, days int, top int, format string) error {
+func runStatsBreakdown(domain string, dimension string, days int, top int, format string, filter SessionFilter) error {
 	if dimension == "" {
-		return fmt.Errorf("--by dimension is required (valid: country, browser, device, referrer, os)")
+		return fmt.Errorf("--by dimension is required (valid: country, browser, device, referrer, os, channel, source, content_group, asn)")
 	}
 
 	validDimensions := map[string]bool{
-		"country":  true,
-		"browser":  true,
-		"device":   true,
-		"referrer": true,
-		"os":       true,
+		"country":       true,
+		"browser":       true,
+		"device":        true,
+		"referrer":      true,
+		"os":            true,
+		"channel":       true,
+		"source":        true,
+		"content_group": true,
+		"asn":           true,
 	}
 
 	if !validDimensions[dimension] {
-		return fmt.Errorf("invalid dimension: %s (valid: country, browser, device, referrer, os)", dimension)
+		return fmt.Errorf("invalid dimension: %s (valid: country, browser, device, referrer, os, channel, source, content_group, asn)", dimension)
+	}
+
+	if err := filter.normalizeChannel(); err != nil {
+		return err
 	}
 
 	if days < 1 || days > 365 {
@@ -337,7 +505,7 @@ func runStatsBreakdown(domain string, dimension string, days int, top int, forma
 		return err
 	}
 
-	stats, err := getBreakdownStatsFn(ctx, database.DB, websiteID, dimension, days, top)
+	stats, err := getBreakdownStatsFn(ctx, database.DB, websiteID, dimension, days, top, filter)
 	if err != nil {
 		return err
 	}
@@ -354,6 +522,143 @@ func runStatsBreakdown(domain string, dimension string, days int, top int, forma
 	}
 }
 
+func runStatsErrors(domain string, days int, top int, format string) error {
+	if days < 1 || days > 365 {
+		return fmt.Errorf("days must be between 1 and 365")
+	}
+
+	if top < 1 || top > 100 {
+		return fmt.Errorf("top must be between 1 and 100")
+	}
+
+	if format == "" {
+		format = "table"
+	}
+
+	if database.DB == nil {
+		if err := connectDatabase(); err != nil {
+			return fmt.Errorf("database connection failed: %w", err)
+		}
+		defer func() { _ = closeDatabase() }()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, err := getWebsiteIDByDomainFn(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	errs, err := getErrorStatsFn(ctx, database.DB, websiteID, days, top)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		return outputErrorsJSON(errs)
+	case "csv":
+		return outputErrorsCSV(errs)
+	case "table":
+		return outputErrorsTable(errs)
+	default:
+		return fmt.Errorf("invalid format: %s (use json, table, or csv)", format)
+	}
+}
+
+func runStatsPaths(domain string, q handlers.PathsQuery, format string) error {
+	if q.Days < 1 || q.Days > 365 {
+		return fmt.Errorf("days must be between 1 and 365")
+	}
+
+	if q.Depth < 1 || q.Depth > 10 {
+		return fmt.Errorf("depth must be between 1 and 10")
+	}
+
+	if q.Top < 1 || q.Top > 50 {
+		return fmt.Errorf("top must be between 1 and 50")
+	}
+
+	if err := q.Normalize(); err != nil {
+		return err
+	}
+
+	if format == "" {
+		format = "table"
+	}
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	if database.DB == nil {
+		if err := connectDatabase(); err != nil {
+			return fmt.Errorf("database connection failed: %w", err)
+		}
+		defer func() { _ = closeDatabase() }()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	websiteID, err := getWebsiteIDByDomainFn(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	report, err := getPathsFn(ctx, database.DB, websiteID, q)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return outputPathsJSON(report)
+	}
+	return outputPathsTable(report)
+}
+
+func runStatsBots(domain string, q handlers.BotsQuery, format string) error {
+	if q.Days < 1 || q.Days > 30 {
+		return fmt.Errorf("days must be between 1 and 30")
+	}
+
+	if q.Top < 1 || q.Top > 100 {
+		return fmt.Errorf("top must be between 1 and 100")
+	}
+
+	if format == "" {
+		format = "table"
+	}
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	if database.DB == nil {
+		if err := connectDatabase(); err != nil {
+			return fmt.Errorf("database connection failed: %w", err)
+		}
+		defer func() { _ = closeDatabase() }()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	websiteID, err := getWebsiteIDByDomainFn(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	report, err := getBotsFn(ctx, database.DB, websiteID, q)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		return outputBotsJSON(report)
+	}
+	return outputBotsTable(report)
+}
+
 func runStatsLive(domain string, interval int, format string) error {
 	if interval < 2 || interval > 60 {
 		interval = 5
@@ -509,12 +814,13 @@ func GetOverviewStats(ctx context.Context, db *sql.DB, websiteID string, days in
 	return stats, nil
 }
 
-func GetTopPages(ctx context.Context, db *sql.DB, websiteID string, days int, limit int) ([]*PageStat, error) {
+func GetTopPages(ctx context.Context, db *sql.DB, websiteID string, days int, limit int, filter SessionFilter) ([]*PageStat, error) {
 	parsedID, err := uuid.Parse(websiteID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid website ID: %w", err)
 	}
 
+	channelClause, args := channelSessionsClause(filter, []any{parsedID, days, limit})
 	query := `
 		SELECT
 			e.url_path,
@@ -524,12 +830,12 @@ func GetTopPages(ctx context.Context, db *sql.DB, websiteID string, days int, li
 		WHERE e.website_id = $1
 		  AND e.created_at >= NOW() - INTERVAL '1 day' * $2
 		  AND e.event_type = 1
-		  AND e.url_path IS NOT NULL
+		  AND e.url_path IS NOT NULL` + erroredSessionsClause(filter) + channelClause + `
 		GROUP BY e.url_path
 		ORDER BY pageviews DESC
 		LIMIT $3`
 
-	rows, err := db.QueryContext(ctx, query, parsedID, days, limit)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query top pages: %w", err)
 	}
@@ -545,10 +851,10 @@ func GetTopPages(ctx context.Context, db *sql.DB, websiteID string, days int, li
 		}
 
 		// Calculate bounce rate for this page
-		bounceRate := calculatePageBounceRate(ctx, db, parsedID, path, days)
+		bounceRate := calculatePageBounceRate(ctx, db, parsedID, path, days, filter)
 
 		// Calculate average time on page
-		avgTime := calculatePageAvgTime(ctx, db, parsedID, path, days)
+		avgTime := calculatePageAvgTime(ctx, db, parsedID, path, days, filter)
 
 		pages = append(pages, &PageStat{
 			Path:           path,
@@ -562,7 +868,8 @@ func GetTopPages(ctx context.Context, db *sql.DB, websiteID string, days int, li
 	return pages, rows.Err()
 }
 
-func GetBreakdownStats(ctx context.Context, db *sql.DB, websiteID string, dimension string, days int, limit int) (*BreakdownStat, error) {
+func GetBreakdownStats(ctx context.Context, db *sql.DB, websiteID string, dimension string, days int, limit int,
+	filter SessionFilter) (*BreakdownStat, error) {
 	parsedID, err := uuid.Parse(websiteID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid website ID: %w", err)
@@ -582,6 +889,14 @@ func GetBreakdownStats(ctx context.Context, db *sql.DB, websiteID string, dimens
 		column = "COALESCE(e.referrer_domain, 'Direct / None')"
 	case "os":
 		column = "COALESCE(s.os, 'Unknown')"
+	case "channel":
+		column = "COALESCE(s.channel, 'Unknown')"
+	case "source":
+		column = "COALESCE(s.source, 'Unknown')"
+	case "content_group":
+		column = "COALESCE(e.content_group, '(ungrouped)')"
+	case "asn":
+		column = "COALESCE(" + handlers.ASNLabelColumn + ", 'Unknown')"
 	default:
 		return nil, fmt.Errorf("invalid dimension: %s", dimension)
 	}
@@ -594,6 +909,7 @@ func GetBreakdownStats(ctx context.Context, db *sql.DB, websiteID string, dimens
 		joinClause = "JOIN session s ON e.session_id = s.session_id"
 	}
 
+	channelClause, args := channelSessionsClause(filter, []any{parsedID, days, limit})
 	query = fmt.Sprintf(`
 		SELECT
 			%s as name,
@@ -603,12 +919,12 @@ func GetBreakdownStats(ctx context.Context, db *sql.DB, websiteID string, dimens
 		%s
 		WHERE e.website_id = $1
 		  AND e.created_at >= NOW() - INTERVAL '1 day' * $2
-		  AND e.event_type = 1
+		  AND e.event_type = 1%s%s
 		GROUP BY %s
 		ORDER BY visitors DESC
-		LIMIT $3`, column, joinClause, column)
+		LIMIT $3`, column, joinClause, erroredSessionsClause(filter), channelClause, column)
 
-	rows, err := db.QueryContext(ctx, query, parsedID, days, limit)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query breakdown: %w", err)
 	}
@@ -628,7 +944,7 @@ func GetBreakdownStats(ctx context.Context, db *sql.DB, websiteID string, dimens
 		}
 
 		// Calculate bounce rate for this dimension value
-		bounceRate := calculateDimensionBounceRate(ctx, db, parsedID, dimension, name, days)
+		bounceRate := calculateDimensionBounceRate(ctx, db, parsedID, dimension, name, days, filter)
 
 		item := map[string]interface{}{
 			"name":        name,
@@ -643,6 +959,104 @@ func GetBreakdownStats(ctx context.Context, db *sql.DB, websiteID string, dimens
 	return stats, rows.Err()
 }
 
+func GetErrorStats(ctx context.Context, db *sql.DB, websiteID string, days int, limit int) ([]*ErrorStat, error) {
+	parsedID, err := uuid.Parse(websiteID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid website ID: %w", err)
+	}
+
+	query := `
+		SELECT
+			message,
+			COALESCE(source_url, ''),
+			line_number,
+			COALESCE(url_path, ''),
+			COALESCE(browser, ''),
+			occurrences,
+			first_seen,
+			last_seen
+		FROM website_error
+		WHERE website_id = $1
+		  AND last_seen >= NOW() - INTERVAL '1 day' * $2
+		ORDER BY occurrences DESC, last_seen DESC
+		LIMIT $3`
+
+	rows, err := db.QueryContext(ctx, query, parsedID, days, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query errors: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var errs []*ErrorStat
+	for rows.Next() {
+		stat := &ErrorStat{}
+		var line sql.NullInt64
+
+		if err := rows.Scan(&stat.Message, &stat.Source, &line, &stat.Path, &stat.Browser,
+			&stat.Occurrences, &stat.FirstSeen, &stat.LastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan error: %w", err)
+		}
+		if line.Valid {
+			stat.Line = &line.Int64
+		}
+
+		errs = append(errs, stat)
+	}
+
+	return errs, rows.Err()
+}
+
+// GetPaths loads the visitor paths report for a website
+func GetPaths(ctx context.Context, db *sql.DB, websiteID string, q handlers.PathsQuery) (*handlers.PathsReport, error) {
+	id, err := uuid.Parse(websiteID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid website ID: %w", err)
+	}
+	return handlers.LoadPaths(ctx, db, id, q)
+}
+
+// GetBots loads the bot traffic report of a website
+func GetBots(ctx context.Context, db *sql.DB, websiteID string, q handlers.BotsQuery) (*handlers.BotsReport, error) {
+	id, err := uuid.Parse(websiteID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid website ID: %w", err)
+	}
+	return handlers.LoadBots(ctx, db, id, q)
+}
+
+// erroredSessionsClause returns the extra WHERE condition used by --with-errors
+func erroredSessionsClause(filter SessionFilter) string {
+	if !filter.WithErrors {
+		return ""
+	}
+	return `
+		  AND e.session_id IN (SELECT session_id FROM session WHERE website_id = $1 AND has_error)`
+}
+
+// channelSessionsClause returns the extra WHERE condition used by --channel,
+// appending the channel to the query args
+func channelSessionsClause(filter SessionFilter, args []any) (string, []any) {
+	if filter.Channel == "" {
+		return "", args
+	}
+	args = append(args, filter.Channel)
+	return fmt.Sprintf(`
+		  AND e.session_id IN (SELECT session_id FROM session WHERE website_id = $1 AND channel = $%d)`, len(args)), args
+}
+
+// normalizeChannel validates --channel and stores its canonical name
+func (f *SessionFilter) normalizeChannel() error {
+	if f.Channel == "" {
+		return nil
+	}
+	name, ok := channels.Parse(f.Channel)
+	if !ok {
+		return fmt.Errorf("invalid channel: %s (valid: %s)", f.Channel, strings.Join(channels.All, ", "))
+	}
+	f.Channel = name
+	return nil
+}
+
 func GetLiveStats(ctx context.Context, db *sql.DB, websiteID string) (*LiveStatsData, error) {
 	parsedID, err := uuid.Parse(websiteID)
 	if err != nil {
@@ -903,7 +1317,8 @@ func getAverageEngagement(ctx context.Context, db *sql.DB, websiteID uuid.UUID,
 	return avgTime.Float64, nil
 }
 
-func calculatePageBounceRate(ctx context.Context, db *sql.DB, websiteID uuid.UUID, path string, days int) float64 {
+func calculatePageBounceRate(ctx context.Context, db *sql.DB, websiteID uuid.UUID, path string, days int, filter SessionFilter) float64 {
+	channelClause, args := channelSessionsClause(filter, []any{websiteID, days, path})
 	query := `
 		SELECT
 			COUNT(DISTINCT CASE WHEN pageview_count = 1 THEN e.session_id END)::float / NULLIF(COUNT(DISTINCT e.session_id), 0) * 100 as bounce_rate
@@ -919,10 +1334,10 @@ func calculatePageBounceRate(ctx context.Context, db *sql.DB, websiteID uuid.UUI
 		WHERE e.website_id = $1
 		  AND e.url_path = $3
 		  AND e.created_at >= NOW() - INTERVAL '1 day' * $2
-		  AND e.event_type = 1`
+		  AND e.event_type = 1` + erroredSessionsClause(filter) + channelClause
 
 	var bounceRate sql.NullFloat64
-	_ = db.QueryRowContext(ctx, query, websiteID, days, path).Scan(&bounceRate)
+	_ = db.QueryRowContext(ctx, query, args...).Scan(&bounceRate)
 
 	if bounceRate.Valid {
 		return bounceRate.Float64
@@ -930,7 +1345,8 @@ func calculatePageBounceRate(ctx context.Context, db *sql.DB, websiteID uuid.UUI
 	return 0
 }
 
-func calculatePageAvgTime(ctx context.Context, db *sql.DB, websiteID uuid.UUID, path string, days int) float64 {
+func calculatePageAvgTime(ctx context.Context, db *sql.DB, websiteID uuid.UUID, path string, days int, filter SessionFilter) float64 {
+	channelClause, args := channelSessionsClause(filter, []any{websiteID, path, days})
 	query := `
 		SELECT AVG(engagement_time)
 		FROM (
@@ -941,12 +1357,12 @@ func calculatePageAvgTime(ctx context.Context, db *sql.DB, websiteID uuid.UUID,
 			WHERE e.website_id = $1
 			  AND e.url_path = $2
 			  AND e.created_at >= NOW() - INTERVAL '1 day' * $3
-			  AND e.event_type = 1
+			  AND e.event_type = 1` + erroredSessionsClause(filter) + channelClause + `
 			GROUP BY e.session_id
 		) session_engagement`
 
 	var avgTime sql.NullFloat64
-	_ = db.QueryRowContext(ctx, query, websiteID, path, days).Scan(&avgTime)
+	_ = db.QueryRowContext(ctx, query, args...).Scan(&avgTime)
 
 	if avgTime.Valid {
 		return avgTime.Float64
@@ -954,7 +1370,8 @@ func calculatePageAvgTime(ctx context.Context, db *sql.DB, websiteID uuid.UUID,
 	return 0
 }
 
-func calculateDimensionBounceRate(ctx context.Context, db *sql.DB, websiteID uuid.UUID, dimension string, value string, days int) float64 {
+func calculateDimensionBounceRate(ctx context.Context, db *sql.DB, websiteID uuid.UUID, dimension string, value string, days int,
+	filter SessionFilter) float64 {
 	var column string
 	var table string
 
@@ -974,6 +1391,18 @@ func calculateDimensionBounceRate(ctx context.Context, db *sql.DB, websiteID uui
 	case "os":
 		column = "s.os"
 		table = "JOIN session s ON e.session_id = s.session_id"
+	case "channel":
+		column = "s.channel"
+		table = "JOIN session s ON e.session_id = s.session_id"
+	case "source":
+		column = "s.source"
+		table = "JOIN session s ON e.session_id = s.session_id"
+	case "content_group":
+		column = "e.content_group"
+		table = "JOIN session s ON e.session_id = s.session_id"
+	case "asn":
+		column = handlers.ASNLabelColumn
+		table = "JOIN session s ON e.session_id = s.session_id"
 	default:
 		return 0
 	}
@@ -981,10 +1410,13 @@ func calculateDimensionBounceRate(ctx context.Context, db *sql.DB, websiteID uui
 	var whereClause string
 	if dimension == "referrer" {
 		whereClause = fmt.Sprintf("COALESCE(%s, 'Direct / None') = $3", column)
+	} else if dimension == "content_group" {
+		whereClause = fmt.Sprintf("COALESCE(%s, '(ungrouped)') = $3", column)
 	} else {
 		whereClause = fmt.Sprintf("COALESCE(%s, 'Unknown') = $3", column)
 	}
 
+	channelClause, args := channelSessionsClause(filter, []any{websiteID, days, value})
 	query := fmt.Sprintf(`
 		SELECT
 			COUNT(DISTINCT CASE WHEN pageview_count = 1 THEN e.session_id END)::float / NULLIF(COUNT(DISTINCT e.session_id), 0) * 100 as bounce_rate
@@ -1001,10 +1433,10 @@ func calculateDimensionBounceRate(ctx context.Context, db *sql.DB, websiteID uui
 		WHERE e.website_id = $1
 		  AND %s
 		  AND e.created_at >= NOW() - INTERVAL '1 day' * $2
-		  AND e.event_type = 1`, table, whereClause)
+		  AND e.event_type = 1%s%s`, table, whereClause, erroredSessionsClause(filter), channelClause)
 
 	var bounceRate sql.NullFloat64
-	_ = db.QueryRowContext(ctx, query, websiteID, days, value).Scan(&bounceRate)
+	_ = db.QueryRowContext(ctx, query, args...).Scan(&bounceRate)
 
 	if bounceRate.Valid {
 		return bounceRate.Float64
@@ -1259,6 +1691,178 @@ func outputBreakdownCSV(stats *BreakdownStat) error {
 	return nil
 }
 
+func outputErrorsJSON(errs []*ErrorStat) error {
+	if errs == nil {
+		errs = []*ErrorStat{}
+	}
+	data, err := json.MarshalIndent(errs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func outputErrorsTable(errs []*ErrorStat) error {
+	if len(errs) == 0 {
+		fmt.Println("No errors reported")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer func() { _ = w.Flush() }()
+
+	_, _ = fmt.Fprintln(w, "MESSAGE\tLOCATION\tPAGE\tBROWSER\tCOUNT\tFIRST SEEN\tLAST SEEN")
+	_, _ = fmt.Fprintln(w, "-------\t--------\t----\t-------\t-----\t----------\t---------")
+
+	for _, e := range errs {
+		message := e.Message
+		if len(message) > 60 {
+			message = message[:57] + "..."
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			message,
+			errorLocation(e),
+			e.Path,
+			e.Browser,
+			e.Occurrences,
+			e.FirstSeen.Format("2006-01-02 15:04"),
+			e.LastSeen.Format("2006-01-02 15:04"),
+		)
+	}
+
+	return nil
+}
+
+func outputErrorsCSV(errs []*ErrorStat) error {
+	w := csv.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	// Write header
+	err := w.Write([]string{"message", "location", "path", "browser", "occurrences", "first_seen", "last_seen"})
+	if err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	// Write rows
+	for _, e := range errs {
+		err := w.Write([]string{
+			e.Message,
+			errorLocation(e),
+			e.Path,
+			e.Browser,
+			fmt.Sprintf("%d", e.Occurrences),
+			e.FirstSeen.Format(time.RFC3339),
+			e.LastSeen.Format(time.RFC3339),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func outputPathsJSON(report *handlers.PathsReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func outputPathsTable(report *handlers.PathsReport) error {
+	if report.Visits == 0 {
+		fmt.Printf("No visits reached %s\n", report.From)
+		return nil
+	}
+
+	fmt.Printf("Paths %s %s (%d visits, last %d days)\n\n", report.Direction, report.From, report.Visits, report.Days)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer func() { _ = w.Flush() }()
+
+	_, _ = fmt.Fprintln(w, "LEVEL\tSTEP\tVISITS\tSHARE\tDROP-OFF")
+	_, _ = fmt.Fprintln(w, "-----\t----\t------\t-----\t--------")
+
+	for _, n := range report.Nodes {
+		step := n.Step
+		if len(step) > 60 {
+			step = step[:57] + "..."
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%d\t%.1f%%\t%d\n",
+			n.Level,
+			step,
+			n.Visits,
+			float64(n.Visits)/float64(report.Visits)*100,
+			n.DropOff,
+		)
+	}
+
+	return nil
+}
+
+func outputBotsJSON(report *handlers.BotsReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func outputBotsTable(report *handlers.BotsReport) error {
+	if report.Hits == 0 {
+		fmt.Printf("No bot hits in the last %d days\n", report.Days)
+		return nil
+	}
+
+	fmt.Printf("Bot hits: %d (AI agents: %d, last %d days)\n\n", report.Hits, report.AIHits, report.Days)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "TYPE\tHITS\tIPS")
+	_, _ = fmt.Fprintln(w, "----\t----\t---")
+	for _, t := range report.ByType {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\n", t.BotType, t.Hits, t.IPs)
+	}
+	_, _ = fmt.Fprintln(w)
+
+	_, _ = fmt.Fprintln(w, "PATTERN\tTYPE\tHITS\tIPS")
+	_, _ = fmt.Fprintln(w, "-------\t----\t----\t---")
+	for _, p := range report.ByPattern {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", p.Pattern, p.BotType, p.Hits, p.IPs)
+	}
+	_, _ = fmt.Fprintln(w)
+
+	_, _ = fmt.Fprintln(w, "PAGE\tHITS\tAI\tPATTERNS")
+	_, _ = fmt.Fprintln(w, "----\t----\t--\t--------")
+	for _, p := range report.ByPage {
+		path := p.Path
+		if len(path) > 60 {
+			path = path[:57] + "..."
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", path, p.Hits, p.AIHits, p.Patterns)
+	}
+	_, _ = fmt.Fprintln(w)
+
+	_, _ = fmt.Fprintln(w, "DAY\tHITS\tAI")
+	_, _ = fmt.Fprintln(w, "---\t----\t--")
+	for _, d := range report.ByDay {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\n", d.Day.Format("2006-01-02"), d.Hits, d.AIHits)
+	}
+
+	return w.Flush()
+}
+
+func errorLocation(e *ErrorStat) string {
+	if e.Line == nil {
+		return e.Source
+	}
+	return fmt.Sprintf("%s:%d", e.Source, *e.Line)
+}
+
 func outputLiveJSON(data *LiveStatsData) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -1301,6 +1905,9 @@ func init() {
 	statsCmd.AddCommand(statsPagesCmd)
 	statsCmd.AddCommand(statsBreakdownCmd)
 	statsCmd.AddCommand(statsLiveCmd)
+	statsCmd.AddCommand(statsErrorsCmd)
+	statsCmd.AddCommand(statsPathsCmd)
+	statsCmd.AddCommand(statsBotsCmd)
 
 	// Overview command flags
 	statsOverviewCmd.Flags().IntVarP(&overviewDays, "days", "d", 7, "Time period in days (1-365)")
@@ -1310,16 +1917,41 @@ func init() {
 	statsPagesCmd.Flags().IntVarP(&pagesDays, "days", "d", 7, "Time period in days (1-365)")
 	statsPagesCmd.Flags().IntVarP(&pagesTop, "top", "t", 10, "Number of pages to show (1-100)")
 	statsPagesCmd.Flags().StringVarP(&pagesFormat, "format", "f", "table", "Output format (json, table, csv)")
+	statsPagesCmd.Flags().BoolVar(&statsWithErrors, "with-errors", false, "Only include sessions that reported a JavaScript error")
+	statsPagesCmd.Flags().StringVar(&statsChannel, "channel", "", "Only include sessions from this traffic channel (e.g. \"organic search\", social)")
 
 	// Breakdown command flags
 	statsBreakdownCmd.Flags().StringVarP(
 		&breakdownDimension, "by", "b", "",
-		"Dimension to break down by (required: country, browser, device, referrer, os)")
+		"Dimension to break down by (required: country, browser, device, referrer, os, channel, source, content_group, asn)")
 	statsBreakdownCmd.Flags().IntVarP(&breakdownDays, "days", "d", 7, "Time period in days (1-365)")
 	statsBreakdownCmd.Flags().IntVarP(&breakdownTop, "top", "t", 10, "Number of items to show (1-100)")
 	statsBreakdownCmd.Flags().StringVarP(&breakdownFormat, "format", "f", "table", "Output format (json, table, csv)")
+	statsBreakdownCmd.Flags().BoolVar(&statsWithErrors, "with-errors", false, "Only include sessions that reported a JavaScript error")
+	statsBreakdownCmd.Flags().StringVar(&statsChannel, "channel", "", "Only include sessions from this traffic channel (e.g. \"organic search\", social)")
+
+	// Errors command flags
+	statsErrorsCmd.Flags().IntVarP(&errorsDays, "days", "d", 7, "Time period in days (1-365)")
+	statsErrorsCmd.Flags().IntVarP(&errorsTop, "top", "t", 10, "Number of error groups to show (1-100)")
+	statsErrorsCmd.Flags().StringVarP(&errorsFormat, "format", "f", "table", "Output format (json, table, csv)")
 
 	// Live command flags
+	statsPathsCmd.Flags().StringVar(&pathsFrom, "from", handlers.PathsFromEntry, "Start step: page path, event:<name> or entry")
+	statsPathsCmd.Flags().StringVar(&pathsDirection, "direction", handlers.PathsDirectionNext, "Direction: next or previous")
+	statsPathsCmd.Flags().IntVar(&pathsDepth, "depth", 3, "Levels to follow (1-10)")
+	statsPathsCmd.Flags().IntVarP(&pathsTop, "top", "t", 5, "Steps kept per level (1-50)")
+	statsPathsCmd.Flags().IntVarP(&pathsDays, "days", "d", 7, "Time period in days (1-365)")
+	statsPathsCmd.Flags().StringVar(&pathsCountry, "country", "", "Only visits from this country code")
+	statsPathsCmd.Flags().StringVar(&pathsBrowser, "browser", "", "Only visits from this browser")
+	statsPathsCmd.Flags().StringVar(&pathsDevice, "device", "", "Only visits from this device type")
+	statsPathsCmd.Flags().StringVarP(&pathsFormat, "format", "f", "table", "Output format (json, table)")
+
+	// Bots command flags
+	statsBotsCmd.Flags().IntVarP(&botsDays, "days", "d", 7, "Time period in days (1-30)")
+	statsBotsCmd.Flags().StringVar(&botsType, "type", "", "Only this bot type (ai, llm_crawler, generic_bot, scraper, headless_browser, datacenter)")
+	statsBotsCmd.Flags().IntVarP(&botsTop, "top", "t", 10, "Patterns and pages to show (1-100)")
+	statsBotsCmd.Flags().StringVarP(&botsFormat, "format", "f", "table", "Output format (json, table)")
+
 	statsLiveCmd.Flags().IntVarP(&liveInterval, "interval", "i", 5, "Update interval in seconds (2-60)")
 	statsLiveCmd.Flags().StringVarP(&liveFormat, "format", "f", "text", "Output format (json, text)")
 }
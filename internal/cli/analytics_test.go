@@ -7,8 +7,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/handlers"
 )
 
 func TestRunStatsOverviewTable(t *testing.T) {
@@ -64,7 +67,7 @@ func TestRunStatsPagesCSV(t *testing.T) {
 		return "site-123", nil
 	})
 
-	stubTopPagesFetcher(t, func(ctx context.Context, db *sql.DB, websiteID string, days int, limit int) ([]*PageStat, error) {
+	stubTopPagesFetcher(t, func(ctx context.Context, db *sql.DB, websiteID string, days int, limit int, filter SessionFilter) ([]*PageStat, error) {
 		assert.Equal(t, 5, limit)
 		return []*PageStat{
 			{
@@ -78,7 +81,7 @@ func TestRunStatsPagesCSV(t *testing.T) {
 	})
 
 	output, err := captureOutput(t, func() error {
-		return runStatsPages("example.com", 7, 5, "csv")
+		return runStatsPages("example.com", 7, 5, "csv", SessionFilter{})
 	})
 	require.NoError(t, err)
 	assert.Contains(t, output, "path,pageviews,unique_visitors")
@@ -86,7 +89,7 @@ func TestRunStatsPagesCSV(t *testing.T) {
 }
 
 func TestRunStatsPagesInvalidTop(t *testing.T) {
-	err := runStatsPages("example.com", 7, 0, "table")
+	err := runStatsPages("example.com", 7, 0, "table", SessionFilter{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "top must be between 1 and 100")
 }
@@ -100,7 +103,7 @@ func TestRunStatsBreakdownJSON(t *testing.T) {
 	})
 
 	stubBreakdownFetcher(t, func(
-		ctx context.Context, db *sql.DB, websiteID, dimension string, days, limit int,
+		ctx context.Context, db *sql.DB, websiteID, dimension string, days, limit int, filter SessionFilter,
 	) (*BreakdownStat, error) {
 		assert.Equal(t, "country", dimension)
 		return &BreakdownStat{
@@ -112,7 +115,7 @@ func TestRunStatsBreakdownJSON(t *testing.T) {
 	})
 
 	output, err := captureOutput(t, func() error {
-		return runStatsBreakdown("example.com", "country", 7, 5, "json")
+		return runStatsBreakdown("example.com", "country", 7, 5, "json", SessionFilter{})
 	})
 	require.NoError(t, err)
 	assert.Contains(t, output, `"dimension": "country"`)
@@ -120,15 +123,239 @@ func TestRunStatsBreakdownJSON(t *testing.T) {
 }
 
 func TestRunStatsBreakdownInvalidDimension(t *testing.T) {
-	err := runStatsBreakdown("example.com", "", 7, 5, "json")
+	err := runStatsBreakdown("example.com", "", 7, 5, "json", SessionFilter{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "--by dimension is required")
 
-	err = runStatsBreakdown("example.com", "invalid", 7, 5, "json")
+	err = runStatsBreakdown("example.com", "invalid", 7, 5, "json", SessionFilter{})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid dimension")
 }
 
+func TestGetBreakdownStatsASN(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := "5f0e9a4c-1a64-4c0f-9f55-7a0f3c9c4f01"
+	mock.ExpectQuery(`'AS' \|\| s\.asn`).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "visitors", "pageviews"}).
+			AddRow("AS16509 Amazon.com, Inc.", 12, 30))
+	mock.ExpectQuery("bounce_rate").WithArgs(sqlmock.AnyArg(), 7, "AS16509 Amazon.com, Inc.").
+		WillReturnRows(sqlmock.NewRows([]string{"bounce_rate"}).AddRow(50.0))
+
+	stats, err := GetBreakdownStats(context.Background(), db, websiteID, "asn", 7, 10, SessionFilter{})
+	require.NoError(t, err)
+	require.Len(t, stats.Items, 1)
+	assert.Equal(t, "AS16509 Amazon.com, Inc.", stats.Items[0]["name"])
+	assert.Equal(t, 50.0, stats.Items[0]["bounce_rate"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTopPagesWithErrorsFiltersAllMetrics(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := "5f0e9a4c-1a64-4c0f-9f55-7a0f3c9c4f01"
+	mock.ExpectQuery(`(?s)has_error.*GROUP BY e.url_path`).
+		WillReturnRows(sqlmock.NewRows([]string{"url_path", "pageviews", "unique_visitors"}).AddRow("/checkout", 8, 3))
+	// Bounce rate and time on page describe the same errored sessions
+	mock.ExpectQuery(`(?s)bounce_rate.*AND has_error`).
+		WillReturnRows(sqlmock.NewRows([]string{"bounce_rate"}).AddRow(25.0))
+	mock.ExpectQuery(`(?s)has_error.*session_engagement`).
+		WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow(12.5))
+
+	pages, err := GetTopPages(context.Background(), db, websiteID, 7, 10, SessionFilter{WithErrors: true})
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	assert.Equal(t, 25.0, pages[0].BounceRate)
+	assert.Equal(t, 12.5, pages[0].AvgTime)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBreakdownStatsChannelFiltersBounceRate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := "5f0e9a4c-1a64-4c0f-9f55-7a0f3c9c4f01"
+	mock.ExpectQuery(`channel = \$4`).WithArgs(sqlmock.AnyArg(), 7, 10, "Organic Search").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "visitors", "pageviews"}).AddRow("US", 4, 9))
+	mock.ExpectQuery(`(?s)bounce_rate.*channel = \$4`).WithArgs(sqlmock.AnyArg(), 7, "US", "Organic Search").
+		WillReturnRows(sqlmock.NewRows([]string{"bounce_rate"}).AddRow(75.0))
+
+	stats, err := GetBreakdownStats(context.Background(), db, websiteID, "country", 7, 10, SessionFilter{Channel: "Organic Search"})
+	require.NoError(t, err)
+	require.Len(t, stats.Items, 1)
+	assert.Equal(t, 75.0, stats.Items[0]["bounce_rate"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetErrorStatsReturnsScanError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("FROM website_error").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"message", "source_url", "line_number", "url_path", "browser", "occurrences", "first_seen", "last_seen",
+		}).AddRow("boom", "", nil, "/", "", "not-a-number", time.Now(), time.Now()))
+
+	_, err = GetErrorStats(context.Background(), db, "5f0e9a4c-1a64-4c0f-9f55-7a0f3c9c4f01", 7, 10)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to scan error")
+}
+
+func TestRunStatsErrorsTable(t *testing.T) {
+	stubDB(t)
+	stubConnectClose(t)
+
+	stubWebsiteIDLookup(t, func(ctx context.Context, domain string) (string, error) {
+		return "site-123", nil
+	})
+
+	line := int64(12)
+	seen := time.Date(2025, 1, 2, 3, 4, 0, 0, time.UTC)
+	stubErrorStatsFetcher(t, func(ctx context.Context, db *sql.DB, websiteID string, days int, limit int) ([]*ErrorStat, error) {
+		assert.Equal(t, "site-123", websiteID)
+		assert.Equal(t, 30, days)
+		return []*ErrorStat{
+			{
+				Message:     "TypeError: x is undefined",
+				Source:      "https://example.com/app.js",
+				Line:        &line,
+				Path:        "/checkout",
+				Browser:     "chrome",
+				Occurrences: 17,
+				FirstSeen:   seen,
+				LastSeen:    seen,
+			},
+		}, nil
+	})
+
+	output, err := captureOutput(t, func() error {
+		return runStatsErrors("example.com", 30, 10, "table")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "TypeError: x is undefined")
+	assert.Contains(t, output, "https://example.com/app.js:12")
+	assert.Contains(t, output, "17")
+}
+
+func TestRunStatsErrorsInvalidFormat(t *testing.T) {
+	stubDB(t)
+	stubConnectClose(t)
+
+	stubWebsiteIDLookup(t, func(ctx context.Context, domain string) (string, error) {
+		return "site-123", nil
+	})
+	stubErrorStatsFetcher(t, func(ctx context.Context, db *sql.DB, websiteID string, days int, limit int) ([]*ErrorStat, error) {
+		return nil, nil
+	})
+
+	err := runStatsErrors("example.com", 7, 10, "xml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid format")
+}
+
+func TestRunStatsPathsTable(t *testing.T) {
+	stubDB(t)
+	stubConnectClose(t)
+
+	stubWebsiteIDLookup(t, func(ctx context.Context, domain string) (string, error) {
+		return "site-123", nil
+	})
+	stubPathsFetcher(t, func(ctx context.Context, db *sql.DB, websiteID string, q handlers.PathsQuery) (*handlers.PathsReport, error) {
+		assert.Equal(t, "site-123", websiteID)
+		assert.Equal(t, "/pricing", q.From)
+		assert.Equal(t, 4, q.Depth)
+		return &handlers.PathsReport{
+			From:      q.From,
+			Direction: q.Direction,
+			Depth:     q.Depth,
+			Days:      q.Days,
+			Visits:    20,
+			Nodes: []handlers.PathNode{
+				{ID: "0:/pricing", Level: 0, Step: "/pricing", Visits: 20, DropOff: 5},
+				{ID: "1:/signup", Level: 1, Step: "/signup", Visits: 15},
+			},
+		}, nil
+	})
+
+	output, err := captureOutput(t, func() error {
+		return runStatsPaths("example.com", handlers.PathsQuery{From: "/pricing", Depth: 4, Top: 5, Days: 7}, "table")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Paths next /pricing (20 visits")
+	assert.Contains(t, output, "/signup")
+	assert.Contains(t, output, "75.0%")
+}
+
+func TestRunStatsPathsValidation(t *testing.T) {
+	err := runStatsPaths("example.com", handlers.PathsQuery{From: "entry", Direction: "previous", Depth: 3, Top: 5, Days: 7}, "table")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "entry")
+
+	err = runStatsPaths("example.com", handlers.PathsQuery{Depth: 11, Top: 5, Days: 7}, "table")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "depth")
+
+	err = runStatsPaths("example.com", handlers.PathsQuery{Depth: 3, Top: 5, Days: 7}, "csv")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid format")
+}
+
+func TestRunStatsBotsTable(t *testing.T) {
+	stubDB(t)
+	stubConnectClose(t)
+
+	stubWebsiteIDLookup(t, func(ctx context.Context, domain string) (string, error) {
+		return "site-123", nil
+	})
+	stubBotsFetcher(t, func(ctx context.Context, db *sql.DB, websiteID string, q handlers.BotsQuery) (*handlers.BotsReport, error) {
+		assert.Equal(t, "site-123", websiteID)
+		assert.Equal(t, "ai", q.BotType)
+		return &handlers.BotsReport{
+			Days:      q.Days,
+			Hits:      12,
+			AIHits:    12,
+			ByType:    []handlers.BotTypeCount{{BotType: handlers.BotTypeAI, Hits: 12, IPs: 3}},
+			ByPattern: []handlers.BotPatternCount{{Pattern: "GPTBot", BotType: handlers.BotTypeAI, Hits: 12, IPs: 3}},
+			ByPage:    []handlers.BotPageCount{{Path: "/docs", Hits: 12, AIHits: 12, Patterns: "GPTBot"}},
+			ByDay:     []handlers.BotDayCount{{Day: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), Hits: 12, AIHits: 12}},
+		}, nil
+	})
+
+	output, err := captureOutput(t, func() error {
+		return runStatsBots("example.com", handlers.BotsQuery{Days: 7, BotType: "ai", Top: 10}, "table")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Bot hits: 12 (AI agents: 12, last 7 days)")
+	assert.Contains(t, output, "GPTBot")
+	assert.Contains(t, output, "/docs")
+	assert.Contains(t, output, "2026-10-17")
+}
+
+func TestRunStatsBotsValidation(t *testing.T) {
+	err := runStatsBots("example.com", handlers.BotsQuery{Days: 31, Top: 10}, "table")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "days")
+
+	err = runStatsBots("example.com", handlers.BotsQuery{Days: 7, Top: 0}, "table")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "top")
+
+	err = runStatsBots("example.com", handlers.BotsQuery{Days: 7, Top: 10}, "csv")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid format")
+}
+
+func TestErroredSessionsClause(t *testing.T) {
+	assert.Empty(t, erroredSessionsClause(SessionFilter{}))
+	assert.Contains(t, erroredSessionsClause(SessionFilter{WithErrors: true}), "has_error")
+}
+
 func TestRunStatsLiveTextHandlesTickerAndSignal(t *testing.T) {
 	stubDB(t)
 	stubConnectClose(t)
@@ -212,7 +439,7 @@ func stubOverviewFetcher(t *testing.T, fn func(context.Context, *sql.DB, string,
 	})
 }
 
-func stubTopPagesFetcher(t *testing.T, fn func(context.Context, *sql.DB, string, int, int) ([]*PageStat, error)) {
+func stubTopPagesFetcher(t *testing.T, fn func(context.Context, *sql.DB, string, int, int, SessionFilter) ([]*PageStat, error)) {
 	t.Helper()
 	original := getTopPagesFn
 	getTopPagesFn = fn
@@ -221,7 +448,7 @@ func stubTopPagesFetcher(t *testing.T, fn func(context.Context, *sql.DB, string,
 	})
 }
 
-func stubBreakdownFetcher(t *testing.T, fn func(context.Context, *sql.DB, string, string, int, int) (*BreakdownStat, error)) {
+func stubBreakdownFetcher(t *testing.T, fn func(context.Context, *sql.DB, string, string, int, int, SessionFilter) (*BreakdownStat, error)) {
 	t.Helper()
 	original := getBreakdownStatsFn
 	getBreakdownStatsFn = fn
@@ -238,3 +465,30 @@ func stubLiveStatsFetcher(t *testing.T, fn func(context.Context, *sql.DB, string
 		getLiveStatsFn = original
 	})
 }
+
+func stubErrorStatsFetcher(t *testing.T, fn func(context.Context, *sql.DB, string, int, int) ([]*ErrorStat, error)) {
+	t.Helper()
+	original := getErrorStatsFn
+	getErrorStatsFn = fn
+	t.Cleanup(func() {
+		getErrorStatsFn = original
+	})
+}
+
+func stubBotsFetcher(t *testing.T, fn func(context.Context, *sql.DB, string, handlers.BotsQuery) (*handlers.BotsReport, error)) {
+	t.Helper()
+	original := getBotsFn
+	getBotsFn = fn
+	t.Cleanup(func() {
+		getBotsFn = original
+	})
+}
+
+func stubPathsFetcher(t *testing.T, fn func(context.Context, *sql.DB, string, handlers.PathsQuery) (*handlers.PathsReport, error)) {
+	t.Helper()
+	original := getPathsFn
+	getPathsFn = fn
+	t.Cleanup(func() {
+		getPathsFn = original
+	})
+}
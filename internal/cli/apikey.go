@@ -34,12 +34,17 @@ The full API key is displayed ONCE on creation. Save it securely - it cannot be
 Available scopes:
   ingest  - Allows pushing analytics events via POST /api/ingest (default)
   stats   - Allows reading stats via GET /api/v1/stats/:website_id
+  forward_client_context
+          - Lets ingest calls pass the end user's IP and User-Agent in
+            context.ip / context.user_agent (used for GeoIP, bot detection
+            and sessions instead of the calling server's)
 
 Examples:
   kaunta apikey create example.com
   kaunta apikey create example.com --name "Rails Backend"
   kaunta apikey create example.com --scope stats --name "Stats Reader"
-  kaunta apikey create example.com --scope ingest,stats --name "Full Access"`,
+  kaunta apikey create example.com --scope ingest,stats --name "Full Access"
+  kaunta apikey create example.com --scope ingest,forward_client_context --name "Rails Backend"`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runAPIKeyCreate(args[0])
@@ -310,6 +315,7 @@ func runAPIKeyShow(keyIDOrPrefix string) error {
 	}
 
 	_, _ = fmt.Fprintf(w, "Scopes:\t%s\n", strings.Join(key.Scopes, ", "))
+	_, _ = fmt.Fprintf(w, "Client Context:\t%s\n", clientContextDescription(key))
 	_, _ = fmt.Fprintf(w, "Rate Limit:\t%d req/min\n", key.RateLimitPerMinute)
 
 	status := "active"
@@ -338,6 +344,14 @@ func runAPIKeyShow(keyIDOrPrefix string) error {
 	return nil
 }
 
+// clientContextDescription explains where ingest events get their IP/User-Agent
+func clientContextDescription(key *models.APIKey) string {
+	if key.HasScope(models.ScopeForwardClientContext) {
+		return "forwarded (end-user IP/User-Agent from payload context)"
+	}
+	return "not forwarded (IP/User-Agent of the calling server)"
+}
+
 // getAPIKeyByPrefix finds an API key by its prefix
 func getAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
 	query := `
@@ -395,7 +409,7 @@ func getAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
 func init() {
 	// Create command flags
 	apikeyCreateCmd.Flags().StringVarP(&apikeyName, "name", "n", "", "Friendly name for the API key (e.g., 'Rails Backend')")
-	apikeyCreateCmd.Flags().StringVarP(&apikeyScopes, "scope", "s", "", "Comma-separated scopes (ingest, stats)")
+	apikeyCreateCmd.Flags().StringVarP(&apikeyScopes, "scope", "s", "", "Comma-separated scopes (ingest, stats, forward_client_context)")
 
 	// List command flags
 	apikeyListCmd.Flags().StringVarP(&apikeyListFormat, "format", "f", "table", "Output format (table, json)")
@@ -0,0 +1,678 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/models"
+)
+
+var botsCmd = &cobra.Command{
+	Use:   "bots",
+	Short: "Manage bot detection patterns, the IP blocklist and hosting ASNs",
+	Long: `Manage how requests are recognised as bots.
+
+User agent patterns are PostgreSQL regexes matched case-insensitively. IP
+blocklist entries mark every request from a range as a bot, whatever its user
+agent. Hosting provider ASNs mark traffic from cloud networks, handled per
+website by its datacenter policy. Bot hits are never recorded as pageviews;
+they show up in 'kaunta stats bots'.
+
+Both are read from the database on every request, so changes apply
+immediately on all servers.`,
+}
+
+var botsPatternsCmd = &cobra.Command{
+	Use:   "patterns",
+	Short: "Manage bot user agent patterns",
+}
+
+var botsPatternsListCmd = &cobra.Command{
+	Use:   "list [--source <name>] [--format json|table]",
+	Short: "List user agent patterns",
+	Long: `List user agent patterns. Sources are "builtin" (shipped with Kaunta),
+"cli" (added with 'kaunta bots patterns add') or the name given to an
+imported list.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsPatternsList(botRulesSource, botRulesFormat)
+	},
+}
+
+var botsPatternsAddCmd = &cobra.Command{
+	Use:   "add <name> <regex> --type <bot-type> [--legitimate] [--notes <text>]",
+	Short: "Add or replace a user agent pattern",
+	Long: `Add a user agent pattern. Adding a pattern with an existing name replaces it.
+
+The regex is checked by PostgreSQL before it is saved, since an invalid
+pattern would break bot detection for every request.
+
+Bot types: ai (llm_crawler), generic_bot, scraper, headless_browser.
+--legitimate marks well-behaved crawlers (search engines, AI crawlers that
+respect robots.txt); it is shown in the bots report.
+
+Examples:
+  kaunta bots patterns add Amazonbot Amazonbot --type ai --legitimate
+  kaunta bots patterns add scrapy 'Scrapy/' --type scraper --notes "Python Scrapy"`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsPatternsAdd(models.BotPattern{
+			Name:         args[0],
+			Regex:        args[1],
+			BotType:      botRulesType,
+			IsLegitimate: botRulesLegitimate,
+			Notes:        botRulesNotes,
+		})
+	},
+}
+
+var botsPatternsRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a user agent pattern",
+	Long:  `Remove a user agent pattern. Built-in patterns can be removed too.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsPatternsRemove(args[0])
+	},
+}
+
+var botsPatternsTestCmd = &cobra.Command{
+	Use:   "test <user-agent> [--ip <address>]",
+	Short: "Show which patterns match a user agent",
+	Long: `List the patterns matching a user agent, the one used for detection first.
+With --ip, the blocklist is checked as well; it takes precedence.
+
+Examples:
+  kaunta bots patterns test "Mozilla/5.0 (compatible; GPTBot/1.2; +https://openai.com/gptbot)"
+  kaunta bots patterns test "curl/8.4.0" --ip 203.0.113.7`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsPatternsTest(args[0], botRulesTestIP)
+	},
+}
+
+var botsPatternsImportCmd = &cobra.Command{
+	Use:   "import <file> --source <name> [--type <bot-type>] [--legitimate] [--replace]",
+	Short: "Import user agent patterns from a crawler list",
+	Long: `Import patterns from a local file. Supported formats:
+
+  - crawler-user-agents JSON (an array of objects with a "pattern" field)
+  - a JSON array of regex strings
+  - plain text, one regex per line ('#' starts a comment)
+
+Patterns are named "<source>:<regex>". Existing names are skipped and regexes
+PostgreSQL rejects are reported. With --replace, patterns previously imported
+from the same source are removed first, so a list can be refreshed in place.
+
+Examples:
+  kaunta bots patterns import crawler-user-agents.json --source crawler-user-agents --replace
+  kaunta bots patterns import scrapers.txt --source scrapers --type scraper`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsPatternsImport(args[0], botRulesSource, botRulesType, botRulesLegitimate, botRulesReplace)
+	},
+}
+
+var botsIPCmd = &cobra.Command{
+	Use:   "ip",
+	Short: "Manage the bot IP blocklist",
+}
+
+var botsIPBlockCmd = &cobra.Command{
+	Use:   "block <ip|cidr> [--type <bot-type>] [--notes <text>]",
+	Short: "Treat all requests from an IP range as bots",
+	Long: `Add an IP address or CIDR range to the blocklist. Requests from it are
+logged as bot hits of the given type (default scraper) instead of being
+recorded. Blocking an existing range updates its type and notes.
+
+Examples:
+  kaunta bots ip block 203.0.113.0/24 --notes "scraper farm"
+  kaunta bots ip block 2001:db8::/32 --type ai`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsIPBlock(args[0], botRulesIPType, botRulesNotes)
+	},
+}
+
+var botsIPUnblockCmd = &cobra.Command{
+	Use:   "unblock <ip|cidr>",
+	Short: "Remove an IP range from the blocklist",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsIPUnblock(args[0])
+	},
+}
+
+var botsIPListCmd = &cobra.Command{
+	Use:   "list [--format json|table]",
+	Short: "List blocked IP ranges",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsIPList(botRulesFormat)
+	},
+}
+
+var botsHostingCmd = &cobra.Command{
+	Use:   "hosting",
+	Short: "Manage the hosting provider ASN list",
+	Long: `Manage the autonomous systems treated as hosting and cloud providers.
+Traffic from them is flagged, excluded or kept according to each website's
+datacenter policy ('kaunta website set-datacenter-policy'). Needs an ASN
+database (see 'kaunta geoip').`,
+}
+
+var botsHostingListCmd = &cobra.Command{
+	Use:   "list [--format json|table]",
+	Short: "List hosting provider ASNs",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsHostingList(botRulesFormat)
+	},
+}
+
+var botsHostingAddCmd = &cobra.Command{
+	Use:   "add <asn> [--name <provider>]",
+	Short: "Mark an ASN as a hosting provider",
+	Long: `Mark an ASN as a hosting provider. Adding a listed ASN renames it.
+
+Examples:
+  kaunta bots hosting add AS24940 --name Hetzner
+  kaunta bots hosting add 63949 --name Linode`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsHostingAdd(args[0], botRulesName)
+	},
+}
+
+var botsHostingRemoveCmd = &cobra.Command{
+	Use:   "remove <asn>",
+	Short: "Stop treating an ASN as a hosting provider",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBotsHostingRemove(args[0])
+	},
+}
+
+// Command flags
+var (
+	botRulesSource     string
+	botRulesFormat     string
+	botRulesType       string
+	botRulesIPType     string
+	botRulesLegitimate bool
+	botRulesNotes      string
+	botRulesName       string
+	botRulesTestIP     string
+	botRulesReplace    bool
+)
+
+// ensureDatabase connects when no connection is open and returns the cleanup
+func ensureDatabase() (func(), error) {
+	if database.DB != nil {
+		return func() {}, nil
+	}
+	if err := connectDatabase(); err != nil {
+		return func() {}, fmt.Errorf("database connection failed: %w", err)
+	}
+	return func() { _ = closeDatabase() }, nil
+}
+
+func runBotsPatternsList(source, format string) error {
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	patterns, err := models.ListBotPatterns(ctx, database.DB, source)
+	if err != nil {
+		return fmt.Errorf("failed to list bot patterns: %w", err)
+	}
+
+	if format == "json" {
+		if patterns == nil {
+			patterns = []*models.BotPattern{}
+		}
+		data, err := json.MarshalIndent(patterns, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(patterns) == 0 {
+		fmt.Println("No bot patterns found")
+		return nil
+	}
+	outputBotPatterns(patterns)
+	return nil
+}
+
+func outputBotPatterns(patterns []*models.BotPattern) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer func() { _ = w.Flush() }()
+
+	_, _ = fmt.Fprintln(w, "NAME\tREGEX\tTYPE\tLEGITIMATE\tSOURCE")
+	_, _ = fmt.Fprintln(w, "----\t-----\t----\t----------\t------")
+	for _, p := range patterns {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n", shortenBotField(p.Name, 40), shortenBotField(p.Regex, 50), p.BotType, p.IsLegitimate, p.Source)
+	}
+}
+
+// shortenBotField keeps long imported names and regexes from widening the table
+func shortenBotField(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	return value[:limit-3] + "..."
+}
+
+func runBotsPatternsAdd(p models.BotPattern) error {
+	if strings.TrimSpace(p.BotType) == "" {
+		return fmt.Errorf("--type is required")
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stored, err := models.UpsertBotPattern(ctx, database.DB, p)
+	if err != nil {
+		return fmt.Errorf("failed to save bot pattern: %w", err)
+	}
+
+	fmt.Printf("Pattern '%s' saved: %s (%s)\n", stored.Name, stored.Regex, stored.BotType)
+	return nil
+}
+
+func runBotsPatternsRemove(name string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := models.DeleteBotPattern(ctx, database.DB, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no bot pattern named %q", name)
+		}
+		return fmt.Errorf("failed to remove bot pattern: %w", err)
+	}
+
+	fmt.Printf("Pattern '%s' removed\n", name)
+	return nil
+}
+
+func runBotsPatternsTest(userAgent, ip string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if ip != "" {
+		blocked, err := models.MatchBlockedIP(ctx, database.DB, ip)
+		if err != nil {
+			return fmt.Errorf("failed to check IP blocklist: %w", err)
+		}
+		if blocked != nil {
+			fmt.Printf("Bot: IP %s is in blocked range %s (%s)\n", ip, blocked.CIDR, blocked.BotType)
+			return nil
+		}
+	}
+
+	matches, err := models.MatchBotPatterns(ctx, database.DB, userAgent)
+	if err != nil {
+		return fmt.Errorf("failed to match bot patterns: %w", err)
+	}
+	if len(matches) == 0 {
+		fmt.Println("Not a bot: no pattern matches")
+		return nil
+	}
+
+	fmt.Printf("Bot: %s (%s)\n\n", matches[0].Name, matches[0].BotType)
+	outputBotPatterns(matches)
+	return nil
+}
+
+func runBotsPatternsImport(path, source, botType string, legitimate, replace bool) error {
+	if strings.TrimSpace(source) == "" {
+		return fmt.Errorf("--source is required")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	regexes, err := parseCrawlerList(data)
+	if err != nil {
+		return err
+	}
+	if len(regexes) == 0 {
+		return fmt.Errorf("no patterns found in %s", path)
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := models.ImportBotPatterns(ctx, database.DB, source, botType, legitimate, regexes, replace)
+	if err != nil {
+		return fmt.Errorf("failed to import bot patterns: %w", err)
+	}
+
+	fmt.Printf("Imported %d patterns from %s as '%s'\n", result.Added, path, source)
+	if replace {
+		fmt.Printf("Removed:  %d previous patterns\n", result.Removed)
+	}
+	if result.Skipped > 0 {
+		fmt.Printf("Skipped:  %d (already imported from this source)\n", result.Skipped)
+	}
+	if len(result.Invalid) > 0 {
+		fmt.Printf("Invalid:  %d\n", len(result.Invalid))
+		for _, regex := range result.Invalid {
+			fmt.Printf("  %s\n", regex)
+		}
+	}
+	return nil
+}
+
+// parseCrawlerList extracts regexes from a crawler-user-agents style JSON
+// array (objects with "pattern", or plain strings) or from a text file with
+// one regex per line. Duplicates are dropped.
+func parseCrawlerList(data []byte) ([]string, error) {
+	var regexes []string
+	seen := make(map[string]bool)
+	add := func(regex string) {
+		regex = strings.TrimSpace(regex)
+		if regex == "" || seen[regex] {
+			return
+		}
+		seen[regex] = true
+		regexes = append(regexes, regex)
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("invalid JSON crawler list: %w", err)
+		}
+		for i, raw := range entries {
+			var regex string
+			if err := json.Unmarshal(raw, &regex); err == nil {
+				add(regex)
+				continue
+			}
+			var entry struct {
+				Pattern string `json:"pattern"`
+			}
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				return nil, fmt.Errorf("invalid entry %d in crawler list: %w", i+1, err)
+			}
+			add(entry.Pattern)
+		}
+		return regexes, nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		add(line)
+	}
+	return regexes, scanner.Err()
+}
+
+func runBotsIPBlock(value, botType, notes string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	blocked, err := models.BlockIP(ctx, database.DB, value, botType, notes)
+	if err != nil {
+		return fmt.Errorf("failed to block %s: %w", value, err)
+	}
+
+	fmt.Printf("Blocked %s (%s)\n", blocked.CIDR, blocked.BotType)
+	return nil
+}
+
+func runBotsIPUnblock(value string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cidr, err := models.UnblockIP(ctx, database.DB, value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s is not blocked", cidr)
+		}
+		return fmt.Errorf("failed to unblock %s: %w", value, err)
+	}
+
+	fmt.Printf("Unblocked %s\n", cidr)
+	return nil
+}
+
+func runBotsIPList(format string) error {
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	list, err := models.ListBlockedIPs(ctx, database.DB)
+	if err != nil {
+		return fmt.Errorf("failed to list blocked IPs: %w", err)
+	}
+
+	if format == "json" {
+		if list == nil {
+			list = []*models.BlockedIP{}
+		}
+		data, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No blocked IP ranges")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CIDR\tTYPE\tNOTES\tADDED")
+	_, _ = fmt.Fprintln(w, "----\t----\t-----\t-----")
+	for _, b := range list {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", b.CIDR, b.BotType, b.Notes, b.CreatedAt.Format("2006-01-02"))
+	}
+	return w.Flush()
+}
+
+func runBotsHostingList(format string) error {
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	list, err := models.ListHostingASNs(ctx, database.DB)
+	if err != nil {
+		return fmt.Errorf("failed to list hosting ASNs: %w", err)
+	}
+
+	if format == "json" {
+		if list == nil {
+			list = []*models.HostingASN{}
+		}
+		data, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No hosting provider ASNs")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ASN\tPROVIDER\tSOURCE")
+	_, _ = fmt.Fprintln(w, "---\t--------\t------")
+	for _, h := range list {
+		_, _ = fmt.Fprintf(w, "AS%d\t%s\t%s\n", h.ASN, h.Name, h.Source)
+	}
+	return w.Flush()
+}
+
+func runBotsHostingAdd(value, name string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	h, err := models.AddHostingASN(ctx, database.DB, value, name)
+	if err != nil {
+		return fmt.Errorf("failed to add hosting ASN: %w", err)
+	}
+
+	fmt.Printf("AS%d (%s) marked as a hosting provider\n", h.ASN, h.Name)
+	return nil
+}
+
+func runBotsHostingRemove(value string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	asn, err := models.RemoveHostingASN(ctx, database.DB, value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("AS%d is not a listed hosting provider", asn)
+		}
+		return fmt.Errorf("failed to remove hosting ASN: %w", err)
+	}
+
+	fmt.Printf("AS%d removed from hosting providers\n", asn)
+	return nil
+}
+
+func init() {
+	botsPatternsListCmd.Flags().StringVar(&botRulesSource, "source", "", "Only patterns from this source (builtin, cli or an import name)")
+	botsPatternsListCmd.Flags().StringVarP(&botRulesFormat, "format", "f", "table", "Output format (json, table)")
+
+	botsPatternsAddCmd.Flags().StringVar(&botRulesType, "type", "", "Bot type: ai, generic_bot, scraper, headless_browser")
+	botsPatternsAddCmd.Flags().BoolVar(&botRulesLegitimate, "legitimate", false, "Mark as a well-behaved crawler")
+	botsPatternsAddCmd.Flags().StringVar(&botRulesNotes, "notes", "", "Free-form description")
+
+	botsPatternsTestCmd.Flags().StringVar(&botRulesTestIP, "ip", "", "Also check this IP against the blocklist")
+
+	botsPatternsImportCmd.Flags().StringVar(&botRulesSource, "source", "", "Name of the list, used to refresh it later (required)")
+	botsPatternsImportCmd.Flags().StringVar(&botRulesType, "type", "generic_bot", "Bot type of the imported patterns")
+	botsPatternsImportCmd.Flags().BoolVar(&botRulesLegitimate, "legitimate", false, "Mark the imported patterns as well-behaved crawlers")
+	botsPatternsImportCmd.Flags().BoolVar(&botRulesReplace, "replace", false, "Remove patterns previously imported from this source first")
+
+	botsIPBlockCmd.Flags().StringVar(&botRulesIPType, "type", "scraper", "Bot type to log hits as")
+	botsIPBlockCmd.Flags().StringVar(&botRulesNotes, "notes", "", "Free-form description")
+
+	botsIPListCmd.Flags().StringVarP(&botRulesFormat, "format", "f", "table", "Output format (json, table)")
+
+	botsHostingListCmd.Flags().StringVarP(&botRulesFormat, "format", "f", "table", "Output format (json, table)")
+	botsHostingAddCmd.Flags().StringVar(&botRulesName, "name", "", "Provider name (default: AS<number>)")
+
+	botsPatternsCmd.AddCommand(botsPatternsListCmd)
+	botsPatternsCmd.AddCommand(botsPatternsAddCmd)
+	botsPatternsCmd.AddCommand(botsPatternsRemoveCmd)
+	botsPatternsCmd.AddCommand(botsPatternsTestCmd)
+	botsPatternsCmd.AddCommand(botsPatternsImportCmd)
+
+	botsIPCmd.AddCommand(botsIPBlockCmd)
+	botsIPCmd.AddCommand(botsIPUnblockCmd)
+	botsIPCmd.AddCommand(botsIPListCmd)
+
+	botsCmd.AddCommand(botsPatternsCmd)
+	botsCmd.AddCommand(botsIPCmd)
+
+	botsHostingCmd.AddCommand(botsHostingListCmd)
+	botsHostingCmd.AddCommand(botsHostingAddCmd)
+	botsHostingCmd.AddCommand(botsHostingRemoveCmd)
+	botsCmd.AddCommand(botsHostingCmd)
+
+	RootCmd.AddCommand(botsCmd)
+}
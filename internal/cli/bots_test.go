@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/database"
+)
+
+func TestParseCrawlerListJSON(t *testing.T) {
+	data := []byte(`[
+		{"pattern": "Googlebot\\/", "url": "http://www.google.com/bot.html", "instances": []},
+		{"pattern": "bingbot"},
+		"AhrefsBot",
+		{"pattern": "bingbot"}
+	]`)
+
+	regexes, err := parseCrawlerList(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`Googlebot\/`, "bingbot", "AhrefsBot"}, regexes)
+
+	_, err = parseCrawlerList([]byte(`[{"pattern": 1}]`))
+	assert.Error(t, err)
+}
+
+func TestParseCrawlerListText(t *testing.T) {
+	data := []byte("# scrapers\nScrapy/\n\n  HTTrack  \nScrapy/\n")
+
+	regexes, err := parseCrawlerList(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Scrapy/", "HTTrack"}, regexes)
+}
+
+func TestRunBotsPatternsTest(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = mockDB.Close() })
+
+	origDB := database.DB
+	database.DB = mockDB
+	t.Cleanup(func() { database.DB = origDB })
+
+	columns := []string{"pattern_id", "pattern_name", "pattern_regex", "bot_type", "is_legitimate", "notes", "source", "created_at"}
+	mock.ExpectQuery("FROM bot_ip_blocklist").WithArgs("198.51.100.4").
+		WillReturnRows(sqlmock.NewRows([]string{"cidr", "bot_type", "notes", "created_at"}))
+	mock.ExpectQuery("FROM bot_user_agent_patterns").WithArgs("GPTBot/1.2").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(2, "GPTBot", "GPTBot", "llm_crawler", true, "OpenAI GPT crawler", "builtin", time.Now()).
+			AddRow(20, "generic_bot", "bot|crawler|spider|scraper", "generic_bot", false, nil, "builtin", time.Now()))
+
+	output, err := captureOutput(t, func() error {
+		return runBotsPatternsTest("GPTBot/1.2", "198.51.100.4")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Bot: GPTBot (llm_crawler)")
+	assert.Contains(t, output, "generic_bot")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunBotsIPBlockRejectsInvalidInput(t *testing.T) {
+	stubDB(t)
+
+	err := runBotsIPBlock("office", "scraper", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid IP address")
+
+	err = runBotsIPBlock("203.0.113.0/24", "robot", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid bot type")
+}
@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/channels"
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/models"
+)
+
+var channelsCmd = &cobra.Command{
+	Use:   "channels",
+	Short: "Manage traffic channel rules",
+	Long: `Manage the rules that normalize referrers to source names and group
+sessions into channels: Direct, Organic Search, Paid Search, Social, Email,
+Referral and AI assistants.
+
+Each session is classified when it starts, from its referrer and utm_medium /
+utm_source. Per-website rules are checked before the built-in ones and apply
+to new sessions within a few minutes.`,
+}
+
+var channelsListCmd = &cobra.Command{
+	Use:   "list [website-domain] [--builtin]",
+	Short: "List channel rules",
+	Long: `List a website's custom rules. Without a domain, or with --builtin, the
+built-in rules are listed as well.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := ""
+		if len(args) == 1 {
+			domain = args[0]
+		}
+		return runChannelsList(domain, channelsBuiltin || domain == "")
+	},
+}
+
+var channelsAddCmd = &cobra.Command{
+	Use:   "add <website-domain> <pattern> --source <name> --channel <channel>",
+	Short: "Add or replace a custom rule",
+	Long: `Add a rule mapping referrers to a source name and channel. Adding a rule
+for an existing pattern replaces it.
+
+Patterns are hosts: "example.com" matches example.com and its subdomains,
+"search.*" matches the search label under any suffix. utm_source values equal
+to the source name or the pattern's name match as well.
+
+Examples:
+  kaunta channels add example.com news.example.org --source "Example News" --channel referral
+  kaunta channels add example.com partner.io --source Partner --channel email
+  kaunta channels add example.com google.com --source "Google Ads" --channel "paid search"`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runChannelsAdd(args[0], args[1], channelsSource, channelsChannel)
+	},
+}
+
+var channelsRemoveCmd = &cobra.Command{
+	Use:   "remove <website-domain> <pattern>",
+	Short: "Remove a custom rule",
+	Long:  `Remove a custom rule. Sessions already classified keep their channel.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runChannelsRemove(args[0], args[1])
+	},
+}
+
+var channelsTestCmd = &cobra.Command{
+	Use:   "test [website-domain] [--referrer <url>] [--utm-source <s>] [--utm-medium <m>]",
+	Short: "Show how a visit would be classified",
+	Long: `Classify a referrer and UTM parameters without recording anything. With a
+domain, that website's custom rules are applied first.
+
+Examples:
+  kaunta channels test --referrer https://l.facebook.com/
+  kaunta channels test example.com --referrer https://news.example.org/post
+  kaunta channels test --utm-source google --utm-medium cpc`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := ""
+		if len(args) == 1 {
+			domain = args[0]
+		}
+		return runChannelsTest(domain, channelsReferrer, channelsUTMSource, channelsUTMMedium)
+	},
+}
+
+// Command flags
+var (
+	channelsBuiltin   bool
+	channelsSource    string
+	channelsChannel   string
+	channelsReferrer  string
+	channelsUTMSource string
+	channelsUTMMedium string
+)
+
+func runChannelsList(domain string, builtin bool) error {
+	var custom []*models.ChannelRule
+	if domain != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+		defer cleanup()
+		if err != nil {
+			return err
+		}
+
+		custom, err = models.ListChannelRules(ctx, database.DB, websiteID)
+		if err != nil {
+			return fmt.Errorf("failed to list channel rules: %w", err)
+		}
+		if len(custom) == 0 && !builtin {
+			fmt.Printf("No custom channel rules for website '%s' (built-in rules apply)\n", domain)
+			return nil
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PATTERN\tSOURCE\tCHANNEL\tORIGIN")
+	_, _ = fmt.Fprintln(w, "-------\t------\t-------\t------")
+	for _, r := range custom {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\tcustom\n", r.Pattern, r.Source, r.Channel)
+	}
+	if builtin {
+		for _, r := range channels.Builtin {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\tbuilt-in\n", r.Pattern, r.Source, r.Channel)
+		}
+	}
+	return w.Flush()
+}
+
+func runChannelsAdd(domain, pattern, source, channel string) error {
+	if strings.TrimSpace(source) == "" {
+		return fmt.Errorf("--source is required")
+	}
+	name, ok := channels.Parse(channel)
+	if !ok {
+		return fmt.Errorf("invalid --channel %q (valid: %s)", channel, strings.Join(channels.All, ", "))
+	}
+	rule := channels.Rule{Pattern: pattern, Source: source, Channel: name}
+	if err := channels.ValidateRule(rule); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	stored, err := models.UpsertChannelRule(ctx, database.DB, websiteID, rule)
+	if err != nil {
+		return fmt.Errorf("failed to save channel rule: %w", err)
+	}
+
+	fmt.Printf("Rule saved for %s: %s -> %s (%s)\n", domain, stored.Pattern, stored.Source, stored.Channel)
+	fmt.Println("New sessions use it once the server's rule cache refreshes (up to 5 minutes).")
+	return nil
+}
+
+func runChannelsRemove(domain, pattern string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if err := models.DeleteChannelRule(ctx, database.DB, websiteID, pattern); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no custom rule for %q on %s", pattern, domain)
+		}
+		return fmt.Errorf("failed to remove channel rule: %w", err)
+	}
+
+	fmt.Printf("Rule '%s' removed from %s\n", pattern, domain)
+	return nil
+}
+
+func runChannelsTest(domain, referrer, utmSource, utmMedium string) error {
+	var custom []channels.Rule
+	if domain != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+		defer cleanup()
+		if err != nil {
+			return err
+		}
+
+		rules, err := models.ListChannelRules(ctx, database.DB, websiteID)
+		if err != nil {
+			return fmt.Errorf("failed to list channel rules: %w", err)
+		}
+		for _, r := range rules {
+			custom = append(custom, r.Rule())
+		}
+	}
+
+	result := channels.Classify(channels.Touch{
+		ReferrerDomain: referrer,
+		UTMSource:      utmSource,
+		UTMMedium:      utmMedium,
+	}, custom)
+
+	fmt.Printf("Source:  %s\nChannel: %s\n", result.Source, result.Channel)
+	return nil
+}
+
+func init() {
+	channelsListCmd.Flags().BoolVar(&channelsBuiltin, "builtin", false, "Include the built-in rules")
+
+	channelsAddCmd.Flags().StringVar(&channelsSource, "source", "", "Source name to report (e.g. \"Example News\")")
+	channelsAddCmd.Flags().StringVar(&channelsChannel, "channel", "", "Channel: direct, organic-search, paid-search, social, email, referral, ai-assistants")
+
+	channelsTestCmd.Flags().StringVar(&channelsReferrer, "referrer", "", "Referrer URL or host")
+	channelsTestCmd.Flags().StringVar(&channelsUTMSource, "utm-source", "", "utm_source value")
+	channelsTestCmd.Flags().StringVar(&channelsUTMMedium, "utm-medium", "", "utm_medium value")
+
+	channelsCmd.AddCommand(channelsListCmd)
+	channelsCmd.AddCommand(channelsAddCmd)
+	channelsCmd.AddCommand(channelsRemoveCmd)
+	channelsCmd.AddCommand(channelsTestCmd)
+
+	RootCmd.AddCommand(channelsCmd)
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunChannelsTestBuiltin(t *testing.T) {
+	output, err := captureOutput(t, func() error {
+		return runChannelsTest("", "https://l.facebook.com/l.php", "", "")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Source:  Facebook")
+	assert.Contains(t, output, "Channel: Social")
+
+	output, err = captureOutput(t, func() error {
+		return runChannelsTest("", "", "google", "cpc")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Channel: Paid Search")
+}
+
+func TestRunChannelsAddValidation(t *testing.T) {
+	err := runChannelsAdd("example.com", "news.example.org", "", "referral")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--source")
+
+	err = runChannelsAdd("example.com", "news.example.org", "News", "television")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --channel")
+
+	err = runChannelsAdd("example.com", "*.example.org", "News", "referral")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid pattern")
+}
+
+func TestRunChannelsListBuiltin(t *testing.T) {
+	output, err := captureOutput(t, func() error {
+		return runChannelsList("", true)
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "google.*")
+	assert.Contains(t, output, "built-in")
+}
+
+func TestSessionFilterNormalizeChannel(t *testing.T) {
+	filter := SessionFilter{Channel: "organic-search"}
+	require.NoError(t, filter.normalizeChannel())
+	assert.Equal(t, "Organic Search", filter.Channel)
+
+	filter = SessionFilter{Channel: "tv"}
+	assert.Error(t, filter.normalizeChannel())
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/seuros/kaunta/internal/database"
 )
 
@@ -544,6 +545,50 @@ func GetAllowedDomains(ctx context.Context, websiteDomain string) ([]string, *We
 	return website.AllowedDomains, website, nil
 }
 
+// SetMatomoSiteID maps a Matomo idsite to a website (nil removes the mapping)
+func SetMatomoSiteID(ctx context.Context, websiteDomain string, siteID *int) error {
+	result, err := database.DB.ExecContext(ctx, `
+		UPDATE website
+		SET matomo_site_id = $1, updated_at = NOW()
+		WHERE LOWER(domain) = LOWER($2) AND deleted_at IS NULL
+	`, siteID, websiteDomain)
+	if err != nil {
+		if strings.Contains(err.Error(), "idx_website_matomo_site_id") {
+			return fmt.Errorf("idsite %d is already mapped to another website", *siteID)
+		}
+		return fmt.Errorf("failed to update website: %w", err)
+	}
+
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("website '%s' not found", websiteDomain)
+	}
+	return nil
+}
+
+// SetWebsiteProxy updates proxy_mode (when mode is non-nil) and trusted_proxies
+// (when setProxies is true) and returns the resulting settings
+func SetWebsiteProxy(ctx context.Context, websiteDomain string, mode *string, setProxies bool, proxies []string) (string, []string, error) {
+	var finalMode string
+	var finalProxies []string
+
+	err := database.DB.QueryRowContext(ctx, `
+		UPDATE website
+		SET proxy_mode = COALESCE($1, proxy_mode),
+		    trusted_proxies = CASE WHEN $2 THEN $3::cidr[] ELSE trusted_proxies END,
+		    updated_at = NOW()
+		WHERE LOWER(domain) = LOWER($4) AND deleted_at IS NULL
+		RETURNING COALESCE(proxy_mode, 'none'), COALESCE(trusted_proxies::text[], '{}')
+	`, mode, setProxies, pq.Array(proxies), websiteDomain).Scan(&finalMode, pq.Array(&finalProxies))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil, fmt.Errorf("website '%s' not found", websiteDomain)
+		}
+		return "", nil, fmt.Errorf("failed to update website: %w", err)
+	}
+
+	return finalMode, finalProxies, nil
+}
+
 // SetPublicStatsEnabled enables or disables public stats for a website
 func SetPublicStatsEnabled(ctx context.Context, websiteDomain string, enabled bool) (*WebsiteDetail, error) {
 	// Get website first to ensure it exists
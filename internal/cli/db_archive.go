@@ -0,0 +1,349 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/archive"
+	"github.com/seuros/kaunta/internal/config"
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/logging"
+	"go.uber.org/zap"
+)
+
+var dbPartitionsArchiveCmd = &cobra.Command{
+	Use:   "archive [<partition>...] [--before <YYYY-MM-DD>] [--to <dir|s3://bucket/prefix>]",
+	Short: "Export partitions to gzipped CSV files and record them in the manifest",
+	Long: `Export partitions to gzipped CSV files in the archive location
+(archive_location / ARCHIVE_LOCATION, or --to), either a local directory or
+an S3-compatible bucket such as s3://kaunta-archive/events. Archived
+partitions are recorded in the manifest ('kaunta db archives') and can be
+loaded back with 'kaunta db restore-archive'.
+
+Partitions stay in the database; archive them before dropping them, or let
+'kaunta db partitions drop' do it. Set ARCHIVE_S3_ENDPOINT to use MinIO or
+another S3-compatible service.`,
+	Example: `  kaunta db partitions archive website_event_2026_01_15
+  kaunta db partitions archive --before 2026-07-01 --table website_event
+  kaunta db partitions archive --before 2026-07-01 --to /var/backups/kaunta`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBPartitionsArchive(args, dbPartitionsTable, dbArchiveBefore, dbArchiveTo)
+	},
+}
+
+var dbArchivesCmd = &cobra.Command{
+	Use:   "archives [--table <name>] [--format json|table]",
+	Short: "List archived partitions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBArchives(dbArchivesTable, dbArchivesFormat)
+	},
+}
+
+var dbRestoreArchiveCmd = &cobra.Command{
+	Use:   "restore-archive <file|s3://bucket/key> [--detached]",
+	Short: "Load an archived partition back into the database",
+	Long: `Load an archived partition back into a table named after the partition
+(e.g. website_event_2026_01_15) and attach it to its parent, so its data
+shows up in reports again. With --detached the table is left standalone for
+ad hoc queries.
+
+If the partition is in the manifest, the file must match its checksum. The
+table must not exist. Restored partitions are old enough to be dropped by
+the next cleanup; drop or detach them when the investigation is done.`,
+	Example: `  kaunta db restore-archive /var/backups/kaunta/website_event_2026_01_15.csv.gz
+  kaunta db restore-archive s3://kaunta-archive/events/website_event_2026_01_15.csv.gz --detached`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBRestoreArchive(args[0], dbRestoreDetached)
+	},
+}
+
+// Command flags
+var (
+	dbArchiveBefore   string
+	dbArchiveTo       string
+	dbArchivesTable   string
+	dbArchivesFormat  string
+	dbRestoreDetached bool
+)
+
+func archiveOptions(cfg *config.Config) archive.Options {
+	if cfg == nil {
+		return archive.Options{Location: os.Getenv("ARCHIVE_LOCATION")}
+	}
+	return archive.Options{
+		Location:  cfg.ArchiveLocation,
+		Endpoint:  cfg.ArchiveS3Endpoint,
+		Region:    cfg.ArchiveS3Region,
+		AccessKey: cfg.ArchiveS3AccessKey,
+		SecretKey: cfg.ArchiveS3SecretKey,
+	}
+}
+
+// newPartitionScheduler returns the server's partition scheduler. It only
+// drops event partitions when a retention (event_retention) is configured.
+// When an archive location is configured, expiring event partitions are
+// archived before they are dropped, and the location is recorded so
+// cleanup_old_partitions() run from cron keeps unarchived partitions too.
+func newPartitionScheduler(ctx context.Context, databaseURL string, cfg *config.Config) *database.PartitionScheduler {
+	scheduler := database.NewPartitionScheduler(databaseURL)
+	if cfg != nil && cfg.EventRetention > 0 {
+		scheduler.SetRetention(int(cfg.EventRetention / (24 * time.Hour)))
+	}
+	opts := archiveOptions(cfg)
+	if err := archive.RecordLocation(ctx, database.DB, opts.Location); err != nil {
+		logging.L().Warn("failed to record archive location", zap.Error(err))
+	}
+	if opts.Location == "" {
+		return scheduler
+	}
+
+	archiver, err := archive.NewArchiver(database.DB, opts)
+	if err != nil {
+		logging.L().Warn("partition archiving disabled", zap.Error(err))
+		return scheduler
+	}
+	scheduler.SetArchiver(func(ctx context.Context, p database.Partition) error {
+		_, err := archiver.EnsureArchived(ctx, p)
+		return err
+	})
+	return scheduler
+}
+
+// archivePartitions archives each partition, stopping at the first failure
+func archivePartitions(ctx context.Context, archiver *archive.Archiver, partitions []database.Partition) error {
+	for _, p := range partitions {
+		entry, err := archiver.Archive(ctx, p)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Archived %s (%d rows, %s) to %s\n", p.Name(), entry.Rows, formatBytes(entry.SizeBytes), entry.Location)
+	}
+	return nil
+}
+
+// archiveBeforeDrop archives a partition about to be dropped unless the
+// manifest already has it
+func archiveBeforeDrop(ctx context.Context, archiver *archive.Archiver, p database.Partition) error {
+	entry, err := archive.GetEntry(ctx, archiver.DB, p.Name())
+	if err != nil {
+		return err
+	}
+	if entry != nil {
+		fmt.Printf("%s is already archived at %s\n", p.Name(), entry.Location)
+		return nil
+	}
+	return archivePartitions(ctx, archiver, []database.Partition{p})
+}
+
+func runDBPartitionsArchive(names []string, table, before, to string) error {
+	if len(names) == 0 && before == "" {
+		return fmt.Errorf("name partitions to archive or pass --before")
+	}
+	if len(names) > 0 && before != "" {
+		return fmt.Errorf("pass either partition names or --before, not both")
+	}
+	var cutoff time.Time
+	if before != "" {
+		var err error
+		if cutoff, err = time.Parse("2006-01-02", before); err != nil {
+			return fmt.Errorf("invalid --before date %q (use YYYY-MM-DD)", before)
+		}
+		if _, err := partitionTables(table); err != nil {
+			return err
+		}
+	}
+
+	cfg, _ := config.Load()
+	opts := archiveOptions(cfg)
+	if to != "" {
+		opts.Location = to
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	archiver, err := archive.NewArchiver(database.DB, opts)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+	defer cancel()
+
+	var partitions []database.Partition
+	if before != "" {
+		old, err := database.PartitionsBefore(ctx, table, cutoff)
+		if err != nil {
+			return err
+		}
+		if len(old) == 0 {
+			fmt.Printf("No partitions before %s\n", cutoff.Format("2006-01-02"))
+			return nil
+		}
+		for _, p := range old {
+			partitions = append(partitions, p.Partition)
+		}
+	} else {
+		for _, name := range names {
+			p, err := database.GetPartition(ctx, name)
+			if err != nil {
+				return err
+			}
+			partitions = append(partitions, p.Partition)
+		}
+	}
+
+	return archivePartitions(ctx, archiver, partitions)
+}
+
+// archiveJSON is the JSON shape of 'kaunta db archives'
+type archiveJSON struct {
+	Name       string    `json:"name"`
+	Table      string    `json:"table"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	Location   string    `json:"location"`
+	Format     string    `json:"format"`
+	Rows       int64     `json:"rows"`
+	SizeBytes  int64     `json:"size_bytes"`
+	SHA256     string    `json:"sha256"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+func runDBArchives(table, format string) error {
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	entries, err := archive.ListEntries(ctx, database.DB, table)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		out := make([]archiveJSON, len(entries))
+		for i, e := range entries {
+			out[i] = archiveJSON{
+				Name:       e.Partition.Name(),
+				Table:      e.Partition.Table,
+				From:       e.Partition.Date.Format("2006-01-02"),
+				To:         e.Partition.Date.AddDate(0, 0, 1).Format("2006-01-02"),
+				Location:   e.Location,
+				Format:     e.Format,
+				Rows:       e.Rows,
+				SizeBytes:  e.SizeBytes,
+				SHA256:     e.SHA256,
+				ArchivedAt: e.ArchivedAt,
+			}
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No archived partitions")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tROWS\tSIZE\tARCHIVED\tLOCATION")
+	_, _ = fmt.Fprintln(w, "----\t----\t----\t--------\t--------")
+	for _, e := range entries {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n",
+			e.Partition.Name(), e.Rows, formatBytes(e.SizeBytes), e.ArchivedAt.Format("2006-01-02 15:04"), e.Location)
+	}
+	return w.Flush()
+}
+
+func runDBRestoreArchive(location string, detached bool) error {
+	p, err := archive.ParseFileName(location)
+	if err != nil {
+		return fmt.Errorf("cannot tell the partition from %s: %w", location, err)
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 6*time.Hour)
+	defer cancel()
+
+	entry, err := archive.GetEntry(ctx, database.DB, p.Name())
+	if err != nil {
+		return err
+	}
+	var wantSHA256 string
+	if entry != nil {
+		wantSHA256 = entry.SHA256
+	}
+
+	cfg, _ := config.Load()
+	r, err := archive.OpenLocation(ctx, location, archiveOptions(cfg))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	rows, err := archive.Restore(ctx, database.DB, p, r, wantSHA256)
+	if errors.Is(err, archive.ErrTableExists) {
+		return fmt.Errorf("%w; drop it first or query it directly", err)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %d rows into %s\n", rows, p.Name())
+
+	if detached {
+		fmt.Printf("%s is a standalone table (attach it with 'kaunta db partitions attach %s')\n", p.Name(), p.Name())
+		return nil
+	}
+
+	info := database.PartitionInfo{Partition: p}
+	if err := info.Attach(ctx, dbPartitionsLockTimeout); err != nil {
+		return fmt.Errorf("restored %s but failed to attach it: %w", p.Name(), err)
+	}
+	fmt.Printf("Attached %s to %s; drop it with 'kaunta db partitions drop' when done, the next cleanup will otherwise remove it\n", p.Name(), p.Table)
+	return nil
+}
+
+func init() {
+	dbPartitionsArchiveCmd.Flags().StringVar(&dbArchiveBefore, "before", "", "Archive partitions covering days before this date (YYYY-MM-DD)")
+	dbPartitionsArchiveCmd.Flags().StringVar(&dbArchiveTo, "to", "", "Archive location, overriding archive_location")
+
+	dbArchivesCmd.Flags().StringVar(&dbArchivesTable, "table", "", "Only this table (website_event, event_idempotency, bot_detection_log)")
+	dbArchivesCmd.Flags().StringVarP(&dbArchivesFormat, "format", "f", "table", "Output format (json, table)")
+
+	dbRestoreArchiveCmd.Flags().BoolVar(&dbRestoreDetached, "detached", false, "Leave the restored table detached from its parent")
+	dbRestoreArchiveCmd.Flags().DurationVar(&dbPartitionsLockTimeout, "lock-timeout", 5*time.Second, "Give up when a table lock isn't granted within this time")
+
+	dbPartitionsCmd.AddCommand(dbPartitionsArchiveCmd)
+	dbCmd.AddCommand(dbArchivesCmd)
+	dbCmd.AddCommand(dbRestoreArchiveCmd)
+}
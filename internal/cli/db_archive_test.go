@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDBArchivesTable(t *testing.T) {
+	_, mock := newDoctorMock(t)
+
+	mock.ExpectQuery("FROM partition_archive").
+		WithArgs("website_event").
+		WillReturnRows(sqlmock.NewRows([]string{"partition_name", "location", "format", "row_count", "size_bytes", "sha256", "archived_at"}).
+			AddRow("website_event_2026_01_15", "s3://kaunta-archive/website_event_2026_01_15.csv.gz", "csv.gz", 1200, 4096, "ab", time.Date(2026, 4, 16, 3, 0, 0, 0, time.UTC)))
+
+	output, err := captureOutput(t, func() error {
+		return runDBArchives("website_event", "table")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "website_event_2026_01_15  1200  4.0 KiB  2026-04-16 03:00  s3://kaunta-archive/website_event_2026_01_15.csv.gz")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunDBPartitionsArchiveValidatesInput(t *testing.T) {
+	assert.ErrorContains(t, runDBPartitionsArchive(nil, "", "", ""), "or pass --before")
+	assert.ErrorContains(t, runDBPartitionsArchive([]string{"website_event_2026_01_15"}, "", "2026-01-01", ""), "not both")
+	assert.ErrorContains(t, runDBPartitionsArchive(nil, "", "yesterday", ""), "invalid --before")
+}
+
+func TestRunDBRestoreArchiveRequiresPartitionFileName(t *testing.T) {
+	err := runDBRestoreArchive("/var/backups/kaunta/events.csv.gz", false)
+	assert.ErrorContains(t, err, "cannot tell the partition")
+}
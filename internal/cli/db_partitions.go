@@ -0,0 +1,417 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/archive"
+	"github.com/seuros/kaunta/internal/config"
+	"github.com/seuros/kaunta/internal/database"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Database maintenance",
+}
+
+var dbPartitionsCmd = &cobra.Command{
+	Use:   "partitions",
+	Short: "Inspect and manage daily partitions",
+	Long: `Inspect and manage the daily partitions of website_event,
+event_idempotency and bot_detection_log.
+
+The server creates partitions 30 days ahead and drops website_event
+partitions after 90 days and bot_detection_log partitions after 30 days.
+These commands are safe to run against a live server: DDL waits at most
+--lock-timeout for locks instead of queueing behind traffic, and partitions
+are detached concurrently before they are dropped.`,
+}
+
+var dbPartitionsListCmd = &cobra.Command{
+	Use:   "list [--table <name>] [--format json|table]",
+	Short: "List partitions with size, row estimate and range",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBPartitionsList(dbPartitionsTable, dbPartitionsFormat)
+	},
+}
+
+var dbPartitionsCreateCmd = &cobra.Command{
+	Use:   "create --ahead <days> [--table <name>]",
+	Short: "Create missing partitions from today through N days ahead",
+	Example: `  kaunta db partitions create --ahead 60
+  kaunta db partitions create --ahead 7 --table event_idempotency`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBPartitionsCreate(dbPartitionsTable, dbPartitionsAhead)
+	},
+}
+
+var dbPartitionsDropCmd = &cobra.Command{
+	Use:   "drop --before <YYYY-MM-DD> [--table <name>] [--dry-run] [--force]",
+	Short: "Drop partitions covering days before a date",
+	Long: `Drop the partitions covering days before --before, freeing their disk
+space. Attached partitions are detached concurrently first, so the parent
+table stays available. Today's and future partitions are never dropped.
+
+When an archive location is configured (archive_location / ARCHIVE_LOCATION)
+website_event partitions are archived first and kept if archiving fails;
+see 'kaunta db partitions archive'. Otherwise, or with --no-archive, their
+data is deleted permanently.`,
+	Example: `  kaunta db partitions drop --before 2026-01-01 --dry-run
+  kaunta db partitions drop --before 2026-01-01 --table bot_detection_log --force`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBPartitionsDrop(dbPartitionsTable, dbPartitionsBefore, dbPartitionsDryRun, dbPartitionsForce, dbPartitionsNoArchive)
+	},
+}
+
+var dbPartitionsDetachCmd = &cobra.Command{
+	Use:   "detach <partition>",
+	Short: "Detach a partition, keeping it as a standalone table",
+	Long: `Detach a partition from its parent without blocking queries
+(DETACH PARTITION ... CONCURRENTLY). The table and its data stay in the
+database but no longer show up in reports. An interrupted detach is
+finalized. Today's and future partitions can't be detached.`,
+	Example: `  kaunta db partitions detach website_event_2026_01_15`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBPartitionsDetach(args[0])
+	},
+}
+
+var dbPartitionsAttachCmd = &cobra.Command{
+	Use:     "attach <partition>",
+	Short:   "Attach a detached partition back to its parent",
+	Example: `  kaunta db partitions attach website_event_2026_01_15`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDBPartitionsAttach(args[0])
+	},
+}
+
+// Command flags
+var (
+	dbPartitionsTable       string
+	dbPartitionsFormat      string
+	dbPartitionsAhead       int
+	dbPartitionsBefore      string
+	dbPartitionsDryRun      bool
+	dbPartitionsForce       bool
+	dbPartitionsNoArchive   bool
+	dbPartitionsLockTimeout time.Duration
+)
+
+// formatBytes renders a size in bytes with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func partitionStatus(p database.PartitionInfo) string {
+	switch {
+	case p.DetachPending:
+		return "detach pending"
+	case p.Attached:
+		return "attached"
+	default:
+		return "detached"
+	}
+}
+
+// partitionJSON is the JSON shape of 'kaunta db partitions list'
+type partitionJSON struct {
+	Name        string `json:"name"`
+	Table       string `json:"table"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Status      string `json:"status"`
+	SizeBytes   int64  `json:"size_bytes"`
+	RowEstimate int64  `json:"row_estimate"`
+}
+
+func runDBPartitionsList(table, format string) error {
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	partitions, err := database.ListPartitions(ctx, table)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		out := make([]partitionJSON, len(partitions))
+		for i, p := range partitions {
+			out[i] = partitionJSON{
+				Name:        p.Name(),
+				Table:       p.Table,
+				From:        p.Date.Format("2006-01-02"),
+				To:          p.Date.AddDate(0, 0, 1).Format("2006-01-02"),
+				Status:      partitionStatus(p),
+				SizeBytes:   p.SizeBytes,
+				RowEstimate: p.RowEstimate,
+			}
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(partitions) == 0 {
+		fmt.Println("No partitions found")
+		return nil
+	}
+
+	var total int64
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tRANGE\tSIZE\tROWS (EST)\tSTATUS")
+	_, _ = fmt.Fprintln(w, "----\t-----\t----\t----------\t------")
+	for _, p := range partitions {
+		total += p.SizeBytes
+		_, _ = fmt.Fprintf(w, "%s\t%s .. %s\t%s\t%d\t%s\n",
+			p.Name(), p.Date.Format("2006-01-02"), p.Date.AddDate(0, 0, 1).Format("2006-01-02"),
+			formatBytes(p.SizeBytes), p.RowEstimate, partitionStatus(p))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d partitions, %s\n", len(partitions), formatBytes(total))
+	return nil
+}
+
+// partitionTables resolves the --table flag
+func partitionTables(table string) ([]string, error) {
+	if table == "" {
+		return database.PartitionedTables, nil
+	}
+	if slices.Contains(database.PartitionedTables, table) {
+		return []string{table}, nil
+	}
+	return nil, fmt.Errorf("%s is not a partitioned table (use one of %s)", table, strings.Join(database.PartitionedTables, ", "))
+}
+
+func runDBPartitionsCreate(table string, ahead int) error {
+	if ahead < 0 {
+		return fmt.Errorf("--ahead must not be negative")
+	}
+	tables, err := partitionTables(table)
+	if err != nil {
+		return err
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	missing, err := database.MissingPartitions(tables, ahead)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		fmt.Printf("All partitions through %s already exist\n", time.Now().AddDate(0, 0, ahead).Format("2006-01-02"))
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	var errs []error
+	for _, p := range missing {
+		if err := p.Create(ctx, dbPartitionsLockTimeout); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		fmt.Printf("Created %s\n", p.Name())
+	}
+	return errors.Join(errs...)
+}
+
+func runDBPartitionsDrop(table, before string, dryRun, force, noArchive bool) error {
+	if before == "" {
+		return fmt.Errorf("--before is required")
+	}
+	cutoff, err := time.Parse("2006-01-02", before)
+	if err != nil {
+		return fmt.Errorf("invalid --before date %q (use YYYY-MM-DD)", before)
+	}
+	if _, err := partitionTables(table); err != nil {
+		return err
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	old, err := database.PartitionsBefore(ctx, table, cutoff)
+	if err != nil {
+		return err
+	}
+	if len(old) == 0 {
+		fmt.Printf("No partitions before %s\n", cutoff.Format("2006-01-02"))
+		return nil
+	}
+
+	var total int64
+	for _, p := range old {
+		total += p.SizeBytes
+		fmt.Printf("  %s (%s, ~%d rows, %s)\n", p.Name(), formatBytes(p.SizeBytes), p.RowEstimate, partitionStatus(p))
+	}
+	fmt.Printf("%d partitions before %s, %s\n", len(old), cutoff.Format("2006-01-02"), formatBytes(total))
+
+	var archiver *archive.Archiver
+	if !noArchive {
+		cfg, _ := config.Load()
+		opts := archiveOptions(cfg)
+		archiver, err = archive.NewArchiver(database.DB, opts)
+		switch {
+		case errors.Is(err, archive.ErrNotConfigured):
+			archiver = nil
+		case err != nil:
+			return err
+		default:
+			fmt.Printf("%s partitions are archived to %s first\n", database.EventTable, opts.Location)
+		}
+	}
+
+	if dryRun {
+		fmt.Println("Dry run: nothing dropped")
+		return nil
+	}
+
+	if !force {
+		prompt := "Permanently drop these partitions and their data? (yes/no): "
+		if archiver != nil {
+			prompt = "Archive and drop these partitions? (yes/no): "
+		}
+		fmt.Print(prompt)
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.ToLower(strings.TrimSpace(response))
+
+		if response != "yes" && response != "y" {
+			fmt.Println("Drop cancelled")
+			return nil
+		}
+	}
+
+	var errs []error
+	for _, p := range old {
+		if archiver != nil && p.Table == database.EventTable {
+			if err := archiveBeforeDrop(ctx, archiver, p.Partition); err != nil {
+				errs = append(errs, fmt.Errorf("%s: not dropped: %w", p.Name(), err))
+				continue
+			}
+		}
+		if err := p.Drop(ctx, dbPartitionsLockTimeout); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		fmt.Printf("Dropped %s\n", p.Name())
+	}
+	return errors.Join(errs...)
+}
+
+func runDBPartitionsDetach(name string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	p, err := database.GetPartition(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := p.Detach(ctx, dbPartitionsLockTimeout); err != nil {
+		return fmt.Errorf("failed to detach %s: %w", name, err)
+	}
+
+	fmt.Printf("Detached %s (re-attach with 'kaunta db partitions attach %s')\n", name, name)
+	return nil
+}
+
+func runDBPartitionsAttach(name string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	p, err := database.GetPartition(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := p.Attach(ctx, dbPartitionsLockTimeout); err != nil {
+		return fmt.Errorf("failed to attach %s: %w", name, err)
+	}
+
+	fmt.Printf("Attached %s to %s\n", name, p.Table)
+	return nil
+}
+
+func init() {
+	dbPartitionsCmd.PersistentFlags().StringVar(&dbPartitionsTable, "table", "", "Only this table (website_event, event_idempotency, bot_detection_log)")
+	dbPartitionsCmd.PersistentFlags().DurationVar(&dbPartitionsLockTimeout, "lock-timeout", 5*time.Second, "Give up when a table lock isn't granted within this time")
+
+	dbPartitionsListCmd.Flags().StringVarP(&dbPartitionsFormat, "format", "f", "table", "Output format (json, table)")
+
+	dbPartitionsCreateCmd.Flags().IntVar(&dbPartitionsAhead, "ahead", 30, "Number of days ahead to create partitions for")
+
+	dbPartitionsDropCmd.Flags().StringVar(&dbPartitionsBefore, "before", "", "Drop partitions covering days before this date (YYYY-MM-DD)")
+	dbPartitionsDropCmd.Flags().BoolVar(&dbPartitionsDryRun, "dry-run", false, "Only list the partitions that would be dropped")
+	dbPartitionsDropCmd.Flags().BoolVar(&dbPartitionsForce, "force", false, "Skip the confirmation prompt")
+	dbPartitionsDropCmd.Flags().BoolVar(&dbPartitionsNoArchive, "no-archive", false, "Don't archive website_event partitions before dropping them")
+
+	dbPartitionsCmd.AddCommand(dbPartitionsListCmd)
+	dbPartitionsCmd.AddCommand(dbPartitionsCreateCmd)
+	dbPartitionsCmd.AddCommand(dbPartitionsDropCmd)
+	dbPartitionsCmd.AddCommand(dbPartitionsDetachCmd)
+	dbPartitionsCmd.AddCommand(dbPartitionsAttachCmd)
+
+	dbCmd.AddCommand(dbPartitionsCmd)
+	RootCmd.AddCommand(dbCmd)
+}
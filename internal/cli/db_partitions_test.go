@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "8.0 KiB", formatBytes(8192))
+	assert.Equal(t, "1.5 GiB", formatBytes(3<<29))
+}
+
+func TestPartitionTables(t *testing.T) {
+	tables, err := partitionTables("")
+	require.NoError(t, err)
+	assert.Len(t, tables, 3)
+
+	tables, err = partitionTables("bot_detection_log")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bot_detection_log"}, tables)
+
+	_, err = partitionTables("session")
+	assert.ErrorContains(t, err, "not a partitioned table")
+}
+
+func TestRunDBPartitionsDropDryRun(t *testing.T) {
+	_, mock := newDoctorMock(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("ARCHIVE_LOCATION", "/var/backups/kaunta")
+
+	mock.ExpectQuery("FROM pg_class c").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "attached", "detach_pending", "size", "rows"}).
+			AddRow("website_event_2025_01_02", true, false, 2048, 20).
+			AddRow("website_event_2025_01_01", false, false, 1024, 10))
+
+	output, err := captureOutput(t, func() error {
+		return runDBPartitionsDrop("website_event", "2025-01-02", true, false, false)
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "website_event_2025_01_01 (1.0 KiB, ~10 rows, detached)")
+	assert.NotContains(t, output, "website_event_2025_01_02")
+	assert.Contains(t, output, "website_event partitions are archived to /var/backups/kaunta first")
+	assert.Contains(t, output, "Dry run: nothing dropped")
+
+	// No DDL was issued
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunDBPartitionsDropValidatesInput(t *testing.T) {
+	assert.ErrorContains(t, runDBPartitionsDrop("", "", true, false, true), "--before is required")
+	assert.ErrorContains(t, runDBPartitionsDrop("", "01/02/2025", true, false, true), "invalid --before")
+	assert.ErrorContains(t, runDBPartitionsDrop("session", "2025-01-02", true, false, true), "not a partitioned table")
+}
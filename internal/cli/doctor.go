@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/seuros/kaunta/internal/config"
 	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/geoip"
 )
 
 var doctorCmd = &cobra.Command{
@@ -25,17 +27,28 @@ var doctorCmd = &cobra.Command{
 
 Checks performed:
   - Data directory writable
-  - GeoIP database exists
+  - GeoIP database exists (and its build date)
   - Database connection
   - PostgreSQL version ≥17
   - Database migrations completed
   - PostgreSQL functions exist
   - PostgreSQL triggers exist
-  - Materialized views exist
+  - Materialized views exist and are populated
+  - Partitions exist for the next 30 days
+  - Self-tracking website exists
+
+With --fix, safe repairs are planned and applied before the checks run:
+pending migrations, missing future partitions, empty materialized views,
+a missing GeoIP database and the self-tracking website. The plan is always
+printed first; repairs that change existing data or the schema ask for
+confirmation unless --force is given. --dry-run only prints the plan.
+With --json the plan and prompts go to stderr.
 
 Example:
   kaunta doctor
-  kaunta doctor --json`,
+  kaunta doctor --json
+  kaunta doctor --fix --dry-run
+  kaunta doctor --fix`,
 	RunE: runDoctor,
 }
 
@@ -105,37 +118,39 @@ func checkDataDirectory(cfg *config.Config) CheckResult {
 }
 
 func checkGeoIPDatabase(cfg *config.Config) CheckResult {
-	geoipPath := filepath.Join(cfg.DataDir, "GeoLite2-City.mmdb")
+	geoipPath := filepath.Join(cfg.DataDir, geoip.DatabaseFile)
 
-	info, err := os.Stat(geoipPath)
-	if err != nil {
+	if _, err := os.Stat(geoipPath); err != nil {
 		if os.IsNotExist(err) {
 			return CheckResult{
 				Name:       "GeoIP Database",
 				Pass:       false,
 				Error:      "GeoLite2-City.mmdb not found",
-				Suggestion: "Database will auto-download on first server start",
+				Suggestion: "Run 'kaunta geoip update' (also auto-downloads on first server start)",
 			}
 		}
 		return CheckResult{Name: "GeoIP Database", Pass: false, Error: err.Error()}
 	}
 
-	// Check if file is readable
-	file, err := os.Open(geoipPath)
+	status, err := geoip.ReadStatus(geoipPath)
 	if err != nil {
 		return CheckResult{
 			Name:       "GeoIP Database",
 			Pass:       false,
-			Error:      "Cannot read GeoLite2-City.mmdb",
-			Suggestion: "Check file permissions",
+			Error:      fmt.Sprintf("Cannot read GeoLite2-City.mmdb: %v", err),
+			Suggestion: "Check file permissions or run 'kaunta geoip update --force'",
 		}
 	}
-	_ = file.Close()
 
+	details := fmt.Sprintf("%.1f MB, built %s, %s",
+		float64(status.Size)/(1024*1024), status.BuildTime.Format("2006-01-02"), formatGeoIPAge(status.BuildTime))
+	if time.Since(status.BuildTime) > geoipStaleAfter {
+		details += "; run 'kaunta geoip update'"
+	}
 	return CheckResult{
 		Name:    "GeoIP Database",
 		Pass:    true,
-		Details: fmt.Sprintf("%.1f MB", float64(info.Size())/(1024*1024)),
+		Details: details,
 	}
 }
 
@@ -304,7 +319,7 @@ func checkPostgreSQLTriggers(db *sql.DB) CheckResult {
 
 func checkMaterializedViews(db *sql.DB) CheckResult {
 	query := `
-		SELECT matviewname
+		SELECT matviewname, ispopulated
 		FROM pg_matviews
 		WHERE schemaname = 'public' AND matviewname = ANY($1)
 	`
@@ -318,14 +333,19 @@ func checkMaterializedViews(db *sql.DB) CheckResult {
 	foundViews := make(map[string]bool)
 	for rows.Next() {
 		var name string
-		_ = rows.Scan(&name)
-		foundViews[name] = true
+		var populated bool
+		_ = rows.Scan(&name, &populated)
+		foundViews[name] = populated
 	}
 
-	var missing []string
+	var missing, unpopulated []string
 	for _, view := range requiredMatViews {
-		if !foundViews[view] {
+		populated, found := foundViews[view]
+		switch {
+		case !found:
 			missing = append(missing, view)
+		case !populated:
+			unpopulated = append(unpopulated, view)
 		}
 	}
 
@@ -338,6 +358,15 @@ func checkMaterializedViews(db *sql.DB) CheckResult {
 		}
 	}
 
+	if len(unpopulated) > 0 {
+		return CheckResult{
+			Name:       "Materialized Views",
+			Pass:       false,
+			Error:      fmt.Sprintf("Not populated: %s", strings.Join(unpopulated, ", ")),
+			Suggestion: "Rebuild them with: kaunta doctor --fix",
+		}
+	}
+
 	return CheckResult{
 		Name:    "Materialized Views",
 		Pass:    true,
@@ -345,8 +374,54 @@ func checkMaterializedViews(db *sql.DB) CheckResult {
 	}
 }
 
+func checkFuturePartitions() CheckResult {
+	missing, err := database.MissingFuturePartitions()
+	if err != nil {
+		return CheckResult{Name: "Future Partitions", Pass: false, Error: err.Error()}
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, len(missing))
+		for i, p := range missing {
+			names[i] = p.Name()
+		}
+		return CheckResult{
+			Name:       "Future Partitions",
+			Pass:       false,
+			Error:      fmt.Sprintf("Missing %d partitions: %s", len(missing), summarizeNames(names, 3)),
+			Suggestion: "Create them with: kaunta doctor --fix (the server also creates them daily)",
+		}
+	}
+
+	return CheckResult{Name: "Future Partitions", Pass: true, Details: "next 30 days"}
+}
+
+func checkSelfWebsite(db *sql.DB) CheckResult {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM website WHERE website_id = $1)`, config.SelfWebsiteID).Scan(&exists)
+	if err != nil {
+		return CheckResult{Name: "Self-Tracking Website", Pass: false, Error: err.Error()}
+	}
+
+	if !exists {
+		return CheckResult{
+			Name:       "Self-Tracking Website",
+			Pass:       false,
+			Error:      fmt.Sprintf("Website %s not found", config.SelfWebsiteID),
+			Suggestion: "Recreate it with: kaunta doctor --fix",
+		}
+	}
+	return CheckResult{Name: "Self-Tracking Website", Pass: true}
+}
+
 func runDoctor(cmd *cobra.Command, args []string) error {
 	jsonOutput, _ := cmd.Flags().GetBool("json")
+	fix, _ := cmd.Flags().GetBool("fix")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	force, _ := cmd.Flags().GetBool("force")
+	if dryRun && !fix {
+		return fmt.Errorf("--dry-run requires --fix")
+	}
 
 	cfg, err := config.Load()
 	if err != nil {
@@ -354,15 +429,38 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Connect to database for remaining checks
+	db, openErr := sql.Open("postgres", cfg.DatabaseURL)
+	if openErr == nil {
+		defer func() { _ = db.Close() }()
+
+		// Partition checks and repairs use the database package helpers
+		if database.DB == nil {
+			database.DB = db
+			defer func() { database.DB = nil }()
+		}
+	}
+
+	failedFixes := 0
+	if fix {
+		// Keep stdout parseable: with --json the plan and prompts go to stderr
+		var out io.Writer = os.Stdout
+		if jsonOutput {
+			out = os.Stderr
+		}
+		failedFixes = runDoctorFix(out, cfg, db, dryRun, force)
+		if dryRun {
+			return nil
+		}
+	}
+
 	results := []CheckResult{}
 
 	// Non-DB checks first
 	results = append(results, checkDataDirectory(cfg))
 	results = append(results, checkGeoIPDatabase(cfg))
 
-	// Connect to database for remaining checks
-	db, err := sql.Open("postgres", cfg.DatabaseURL)
-	if err != nil {
+	if err := openErr; err != nil {
 		results = append(results, CheckResult{
 			Name:       "Database Connection",
 			Pass:       false,
@@ -370,14 +468,14 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 			Suggestion: "Verify DATABASE_URL is valid",
 		})
 	} else {
-		defer func() { _ = db.Close() }()
-
 		results = append(results, checkDatabaseConnection(db))
 		results = append(results, checkPostgreSQLVersion(db))
 		results = append(results, checkMigrations(cfg))
 		results = append(results, checkPostgreSQLFunctions(db))
 		results = append(results, checkPostgreSQLTriggers(db))
 		results = append(results, checkMaterializedViews(db))
+		results = append(results, checkFuturePartitions())
+		results = append(results, checkSelfWebsite(db))
 	}
 
 	// Output results
@@ -396,7 +494,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if !allPassed {
+	if !allPassed || failedFixes > 0 {
 		os.Exit(1)
 	}
 
@@ -446,5 +544,8 @@ func outputDoctorJSON(results []CheckResult) {
 
 func init() {
 	doctorCmd.Flags().Bool("json", false, "Output results as JSON")
+	doctorCmd.Flags().Bool("fix", false, "Plan and apply safe repairs before running the checks")
+	doctorCmd.Flags().Bool("dry-run", false, "With --fix, only print the repair plan")
+	doctorCmd.Flags().Bool("force", false, "With --fix, apply repairs that change data or schema without asking")
 	RootCmd.AddCommand(doctorCmd)
 }
@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/seuros/kaunta/internal/config"
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/geoip"
+)
+
+// doctorFix is a repair planned by 'kaunta doctor --fix'
+type doctorFix struct {
+	Check       string
+	Description string
+	// Destructive repairs change existing data or schema and need confirmation
+	Destructive bool
+	Apply       func(ctx context.Context) error
+}
+
+// planDoctorFixes inspects the installation and returns the repairs to
+// apply, in order. Problems that need manual intervention are returned as
+// notes.
+func planDoctorFixes(ctx context.Context, cfg *config.Config, db *sql.DB) ([]doctorFix, []string) {
+	var fixes []doctorFix
+	var notes []string
+
+	if fix, note := planGeoIPFix(cfg); fix != nil {
+		fixes = append(fixes, *fix)
+	} else if note != "" {
+		notes = append(notes, note)
+	}
+
+	if db == nil || db.PingContext(ctx) != nil {
+		notes = append(notes, "Database unreachable: verify DATABASE_URL and that PostgreSQL is running")
+		return fixes, notes
+	}
+
+	migrationFix, note := planMigrationFix(cfg)
+	if note != "" {
+		notes = append(notes, note)
+	}
+	if migrationFix != nil {
+		// Objects created by migrations are only repaired on an up to date schema
+		fixes = append(fixes, *migrationFix)
+		notes = append(notes, "Partitions, views and the self-tracking website are checked once migrations are applied; run 'kaunta doctor --fix' again")
+		return fixes, notes
+	}
+
+	if fix, err := planPartitionFix(); err != nil {
+		notes = append(notes, fmt.Sprintf("Could not inspect partitions: %v", err))
+	} else if fix != nil {
+		fixes = append(fixes, *fix)
+	}
+
+	if fix, err := planMatViewFix(ctx, db); err != nil {
+		notes = append(notes, fmt.Sprintf("Could not inspect materialized views: %v", err))
+	} else if fix != nil {
+		fixes = append(fixes, *fix)
+	}
+
+	if fix, err := planSelfWebsiteFix(ctx, db); err != nil {
+		notes = append(notes, fmt.Sprintf("Could not inspect the self-tracking website: %v", err))
+	} else if fix != nil {
+		fixes = append(fixes, *fix)
+	}
+
+	return fixes, notes
+}
+
+func planGeoIPFix(cfg *config.Config) (*doctorFix, string) {
+	path := filepath.Join(cfg.DataDir, geoip.DatabaseFile)
+	opts := geoipOptions(cfg)
+
+	_, statErr := os.Stat(path)
+	switch {
+	case os.IsNotExist(statErr):
+		return &doctorFix{
+			Check:       "GeoIP Database",
+			Description: fmt.Sprintf("Download the GeoIP database from %s", geoip.SourceName(opts)),
+			Apply: func(ctx context.Context) error {
+				_, err := geoip.Update(ctx, opts, false)
+				return err
+			},
+		}, ""
+	case statErr != nil:
+		return nil, fmt.Sprintf("GeoIP database: %v", statErr)
+	}
+
+	if _, err := geoip.ReadStatus(path); err != nil {
+		return &doctorFix{
+			Check:       "GeoIP Database",
+			Description: fmt.Sprintf("Replace the unreadable %s with a fresh download", geoip.DatabaseFile),
+			Destructive: true,
+			Apply: func(ctx context.Context) error {
+				_, err := geoip.Update(ctx, opts, true)
+				return err
+			},
+		}, ""
+	}
+	return nil, ""
+}
+
+func planMigrationFix(cfg *config.Config) (*doctorFix, string) {
+	version, dirty, err := database.GetMigrationVersion(cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Sprintf("Could not read the migration version: %v", err)
+	}
+	latest := database.LatestMigrationVersion
+
+	switch {
+	case dirty:
+		return nil, fmt.Sprintf("Migration %d is dirty and needs manual intervention", version)
+	case version > latest:
+		return nil, fmt.Sprintf("Database schema v%d is newer than this binary (v%d); upgrade Kaunta", version, latest)
+	case version == latest:
+		return nil, ""
+	}
+
+	return &doctorFix{
+		Check:       "Database Migrations",
+		Description: fmt.Sprintf("Run %d pending migrations (v%d --> v%d)", latest-version, version, latest),
+		Destructive: true,
+		Apply: func(ctx context.Context) error {
+			return database.RunMigrations(cfg.DatabaseURL)
+		},
+	}, ""
+}
+
+func planPartitionFix() (*doctorFix, error) {
+	missing, err := database.MissingFuturePartitions()
+	if err != nil || len(missing) == 0 {
+		return nil, err
+	}
+
+	names := make([]string, len(missing))
+	for i, p := range missing {
+		names[i] = p.Name()
+	}
+	return &doctorFix{
+		Check:       "Future Partitions",
+		Description: fmt.Sprintf("Create %d missing partitions (%s)", len(missing), summarizeNames(names, 3)),
+		Apply: func(ctx context.Context) error {
+			var errs []error
+			for _, p := range missing {
+				if err := p.Ensure(); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}, nil
+}
+
+func planMatViewFix(ctx context.Context, db *sql.DB) (*doctorFix, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT matviewname, ispopulated
+		FROM pg_matviews
+		WHERE schemaname = 'public' AND matviewname = ANY($1)
+		ORDER BY matviewname
+	`, pq.Array(requiredMatViews))
+	if err != nil {
+		return nil, err
+	}
+	populated := make(map[string]bool)
+	var views []string
+	for rows.Next() {
+		var name string
+		var isPopulated bool
+		if err := rows.Scan(&name, &isPopulated); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		views = append(views, name)
+		populated[name] = isPopulated
+	}
+	_ = rows.Close()
+
+	// Empty views are only suspicious when there is traffic to aggregate
+	var hasEvents bool
+	if err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM website_event)`).Scan(&hasEvents); err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for _, view := range views {
+		if !populated[view] {
+			stale = append(stale, view)
+			continue
+		}
+		if !hasEvents {
+			continue
+		}
+		var empty bool
+		if err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT NOT EXISTS (SELECT 1 FROM %s)`, pq.QuoteIdentifier(view))).Scan(&empty); err != nil {
+			return nil, err
+		}
+		if empty {
+			stale = append(stale, view)
+		}
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	return &doctorFix{
+		Check:       "Materialized Views",
+		Description: fmt.Sprintf("Rebuild empty or unpopulated materialized views (%s)", strings.Join(stale, ", ")),
+		Apply: func(ctx context.Context) error {
+			for _, view := range stale {
+				// CONCURRENTLY is not allowed on unpopulated views
+				if _, err := db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW "+pq.QuoteIdentifier(view)); err != nil {
+					return fmt.Errorf("%s: %w", view, err)
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+func planSelfWebsiteFix(ctx context.Context, db *sql.DB) (*doctorFix, error) {
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM website WHERE website_id = $1)`, config.SelfWebsiteID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, nil
+	}
+
+	apply := func(ctx context.Context) error {
+		ensureSelfWebsite()
+		var created bool
+		if err := database.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM website WHERE website_id = $1)`, config.SelfWebsiteID).Scan(&created); err != nil {
+			return err
+		}
+		if !created {
+			return errors.New("self-tracking website was not created (see the log)")
+		}
+		return nil
+	}
+
+	var oldID string
+	err := db.QueryRowContext(ctx, `SELECT website_id FROM website WHERE domain = 'self' LIMIT 1`).Scan(&oldID)
+	switch {
+	case err == nil:
+		return &doctorFix{
+			Check:       "Self-Tracking Website",
+			Description: fmt.Sprintf("Move the self-tracking website %s and its sessions and events to %s", oldID, config.SelfWebsiteID),
+			Destructive: true,
+			Apply:       apply,
+		}, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return &doctorFix{
+			Check:       "Self-Tracking Website",
+			Description: "Recreate the self-tracking website",
+			Apply:       apply,
+		}, nil
+	default:
+		return nil, err
+	}
+}
+
+// summarizeNames lists the first n names and counts the rest
+func summarizeNames(names []string, n int) string {
+	if len(names) <= n {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:n], ", "), len(names)-n)
+}
+
+// printDoctorPlan prints the planned repairs and manual notes
+func printDoctorPlan(out io.Writer, fixes []doctorFix, notes []string) {
+	_, _ = fmt.Fprintln(out, "\n🔧 Kaunta Repair Plan")
+	if len(fixes) == 0 {
+		_, _ = fmt.Fprintln(out, "Nothing to repair automatically")
+	}
+	for i, fix := range fixes {
+		marker := ""
+		if fix.Destructive {
+			marker = " [needs confirmation]"
+		}
+		_, _ = fmt.Fprintf(out, "%d. %s: %s%s\n", i+1, fix.Check, fix.Description, marker)
+	}
+	for _, note := range notes {
+		_, _ = fmt.Fprintf(out, "  ⚠ %s\n", note)
+	}
+	_, _ = fmt.Fprintln(out)
+}
+
+// runDoctorFix prints the repair plan and progress to out and applies it.
+// Destructive repairs are confirmed one by one unless force is set. Returns
+// the number of failed repairs.
+func runDoctorFix(out io.Writer, cfg *config.Config, db *sql.DB, dryRun, force bool) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	fixes, notes := planDoctorFixes(ctx, cfg, db)
+	printDoctorPlan(out, fixes, notes)
+	if dryRun || len(fixes) == 0 {
+		return 0
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	failed := 0
+	for _, fix := range fixes {
+		if fix.Destructive && !force {
+			_, _ = fmt.Fprintf(out, "%s? (yes/no): ", fix.Description)
+			response, _ := reader.ReadString('\n')
+			response = strings.ToLower(strings.TrimSpace(response))
+			if response != "yes" && response != "y" {
+				_, _ = fmt.Fprintf(out, "- Skipped: %s\n", fix.Check)
+				continue
+			}
+		}
+
+		if err := fix.Apply(ctx); err != nil {
+			_, _ = fmt.Fprintf(out, "✗ %s: %v\n", fix.Check, err)
+			failed++
+			continue
+		}
+		_, _ = fmt.Fprintf(out, "✓ %s: %s\n", fix.Check, fix.Description)
+	}
+	return failed
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/config"
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/geoip"
+)
+
+func newDoctorMock(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	original := database.DB
+	database.DB = db
+	t.Cleanup(func() {
+		database.DB = original
+		_ = db.Close()
+	})
+	return db, mock
+}
+
+func TestPlanMatViewFix(t *testing.T) {
+	db, mock := newDoctorMock(t)
+
+	mock.ExpectQuery("SELECT matviewname, ispopulated").
+		WillReturnRows(sqlmock.NewRows([]string{"matviewname", "ispopulated"}).
+			AddRow("daily_website_stats", true).
+			AddRow("hourly_website_stats", false).
+			AddRow("realtime_website_stats", true))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM website_event\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`SELECT NOT EXISTS \(SELECT 1 FROM "daily_website_stats"\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"empty"}).AddRow(true))
+	mock.ExpectQuery(`SELECT NOT EXISTS \(SELECT 1 FROM "realtime_website_stats"\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"empty"}).AddRow(false))
+
+	fix, err := planMatViewFix(context.Background(), db)
+	require.NoError(t, err)
+	require.NotNil(t, fix)
+	assert.False(t, fix.Destructive)
+	assert.Contains(t, fix.Description, "daily_website_stats, hourly_website_stats")
+
+	mock.ExpectExec(`REFRESH MATERIALIZED VIEW "daily_website_stats"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`REFRESH MATERIALIZED VIEW "hourly_website_stats"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, fix.Apply(context.Background()))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlanMatViewFixIgnoresEmptyViewsWithoutTraffic(t *testing.T) {
+	db, mock := newDoctorMock(t)
+
+	mock.ExpectQuery("SELECT matviewname, ispopulated").
+		WillReturnRows(sqlmock.NewRows([]string{"matviewname", "ispopulated"}).
+			AddRow("daily_website_stats", true))
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM website_event\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	fix, err := planMatViewFix(context.Background(), db)
+	require.NoError(t, err)
+	assert.Nil(t, fix)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPlanSelfWebsiteFix(t *testing.T) {
+	t.Run("exists", func(t *testing.T) {
+		db, mock := newDoctorMock(t)
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM website WHERE website_id").
+			WithArgs(config.SelfWebsiteID).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		fix, err := planSelfWebsiteFix(context.Background(), db)
+		require.NoError(t, err)
+		assert.Nil(t, fix)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		db, mock := newDoctorMock(t)
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM website WHERE website_id").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery("SELECT website_id FROM website WHERE domain = 'self'").
+			WillReturnError(sql.ErrNoRows)
+
+		fix, err := planSelfWebsiteFix(context.Background(), db)
+		require.NoError(t, err)
+		require.NotNil(t, fix)
+		assert.False(t, fix.Destructive)
+		assert.Equal(t, "Recreate the self-tracking website", fix.Description)
+	})
+
+	t.Run("old id needs confirmation", func(t *testing.T) {
+		db, mock := newDoctorMock(t)
+		mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM website WHERE website_id").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery("SELECT website_id FROM website WHERE domain = 'self'").
+			WillReturnRows(sqlmock.NewRows([]string{"website_id"}).AddRow("3f1c0000-0000-0000-0000-000000000001"))
+
+		fix, err := planSelfWebsiteFix(context.Background(), db)
+		require.NoError(t, err)
+		require.NotNil(t, fix)
+		assert.True(t, fix.Destructive)
+		assert.Contains(t, fix.Description, "3f1c0000-0000-0000-0000-000000000001")
+	})
+}
+
+func TestPrintDoctorPlan(t *testing.T) {
+	var buf bytes.Buffer
+	printDoctorPlan(&buf, []doctorFix{
+		{Check: "Future Partitions", Description: "Create 2 missing partitions"},
+		{Check: "Database Migrations", Description: "Run 1 pending migrations (v36 --> v37)", Destructive: true},
+	}, []string{"Migration 12 is dirty and needs manual intervention"})
+	output := buf.String()
+
+	assert.Contains(t, output, "1. Future Partitions: Create 2 missing partitions\n")
+	assert.Contains(t, output, "2. Database Migrations: Run 1 pending migrations (v36 --> v37) [needs confirmation]")
+	assert.Contains(t, output, "⚠ Migration 12 is dirty")
+
+	buf.Reset()
+	printDoctorPlan(&buf, nil, nil)
+	assert.Contains(t, buf.String(), "Nothing to repair automatically")
+}
+
+func TestRunDoctorFixDryRun(t *testing.T) {
+	dataDir := t.TempDir()
+	cfg := &config.Config{DataDir: dataDir}
+
+	var buf bytes.Buffer
+	var failed int
+	stdout, _ := captureOutput(t, func() error {
+		failed = runDoctorFix(&buf, cfg, nil, true, false)
+		return nil
+	})
+	output := buf.String()
+
+	assert.Empty(t, stdout, "the plan goes to the given writer only")
+
+	assert.Zero(t, failed)
+	assert.Contains(t, output, "1. GeoIP Database: Download the GeoIP database")
+	assert.Contains(t, output, "Database unreachable")
+	assert.NoFileExists(t, filepath.Join(dataDir, geoip.DatabaseFile))
+}
+
+func TestSummarizeNames(t *testing.T) {
+	assert.Equal(t, "a, b", summarizeNames([]string{"a", "b"}, 3))
+	assert.Equal(t, "a, b, c and 2 more", summarizeNames([]string{"a", "b", "c", "d", "e"}, 3))
+}
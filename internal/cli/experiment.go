@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/handlers"
+	"github.com/seuros/kaunta/internal/models"
+)
+
+var experimentCmd = &cobra.Command{
+	Use:   "experiment",
+	Short: "Manage A/B experiments",
+	Long: `Manage A/B experiments reported from event tags.
+
+Each variant maps to the tag the tracker sends (data-tag="..." on the script
+tag). A visitor belongs to the variant of the first tagged event seen after
+the experiment started, and converts by completing the experiment's goal.`,
+}
+
+var experimentCreateCmd = &cobra.Command{
+	Use:   "create <website-domain> <name> --goal <goal-name> --variant <name=tag> --variant <name=tag>",
+	Short: "Create an experiment",
+	Long: `Create an experiment for an existing goal. The first variant is the control.
+
+The report window starts now; events tagged earlier are not counted.
+
+Examples:
+  kaunta experiment create example.com pricing-v2 --goal Signup --variant control=pricing-a --variant new=pricing-b
+  kaunta experiment create example.com hero --goal Purchase --variant hero-a --variant hero-b --variant hero-c`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExperimentCreate(args[0], args[1], experimentGoal, experimentVariants)
+	},
+}
+
+var experimentListCmd = &cobra.Command{
+	Use:   "list <website-domain>",
+	Short: "List experiments for a website",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExperimentList(args[0])
+	},
+}
+
+var experimentReportCmd = &cobra.Command{
+	Use:   "report <website-domain> <name> [--format json|table]",
+	Short: "Show visitors, conversions and significance per variant",
+	Long: `Show the experiment result per variant: visitors, conversions, conversion
+rate, uplift over the control and a two-sided significance test (p < 0.05).
+
+The same report is available at GET /api/v1/stats/:website_id/experiments/:name
+with a stats API key.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExperimentReport(args[0], args[1], experimentFormat)
+	},
+}
+
+var experimentStopCmd = &cobra.Command{
+	Use:   "stop <website-domain> <name>",
+	Short: "Stop an experiment",
+	Long:  `Stop an experiment. Its report keeps the window from start to now.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExperimentStop(args[0], args[1])
+	},
+}
+
+var experimentDeleteCmd = &cobra.Command{
+	Use:   "delete <website-domain> <name>",
+	Short: "Delete an experiment",
+	Long:  `Delete an experiment definition. Tagged events and goal completions are kept.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExperimentDelete(args[0], args[1])
+	},
+}
+
+// Command flags
+var (
+	experimentGoal     string
+	experimentVariants []string
+	experimentFormat   string
+)
+
+// parseExperimentVariants reads "name=tag" (or just "tag", used as the name too)
+func parseExperimentVariants(values []string) ([]models.ExperimentVariant, error) {
+	var variants []models.ExperimentVariant
+	for _, value := range values {
+		name, tag, found := strings.Cut(value, "=")
+		if !found {
+			tag = name
+		}
+		name, tag = strings.TrimSpace(name), strings.TrimSpace(tag)
+		if name == "" || tag == "" {
+			return nil, fmt.Errorf("invalid variant %q (use name=tag)", value)
+		}
+		variants = append(variants, models.ExperimentVariant{Name: name, Tag: tag})
+	}
+	if err := models.ValidateExperimentVariants(variants); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+// websiteUUIDByDomain connects if needed and resolves the website; the returned
+// func closes a connection opened here
+func websiteUUIDByDomain(ctx context.Context, domain string) (uuid.UUID, func(), error) {
+	cleanup := func() {}
+	if database.DB == nil {
+		if err := connectDatabase(); err != nil {
+			return uuid.Nil, cleanup, fmt.Errorf("database connection failed: %w", err)
+		}
+		cleanup = func() { _ = closeDatabase() }
+	}
+
+	id, err := getWebsiteIDByDomainFn(ctx, domain)
+	if err != nil {
+		return uuid.Nil, cleanup, err
+	}
+	websiteID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, cleanup, fmt.Errorf("invalid website ID: %w", err)
+	}
+	return websiteID, cleanup, nil
+}
+
+func runExperimentCreate(domain, name, goal string, variantValues []string) error {
+	if strings.TrimSpace(goal) == "" {
+		return fmt.Errorf("--goal is required")
+	}
+	variants, err := parseExperimentVariants(variantValues)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	exp, err := models.CreateExperiment(ctx, database.DB, websiteID, name, goal, variants)
+	if errors.Is(err, models.ErrGoalNotFound) {
+		return fmt.Errorf("goal %q not found for %s (create it in the dashboard first)", goal, domain)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create experiment: %w", err)
+	}
+
+	fmt.Printf("Experiment '%s' started for %s (goal: %s)\n\n", exp.Name, domain, exp.GoalName)
+	for i, v := range exp.Variants {
+		role := ""
+		if i == 0 {
+			role = " (control)"
+		}
+		fmt.Printf("  %s%s: data-tag=\"%s\"\n", v.Name, role, v.Tag)
+	}
+	fmt.Println()
+	fmt.Println("Serve each variant with its tag on the tracker script, e.g.:")
+	fmt.Printf("  <script src=\".../k.js\" data-website-id=\"%s\" data-tag=\"%s\"></script>\n", websiteID, exp.Variants[0].Tag)
+	return nil
+}
+
+func runExperimentList(domain string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	experiments, err := models.ListExperiments(ctx, database.DB, websiteID)
+	if err != nil {
+		return fmt.Errorf("failed to list experiments: %w", err)
+	}
+
+	if len(experiments) == 0 {
+		fmt.Printf("No experiments found for website '%s'\n", domain)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "NAME\tGOAL\tVARIANTS\tSTATUS\tSTARTED")
+	_, _ = fmt.Fprintln(w, "----\t----\t--------\t------\t-------")
+	for _, exp := range experiments {
+		names := make([]string, len(exp.Variants))
+		for i, v := range exp.Variants {
+			names[i] = v.Name + "=" + v.Tag
+		}
+		status := "running"
+		if exp.EndedAt != nil {
+			status = "stopped " + exp.EndedAt.Format("2006-01-02")
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			exp.Name,
+			exp.GoalName,
+			strings.Join(names, ", "),
+			status,
+			exp.StartedAt.Format("2006-01-02 15:04"),
+		)
+	}
+	return w.Flush()
+}
+
+func runExperimentReport(domain, name, format string) error {
+	if format == "" {
+		format = "table"
+	}
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	exp, err := models.GetExperimentByName(ctx, database.DB, websiteID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("experiment %q not found for %s", name, domain)
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := handlers.LoadExperimentReport(ctx, database.DB, exp, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	return outputExperimentTable(report)
+}
+
+func outputExperimentTable(report *handlers.ExperimentReport) error {
+	status := "running"
+	if !report.Running {
+		status = "stopped"
+	}
+	fmt.Printf("Experiment: %s (%s)\nGoal: %s\nWindow: %s - %s\n\n",
+		report.Experiment, status, report.Goal,
+		report.From.Format("2006-01-02 15:04"), report.To.Format("2006-01-02 15:04"))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VARIANT\tTAG\tVISITORS\tCONVERSIONS\tRATE\tUPLIFT\tP-VALUE\tSIGNIFICANT")
+	_, _ = fmt.Fprintln(w, "-------\t---\t--------\t-----------\t----\t------\t-------\t-----------")
+	for _, v := range report.Variants {
+		name := v.Name
+		uplift, pValue, significant := "-", "-", "-"
+		if v.Control {
+			name += " (control)"
+		} else {
+			if v.Uplift != nil {
+				uplift = fmt.Sprintf("%+.2f%%", *v.Uplift)
+			}
+			if v.PValue != nil {
+				pValue = fmt.Sprintf("%.4f", *v.PValue)
+				significant = "no"
+				if v.Significant {
+					significant = "yes"
+				}
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.2f%%\t%s\t%s\t%s\n",
+			name, v.Tag, v.Visitors, v.Conversions, v.ConversionRate, uplift, pValue, significant)
+	}
+	return w.Flush()
+}
+
+func runExperimentStop(domain, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if err := models.StopExperiment(ctx, database.DB, websiteID, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no running experiment %q for %s", name, domain)
+		}
+		return fmt.Errorf("failed to stop experiment: %w", err)
+	}
+
+	fmt.Printf("Experiment '%s' stopped\n", name)
+	return nil
+}
+
+func runExperimentDelete(domain, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if err := models.DeleteExperiment(ctx, database.DB, websiteID, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("experiment %q not found for %s", name, domain)
+		}
+		return fmt.Errorf("failed to delete experiment: %w", err)
+	}
+
+	fmt.Printf("Experiment '%s' deleted\n", name)
+	return nil
+}
+
+func init() {
+	experimentCreateCmd.Flags().StringVarP(&experimentGoal, "goal", "g", "", "Name of the goal that counts as a conversion")
+	experimentCreateCmd.Flags().StringArrayVar(&experimentVariants, "variant", nil, "Variant as name=tag (repeat; first is the control)")
+
+	experimentReportCmd.Flags().StringVarP(&experimentFormat, "format", "f", "table", "Output format (json, table)")
+
+	experimentCmd.AddCommand(experimentCreateCmd)
+	experimentCmd.AddCommand(experimentListCmd)
+	experimentCmd.AddCommand(experimentReportCmd)
+	experimentCmd.AddCommand(experimentStopCmd)
+	experimentCmd.AddCommand(experimentDeleteCmd)
+
+	RootCmd.AddCommand(experimentCmd)
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/handlers"
+	"github.com/seuros/kaunta/internal/models"
+)
+
+func TestParseExperimentVariants(t *testing.T) {
+	variants, err := parseExperimentVariants([]string{"control=pricing-a", " new = pricing-b ", "pricing-c"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.ExperimentVariant{
+		{Name: "control", Tag: "pricing-a"},
+		{Name: "new", Tag: "pricing-b"},
+		{Name: "pricing-c", Tag: "pricing-c"},
+	}, variants)
+
+	_, err = parseExperimentVariants([]string{"control="})
+	assert.Error(t, err)
+
+	_, err = parseExperimentVariants([]string{"only-one"})
+	assert.Error(t, err)
+}
+
+func TestRunExperimentCreateRequiresGoal(t *testing.T) {
+	err := runExperimentCreate("example.com", "pricing", "", []string{"a", "b"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--goal")
+}
+
+func TestOutputExperimentTable(t *testing.T) {
+	uplift, pValue := 30.0, 0.0355
+	report := &handlers.ExperimentReport{
+		Experiment: "pricing",
+		Goal:       "Signup",
+		From:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:         time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+		Running:    true,
+		Variants: []handlers.VariantResult{
+			{Name: "control", Tag: "a", Control: true, Visitors: 1000, Conversions: 100, ConversionRate: 10},
+			{Name: "new", Tag: "b", Visitors: 1000, Conversions: 130, ConversionRate: 13, Uplift: &uplift, PValue: &pValue, Significant: true},
+		},
+	}
+
+	output, err := captureOutput(t, func() error {
+		return outputExperimentTable(report)
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Experiment: pricing (running)")
+	assert.Contains(t, output, "control (control)")
+	assert.Contains(t, output, "+30.00%")
+	assert.Contains(t, output, "0.0355")
+	assert.Contains(t, output, "yes")
+}
@@ -0,0 +1,223 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/config"
+	"github.com/seuros/kaunta/internal/geoip"
+)
+
+// geoipStaleAfter is the build age after which doctor and status suggest an
+// update; GeoLite2 is rebuilt twice a week
+const geoipStaleAfter = 60 * 24 * time.Hour
+
+var geoipCmd = &cobra.Command{
+	Use:   "geoip",
+	Short: "Manage the GeoIP database",
+	Long: `Manage the GeoLite2-City database used to resolve visitor locations.
+
+The server downloads a new build every geoip_update_interval (default 7d,
+"off" to disable) and reloads the file without a restart when it changes on
+disk, e.g. after 'kaunta geoip update' or MaxMind's geoipupdate. A download
+that fails verification is discarded and the current database is kept.
+
+Configuration (kaunta.toml or environment):
+  geoip_source / GEOIP_SOURCE                     URL, .mmdb(.gz)/.tar.gz file or directory
+  geoip_license_key / GEOIP_LICENSE_KEY           MaxMind license key (used without a source)
+  geoip_update_interval / GEOIP_UPDATE_INTERVAL   e.g. 7d, 24h, off
+  geoip_asn_database / GEOIP_ASN_DATABASE         GeoLite2-ASN database (default: data_dir/GeoLite2-ASN.mmdb)
+
+The ASN database is optional and not downloaded. When present, the ASN and
+organisation of visitors are recorded and hosting provider traffic is
+handled by each website's datacenter policy.`,
+}
+
+var geoipUpdateCmd = &cobra.Command{
+	Use:   "update [--source <url|path>] [--license-key <key>] [--force]",
+	Short: "Download and install the latest GeoIP database",
+	Long: `Download the GeoIP database from the configured source, verify it and
+replace the file in the data directory. A running server picks it up within
+a minute. Builds that aren't newer than the installed one are skipped unless
+--force is given.
+
+Examples:
+  kaunta geoip update
+  kaunta geoip update --license-key $MAXMIND_LICENSE_KEY
+  kaunta geoip update --source /usr/share/GeoIP`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGeoIPUpdate(geoipSource, geoipLicenseKey, geoipForce)
+	},
+}
+
+var geoipStatusCmd = &cobra.Command{
+	Use:   "status [--format json|table]",
+	Short: "Show the installed GeoIP database",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGeoIPStatus(geoipFormat)
+	},
+}
+
+// Command flags
+var (
+	geoipSource     string
+	geoipLicenseKey string
+	geoipForce      bool
+	geoipFormat     string
+)
+
+// geoipOptions builds the GeoIP options from the configuration
+func geoipOptions(cfg *config.Config) geoip.Options {
+	if cfg == nil {
+		return geoip.Options{DataDir: getEnv("DATA_DIR", "./data")}
+	}
+	return geoip.Options{
+		DataDir:    cfg.DataDir,
+		Source:     cfg.GeoIPSource,
+		LicenseKey: cfg.GeoIPLicenseKey,
+		ASNPath:    cfg.GeoIPASNDatabase,
+	}
+}
+
+func geoipUpdateInterval(cfg *config.Config) time.Duration {
+	if cfg == nil {
+		return config.DefaultGeoIPUpdateInterval
+	}
+	return cfg.GeoIPUpdateInterval
+}
+
+// formatGeoIPAge describes how long ago a database was built
+func formatGeoIPAge(build time.Time) string {
+	days := int(time.Since(build).Hours() / 24)
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "1 day old"
+	default:
+		return fmt.Sprintf("%d days old", days)
+	}
+}
+
+func runGeoIPUpdate(source, licenseKey string, force bool) error {
+	cfg, _ := config.Load()
+	opts := geoipOptions(cfg)
+	if source != "" {
+		opts.Source = source
+	}
+	if licenseKey != "" {
+		opts.LicenseKey = licenseKey
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	fmt.Printf("Fetching GeoIP database from %s\n", geoip.SourceName(opts))
+	result, err := geoip.Update(ctx, opts, force)
+	if err != nil {
+		return fmt.Errorf("geoip update failed (current database kept): %w", err)
+	}
+
+	if !result.Updated {
+		fmt.Printf("Already up to date (build %s)\n", result.Build.Format("2006-01-02"))
+		return nil
+	}
+	if !result.PreviousBuild.IsZero() {
+		fmt.Printf("Previous build: %s\n", result.PreviousBuild.Format("2006-01-02"))
+	}
+	fmt.Printf("Installed build: %s\n", result.Build.Format("2006-01-02"))
+	fmt.Printf("Path:           %s\n", filepath.Join(opts.DataDir, geoip.DatabaseFile))
+	return nil
+}
+
+// geoipStatusOutput is the JSON shape of 'kaunta geoip status'
+type geoipStatusOutput struct {
+	*geoip.Status
+	Stale          bool          `json:"stale"`
+	Source         string        `json:"source"`
+	UpdateInterval string        `json:"update_interval"`
+	ASN            *geoip.Status `json:"asn_database,omitempty"`
+}
+
+func runGeoIPStatus(format string) error {
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	cfg, _ := config.Load()
+	opts := geoipOptions(cfg)
+	path := filepath.Join(opts.DataDir, geoip.DatabaseFile)
+
+	status, err := geoip.ReadStatus(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no GeoIP database at %s (run 'kaunta geoip update')", path)
+		}
+		return fmt.Errorf("failed to read GeoIP database: %w", err)
+	}
+
+	interval := "off"
+	if d := geoipUpdateInterval(cfg); d > 0 {
+		interval = d.String()
+	}
+	out := geoipStatusOutput{
+		Status:         status,
+		Stale:          time.Since(status.BuildTime) > geoipStaleAfter,
+		Source:         geoip.SourceName(opts),
+		UpdateInterval: interval,
+	}
+	if asn, err := geoip.ReadStatus(geoip.ASNPath(opts)); err == nil {
+		out.ASN = asn
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Path:\t%s\n", status.Path)
+	_, _ = fmt.Fprintf(w, "Type:\t%s\n", status.DatabaseType)
+	_, _ = fmt.Fprintf(w, "Build:\t%s (%s)\n", status.BuildTime.Format("2006-01-02"), formatGeoIPAge(status.BuildTime))
+	_, _ = fmt.Fprintf(w, "Size:\t%.1f MB\n", float64(status.Size)/(1024*1024))
+	_, _ = fmt.Fprintf(w, "Source:\t%s\n", out.Source)
+	_, _ = fmt.Fprintf(w, "Update interval:\t%s\n", out.UpdateInterval)
+	if out.ASN != nil {
+		_, _ = fmt.Fprintf(w, "ASN database:\t%s (%s, built %s)\n", out.ASN.Path, out.ASN.DatabaseType, out.ASN.BuildTime.Format("2006-01-02"))
+	} else {
+		_, _ = fmt.Fprintf(w, "ASN database:\tnot installed (%s)\n", geoip.ASNPath(opts))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if out.Stale {
+		fmt.Println("\nThe database is out of date; run 'kaunta geoip update'")
+	}
+	return nil
+}
+
+func init() {
+	geoipUpdateCmd.Flags().StringVar(&geoipSource, "source", "", "URL, file or directory to update from (default: configured source)")
+	geoipUpdateCmd.Flags().StringVar(&geoipLicenseKey, "license-key", "", "MaxMind license key (default: configured key)")
+	geoipUpdateCmd.Flags().BoolVar(&geoipForce, "force", false, "Install even if the build isn't newer")
+
+	geoipStatusCmd.Flags().StringVarP(&geoipFormat, "format", "f", "table", "Output format (json, table)")
+
+	geoipCmd.AddCommand(geoipUpdateCmd)
+	geoipCmd.AddCommand(geoipStatusCmd)
+
+	RootCmd.AddCommand(geoipCmd)
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/config"
+)
+
+func TestGeoIPOptions(t *testing.T) {
+	cfg := &config.Config{DataDir: "/var/lib/kaunta", GeoIPLicenseKey: "key", GeoIPUpdateInterval: time.Hour}
+	opts := geoipOptions(cfg)
+	assert.Equal(t, "/var/lib/kaunta", opts.DataDir)
+	assert.Equal(t, "key", opts.LicenseKey)
+	assert.Equal(t, time.Hour, geoipUpdateInterval(cfg))
+
+	t.Setenv("DATA_DIR", "/tmp/kaunta")
+	assert.Equal(t, "/tmp/kaunta", geoipOptions(nil).DataDir)
+	assert.Equal(t, config.DefaultGeoIPUpdateInterval, geoipUpdateInterval(nil))
+}
+
+func TestFormatGeoIPAge(t *testing.T) {
+	assert.Equal(t, "today", formatGeoIPAge(time.Now()))
+	assert.Equal(t, "1 day old", formatGeoIPAge(time.Now().Add(-30*time.Hour)))
+	assert.Equal(t, "10 days old", formatGeoIPAge(time.Now().Add(-10*24*time.Hour)))
+}
+
+func TestRunGeoIPStatusMissingDatabase(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("DATA_DIR", t.TempDir())
+
+	err := runGeoIPStatus("table")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "kaunta geoip update")
+
+	assert.Error(t, runGeoIPStatus("xml"))
+}
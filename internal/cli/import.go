@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/seuros/kaunta/internal/config"
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/geoip"
+	"github.com/seuros/kaunta/internal/handlers"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/logimport"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import analytics data from external sources",
+	Long: `Import analytics data from sources other than the JavaScript tracker.
+
+Useful for sites where the tracking script can't be embedded.`,
+}
+
+// Import logs command flags
+var (
+	importLogFormat   string
+	importLogFile     string
+	importLogFollow   bool
+	importLogAllHosts bool
+)
+
+var importLogsCmd = &cobra.Command{
+	Use:   "logs <website-domain> --format <format> --file <path> [--follow]",
+	Short: "Import pageviews from web server access logs",
+	Long: `Parse web server access logs and record HTML page hits as pageviews.
+
+Asset requests (css, js, images, fonts...), non-GET requests and error
+responses are skipped. Hits go through the same bot detection, user agent
+parsing, GeoIP lookup and session ID scheme as the tracker.
+
+Formats:
+  nginx               nginx "combined" log_format
+  apache-combined     Apache combined LogFormat
+  caddy-json          Caddy JSON access logs
+  cloudflare-logpush  Cloudflare Logpush http_requests (NDJSON)
+
+Options:
+  --format      Log format (required)
+  --file        Log file path, .gz files are decompressed (required)
+  --follow      Keep reading new lines as they are appended (like tail -F)
+  --all-hosts   Import hits for every Host, not just the website domain
+
+Re-importing a file skips the hits it already recorded, as long as their keys
+are within the event_idempotency retention (7 days). With --follow only lines
+written after the command starts are imported.
+
+Examples:
+  kaunta import logs mysite.com --format nginx --file /var/log/nginx/access.log.1.gz
+  kaunta import logs mysite.com --format caddy-json --file /var/log/caddy/access.log --follow`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImportLogs(args[0], importLogFormat, importLogFile, importLogFollow, importLogAllHosts)
+	},
+}
+
+var (
+	recordServerPageviewFn = handlers.RecordServerPageview
+	ensureEventPartitionFn = database.EnsureEventPartition
+	// Imported hits are keyed in today's event_idempotency partition
+	ensureIdempotencyPartitionFn = func(date time.Time) error {
+		return database.Partition{Table: database.IdempotencyTable, Date: date}.Ensure()
+	}
+	initGeoIPFn = func() error {
+		cfg, _ := config.Load()
+		return geoip.Init(geoipOptions(cfg))
+	}
+	followPollInterval = time.Second
+)
+
+// importLogStats counts what happened to each log line
+type importLogStats struct {
+	Lines     int64
+	Recorded  int64
+	Skipped   int64 // assets, non-GET, errors
+	OtherHost int64
+	Bots      int64
+	Spam      int64
+	Excluded  int64 // internal traffic
+	Duplicate int64 // already imported
+	Invalid   int64
+	Failed    int64
+}
+
+func runImportLogs(domain, format, filePath string, follow, allHosts bool) error {
+	if !isValidLogFormat(format) {
+		return fmt.Errorf("invalid format: %q (valid: %s)", format, strings.Join(logimport.Formats, ", "))
+	}
+	if filePath == "" {
+		return fmt.Errorf("--file is required")
+	}
+
+	if database.DB == nil {
+		if err := connectDatabase(); err != nil {
+			return fmt.Errorf("database connection failed: %w", err)
+		}
+		defer func() { _ = closeDatabase() }()
+	}
+
+	if err := initGeoIPFn(); err != nil {
+		logging.L().Warn("geoip unavailable, importing without location data", zap.Error(err))
+	} else {
+		defer func() { _ = geoip.Close() }()
+	}
+
+	lookupCtx, lookupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	websiteIDStr, err := getWebsiteIDByDomainFn(lookupCtx, domain)
+	lookupCancel()
+	if err != nil {
+		return err
+	}
+	websiteID, err := uuid.Parse(websiteIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid website ID: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	importer := &logImporter{
+		websiteID:  websiteID,
+		domain:     strings.ToLower(domain),
+		format:     format,
+		allHosts:   allHosts,
+		partitions: make(map[string]bool),
+	}
+
+	if follow {
+		fmt.Printf("Following %s (%s) for %s, press Ctrl+C to stop\n", filePath, format, domain)
+		err = logimport.Follow(ctx, filePath, followPollInterval, func(line string) error {
+			importer.processLine(ctx, line)
+			return nil
+		})
+	} else {
+		f, openErr := logimport.Open(filePath)
+		if openErr != nil {
+			return fmt.Errorf("failed to open log file: %w", openErr)
+		}
+		defer func() { _ = f.Close() }()
+
+		err = logimport.ScanLines(ctx, f, func(line string) error {
+			importer.processLine(ctx, line)
+			return nil
+		})
+	}
+
+	printImportLogStats(importer.stats)
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("import stopped: %w", err)
+	}
+	return nil
+}
+
+type logImporter struct {
+	websiteID  uuid.UUID
+	domain     string
+	format     string
+	allHosts   bool
+	partitions map[string]bool
+	stats      importLogStats
+}
+
+func (li *logImporter) processLine(ctx context.Context, line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	li.stats.Lines++
+
+	hit, err := logimport.ParseLine(li.format, line)
+	if err != nil {
+		li.stats.Invalid++
+		logging.L().Debug("skipping unparseable log line", zap.Error(err))
+		return
+	}
+
+	if !hit.IsPageview() {
+		li.stats.Skipped++
+		return
+	}
+
+	if !li.allHosts && !hostMatchesDomain(hit.Host, li.domain) {
+		li.stats.OtherHost++
+		return
+	}
+
+	today := time.Now().UTC()
+	if key := database.IdempotencyTable + ":" + today.Format("2006-01-02"); !li.partitions[key] {
+		if err := ensureIdempotencyPartitionFn(today); err != nil {
+			logging.L().Warn("failed to create idempotency partition", zap.Time("day", today), zap.Error(err))
+		}
+		li.partitions[key] = true
+	}
+
+	day := hit.Time.UTC().Format("2006-01-02")
+	if !li.partitions[day] {
+		if _, err := ensureEventPartitionFn(hit.Time.UTC()); err != nil {
+			logging.L().Warn("failed to create partition", zap.String("day", day), zap.Error(err))
+		}
+		li.partitions[day] = true
+	}
+
+	hostname := hit.Host
+	if hostname == "" {
+		hostname = li.domain
+	}
+
+	recordCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := recordServerPageviewFn(recordCtx, li.websiteID, handlers.ServerPageview{
+		Time:      hit.Time,
+		IP:        hit.IP,
+		UserAgent: hit.UserAgent,
+		Hostname:  hostname,
+		URI:       hit.URI,
+		Referrer:  hit.Referrer,
+	})
+	if err != nil {
+		li.stats.Failed++
+		logging.L().Warn("failed to record imported pageview",
+			zap.String("uri", hit.URI),
+			zap.Error(err))
+		return
+	}
+
+	switch result {
+	case handlers.ServerPageviewBot:
+		li.stats.Bots++
+	case handlers.ServerPageviewSpam:
+		li.stats.Spam++
+	case handlers.ServerPageviewExcluded:
+		li.stats.Excluded++
+	case handlers.ServerPageviewDuplicate:
+		li.stats.Duplicate++
+	default:
+		li.stats.Recorded++
+	}
+}
+
+func isValidLogFormat(format string) bool {
+	for _, f := range logimport.Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// hostMatchesDomain accepts the domain itself, its www. variant and subdomains.
+// Logs without a Host (nginx/apache combined) always match.
+func hostMatchesDomain(host, domain string) bool {
+	if host == "" {
+		return true
+	}
+	host = strings.ToLower(host)
+	if i := strings.LastIndex(host, ":"); i > 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func printImportLogStats(stats importLogStats) {
+	fmt.Println()
+	fmt.Println("Import summary")
+	fmt.Println(strings.Repeat("-", 40))
+	fmt.Printf("Lines read:          %d\n", stats.Lines)
+	fmt.Printf("Pageviews recorded:  %d\n", stats.Recorded)
+	fmt.Printf("Skipped (non-page):  %d\n", stats.Skipped)
+	fmt.Printf("Other hosts:         %d\n", stats.OtherHost)
+	fmt.Printf("Bots:                %d\n", stats.Bots)
+	fmt.Printf("Spam referrers:      %d\n", stats.Spam)
+	fmt.Printf("Internal traffic:    %d\n", stats.Excluded)
+	fmt.Printf("Already imported:    %d\n", stats.Duplicate)
+	fmt.Printf("Unparseable lines:   %d\n", stats.Invalid)
+	if stats.Failed > 0 {
+		fmt.Printf("Failed inserts:      %d\n", stats.Failed)
+	}
+}
+
+func init() {
+	importCmd.AddCommand(importLogsCmd)
+
+	importLogsCmd.Flags().StringVar(&importLogFormat, "format", "", "Log format (nginx, apache-combined, caddy-json, cloudflare-logpush)")
+	importLogsCmd.Flags().StringVar(&importLogFile, "file", "", "Path to access log (.gz supported)")
+	importLogsCmd.Flags().BoolVar(&importLogFollow, "follow", false, "Tail the log and import new lines as they arrive")
+	importLogsCmd.Flags().BoolVar(&importLogAllHosts, "all-hosts", false, "Import hits for every Host header, not just the website domain")
+	_ = importLogsCmd.MarkFlagRequired("format")
+	_ = importLogsCmd.MarkFlagRequired("file")
+
+	RootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/handlers"
+)
+
+func stubLogImportDeps(t *testing.T, record func(context.Context, uuid.UUID, handlers.ServerPageview) (string, error)) {
+	t.Helper()
+	originalRecord := recordServerPageviewFn
+	originalPartition := ensureEventPartitionFn
+	originalIdempotencyPartition := ensureIdempotencyPartitionFn
+	originalGeoIP := initGeoIPFn
+	recordServerPageviewFn = record
+	ensureEventPartitionFn = func(time.Time) (string, error) { return "", nil }
+	ensureIdempotencyPartitionFn = func(time.Time) error { return nil }
+	initGeoIPFn = func() error { return nil }
+	t.Cleanup(func() {
+		recordServerPageviewFn = originalRecord
+		ensureEventPartitionFn = originalPartition
+		ensureIdempotencyPartitionFn = originalIdempotencyPartition
+		initGeoIPFn = originalGeoIP
+	})
+}
+
+func TestRunImportLogsGzip(t *testing.T) {
+	stubDB(t)
+	stubConnectClose(t)
+
+	websiteID := uuid.New()
+	stubWebsiteIDLookup(t, func(ctx context.Context, domain string) (string, error) {
+		return websiteID.String(), nil
+	})
+
+	var recorded []handlers.ServerPageview
+	stubLogImportDeps(t, func(ctx context.Context, id uuid.UUID, pv handlers.ServerPageview) (string, error) {
+		assert.Equal(t, websiteID, id)
+		recorded = append(recorded, pv)
+		if pv.UserAgent == "Googlebot/2.1" {
+			return handlers.ServerPageviewBot, nil
+		}
+		return handlers.ServerPageviewRecorded, nil
+	})
+
+	lines := `203.0.113.7 - - [10/Oct/2025:13:55:36 +0000] "GET /pricing HTTP/1.1" 200 5120 "-" "Mozilla/5.0 Chrome/120.0"
+203.0.113.7 - - [10/Oct/2025:13:55:37 +0000] "GET /static/app.js HTTP/1.1" 200 812 "-" "Mozilla/5.0 Chrome/120.0"
+66.249.66.1 - - [10/Oct/2025:13:56:00 +0000] "GET /docs HTTP/1.1" 200 512 "-" "Googlebot/2.1"
+garbage line
+`
+	path := filepath.Join(t.TempDir(), "access.log.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte(lines))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	output, err := captureOutput(t, func() error {
+		return runImportLogs("example.com", "nginx", path, false, false)
+	})
+	require.NoError(t, err)
+
+	require.Len(t, recorded, 2)
+	assert.Equal(t, "/pricing", recorded[0].URI)
+	assert.Equal(t, "example.com", recorded[0].Hostname)
+	assert.Contains(t, output, "Pageviews recorded:  1")
+	assert.Contains(t, output, "Skipped (non-page):  1")
+	assert.Contains(t, output, "Bots:                1")
+	assert.Contains(t, output, "Unparseable lines:   1")
+}
+
+func TestRunImportLogsSkipsImportedHits(t *testing.T) {
+	stubDB(t)
+	stubConnectClose(t)
+
+	websiteID := uuid.New()
+	stubWebsiteIDLookup(t, func(ctx context.Context, domain string) (string, error) {
+		return websiteID.String(), nil
+	})
+	stubLogImportDeps(t, func(ctx context.Context, id uuid.UUID, pv handlers.ServerPageview) (string, error) {
+		return handlers.ServerPageviewDuplicate, nil
+	})
+	var ensured []time.Time
+	ensureIdempotencyPartitionFn = func(date time.Time) error {
+		ensured = append(ensured, date)
+		return nil
+	}
+
+	lines := `203.0.113.7 - - [10/Oct/2025:13:55:36 +0000] "GET /pricing HTTP/1.1" 200 5120 "-" "Mozilla/5.0 Chrome/120.0"
+203.0.113.7 - - [10/Oct/2025:13:58:02 +0000] "GET /docs HTTP/1.1" 200 512 "-" "Mozilla/5.0 Chrome/120.0"
+`
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(path, []byte(lines), 0o644))
+
+	output, err := captureOutput(t, func() error {
+		return runImportLogs("example.com", "nginx", path, false, false)
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, output, "Pageviews recorded:  0")
+	assert.Contains(t, output, "Already imported:    2")
+	require.Len(t, ensured, 1, "today's idempotency partition is ensured once")
+	assert.Equal(t, time.Now().UTC().Format("2006-01-02"), ensured[0].Format("2006-01-02"))
+}
+
+func TestRunImportLogsInvalidFormat(t *testing.T) {
+	err := runImportLogs("example.com", "iis", "access.log", false, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid format")
+}
+
+func TestHostMatchesDomain(t *testing.T) {
+	assert.True(t, hostMatchesDomain("", "example.com"))
+	assert.True(t, hostMatchesDomain("example.com", "example.com"))
+	assert.True(t, hostMatchesDomain("WWW.Example.com:443", "example.com"))
+	assert.True(t, hostMatchesDomain("blog.example.com", "example.com"))
+	assert.False(t, hostMatchesDomain("notexample.com", "example.com"))
+	assert.False(t, hostMatchesDomain("other.org", "example.com"))
+}
@@ -30,7 +30,9 @@ import (
 	"github.com/seuros/kaunta/internal/httpx"
 	"github.com/seuros/kaunta/internal/logging"
 	appmiddleware "github.com/seuros/kaunta/internal/middleware"
+	"github.com/seuros/kaunta/internal/models"
 	"github.com/seuros/kaunta/internal/realtime"
+	"github.com/seuros/kaunta/internal/spam"
 	"go.uber.org/zap"
 )
 
@@ -261,10 +263,18 @@ func serveAnalytics(
 	cfg, err := config.Load()
 	if err != nil {
 		logging.L().Warn("failed to load config for trusted origins", zap.Error(err))
-	} else if len(cfg.TrustedOrigins) > 0 {
-		syncTrustedOrigins(cfg.TrustedOrigins)
+	} else {
+		if len(cfg.TrustedOrigins) > 0 {
+			syncTrustedOrigins(cfg.TrustedOrigins)
+		}
+		if err := handlers.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+			logging.L().Warn("ignoring invalid trusted_proxies setting", zap.Error(err))
+		}
 	}
 
+	// Add built-in spam referrers shipped with this version
+	syncBuiltinSpamReferrers()
+
 	// Ensure self website exists for dogfooding (creates if missing for existing installations)
 	ensureSelfWebsite()
 
@@ -274,20 +284,22 @@ func serveAnalytics(
 		logging.L().Warn("failed to initialize trusted origins cache", zap.Error(err))
 	}
 
-	// Initialize GeoIP database (downloads if missing)
-	dataDir := os.Getenv("DATA_DIR")
-	if dataDir == "" {
-		dataDir = "./data"
-	}
-	if err := geoip.Init(dataDir); err != nil {
+	// Initialize GeoIP database (downloads if missing) and keep it current
+	if err := geoip.Init(geoipOptions(cfg)); err != nil {
 		logging.Fatal("geoip initialization failed", zap.Error(err))
 	}
+	geoip.StartRefresher(ctx, geoipUpdateInterval(cfg))
 	defer func() {
 		if err := geoip.Close(); err != nil {
 			logging.L().Warn("error closing geoip", zap.Error(err))
 		}
 	}()
 
+	// Create partitions ahead; with a retention, drop (archiving first) expired ones
+	partitionScheduler := newPartitionScheduler(ctx, databaseURL, cfg)
+	partitionScheduler.Start()
+	defer partitionScheduler.Stop()
+
 	r := chi.NewRouter()
 	r.Use(chimiddleware.Recoverer)
 	r.Use(requestLoggerMiddleware())
@@ -370,9 +382,34 @@ func serveAnalytics(
 	r.Options("/api/send", optionsOK)
 	r.Post("/api/send", handlers.HandleTracking)
 
+	// Tracking API (Plausible-compatible)
+	r.Options("/api/event", optionsOK)
+	r.Post("/api/event", handlers.HandlePlausibleEvent)
+
+	// Tracking API (Matomo-compatible)
+	for _, path := range []string{"/matomo.php", "/piwik.php"} {
+		r.Options(path, optionsOK)
+		r.Get(path, handlers.HandleMatomoTracking)
+		r.Post(path, handlers.HandleMatomoTracking)
+	}
+
 	// Pixel tracking (for email, RSS, no-JS environments)
 	r.Get("/p/{id}.gif", handlers.HandlePixelTracking)
 
+	// Visitor opt-out page (public, works without JavaScript)
+	r.Get("/optout/{website_id}", func(w http.ResponseWriter, r *http.Request) {
+		data, status := handlers.OptOutPageData(r)
+		if status != http.StatusOK {
+			http.NotFound(w, r)
+			return
+		}
+		data["Version"] = Version
+		if err := render(w, "views/optout", "views/layouts/base", data); err != nil {
+			http.Error(w, "Failed to render opt-out page", http.StatusInternalServerError)
+		}
+	})
+	r.Post("/optout/{website_id}", handlers.HandleOptOut)
+
 	// Server-side Ingest API (for backend event ingestion)
 	// Uses API key authentication instead of session-based auth
 	r.Options("/api/ingest", optionsOK)
@@ -380,6 +417,15 @@ func serveAnalytics(
 	r.With(appmiddleware.APIKeyAuth).Post("/api/ingest", handlers.HandleIngest)
 	r.With(appmiddleware.APIKeyAuth).Post("/api/ingest/batch", handlers.HandleIngestBatch)
 
+	// Segment-compatible HTTP Tracking API (API key as Basic auth username)
+	for _, path := range []string{"/v1/track", "/v1/page", "/v1/identify", "/v1/batch"} {
+		r.Options(path, optionsOK)
+	}
+	r.With(appmiddleware.APIKeyAuth).Post("/v1/track", handlers.HandleSegmentTrack)
+	r.With(appmiddleware.APIKeyAuth).Post("/v1/page", handlers.HandleSegmentPage)
+	r.With(appmiddleware.APIKeyAuth).Post("/v1/identify", handlers.HandleSegmentIdentify)
+	r.With(appmiddleware.APIKeyAuth).Post("/v1/batch", handlers.HandleSegmentBatch)
+
 	// Stats API (Plausible-inspired) - protected
 	r.With(appmiddleware.Auth).Get("/api/stats/realtime/{website_id}", handlers.HandleCurrentVisitors)
 
@@ -459,6 +505,7 @@ func serveAnalytics(
 	authProtected.Get("/api/dashboard/realtime", handlers.HandleRealtimeVisitors)
 	authProtected.Get("/api/dashboard/campaigns-init", handlers.HandleCampaignsInit)
 	authProtected.Get("/api/dashboard/campaigns", handlers.HandleCampaigns)
+	authProtected.Get("/api/dashboard/attribution", handlers.HandleAttribution)
 	authProtected.Get("/api/dashboard/websites-init", handlers.HandleWebsitesInit)
 	authProtected.Post("/api/dashboard/websites-create", handlers.HandleWebsitesCreate)
 	authProtected.Get("/api/dashboard/map-init", handlers.HandleMapInit)
@@ -468,6 +515,9 @@ func serveAnalytics(
 	authProtected.Delete("/api/dashboard/goals/{id}", handlers.HandleGoalsDelete)
 	authProtected.Get("/api/dashboard/goals/{id}/analytics", handlers.HandleGoalsAnalytics)
 	authProtected.Get("/api/dashboard/goals/{id}/breakdown/{type}", handlers.HandleGoalsBreakdown)
+	authProtected.Get("/api/dashboard/errors", handlers.HandleErrors)
+	authProtected.Get("/api/dashboard/paths", handlers.HandlePaths)
+	authProtected.Get("/api/dashboard/bots", handlers.HandleBots)
 
 	// Website Management API (protected)
 	authProtected.Get("/api/websites/list", handlers.HandleWebsiteList)
@@ -483,6 +533,9 @@ func serveAnalytics(
 
 	// API Key Stats API (requires API key with stats scope)
 	r.With(appmiddleware.APIKeyAuthAny).Get("/api/v1/stats/{website_id}", handlers.HandleAPIStats)
+	r.With(appmiddleware.APIKeyAuthAny).Get("/api/v1/stats/{website_id}/paths", handlers.HandleAPIPaths)
+	r.With(appmiddleware.APIKeyAuthAny).Get("/api/v1/stats/{website_id}/attribution", handlers.HandleAPIAttribution)
+	r.With(appmiddleware.APIKeyAuthAny).Get("/api/v1/stats/{website_id}/experiments/{name}", handlers.HandleAPIExperimentReport)
 
 	// Website Management Dashboard page (protected)
 	r.With(appmiddleware.AuthWithRedirect).Get("/dashboard/websites", func(w http.ResponseWriter, r *http.Request) {
@@ -505,6 +558,36 @@ func serveAnalytics(
 		}
 	})
 
+	r.With(appmiddleware.AuthWithRedirect).Get("/dashboard/errors", func(w http.ResponseWriter, r *http.Request) {
+		if err := render(w, "views/dashboard/errors", "views/layouts/dashboard", map[string]any{
+			"Title":         "Errors",
+			"Version":       Version,
+			"SelfWebsiteID": config.SelfWebsiteID,
+		}); err != nil {
+			http.Error(w, "Failed to render errors view", http.StatusInternalServerError)
+		}
+	})
+
+	r.With(appmiddleware.AuthWithRedirect).Get("/dashboard/paths", func(w http.ResponseWriter, r *http.Request) {
+		if err := render(w, "views/dashboard/paths", "views/layouts/dashboard", map[string]any{
+			"Title":         "Paths",
+			"Version":       Version,
+			"SelfWebsiteID": config.SelfWebsiteID,
+		}); err != nil {
+			http.Error(w, "Failed to render paths view", http.StatusInternalServerError)
+		}
+	})
+
+	r.With(appmiddleware.AuthWithRedirect).Get("/dashboard/bots", func(w http.ResponseWriter, r *http.Request) {
+		if err := render(w, "views/dashboard/bots", "views/layouts/dashboard", map[string]any{
+			"Title":         "Bots",
+			"Version":       Version,
+			"SelfWebsiteID": config.SelfWebsiteID,
+		}); err != nil {
+			http.Error(w, "Failed to render bots view", http.StatusInternalServerError)
+		}
+	})
+
 	port := getEnv("PORT", "3000")
 	server := &http.Server{
 		Addr:    ":" + port,
@@ -848,10 +931,14 @@ func isSafeMethod(method string) bool {
 
 func shouldSkipCSRF(r *http.Request) bool {
 	path := r.URL.Path
-	if path == "/api/send" {
+	if path == "/api/send" || path == "/api/event" || path == "/matomo.php" || path == "/piwik.php" {
+		return true
+	}
+	if strings.HasPrefix(path, "/api/ingest") || strings.HasPrefix(path, "/v1/") {
 		return true
 	}
-	if strings.HasPrefix(path, "/api/ingest") {
+	// Plain HTML form; it only toggles the visitor's own opt-out cookie
+	if strings.HasPrefix(path, "/optout/") {
 		return true
 	}
 	if isSafeMethod(r.Method) && (strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".css")) {
@@ -1285,6 +1372,22 @@ func syncTrustedOrigins(origins []string) {
 	logging.L().Info("finished syncing trusted origins")
 }
 
+// syncBuiltinSpamReferrers seeds the embedded spam list. Domains already
+// stored, including disabled built-ins, are left alone.
+func syncBuiltinSpamReferrers() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	added, err := models.SeedSpamReferrers(ctx, database.DB, spam.Builtin())
+	if err != nil {
+		logging.L().Warn("failed to seed spam referrers", zap.Error(err))
+		return
+	}
+	if added > 0 {
+		logging.L().Info("seeded built-in spam referrers", zap.Int64("added", added))
+	}
+}
+
 // normalizeOriginForCSRF converts a domain (with or without scheme) into a full origin URL
 // acceptable by Fiber's CSRF middleware. It rejects paths, queries, fragments, wildcards,
 // and empty values.
@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/blang/semver"
+	"github.com/seuros/kaunta/internal/database"
 	"github.com/seuros/kaunta/internal/selfupdate"
 	"github.com/spf13/cobra"
 )
@@ -19,14 +20,47 @@ var (
 	selfUpgradeRequested bool
 	selfUpgradeCheckOnly bool
 	selfUpgradeAutoYes   bool
+	selfUpgradeRollback  bool
+	selfUpgradeChannel   string
+	selfUpgradeVersion   string
+	selfUpgradeSource    string
+	selfUpgradeMajor     bool
 )
 
+// selfUpgradeSourceEnv overrides the release API the upgrade checks
+const selfUpgradeSourceEnv = "KAUNTA_SELF_UPGRADE_URL"
+
+// selfUpgradeOptions controls which release --self-upgrade installs
+type selfUpgradeOptions struct {
+	CheckOnly  bool
+	AutoYes    bool
+	Channel    string
+	Version    string
+	Source     string
+	AllowMajor bool
+}
+
 func setupSelfUpgrade() {
 	RootCmd.PersistentFlags().BoolVar(&selfUpgradeRequested, "self-upgrade", false, "Upgrade Kaunta to the latest release and exit")
 	RootCmd.PersistentFlags().BoolVar(
 		&selfUpgradeCheckOnly, "self-upgrade-check", false,
 		"Only check whether a newer Kaunta release is available")
 	RootCmd.PersistentFlags().BoolVar(&selfUpgradeAutoYes, "self-upgrade-yes", false, "Skip confirmation prompts when running --self-upgrade")
+	RootCmd.PersistentFlags().BoolVar(
+		&selfUpgradeRollback, "self-upgrade-rollback", false,
+		"Restore the binary replaced by the last --self-upgrade and exit")
+	RootCmd.PersistentFlags().StringVar(
+		&selfUpgradeChannel, "self-upgrade-channel", selfupdate.ChannelStable,
+		"Release channel for --self-upgrade (stable, prerelease)")
+	RootCmd.PersistentFlags().StringVar(
+		&selfUpgradeVersion, "self-upgrade-version", "",
+		"Install this release (e.g. v1.4.2) instead of the newest one")
+	RootCmd.PersistentFlags().StringVar(
+		&selfUpgradeSource, "self-upgrade-source", "",
+		"GitHub compatible releases API to upgrade from (env: "+selfUpgradeSourceEnv+")")
+	RootCmd.PersistentFlags().BoolVar(
+		&selfUpgradeMajor, "allow-major", false,
+		"Allow --self-upgrade to cross a major version")
 
 	existingPreRun := RootCmd.PersistentPreRunE
 	RootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
@@ -52,16 +86,36 @@ func hideSelfUpgradeFlagsIfDevBuild() {
 		_ = RootCmd.PersistentFlags().MarkHidden("self-upgrade")
 		_ = RootCmd.PersistentFlags().MarkHidden("self-upgrade-check")
 		_ = RootCmd.PersistentFlags().MarkHidden("self-upgrade-yes")
+		_ = RootCmd.PersistentFlags().MarkHidden("self-upgrade-rollback")
+		_ = RootCmd.PersistentFlags().MarkHidden("self-upgrade-channel")
+		_ = RootCmd.PersistentFlags().MarkHidden("self-upgrade-version")
+		_ = RootCmd.PersistentFlags().MarkHidden("self-upgrade-source")
+		_ = RootCmd.PersistentFlags().MarkHidden("allow-major")
 	}
 }
 
 func handleSelfUpgradeFlags() error {
 	// Self-upgrade is flag-only (no config) to avoid accidental auto-upgrades
-	if !selfUpgradeRequested && !selfUpgradeCheckOnly {
+	if !selfUpgradeRequested && !selfUpgradeCheckOnly && !selfUpgradeRollback {
 		return nil
 	}
 
-	if err := runSelfUpgrade(selfUpgradeCheckOnly, selfUpgradeAutoYes); err != nil {
+	if selfUpgradeRollback {
+		if err := runSelfUpgradeRollback(); err != nil {
+			return err
+		}
+		os.Exit(0)
+	}
+
+	opts := selfUpgradeOptions{
+		CheckOnly:  selfUpgradeCheckOnly,
+		AutoYes:    selfUpgradeAutoYes,
+		Channel:    selfUpgradeChannel,
+		Version:    selfUpgradeVersion,
+		Source:     selfUpgradeSource,
+		AllowMajor: selfUpgradeMajor,
+	}
+	if err := runSelfUpgrade(opts); err != nil {
 		return err
 	}
 
@@ -69,7 +123,7 @@ func handleSelfUpgradeFlags() error {
 	return nil
 }
 
-func runSelfUpgrade(checkOnly, autoYes bool) error {
+func runSelfUpgrade(opts selfUpgradeOptions) error {
 	versionStr := strings.TrimSpace(strings.TrimPrefix(Version, "v"))
 	if versionStr == "" {
 		return errors.New("self-upgrade is only available for release builds")
@@ -82,24 +136,67 @@ func runSelfUpgrade(checkOnly, autoYes bool) error {
 
 	fmt.Printf("Checking current version... v%s\n", current)
 
-	fmt.Print("Checking latest released version... ")
+	source := opts.Source
+	if source == "" {
+		source = os.Getenv(selfUpgradeSourceEnv)
+	}
 	client := selfupdate.NewClient("seuros", "kaunta")
-	latest, err := client.DetectLatest()
+	if source != "" {
+		client = selfupdate.NewClientWithURL(source)
+		fmt.Printf("Using release source %s\n", source)
+	}
+
+	if opts.Version != "" {
+		fmt.Printf("Checking release %s... ", opts.Version)
+	} else {
+		fmt.Printf("Checking latest %s version... ", opts.Channel)
+	}
+	latest, err := client.Detect(opts.Channel, opts.Version)
 	if err != nil {
 		fmt.Println()
 		return fmt.Errorf("failed to check for updates: %w", err)
 	}
 
+	// The tag is whatever the release API (possibly a mirror) claims; the
+	// checks below rely on the version in the signed checksums
+	if err := selfupdate.Verify(latest); err != nil {
+		fmt.Println()
+		return fmt.Errorf("release %s failed verification: %w", latest.TagName, err)
+	}
 	latestVer := latest.Version
-	fmt.Printf("v%s\n", latestVer)
+	fmt.Printf("v%s (signature verified)\n", latestVer)
 
-	if !latestVer.GT(current) {
+	switch {
+	case latestVer.EQ(current):
 		fmt.Println("Kaunta is already up to date")
 		return nil
+	case latestVer.LT(current) && opts.Version == "":
+		// Only an explicit --self-upgrade-version downgrades
+		fmt.Println("Kaunta is already up to date")
+		return nil
+	case latestVer.LT(current):
+		fmt.Printf("Downgrading! v%s --> v%s\n", current, latestVer)
+	default:
+		fmt.Printf("New release found! v%s --> v%s\n", current, latestVer)
+	}
+
+	if err := checkMajorUpgrade(current, latestVer, opts.AllowMajor); err != nil {
+		return err
 	}
 
-	fmt.Printf("New release found! v%s --> v%s\n", current, latestVer)
-	if checkOnly {
+	migrations, err := selfupdate.MigrationVersion(latest)
+	switch {
+	case errors.Is(err, selfupdate.ErrNoMigrationVersion):
+		fmt.Printf("Note: v%s doesn't publish its migration version; back up the database before upgrading.\n", latestVer)
+	case err != nil:
+		fmt.Printf("Note: could not check v%s for database migrations: %v\n", latestVer, err)
+	default:
+		if notice := migrationNotice(database.LatestMigrationVersion, migrations, latestVer); notice != "" {
+			fmt.Println(notice)
+		}
+	}
+
+	if opts.CheckOnly {
 		return nil
 	}
 
@@ -122,7 +219,7 @@ func runSelfUpgrade(checkOnly, autoYes bool) error {
 	}
 	fmt.Println()
 
-	if !autoYes {
+	if !opts.AutoYes {
 		fmt.Println("The new release will download and replace the current binary.")
 		fmt.Print("Do you want to continue? [Y/n] ")
 
@@ -139,11 +236,51 @@ func runSelfUpgrade(checkOnly, autoYes bool) error {
 		}
 	}
 
-	fmt.Println("Downloading release...")
-	if err := selfupdate.UpdateTo(asset.BrowserDownloadURL, exe); err != nil {
-		return fmt.Errorf("self-upgrade failed: %w", err)
+	fmt.Println("Downloading and verifying release...")
+	if err := selfupdate.UpdateTo(latest, exe); err != nil {
+		return fmt.Errorf("self-upgrade failed (current binary kept): %w", err)
 	}
 
 	fmt.Printf("Updated Kaunta to v%s\n", latestVer)
+	fmt.Printf("Previous binary kept at %s (restore with --self-upgrade-rollback)\n", selfupdate.PreviousPath(exe))
+	return nil
+}
+
+// checkMajorUpgrade refuses to cross a major version unless allowed; major
+// releases may change configuration and the database in incompatible ways
+func checkMajorUpgrade(current, target semver.Version, allowMajor bool) error {
+	if current.Major == target.Major || allowMajor {
+		return nil
+	}
+	return fmt.Errorf("v%s is a different major version than v%s; read the release notes and pass --allow-major to continue", target, current)
+}
+
+// migrationNotice warns when the target release expects a different schema
+// than the one the running binary migrated the database to
+func migrationNotice(schema, target uint, version semver.Version) string {
+	switch {
+	case target > schema:
+		return fmt.Sprintf("Warning: v%s adds database migrations (schema %d --> %d). They run when the server starts; back up the database first.", version, schema, target)
+	case target < schema:
+		return fmt.Sprintf("Warning: the database schema (%d) is newer than v%s expects (%d). Migrations aren't reverted on downgrade.", schema, version, target)
+	}
+	return ""
+}
+
+func runSelfUpgradeRollback() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to determine executable path: %w", err)
+	}
+
+	if err := selfupdate.Rollback(exe); err != nil {
+		if errors.Is(err, selfupdate.ErrNoPrevious) {
+			return fmt.Errorf("%w (expected %s)", err, selfupdate.PreviousPath(exe))
+		}
+		return fmt.Errorf("rollback failed: %w", err)
+	}
+
+	fmt.Printf("Restored the previous Kaunta binary at %q\n", exe)
+	fmt.Println("Run --self-upgrade-rollback again to return to the newer release.")
 	return nil
 }
@@ -0,0 +1,28 @@
+//go:build !docker
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMajorUpgrade(t *testing.T) {
+	v := semver.MustParse
+
+	assert.NoError(t, checkMajorUpgrade(v("1.4.2"), v("1.5.0"), false))
+	assert.NoError(t, checkMajorUpgrade(v("1.4.2"), v("1.3.0"), false))
+	assert.ErrorContains(t, checkMajorUpgrade(v("1.4.2"), v("2.0.0"), false), "--allow-major")
+	assert.ErrorContains(t, checkMajorUpgrade(v("2.0.0"), v("1.9.0"), false), "--allow-major")
+	assert.NoError(t, checkMajorUpgrade(v("1.4.2"), v("2.0.0"), true))
+}
+
+func TestMigrationNotice(t *testing.T) {
+	v := semver.MustParse("1.5.0")
+
+	assert.Empty(t, migrationNotice(37, 37, v))
+	assert.Contains(t, migrationNotice(37, 41, v), "adds database migrations (schema 37 --> 41)")
+	assert.Contains(t, migrationNotice(37, 30, v), "newer than v1.5.0 expects (30)")
+}
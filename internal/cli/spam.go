@@ -0,0 +1,313 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/models"
+	"github.com/seuros/kaunta/internal/spam"
+)
+
+var spamCmd = &cobra.Command{
+	Use:   "spam",
+	Short: "Manage the referrer spam list",
+	Long: `Manage the list of referrer spam domains. Hits whose referrer is a listed
+domain, or a subdomain of one, are dropped and counted per website.
+
+The list is seeded at startup from the domains built into Kaunta. Changes
+apply to new hits once the server's list cache refreshes (up to 5 minutes).`,
+}
+
+var spamListCmd = &cobra.Command{
+	Use:   "list [--source <name>] [--all] [--format json|table]",
+	Short: "List spam domains",
+	Long: `List spam domains. Sources are "builtin", "cli" (added with 'kaunta spam
+add') or the name given to an imported list. --all includes built-in
+domains that were removed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSpamList(spamSource, spamAll, spamFormat)
+	},
+}
+
+var spamAddCmd = &cobra.Command{
+	Use:   "add <domain>",
+	Short: "Add a spam domain",
+	Long: `Add a spam domain. It matches the domain and its subdomains, never other
+hosts containing it (spam.com doesn't match notspam.com). Adding a removed
+built-in domain restores it.
+
+Examples:
+  kaunta spam add best-seo-offers.example
+  kaunta spam add https://www.spam-site.example/`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSpamAdd(args[0])
+	},
+}
+
+var spamRemoveCmd = &cobra.Command{
+	Use:   "remove <domain>",
+	Short: "Remove a spam domain",
+	Long:  `Remove a spam domain. Built-in domains are disabled so they stay removed after upgrades.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSpamRemove(args[0])
+	},
+}
+
+var spamImportCmd = &cobra.Command{
+	Use:   "import <file> [--source <name>] [--replace]",
+	Short: "Import spam domains from a file",
+	Long: `Import domains from a file in Matomo's referrer-spam-blacklist format: one
+domain per line. Blank lines and lines starting with '#' are skipped.
+
+Domains already listed keep their source. With --replace, domains previously
+imported from the same source are removed first, so a list can be refreshed
+in place.
+
+Examples:
+  kaunta spam import spammers.txt
+  kaunta spam import spammers.txt --source matomo --replace`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSpamImport(args[0], spamSource, spamReplace)
+	},
+}
+
+var spamStatsCmd = &cobra.Command{
+	Use:   "stats <website-domain> [--days N] [--format json|table]",
+	Short: "Show spam hits dropped for a website",
+	Long: `Show how many hits were dropped as referrer spam for a website, per day
+and spam domain.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSpamStats(args[0], spamDays, spamFormat)
+	},
+}
+
+// Command flags
+var (
+	spamSource  string
+	spamAll     bool
+	spamReplace bool
+	spamDays    int
+	spamFormat  string
+)
+
+func runSpamList(source string, all bool, format string) error {
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	list, err := models.ListSpamReferrers(ctx, database.DB, source, all)
+	if err != nil {
+		return fmt.Errorf("failed to list spam domains: %w", err)
+	}
+
+	if format == "json" {
+		if list == nil {
+			list = []*models.SpamReferrer{}
+		}
+		data, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No spam domains found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DOMAIN\tSOURCE\tENABLED")
+	_, _ = fmt.Fprintln(w, "------\t------\t-------")
+	for _, s := range list {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%t\n", s.Domain, s.Source, s.Enabled)
+	}
+	return w.Flush()
+}
+
+func runSpamAdd(value string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s, err := models.AddSpamReferrer(ctx, database.DB, value)
+	if err != nil {
+		return fmt.Errorf("failed to add spam domain: %w", err)
+	}
+
+	fmt.Printf("Spam domain '%s' added (%s)\n", s.Domain, s.Source)
+	return nil
+}
+
+func runSpamRemove(value string) error {
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	domain, err := models.RemoveSpamReferrer(ctx, database.DB, value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s is not on the spam list", domain)
+		}
+		return fmt.Errorf("failed to remove spam domain: %w", err)
+	}
+
+	fmt.Printf("Spam domain '%s' removed\n", domain)
+	return nil
+}
+
+func runSpamImport(path, source string, replace bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	domains, invalid, err := spam.ParseList(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(domains) == 0 {
+		return fmt.Errorf("no domains found in %s", path)
+	}
+
+	cleanup, err := ensureDatabase()
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	result, err := models.ImportSpamReferrers(ctx, database.DB, source, domains, replace)
+	if err != nil {
+		return fmt.Errorf("failed to import spam domains: %w", err)
+	}
+
+	fmt.Printf("Imported %d domains from %s as '%s'\n", result.Added, path, source)
+	if replace {
+		fmt.Printf("Removed:  %d previous domains\n", result.Removed)
+	}
+	if result.Skipped > 0 {
+		fmt.Printf("Skipped:  %d (already listed)\n", result.Skipped)
+	}
+	if len(invalid) > 0 {
+		fmt.Printf("Invalid:  %d\n", len(invalid))
+		for _, line := range invalid {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	return nil
+}
+
+func runSpamStats(domain string, days int, format string) error {
+	if days < 1 || days > 365 {
+		return fmt.Errorf("days must be between 1 and 365")
+	}
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	counts, err := models.ListSpamHits(ctx, database.DB, websiteID, days)
+	if err != nil {
+		return fmt.Errorf("failed to load spam hits: %w", err)
+	}
+	return outputSpamHits(domain, days, counts, format)
+}
+
+func outputSpamHits(domain string, days int, counts []models.SpamHitCount, format string) error {
+	if format == "json" {
+		if counts == nil {
+			counts = []models.SpamHitCount{}
+		}
+		data, err := json.MarshalIndent(counts, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(counts) == 0 {
+		fmt.Printf("No spam hits dropped for %s in the last %d days\n", domain, days)
+		return nil
+	}
+
+	var total int64
+	for _, c := range counts {
+		total += c.Hits
+	}
+	fmt.Printf("Spam hits dropped for %s: %d (last %d days)\n\n", domain, total, days)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DAY\tSPAM DOMAIN\tHITS")
+	_, _ = fmt.Fprintln(w, "---\t-----------\t----")
+	for _, c := range counts {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\n", c.Day.Format("2006-01-02"), c.Domain, c.Hits)
+	}
+	return w.Flush()
+}
+
+func init() {
+	spamListCmd.Flags().StringVar(&spamSource, "source", "", "Only domains from this source (builtin, cli or an import name)")
+	spamListCmd.Flags().BoolVar(&spamAll, "all", false, "Include removed built-in domains")
+	spamListCmd.Flags().StringVarP(&spamFormat, "format", "f", "table", "Output format (json, table)")
+
+	spamImportCmd.Flags().StringVar(&spamSource, "source", "import", "Name of the list, used to refresh it later")
+	spamImportCmd.Flags().BoolVar(&spamReplace, "replace", false, "Remove domains previously imported from this source first")
+
+	spamStatsCmd.Flags().IntVarP(&spamDays, "days", "d", 30, "Time period in days (1-365)")
+	spamStatsCmd.Flags().StringVarP(&spamFormat, "format", "f", "table", "Output format (json, table)")
+
+	spamCmd.AddCommand(spamListCmd)
+	spamCmd.AddCommand(spamAddCmd)
+	spamCmd.AddCommand(spamRemoveCmd)
+	spamCmd.AddCommand(spamImportCmd)
+	spamCmd.AddCommand(spamStatsCmd)
+
+	RootCmd.AddCommand(spamCmd)
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/models"
+)
+
+func TestOutputSpamHits(t *testing.T) {
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	counts := []models.SpamHitCount{
+		{Day: day, Domain: "semalt.com", Hits: 7},
+		{Day: day, Domain: "darodar.com", Hits: 2},
+	}
+
+	output, err := captureOutput(t, func() error {
+		return outputSpamHits("example.com", 30, counts, "table")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Spam hits dropped for example.com: 9 (last 30 days)")
+	assert.Regexp(t, `2026-10-17\s+semalt\.com\s+7`, output)
+
+	output, err = captureOutput(t, func() error {
+		return outputSpamHits("example.com", 30, nil, "table")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "No spam hits dropped")
+}
+
+func TestRunSpamStatsValidation(t *testing.T) {
+	err := runSpamStats("example.com", 0, "table")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "days")
+
+	err = runSpamStats("example.com", 7, "csv")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid format")
+}
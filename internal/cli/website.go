@@ -7,11 +7,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/handlers"
+	"github.com/seuros/kaunta/internal/models"
 	"github.com/spf13/cobra"
 )
 
@@ -739,6 +742,189 @@ func runSetPublicStats(domain string, enabled bool) error {
 	return nil
 }
 
+var websiteSetMatomoIDCmd = &cobra.Command{
+	Use:   "set-matomo-id <domain> <idsite>",
+	Short: "Map a Matomo idsite to a website",
+	Long: `Map a numeric Matomo site ID to a website so existing Matomo trackers
+pointed at /matomo.php or /piwik.php are recorded for it.
+
+Use "none" as idsite to remove the mapping.
+
+Example:
+  kaunta website set-matomo-id example.com 3`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSetMatomoSiteID(args[0], args[1])
+	},
+}
+
+func runSetMatomoSiteID(domain, idsite string) error {
+	var siteID *int
+	if idsite != "none" {
+		id, err := strconv.Atoi(idsite)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid idsite %q: must be a positive integer or \"none\"", idsite)
+		}
+		siteID = &id
+	}
+
+	if err := database.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := SetMatomoSiteID(ctx, domain, siteID); err != nil {
+		return err
+	}
+
+	if siteID == nil {
+		fmt.Printf("Removed Matomo idsite mapping for website '%s'\n", domain)
+	} else {
+		fmt.Printf("Matomo idsite %d now maps to website '%s'\n", *siteID, domain)
+	}
+	return nil
+}
+
+var (
+	setProxyMode    string
+	setProxyTrusted string
+)
+
+var websiteSetProxyCmd = &cobra.Command{
+	Use:   "set-proxy <domain> [--mode <mode>] [--trusted-proxies <cidrs>]",
+	Short: "Configure how client IPs are resolved behind proxies",
+	Long: `Configure the proxy mode and trusted proxy CIDRs for a website.
+
+Modes:
+  none        Forwarded / X-Forwarded-For / X-Real-IP chain (default)
+  xforwarded  Same as none
+  cloudflare  CF-Connecting-IP
+  fastly      Fastly-Client-IP
+  akamai      True-Client-IP
+  bunny       CDN-ClientIP
+
+The X-Forwarded-For and Forwarded chains are walked right to left, skipping
+trusted proxies. When trusted proxies are configured (here or with the global
+trusted_proxies / TRUSTED_PROXIES setting), forwarding headers are ignored
+for requests not coming from one of them.
+
+Use "none" for --trusted-proxies to clear the list.
+
+Examples:
+  kaunta website set-proxy example.com --mode cloudflare
+  kaunta website set-proxy example.com --mode xforwarded --trusted-proxies 10.0.0.0/8,192.0.2.10`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSetWebsiteProxy(args[0], setProxyMode, setProxyTrusted,
+			cmd.Flags().Changed("mode"), cmd.Flags().Changed("trusted-proxies"))
+	},
+}
+
+func runSetWebsiteProxy(domain, mode, trusted string, modeSet, trustedSet bool) error {
+	if !modeSet && !trustedSet {
+		return fmt.Errorf("nothing to update: pass --mode and/or --trusted-proxies")
+	}
+
+	var modePtr *string
+	if modeSet {
+		if !isValidProxyMode(mode) {
+			return fmt.Errorf("invalid proxy mode %q (valid: %s)", mode, strings.Join(handlers.ProxyModes, ", "))
+		}
+		modePtr = &mode
+	}
+
+	var proxies []string
+	if trustedSet && trusted != "none" {
+		for _, entry := range strings.Split(trusted, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				proxies = append(proxies, entry)
+			}
+		}
+		prefixes, err := handlers.ParseTrustedProxies(proxies)
+		if err != nil {
+			return err
+		}
+		proxies = proxies[:0]
+		for _, prefix := range prefixes {
+			proxies = append(proxies, prefix.String())
+		}
+	}
+
+	if err := database.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	finalMode, finalProxies, err := SetWebsiteProxy(ctx, domain, modePtr, trustedSet, proxies)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Proxy mode for website '%s': %s\n", domain, finalMode)
+	if len(finalProxies) > 0 {
+		fmt.Printf("Trusted proxies: %s\n", strings.Join(finalProxies, ", "))
+	} else {
+		fmt.Println("Trusted proxies: (none, global setting only)")
+	}
+	return nil
+}
+
+var websiteSetDatacenterPolicyCmd = &cobra.Command{
+	Use:   "set-datacenter-policy <domain> <flag|exclude|keep>",
+	Short: "Choose how traffic from hosting providers is handled",
+	Long: `Choose how hits from hosting provider networks (AWS, GCP, Hetzner, ...) are
+handled. Networks are recognised by their ASN, which needs an ASN database
+(see 'kaunta geoip'); the provider list is managed with 'kaunta bots hosting'.
+
+Policies:
+  flag     Record the hit and mark the session as hosting traffic (default)
+  exclude  Drop the hit and log it as a 'datacenter' bot in 'kaunta stats bots'
+  keep     Record the hit as regular traffic
+
+Examples:
+  kaunta website set-datacenter-policy example.com exclude`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSetDatacenterPolicy(args[0], args[1])
+	},
+}
+
+func runSetDatacenterPolicy(domain, policy string) error {
+	policy, err := models.ParseDatacenterPolicy(policy)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if err := models.SetDatacenterPolicy(ctx, database.DB, websiteID, policy); err != nil {
+		return fmt.Errorf("failed to update website: %w", err)
+	}
+
+	fmt.Printf("Datacenter policy for website '%s': %s\n", domain, policy)
+	return nil
+}
+
+func isValidProxyMode(mode string) bool {
+	for _, m := range handlers.ProxyModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	// Add subcommands to website
 	websiteCmd.AddCommand(websiteListCmd)
@@ -752,6 +938,9 @@ func init() {
 	websiteCmd.AddCommand(websiteListDomainsCmd)
 	websiteCmd.AddCommand(websiteEnablePublicStatsCmd)
 	websiteCmd.AddCommand(websiteDisablePublicStatsCmd)
+	websiteCmd.AddCommand(websiteSetMatomoIDCmd)
+	websiteCmd.AddCommand(websiteSetProxyCmd)
+	websiteCmd.AddCommand(websiteSetDatacenterPolicyCmd)
 	// checkWebsiteCmd added in devops.go
 
 	// List command flags
@@ -774,6 +963,10 @@ func init() {
 	// Add domain command flags
 	websiteAddDomainCmd.Flags().StringVarP(&addDomainAllowed, "allowed", "a", "", "Comma-separated list of additional domains to allow")
 
+	// Set proxy command flags
+	websiteSetProxyCmd.Flags().StringVar(&setProxyMode, "mode", "", "Proxy mode (none, xforwarded, cloudflare, fastly, akamai, bunny)")
+	websiteSetProxyCmd.Flags().StringVar(&setProxyTrusted, "trusted-proxies", "", "Comma-separated trusted proxy IPs/CIDRs, or \"none\"")
+
 	// List domains command flags
 	websiteListDomainsCmd.Flags().StringVarP(&listDomainsFormat, "format", "f", "text", "Output format (text, json, table)")
 }
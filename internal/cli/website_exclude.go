@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/exclusions"
+	"github.com/seuros/kaunta/internal/models"
+)
+
+var websiteExcludeCmd = &cobra.Command{
+	Use:   "exclude",
+	Short: "Exclude internal traffic by IP range or user agent",
+	Long: `Manage internal traffic that is never recorded for a website, such as
+office and VPN networks or uptime monitors.
+
+Rule kinds:
+  ip          CIDR (10.0.0.0/8) or single address (192.0.2.10)
+  user_agent  Case-insensitive substring of the User-Agent (alias: ua)
+
+Rules are checked by /api/send, the tracking pixel, /api/ingest and log
+imports before anything is written. They apply once the server's cache
+refreshes (up to 5 minutes).
+
+Visitors can also exclude their own browser on the opt-out page:
+  https://<kaunta-host>/optout/<website_id>`,
+}
+
+var websiteExcludeListCmd = &cobra.Command{
+	Use:   "list <domain> [--format json|table]",
+	Short: "List a website's exclusion rules",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteExcludeList(args[0], websiteExcludeFormat)
+	},
+}
+
+var websiteExcludeAddCmd = &cobra.Command{
+	Use:   "add <domain> <ip|user_agent> <value>",
+	Short: "Add an exclusion rule",
+	Long: `Add an exclusion rule. Single IP addresses are stored as /32 or /128.
+
+Examples:
+  kaunta website exclude add example.com ip 203.0.113.0/24
+  kaunta website exclude add example.com ip 2001:db8::/32
+  kaunta website exclude add example.com ua UptimeRobot`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteExcludeAdd(args[0], args[1], args[2])
+	},
+}
+
+var websiteExcludeRemoveCmd = &cobra.Command{
+	Use:   "remove <domain> <ip|user_agent> <value>",
+	Short: "Remove an exclusion rule",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteExcludeRemove(args[0], args[1], args[2])
+	},
+}
+
+var websiteExcludeFormat string
+
+// parseExclusionRule validates CLI arguments into a normalized rule
+func parseExclusionRule(kindArg, value string) (exclusions.Rule, error) {
+	kind, ok := exclusions.ParseKind(kindArg)
+	if !ok {
+		return exclusions.Rule{}, fmt.Errorf("invalid exclusion kind %q (valid: ip, user_agent)", kindArg)
+	}
+	return exclusions.Normalize(exclusions.Rule{Kind: kind, Value: value})
+}
+
+func runWebsiteExcludeList(domain, format string) error {
+	if format == "" {
+		format = "table"
+	}
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	list, err := models.ListTrafficExclusions(ctx, database.DB, websiteID)
+	if err != nil {
+		return fmt.Errorf("failed to list exclusions: %w", err)
+	}
+	return outputTrafficExclusions(websiteID, list, format)
+}
+
+func outputTrafficExclusions(websiteID uuid.UUID, list []*models.TrafficExclusion, format string) error {
+	if format == "json" {
+		if list == nil {
+			list = []*models.TrafficExclusion{}
+		}
+		data, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No exclusion rules")
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "KIND\tVALUE\tCREATED")
+		_, _ = fmt.Fprintln(w, "----\t-----\t-------")
+		for _, e := range list {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", e.Kind, e.Value, e.CreatedAt.Format("2006-01-02"))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("\nOpt-out page: /optout/%s\n", websiteID)
+	return nil
+}
+
+func runWebsiteExcludeAdd(domain, kindArg, value string) error {
+	rule, err := parseExclusionRule(kindArg, value)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	stored, err := models.AddTrafficExclusion(ctx, database.DB, websiteID, rule)
+	if err != nil {
+		return fmt.Errorf("failed to save exclusion: %w", err)
+	}
+
+	fmt.Printf("Excluding %s %s for %s\n", stored.Kind, stored.Value, domain)
+	return nil
+}
+
+func runWebsiteExcludeRemove(domain, kindArg, value string) error {
+	rule, err := parseExclusionRule(kindArg, value)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if err := models.DeleteTrafficExclusion(ctx, database.DB, websiteID, rule); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no %s exclusion %q for %s", rule.Kind, rule.Value, domain)
+		}
+		return fmt.Errorf("failed to remove exclusion: %w", err)
+	}
+
+	fmt.Printf("Exclusion %s %s removed from %s\n", rule.Kind, rule.Value, domain)
+	return nil
+}
+
+func init() {
+	websiteExcludeListCmd.Flags().StringVarP(&websiteExcludeFormat, "format", "f", "table", "Output format (json, table)")
+
+	websiteExcludeCmd.AddCommand(websiteExcludeListCmd)
+	websiteExcludeCmd.AddCommand(websiteExcludeAddCmd)
+	websiteExcludeCmd.AddCommand(websiteExcludeRemoveCmd)
+
+	websiteCmd.AddCommand(websiteExcludeCmd)
+}
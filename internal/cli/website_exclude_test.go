@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/exclusions"
+	"github.com/seuros/kaunta/internal/models"
+)
+
+func TestParseExclusionRule(t *testing.T) {
+	rule, err := parseExclusionRule("ip", "192.0.2.10")
+	require.NoError(t, err)
+	assert.Equal(t, exclusions.Rule{Kind: exclusions.KindIP, Value: "192.0.2.10/32"}, rule)
+
+	rule, err = parseExclusionRule("ua", " UptimeRobot ")
+	require.NoError(t, err)
+	assert.Equal(t, exclusions.Rule{Kind: exclusions.KindUserAgent, Value: "UptimeRobot"}, rule)
+
+	_, err = parseExclusionRule("cookie", "x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid exclusion kind")
+
+	_, err = parseExclusionRule("ip", "office")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid IP address")
+}
+
+func TestOutputTrafficExclusions(t *testing.T) {
+	websiteID := uuid.New()
+	list := []*models.TrafficExclusion{
+		{Kind: "ip", Value: "10.0.0.0/8", CreatedAt: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)},
+	}
+
+	output, err := captureOutput(t, func() error {
+		return outputTrafficExclusions(websiteID, list, "table")
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `ip\s+10\.0\.0\.0/8\s+2026-03-02`, output)
+	assert.Contains(t, output, "/optout/"+websiteID.String())
+}
@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/models"
+	"github.com/seuros/kaunta/internal/redact"
+)
+
+var websiteRedactionCmd = &cobra.Command{
+	Use:   "redaction",
+	Short: "Manage PII redaction for tracked events",
+	Long: `Manage how personal data is removed from events before they are stored.
+
+Redaction applies to url_query, referrer_query, page_title and event
+properties for /api/send, the tracking pixel and /api/ingest. It detects
+email addresses, JWTs, card numbers (Luhn-checked) and sensitive parameter
+names (email, password, token, api_key, ... plus any configured ones).
+
+Modes:
+  mask  Replace matches with a placeholder such as [email] (default)
+  drop  Remove matching query parameters, properties and text
+  off   Store values verbatim
+
+Settings apply to new events once the server's cache refreshes (up to
+5 minutes); stored events are not rewritten.`,
+}
+
+var websiteRedactionShowCmd = &cobra.Command{
+	Use:   "show <domain> [--format json|table]",
+	Short: "Show a website's redaction settings",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteRedactionShow(args[0], websiteRedactionFormat)
+	},
+}
+
+var websiteRedactionSetCmd = &cobra.Command{
+	Use:   "set <domain> [--mode off|mask|drop] [--params <names>]",
+	Short: "Update redaction settings",
+	Long: `Update redaction settings. Only the flags given are changed.
+Use "none" for --params to clear the extra parameter names.
+
+Examples:
+  kaunta website redaction set example.com --mode drop
+  kaunta website redaction set example.com --params invite_code,customer_ref`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteRedactionSet(args[0],
+			flagString(cmd, "mode", websiteRedactionMode),
+			flagString(cmd, "params", websiteRedactionParams))
+	},
+}
+
+var websiteRedactionStatsCmd = &cobra.Command{
+	Use:   "stats <domain> [--days N] [--format json|table]",
+	Short: "Show how many values were redacted per day",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteRedactionStats(args[0], websiteRedactionDays, websiteRedactionFormat)
+	},
+}
+
+var (
+	websiteRedactionFormat string
+	websiteRedactionMode   string
+	websiteRedactionParams string
+	websiteRedactionDays   int
+)
+
+func runWebsiteRedactionShow(domain, format string) error {
+	if format == "" {
+		format = "table"
+	}
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := models.GetRedactionConfig(ctx, database.DB, websiteID)
+	if err != nil {
+		return fmt.Errorf("failed to load redaction settings: %w", err)
+	}
+	return outputRedactionConfig(cfg, format)
+}
+
+func outputRedactionConfig(cfg redact.Config, format string) error {
+	if format == "json" {
+		data, err := json.MarshalIndent(map[string]any{
+			"mode":           cfg.Mode,
+			"params":         cfg.Params,
+			"default_params": redact.DefaultParams,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Mode:           %s\n", cfg.Mode)
+	fmt.Printf("Extra params:   %s\n", listOrNone(cfg.Params))
+	fmt.Printf("Default params: %s\n", listOrNone(redact.DefaultParams))
+	return nil
+}
+
+func runWebsiteRedactionSet(domain string, mode, params *string) error {
+	if mode == nil && params == nil {
+		return fmt.Errorf("nothing to update: pass --mode and/or --params")
+	}
+	if mode != nil {
+		if err := redact.ValidateMode(*mode); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := models.GetRedactionConfig(ctx, database.DB, websiteID)
+	if err != nil {
+		return fmt.Errorf("failed to load redaction settings: %w", err)
+	}
+	if mode != nil {
+		cfg.Mode = *mode
+	}
+	if params != nil {
+		cfg.Params = parseQueryParamList(*params)
+	}
+
+	if err := models.UpdateRedactionConfig(ctx, database.DB, websiteID, cfg); err != nil {
+		return fmt.Errorf("failed to update redaction settings: %w", err)
+	}
+
+	fmt.Printf("Redaction settings updated for website '%s' (mode: %s)\n", domain, cfg.Mode)
+	return nil
+}
+
+func runWebsiteRedactionStats(domain string, days int, format string) error {
+	if days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+	if format == "" {
+		format = "table"
+	}
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	counts, err := models.ListRedactionCounts(ctx, database.DB, websiteID, days)
+	if err != nil {
+		return fmt.Errorf("failed to load redaction counts: %w", err)
+	}
+	return outputRedactionCounts(counts, format)
+}
+
+func outputRedactionCounts(counts []models.RedactionCount, format string) error {
+	if format == "json" {
+		if counts == nil {
+			counts = []models.RedactionCount{}
+		}
+		data, err := json.MarshalIndent(counts, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(counts) == 0 {
+		fmt.Println("No redactions recorded")
+		return nil
+	}
+
+	// One row per day, one column per kind
+	var days []string
+	byDay := make(map[string]map[string]int64)
+	for _, c := range counts {
+		day := c.Day.Format("2006-01-02")
+		if byDay[day] == nil {
+			byDay[day] = make(map[string]int64)
+			days = append(days, day)
+		}
+		byDay[day][c.Kind] += c.Count
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "DAY\tEMAIL\tJWT\tCARD\tPARAM\tTOTAL")
+	_, _ = fmt.Fprintln(w, "---\t-----\t---\t----\t-----\t-----")
+	for _, day := range days {
+		var total int64
+		_, _ = fmt.Fprint(w, day)
+		for _, kind := range redact.Kinds {
+			n := byDay[day][kind]
+			total += n
+			_, _ = fmt.Fprintf(w, "\t%d", n)
+		}
+		_, _ = fmt.Fprintf(w, "\t%d\n", total)
+	}
+	return w.Flush()
+}
+
+func init() {
+	websiteRedactionShowCmd.Flags().StringVarP(&websiteRedactionFormat, "format", "f", "table", "Output format (json, table)")
+	websiteRedactionStatsCmd.Flags().StringVarP(&websiteRedactionFormat, "format", "f", "table", "Output format (json, table)")
+	websiteRedactionStatsCmd.Flags().IntVar(&websiteRedactionDays, "days", 30, "Number of days to show")
+	websiteRedactionSetCmd.Flags().StringVar(&websiteRedactionMode, "mode", "", "Redaction mode: off, mask or drop")
+	websiteRedactionSetCmd.Flags().StringVar(&websiteRedactionParams, "params", "", "Comma-separated extra sensitive parameter names (none to clear)")
+
+	websiteRedactionCmd.AddCommand(websiteRedactionShowCmd)
+	websiteRedactionCmd.AddCommand(websiteRedactionSetCmd)
+	websiteRedactionCmd.AddCommand(websiteRedactionStatsCmd)
+
+	websiteCmd.AddCommand(websiteRedactionCmd)
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/models"
+)
+
+func TestOutputRedactionCounts(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	counts := []models.RedactionCount{
+		{Day: day, Kind: "email", Count: 3},
+		{Day: day, Kind: "param", Count: 2},
+		{Day: day.AddDate(0, 0, -1), Kind: "jwt", Count: 1},
+	}
+
+	output, err := captureOutput(t, func() error {
+		return outputRedactionCounts(counts, "table")
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `2026-03-02\s+3\s+0\s+0\s+2\s+5`, output)
+	assert.Regexp(t, `2026-03-01\s+0\s+1\s+0\s+0\s+1`, output)
+
+	output, err = captureOutput(t, func() error {
+		return outputRedactionCounts(nil, "table")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "No redactions recorded")
+}
+
+func TestRunWebsiteRedactionValidation(t *testing.T) {
+	err := runWebsiteRedactionSet("example.com", nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nothing to update")
+
+	mode := "scramble"
+	err = runWebsiteRedactionSet("example.com", &mode, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid redaction mode")
+
+	err = runWebsiteRedactionStats("example.com", 0, "table")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--days")
+}
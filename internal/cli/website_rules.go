@@ -0,0 +1,393 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/models"
+	"github.com/seuros/kaunta/internal/urlrules"
+)
+
+var websiteRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Manage URL normalization and content group rules",
+	Long: `Manage how page URLs are normalized when events are recorded.
+
+Steps, in order:
+  1. Lowercase the path (--lowercase)
+  2. Strip or add the trailing slash (--trailing-slash keep|strip|add)
+  3. Keep only allowed / drop denied query parameters
+  4. First matching rewrite: regex -> template (/users/\d+ -> /users/:id)
+  5. First matching content group: regex -> name (Docs, Blog)
+
+Rules apply to new events once the server's rule cache refreshes (up to
+5 minutes); stored events are not rewritten.`,
+}
+
+var websiteRulesListCmd = &cobra.Command{
+	Use:   "list <domain> [--format json|table]",
+	Short: "Show a website's URL settings and rules",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteRulesList(args[0], websiteRulesFormat)
+	},
+}
+
+var websiteRulesSetCmd = &cobra.Command{
+	Use:   "set <domain> [--lowercase] [--trailing-slash keep|strip|add] [--query-allow <params>] [--query-deny <params>]",
+	Short: "Update URL normalization settings",
+	Long: `Update URL normalization settings. Only the flags given are changed.
+Use "none" for --query-allow or --query-deny to clear the list.
+
+Examples:
+  kaunta website rules set example.com --lowercase --trailing-slash strip
+  kaunta website rules set example.com --query-deny token,session_id,fbclid
+  kaunta website rules set example.com --query-allow q,page`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteRulesSet(args[0], websiteRulesSettingsUpdate{
+			lowercase:     flagBool(cmd, "lowercase", websiteRulesLowercase),
+			trailingSlash: flagString(cmd, "trailing-slash", websiteRulesTrailingSlash),
+			queryAllow:    flagString(cmd, "query-allow", websiteRulesQueryAllow),
+			queryDeny:     flagString(cmd, "query-deny", websiteRulesQueryDeny),
+		})
+	},
+}
+
+var websiteRulesAddRewriteCmd = &cobra.Command{
+	Use:   "add-rewrite <domain> <regex> <template>",
+	Short: "Rewrite matching paths to a template",
+	Long: `Rewrite paths matching a regular expression to a template. Capture groups
+can be referenced as $1, $2. The first matching rewrite wins.
+
+Examples:
+  kaunta website rules add-rewrite example.com '^/users/\d+/settings$' /users/:id/settings
+  kaunta website rules add-rewrite example.com '^/orders/[a-z0-9-]+$' /orders/:id
+  kaunta website rules add-rewrite example.com '^/(en|de)/(.*)$' '/:lang/$2'`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteRulesAdd(args[0], urlrules.Rule{Kind: urlrules.KindRewrite, Pattern: args[1], Value: args[2]})
+	},
+}
+
+var websiteRulesAddGroupCmd = &cobra.Command{
+	Use:   "add-group <domain> <regex> <name>",
+	Short: "Assign matching paths to a content group",
+	Long: `Assign paths matching a regular expression (after rewrites) to a named
+content group. The first matching group wins.
+
+Examples:
+  kaunta website rules add-group example.com '^/docs(/|$)' Docs
+  kaunta website rules add-group example.com '^/blog/' Blog`,
+	Args: cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteRulesAdd(args[0], urlrules.Rule{Kind: urlrules.KindGroup, Pattern: args[1], Value: args[2]})
+	},
+}
+
+var websiteRulesRemoveCmd = &cobra.Command{
+	Use:   "remove <domain> <rewrite|group> <regex>",
+	Short: "Remove a rewrite or content group rule",
+	Args:  cobra.ExactArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteRulesRemove(args[0], args[1], args[2])
+	},
+}
+
+var websiteRulesTestCmd = &cobra.Command{
+	Use:   "test <domain> <url>",
+	Short: "Show how a URL would be rewritten",
+	Long: `Apply a website's URL settings and rules to a URL without recording anything.
+
+Example:
+  kaunta website rules test example.com 'https://example.com/Users/8123/settings/?token=x'`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWebsiteRulesTest(args[0], args[1])
+	},
+}
+
+// Command flags
+var (
+	websiteRulesFormat        string
+	websiteRulesLowercase     bool
+	websiteRulesTrailingSlash string
+	websiteRulesQueryAllow    string
+	websiteRulesQueryDeny     string
+)
+
+// websiteRulesSettingsUpdate holds the flags passed to "rules set"; nil means unchanged
+type websiteRulesSettingsUpdate struct {
+	lowercase     *bool
+	trailingSlash *string
+	queryAllow    *string
+	queryDeny     *string
+}
+
+func flagBool(cmd *cobra.Command, name string, value bool) *bool {
+	if !cmd.Flags().Changed(name) {
+		return nil
+	}
+	return &value
+}
+
+func flagString(cmd *cobra.Command, name, value string) *string {
+	if !cmd.Flags().Changed(name) {
+		return nil
+	}
+	return &value
+}
+
+// parseQueryParamList splits "a,b" into parameter names; "none" clears the list
+func parseQueryParamList(value string) []string {
+	if strings.TrimSpace(value) == "none" {
+		return nil
+	}
+	var params []string
+	for _, p := range strings.Split(value, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// loadWebsiteRuleset reads and compiles a website's URL settings and rules
+func loadWebsiteRuleset(ctx context.Context, domain string) (urlrules.Settings, []*models.URLRule, *urlrules.Ruleset, func(), error) {
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	if err != nil {
+		return urlrules.Settings{}, nil, nil, cleanup, err
+	}
+	settings, err := models.GetURLSettings(ctx, database.DB, websiteID)
+	if err != nil {
+		return urlrules.Settings{}, nil, nil, cleanup, fmt.Errorf("failed to load URL settings: %w", err)
+	}
+	stored, err := models.ListURLRules(ctx, database.DB, websiteID)
+	if err != nil {
+		return urlrules.Settings{}, nil, nil, cleanup, fmt.Errorf("failed to list URL rules: %w", err)
+	}
+	rules := make([]urlrules.Rule, len(stored))
+	for i, r := range stored {
+		rules[i] = r.Rule()
+	}
+	ruleset, err := urlrules.Compile(settings, rules)
+	if err != nil {
+		return urlrules.Settings{}, nil, nil, cleanup, err
+	}
+	return settings, stored, ruleset, cleanup, nil
+}
+
+func runWebsiteRulesList(domain, format string) error {
+	if format == "" {
+		format = "table"
+	}
+	if format != "json" && format != "table" {
+		return fmt.Errorf("invalid format: %s (use json or table)", format)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	settings, stored, _, cleanup, err := loadWebsiteRuleset(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		data, err := json.MarshalIndent(map[string]any{
+			"settings": settings,
+			"rules":    stored,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Lowercase paths: %t\n", settings.Lowercase)
+	fmt.Printf("Trailing slash:  %s\n", settings.TrailingSlash)
+	fmt.Printf("Query allow:     %s\n", listOrNone(settings.QueryAllow))
+	fmt.Printf("Query deny:      %s\n\n", listOrNone(settings.QueryDeny))
+
+	if len(stored) == 0 {
+		fmt.Println("No rewrite or content group rules")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "#\tKIND\tPATTERN\tVALUE")
+	_, _ = fmt.Fprintln(w, "-\t----\t-------\t-----")
+	for i, r := range stored {
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, r.Kind, r.Pattern, r.Value)
+	}
+	return w.Flush()
+}
+
+func listOrNone(values []string) string {
+	if len(values) == 0 {
+		return "(none)"
+	}
+	return strings.Join(values, ", ")
+}
+
+func runWebsiteRulesSet(domain string, update websiteRulesSettingsUpdate) error {
+	if update.lowercase == nil && update.trailingSlash == nil && update.queryAllow == nil && update.queryDeny == nil {
+		return fmt.Errorf("nothing to update: pass --lowercase, --trailing-slash, --query-allow and/or --query-deny")
+	}
+	if update.trailingSlash != nil {
+		if err := urlrules.ValidateSettings(urlrules.Settings{TrailingSlash: *update.trailingSlash}); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	settings, err := models.GetURLSettings(ctx, database.DB, websiteID)
+	if err != nil {
+		return fmt.Errorf("failed to load URL settings: %w", err)
+	}
+	if update.lowercase != nil {
+		settings.Lowercase = *update.lowercase
+	}
+	if update.trailingSlash != nil {
+		settings.TrailingSlash = *update.trailingSlash
+	}
+	if update.queryAllow != nil {
+		settings.QueryAllow = parseQueryParamList(*update.queryAllow)
+	}
+	if update.queryDeny != nil {
+		settings.QueryDeny = parseQueryParamList(*update.queryDeny)
+	}
+
+	if err := models.UpdateURLSettings(ctx, database.DB, websiteID, settings); err != nil {
+		return fmt.Errorf("failed to update URL settings: %w", err)
+	}
+
+	fmt.Printf("URL settings updated for website '%s'\n", domain)
+	return nil
+}
+
+func runWebsiteRulesAdd(domain string, rule urlrules.Rule) error {
+	if err := urlrules.ValidateRule(rule); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	stored, err := models.AddURLRule(ctx, database.DB, websiteID, rule)
+	if err != nil {
+		return fmt.Errorf("failed to save URL rule: %w", err)
+	}
+
+	fmt.Printf("Rule saved for %s: %s %s -> %s\n", domain, stored.Kind, stored.Pattern, stored.Value)
+	return nil
+}
+
+func runWebsiteRulesRemove(domain, kind, pattern string) error {
+	if kind != urlrules.KindRewrite && kind != urlrules.KindGroup {
+		return fmt.Errorf("invalid rule kind %q (valid: rewrite, group)", kind)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	websiteID, cleanup, err := websiteUUIDByDomain(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	if err := models.DeleteURLRule(ctx, database.DB, websiteID, kind, pattern); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no %s rule %q for %s", kind, pattern, domain)
+		}
+		return fmt.Errorf("failed to remove URL rule: %w", err)
+	}
+
+	fmt.Printf("Rule '%s' removed from %s\n", pattern, domain)
+	return nil
+}
+
+func runWebsiteRulesTest(domain, rawURL string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, _, ruleset, cleanup, err := loadWebsiteRuleset(ctx, domain)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	return outputURLRuleTest(ruleset, rawURL)
+}
+
+func outputURLRuleTest(ruleset *urlrules.Ruleset, rawURL string) error {
+	result, err := ruleset.ApplyURL(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+
+	group := result.ContentGroup
+	if group == "" {
+		group = "(ungrouped)"
+	}
+	query := result.Query
+	if query == "" {
+		query = "(none)"
+	}
+
+	fmt.Printf("Input:         %s\n", rawURL)
+	fmt.Printf("Path:          %s\n", result.Path)
+	fmt.Printf("Query:         %s\n", query)
+	fmt.Printf("Content group: %s\n", group)
+	if result.Rewritten {
+		fmt.Println("Rewritten:     yes")
+	} else {
+		fmt.Println("Rewritten:     no")
+	}
+	return nil
+}
+
+func init() {
+	websiteRulesListCmd.Flags().StringVarP(&websiteRulesFormat, "format", "f", "table", "Output format (json, table)")
+
+	websiteRulesSetCmd.Flags().BoolVar(&websiteRulesLowercase, "lowercase", false, "Lowercase URL paths (--lowercase=false to disable)")
+	websiteRulesSetCmd.Flags().StringVar(&websiteRulesTrailingSlash, "trailing-slash", "", "Trailing slash handling: keep, strip or add")
+	websiteRulesSetCmd.Flags().StringVar(&websiteRulesQueryAllow, "query-allow", "", "Comma-separated query parameters to keep (none to clear)")
+	websiteRulesSetCmd.Flags().StringVar(&websiteRulesQueryDeny, "query-deny", "", "Comma-separated query parameters to drop (none to clear)")
+
+	websiteRulesCmd.AddCommand(websiteRulesListCmd)
+	websiteRulesCmd.AddCommand(websiteRulesSetCmd)
+	websiteRulesCmd.AddCommand(websiteRulesAddRewriteCmd)
+	websiteRulesCmd.AddCommand(websiteRulesAddGroupCmd)
+	websiteRulesCmd.AddCommand(websiteRulesRemoveCmd)
+	websiteRulesCmd.AddCommand(websiteRulesTestCmd)
+
+	websiteCmd.AddCommand(websiteRulesCmd)
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/urlrules"
+)
+
+func TestOutputURLRuleTest(t *testing.T) {
+	ruleset, err := urlrules.Compile(
+		urlrules.Settings{Lowercase: true, TrailingSlash: urlrules.TrailingSlashStrip, QueryDeny: []string{"token"}},
+		[]urlrules.Rule{
+			{Kind: urlrules.KindRewrite, Pattern: `^/users/\d+/settings$`, Value: "/users/:id/settings"},
+			{Kind: urlrules.KindGroup, Pattern: `^/users/`, Value: "Account"},
+		})
+	require.NoError(t, err)
+
+	output, err := captureOutput(t, func() error {
+		return outputURLRuleTest(ruleset, "https://example.com/Users/8123/Settings/?token=x&tab=2")
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Path:          /users/:id/settings")
+	assert.Contains(t, output, "Query:         tab=2")
+	assert.Contains(t, output, "Content group: Account")
+	assert.Contains(t, output, "Rewritten:     yes")
+}
+
+func TestParseQueryParamList(t *testing.T) {
+	assert.Equal(t, []string{"q", "page"}, parseQueryParamList(" q, page ,"))
+	assert.Nil(t, parseQueryParamList("none"))
+}
+
+func TestRunWebsiteRulesValidation(t *testing.T) {
+	err := runWebsiteRulesSet("example.com", websiteRulesSettingsUpdate{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nothing to update")
+
+	mode := "sometimes"
+	err = runWebsiteRulesSet("example.com", websiteRulesSettingsUpdate{trailingSlash: &mode})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "trailing slash")
+
+	err = runWebsiteRulesAdd("example.com", urlrules.Rule{Kind: urlrules.KindRewrite, Pattern: "([", Value: "/x"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid pattern")
+
+	err = runWebsiteRulesRemove("example.com", "redirect", "^/x")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid rule kind")
+}
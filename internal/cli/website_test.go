@@ -277,3 +277,11 @@ func TestOutputTables(t *testing.T) {
 	assert.Contains(t, singleOutput, "Allowed Domains:")
 	assert.Contains(t, singleOutput, "a.com, b.com")
 }
+
+func TestRunSetMatomoSiteIDInvalid(t *testing.T) {
+	for _, idsite := range []string{"abc", "0", "-2"} {
+		err := runSetMatomoSiteID("example.com", idsite)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid idsite")
+	}
+}
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -19,9 +22,26 @@ type Config struct {
 	DataDir        string
 	SecureCookies  bool
 	TrustedOrigins []string
-	InstallLock    bool // Whether installation is locked (setup completed)
+	TrustedProxies []string // IPs/CIDRs allowed to set forwarding headers
+	InstallLock    bool     // Whether installation is locked (setup completed)
+
+	GeoIPSource         string        // URL, file or directory to update GeoLite2-City from
+	GeoIPLicenseKey     string        // MaxMind license key, used when GeoIPSource is empty
+	GeoIPASNDatabase    string        // GeoLite2-ASN compatible database (default: data_dir/GeoLite2-ASN.mmdb)
+	GeoIPUpdateInterval time.Duration // 0 disables scheduled downloads
+
+	EventRetention time.Duration // Age after which event partitions are dropped; 0 (default) keeps them
+
+	ArchiveLocation    string // Directory or s3://bucket/prefix for archived partitions; empty disables archiving
+	ArchiveS3Endpoint  string // S3-compatible endpoint, e.g. http://localhost:9000 for MinIO
+	ArchiveS3Region    string
+	ArchiveS3AccessKey string
+	ArchiveS3SecretKey string
 }
 
+// DefaultGeoIPUpdateInterval is how often the server downloads a new GeoIP database
+const DefaultGeoIPUpdateInterval = 7 * 24 * time.Hour
+
 // Load loads configuration from multiple sources with priority:
 // 1. Command flags (set via viper.Set)
 // 2. Config file (~/.kaunta/config.toml or ./kaunta.toml)
@@ -66,6 +86,8 @@ func buildConfig(v *viper.Viper, overrideDatabaseURL, overridePort, overrideData
 		SecureCookies:  true, // Default to secure (safe for production/HTTPS proxies)
 		TrustedOrigins: []string{"localhost"},
 		InstallLock:    false,
+
+		GeoIPUpdateInterval: DefaultGeoIPUpdateInterval,
 	}
 
 	// Apply config file values
@@ -84,6 +106,43 @@ func buildConfig(v *viper.Viper, overrideDatabaseURL, overridePort, overrideData
 	if v.IsSet("secure_cookies") {
 		cfg.SecureCookies = v.GetBool("secure_cookies")
 	}
+	if v.IsSet("trusted_proxies") {
+		cfg.TrustedProxies = parseList(v.GetString("trusted_proxies"))
+	}
+	if v.IsSet("geoip_source") {
+		cfg.GeoIPSource = v.GetString("geoip_source")
+	}
+	if v.IsSet("geoip_license_key") {
+		cfg.GeoIPLicenseKey = v.GetString("geoip_license_key")
+	}
+	if v.IsSet("geoip_asn_database") {
+		cfg.GeoIPASNDatabase = v.GetString("geoip_asn_database")
+	}
+	if v.IsSet("geoip_update_interval") {
+		if interval, err := ParseInterval(v.GetString("geoip_update_interval")); err == nil {
+			cfg.GeoIPUpdateInterval = interval
+		}
+	}
+	if v.IsSet("event_retention") {
+		if retention, err := ParseInterval(v.GetString("event_retention")); err == nil {
+			cfg.EventRetention = retention
+		}
+	}
+	if v.IsSet("archive_location") {
+		cfg.ArchiveLocation = v.GetString("archive_location")
+	}
+	if v.IsSet("archive_s3_endpoint") {
+		cfg.ArchiveS3Endpoint = v.GetString("archive_s3_endpoint")
+	}
+	if v.IsSet("archive_s3_region") {
+		cfg.ArchiveS3Region = v.GetString("archive_s3_region")
+	}
+	if v.IsSet("archive_s3_access_key") {
+		cfg.ArchiveS3AccessKey = v.GetString("archive_s3_access_key")
+	}
+	if v.IsSet("archive_s3_secret_key") {
+		cfg.ArchiveS3SecretKey = v.GetString("archive_s3_secret_key")
+	}
 	if v.IsSet("security.install_lock") {
 		cfg.InstallLock = v.GetBool("security.install_lock")
 	}
@@ -107,6 +166,49 @@ func buildConfig(v *viper.Viper, overrideDatabaseURL, overridePort, overrideData
 			cfg.TrustedOrigins = parseTrustedOrigins(envOrigins)
 		}
 	}
+	if !v.IsSet("trusted_proxies") {
+		if envProxies := os.Getenv("TRUSTED_PROXIES"); envProxies != "" {
+			cfg.TrustedProxies = parseList(envProxies)
+		}
+	}
+	if !v.IsSet("geoip_source") {
+		cfg.GeoIPSource = os.Getenv("GEOIP_SOURCE")
+	}
+	if !v.IsSet("geoip_license_key") {
+		cfg.GeoIPLicenseKey = os.Getenv("GEOIP_LICENSE_KEY")
+	}
+	if !v.IsSet("geoip_asn_database") {
+		cfg.GeoIPASNDatabase = os.Getenv("GEOIP_ASN_DATABASE")
+	}
+	if !v.IsSet("geoip_update_interval") {
+		if envInterval := os.Getenv("GEOIP_UPDATE_INTERVAL"); envInterval != "" {
+			if interval, err := ParseInterval(envInterval); err == nil {
+				cfg.GeoIPUpdateInterval = interval
+			}
+		}
+	}
+	if !v.IsSet("event_retention") {
+		if envRetention := os.Getenv("EVENT_RETENTION"); envRetention != "" {
+			if retention, err := ParseInterval(envRetention); err == nil {
+				cfg.EventRetention = retention
+			}
+		}
+	}
+	if !v.IsSet("archive_location") {
+		cfg.ArchiveLocation = os.Getenv("ARCHIVE_LOCATION")
+	}
+	if !v.IsSet("archive_s3_endpoint") {
+		cfg.ArchiveS3Endpoint = os.Getenv("ARCHIVE_S3_ENDPOINT")
+	}
+	if !v.IsSet("archive_s3_region") {
+		cfg.ArchiveS3Region = firstEnv("ARCHIVE_S3_REGION", "AWS_REGION")
+	}
+	if !v.IsSet("archive_s3_access_key") {
+		cfg.ArchiveS3AccessKey = firstEnv("ARCHIVE_S3_ACCESS_KEY", "AWS_ACCESS_KEY_ID")
+	}
+	if !v.IsSet("archive_s3_secret_key") {
+		cfg.ArchiveS3SecretKey = firstEnv("ARCHIVE_S3_SECRET_KEY", "AWS_SECRET_ACCESS_KEY")
+	}
 	if !v.IsSet("secure_cookies") {
 		if envSecure := os.Getenv("SECURE_COOKIES"); envSecure != "" {
 			cfg.SecureCookies = envSecure == "true"
@@ -128,6 +230,16 @@ func buildConfig(v *viper.Viper, overrideDatabaseURL, overridePort, overrideData
 	return cfg
 }
 
+// firstEnv returns the first non-empty environment variable of keys
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 // parseTrustedOrigins parses a comma-separated string into a slice of trimmed, lowercased origins
 func parseTrustedOrigins(originsStr string) []string {
 	if originsStr == "" {
@@ -147,3 +259,38 @@ func parseTrustedOrigins(originsStr string) []string {
 
 	return origins
 }
+
+// parseList splits a comma-separated string into trimmed, non-empty values
+func parseList(value string) []string {
+	var items []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+// ParseInterval parses a duration such as "12h" or "7d". "0" and "off"
+// return 0 (disabled).
+func ParseInterval(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "0", "off", "false", "never":
+		return 0, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid interval: %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid interval: %q", value)
+	}
+	return d, nil
+}
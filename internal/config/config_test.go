@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -127,6 +128,123 @@ data_dir = "./config-data"
 	assert.Equal(t, []string{"example.com", "foo.test"}, cfg.TrustedOrigins)
 }
 
+func TestLoadTrustedProxies(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.0.2.1,,")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.0.2.1"}, cfg.TrustedProxies)
+
+	writeTestConfig(t, home, `trusted_proxies = "172.16.0.0/12"`)
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"172.16.0.0/12"}, cfg.TrustedProxies)
+}
+
+func TestLoadGeoIPSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	unsetEnv(t, "GEOIP_SOURCE")
+	unsetEnv(t, "GEOIP_LICENSE_KEY")
+	unsetEnv(t, "GEOIP_UPDATE_INTERVAL")
+	unsetEnv(t, "GEOIP_ASN_DATABASE")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "", cfg.GeoIPSource)
+	assert.Equal(t, DefaultGeoIPUpdateInterval, cfg.GeoIPUpdateInterval)
+
+	t.Setenv("GEOIP_LICENSE_KEY", "env-key")
+	t.Setenv("GEOIP_UPDATE_INTERVAL", "off")
+	t.Setenv("GEOIP_ASN_DATABASE", "/var/lib/GeoIP/GeoLite2-ASN.mmdb")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "env-key", cfg.GeoIPLicenseKey)
+	assert.Equal(t, "/var/lib/GeoIP/GeoLite2-ASN.mmdb", cfg.GeoIPASNDatabase)
+	assert.Equal(t, time.Duration(0), cfg.GeoIPUpdateInterval)
+
+	writeTestConfig(t, home, "geoip_source = \"/usr/share/GeoIP\"\ngeoip_update_interval = \"1d\"\n")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/share/GeoIP", cfg.GeoIPSource)
+	assert.Equal(t, "env-key", cfg.GeoIPLicenseKey)
+	assert.Equal(t, 24*time.Hour, cfg.GeoIPUpdateInterval)
+}
+
+func TestLoadEventRetention(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	unsetEnv(t, "EVENT_RETENTION")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), cfg.EventRetention)
+
+	t.Setenv("EVENT_RETENTION", "90d")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, 90*24*time.Hour, cfg.EventRetention)
+
+	writeTestConfig(t, home, "event_retention = \"off\"\n")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), cfg.EventRetention)
+}
+
+func TestLoadArchiveSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	for _, key := range []string{"ARCHIVE_LOCATION", "ARCHIVE_S3_ENDPOINT", "ARCHIVE_S3_REGION", "AWS_REGION",
+		"ARCHIVE_S3_ACCESS_KEY", "AWS_ACCESS_KEY_ID", "ARCHIVE_S3_SECRET_KEY", "AWS_SECRET_ACCESS_KEY"} {
+		unsetEnv(t, key)
+	}
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "", cfg.ArchiveLocation)
+
+	t.Setenv("ARCHIVE_LOCATION", "s3://kaunta-archive/events")
+	t.Setenv("AWS_ACCESS_KEY_ID", "aws-key")
+	t.Setenv("ARCHIVE_S3_SECRET_KEY", "archive-secret")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "aws-secret")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "s3://kaunta-archive/events", cfg.ArchiveLocation)
+	assert.Equal(t, "aws-key", cfg.ArchiveS3AccessKey)
+	assert.Equal(t, "archive-secret", cfg.ArchiveS3SecretKey)
+
+	writeTestConfig(t, home, "archive_location = \"/var/lib/kaunta/archive\"\narchive_s3_endpoint = \"http://minio:9000\"\n")
+	cfg, err = Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/var/lib/kaunta/archive", cfg.ArchiveLocation)
+	assert.Equal(t, "http://minio:9000", cfg.ArchiveS3Endpoint)
+}
+
+func TestParseInterval(t *testing.T) {
+	cases := map[string]time.Duration{
+		"7d":  7 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"0":   0,
+		"Off": 0,
+	}
+	for input, want := range cases {
+		got, err := ParseInterval(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "weekly", "-1d", "-5m"} {
+		_, err := ParseInterval(input)
+		assert.Error(t, err, input)
+	}
+}
+
 func TestSanitizeTrustedDomain(t *testing.T) {
 	tests := []struct {
 		input       string
@@ -3,4 +3,4 @@
 
 package database
 
-const LatestMigrationVersion uint = 25
+const LatestMigrationVersion uint = 43
@@ -0,0 +1,40 @@
+-- Frontend JavaScript error tracking
+-- Migration 000026
+
+-- ============================================================
+-- Error Groups (one row per normalized fingerprint per website)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS website_error (
+    error_id UUID PRIMARY KEY DEFAULT uuidv7(),
+    website_id UUID NOT NULL REFERENCES website(website_id) ON DELETE CASCADE,
+    fingerprint VARCHAR(32) NOT NULL,
+    message TEXT NOT NULL,
+    stack TEXT,
+    source_url VARCHAR(2000),
+    line_number INTEGER,
+    column_number INTEGER,
+    browser VARCHAR(20),
+    url_path VARCHAR(500),
+    occurrences BIGINT NOT NULL DEFAULT 1,
+    first_seen TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_website_error_fingerprint UNIQUE (website_id, fingerprint)
+);
+
+CREATE INDEX IF NOT EXISTS idx_website_error_last_seen ON website_error (website_id, last_seen DESC);
+
+COMMENT ON TABLE website_error IS 'Frontend JavaScript errors grouped by normalized fingerprint';
+COMMENT ON COLUMN website_error.fingerprint IS 'MD5 of normalized message + first stack frame (see handlers.errorFingerprint)';
+COMMENT ON COLUMN website_error.stack IS 'Stack trace of the most recent occurrence';
+COMMENT ON COLUMN website_error.browser IS 'Browser of the most recent occurrence';
+
+-- ============================================================
+-- Session flag for error filtering
+-- ============================================================
+
+ALTER TABLE session ADD COLUMN IF NOT EXISTS has_error BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_session_has_error ON session (website_id) WHERE has_error;
+
+COMMENT ON COLUMN session.has_error IS 'True once any frontend error was reported during the session';
@@ -0,0 +1,6 @@
+-- Numeric Matomo site ID (idsite) so legacy matomo.php/piwik.php trackers can be mapped to a website
+ALTER TABLE website ADD COLUMN IF NOT EXISTS matomo_site_id INTEGER;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_website_matomo_site_id
+    ON website (matomo_site_id)
+    WHERE matomo_site_id IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,10 @@
+-- Per-website trusted proxy CIDRs and additional CDN proxy modes
+
+ALTER TABLE website ADD COLUMN IF NOT EXISTS trusted_proxies CIDR[];
+
+ALTER TABLE website DROP CONSTRAINT IF EXISTS check_proxy_mode;
+ALTER TABLE website ADD CONSTRAINT check_proxy_mode
+  CHECK (proxy_mode IN ('none', 'xforwarded', 'cloudflare', 'fastly', 'akamai', 'bunny'));
+
+COMMENT ON COLUMN website.proxy_mode IS 'Proxy mode for IP extraction: none/xforwarded (Forwarded, X-Forwarded-For, X-Real-IP chain), cloudflare (CF-Connecting-IP), fastly (Fastly-Client-IP), akamai (True-Client-IP), bunny (CDN-ClientIP)';
+COMMENT ON COLUMN website.trusted_proxies IS 'Proxy CIDRs trusted to set forwarding headers, in addition to the global trusted_proxies setting';
@@ -0,0 +1,31 @@
+-- A/B experiments reported from website_event.tag
+-- Migration 000029
+
+-- ============================================================
+-- Experiments (variant -> tag mapping + goal per website)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS experiments (
+    experiment_id UUID PRIMARY KEY DEFAULT uuidv7(),
+    website_id UUID NOT NULL REFERENCES website(website_id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+    variants JSONB NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_experiment_name UNIQUE (website_id, name),
+    CONSTRAINT check_experiment_variants CHECK (jsonb_typeof(variants) = 'array' AND jsonb_array_length(variants) >= 2)
+);
+
+CREATE INDEX IF NOT EXISTS idx_experiments_website_id ON experiments (website_id);
+
+-- Exposure lookup: sessions that saw a tag within the experiment window
+CREATE INDEX IF NOT EXISTS idx_website_event_tag
+    ON website_event (website_id, tag, created_at)
+    WHERE tag IS NOT NULL;
+
+COMMENT ON TABLE experiments IS 'A/B experiments: visitors are assigned to a variant by the tag of their events (data-tag)';
+COMMENT ON COLUMN experiments.variants IS 'Ordered [{"name": ..., "tag": ...}]; the first variant is the control';
+COMMENT ON COLUMN experiments.goal_id IS 'Conversions are read from goal_completions for this goal';
+COMMENT ON COLUMN experiments.ended_at IS 'Set when the experiment is stopped; the report window is started_at..ended_at';
@@ -0,0 +1,35 @@
+-- Traffic channel grouping and referrer source normalization
+-- Migration 000030
+
+-- ============================================================
+-- Session channel/source (classified at ingest from the first touch)
+-- ============================================================
+
+ALTER TABLE session ADD COLUMN IF NOT EXISTS channel VARCHAR(32);
+ALTER TABLE session ADD COLUMN IF NOT EXISTS source VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_session_website_channel
+    ON session (website_id, channel, created_at);
+
+COMMENT ON COLUMN session.channel IS 'Traffic channel of the first touch: Direct, Organic Search, Paid Search, Social, Email, Referral, AI assistants';
+COMMENT ON COLUMN session.source IS 'Normalized source name of the first touch (Google, Facebook, Slack, ...)';
+
+-- ============================================================
+-- Per-website channel rules (checked before the built-in rules)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS channel_rules (
+    rule_id UUID PRIMARY KEY DEFAULT uuidv7(),
+    website_id UUID NOT NULL REFERENCES website(website_id) ON DELETE CASCADE,
+    pattern VARCHAR(255) NOT NULL,
+    source VARCHAR(100) NOT NULL,
+    channel VARCHAR(32) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_channel_rule_pattern UNIQUE (website_id, pattern),
+    CONSTRAINT check_channel_rule_channel CHECK (channel IN (
+        'Direct', 'Organic Search', 'Paid Search', 'Social', 'Email', 'Referral', 'AI assistants'
+    ))
+);
+
+COMMENT ON TABLE channel_rules IS 'Custom referrer rules: hosts matching pattern are reported as source in channel';
+COMMENT ON COLUMN channel_rules.pattern IS 'Referrer host (matches subdomains) or label with trailing .* wildcard (google.*)';
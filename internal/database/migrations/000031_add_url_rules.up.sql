@@ -0,0 +1,53 @@
+-- URL normalization and content grouping rules per website
+-- Migration 000031
+
+-- ============================================================
+-- Website normalization settings
+-- ============================================================
+
+ALTER TABLE website ADD COLUMN IF NOT EXISTS url_lowercase BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE website ADD COLUMN IF NOT EXISTS url_trailing_slash VARCHAR(10) NOT NULL DEFAULT 'keep';
+ALTER TABLE website ADD COLUMN IF NOT EXISTS url_query_allow TEXT[];
+ALTER TABLE website ADD COLUMN IF NOT EXISTS url_query_deny TEXT[];
+
+ALTER TABLE website DROP CONSTRAINT IF EXISTS check_url_trailing_slash;
+ALTER TABLE website ADD CONSTRAINT check_url_trailing_slash
+  CHECK (url_trailing_slash IN ('keep', 'strip', 'add'));
+
+COMMENT ON COLUMN website.url_lowercase IS 'Lowercase URL paths at ingest';
+COMMENT ON COLUMN website.url_trailing_slash IS 'Trailing slash handling at ingest: keep, strip or add';
+COMMENT ON COLUMN website.url_query_allow IS 'When set, only these query parameters are stored';
+COMMENT ON COLUMN website.url_query_deny IS 'Query parameters removed before storing';
+
+-- ============================================================
+-- Rewrite and content group rules (applied in position order)
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS url_rules (
+    rule_id UUID PRIMARY KEY DEFAULT uuidv7(),
+    website_id UUID NOT NULL REFERENCES website(website_id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL,
+    pattern TEXT NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_url_rule_pattern UNIQUE (website_id, kind, pattern),
+    CONSTRAINT check_url_rule_kind CHECK (kind IN ('rewrite', 'group'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_url_rules_website_id ON url_rules (website_id, position);
+
+COMMENT ON TABLE url_rules IS 'Per-website URL rules: rewrite (regex -> path template) or group (regex -> content group name)';
+COMMENT ON COLUMN url_rules.pattern IS 'Regular expression matched against the normalized URL path';
+
+-- ============================================================
+-- Content group per event
+-- ============================================================
+
+ALTER TABLE website_event ADD COLUMN IF NOT EXISTS content_group VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_website_event_content_group
+    ON website_event (website_id, content_group, created_at)
+    WHERE content_group IS NOT NULL;
+
+COMMENT ON COLUMN website_event.content_group IS 'Content group of the page (from url_rules) at ingest time';
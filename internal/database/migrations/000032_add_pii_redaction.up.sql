@@ -0,0 +1,30 @@
+-- PII redaction on ingest
+-- Migration 000032
+
+-- ============================================================
+-- Website redaction settings
+-- ============================================================
+
+ALTER TABLE website ADD COLUMN IF NOT EXISTS pii_redaction VARCHAR(10) NOT NULL DEFAULT 'mask';
+ALTER TABLE website ADD COLUMN IF NOT EXISTS pii_params TEXT[];
+
+ALTER TABLE website DROP CONSTRAINT IF EXISTS check_pii_redaction;
+ALTER TABLE website ADD CONSTRAINT check_pii_redaction
+  CHECK (pii_redaction IN ('off', 'mask', 'drop'));
+
+COMMENT ON COLUMN website.pii_redaction IS 'Redaction of emails, JWTs, card numbers and sensitive parameters at ingest: off, mask or drop';
+COMMENT ON COLUMN website.pii_params IS 'Extra query parameter / property names to redact, in addition to the built-in list';
+
+-- ============================================================
+-- Daily redaction counters
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS pii_redactions (
+    website_id UUID NOT NULL REFERENCES website(website_id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (website_id, day, kind)
+);
+
+COMMENT ON TABLE pii_redactions IS 'Number of values redacted at ingest per website, day and kind (email, jwt, card, param)';
@@ -0,0 +1,14 @@
+-- Internal traffic exclusion rules per website
+-- Migration 000033
+
+CREATE TABLE IF NOT EXISTS traffic_exclusions (
+    exclusion_id UUID PRIMARY KEY DEFAULT uuidv7(),
+    website_id UUID NOT NULL REFERENCES website(website_id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_traffic_exclusion UNIQUE (website_id, kind, value),
+    CONSTRAINT check_traffic_exclusion_kind CHECK (kind IN ('ip', 'user_agent'))
+);
+
+COMMENT ON TABLE traffic_exclusions IS 'Per-website internal traffic: ip (CIDR) or user_agent (case-insensitive substring) rules checked before events are recorded';
@@ -0,0 +1,115 @@
+-- Bot pattern and IP blocklist management
+-- Migration 000035
+--
+-- bot_user_agent_patterns could only be changed by migrations. Patterns now
+-- record where they came from (builtin, cli or an imported list) so lists can
+-- be re-imported, and an IP/CIDR blocklist marks every request from a range
+-- as a bot. Both tables are read by update_ip_metadata() on each request, so
+-- changes apply on every replica without a restart.
+
+ALTER TABLE bot_user_agent_patterns ADD COLUMN IF NOT EXISTS source VARCHAR(100) NOT NULL DEFAULT 'builtin';
+ALTER TABLE bot_user_agent_patterns ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_bot_patterns_source ON bot_user_agent_patterns (source);
+
+COMMENT ON COLUMN bot_user_agent_patterns.source IS 'builtin, cli, or the name of an imported crawler list';
+
+CREATE TABLE IF NOT EXISTS bot_ip_blocklist (
+    cidr cidr PRIMARY KEY,
+    bot_type VARCHAR(50) NOT NULL DEFAULT 'scraper',
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bot_ip_blocklist_cidr ON bot_ip_blocklist USING gist (cidr inet_ops);
+
+COMMENT ON TABLE bot_ip_blocklist IS 'IP ranges whose requests are always treated as bots';
+
+CREATE OR REPLACE FUNCTION update_ip_metadata(
+    p_ip inet,
+    p_user_agent text,
+    p_country char(2) DEFAULT NULL,
+    p_website_id uuid DEFAULT NULL,
+    p_url_path text DEFAULT NULL
+)
+RETURNS boolean AS $$
+DECLARE
+    v_is_bot boolean := false;
+    v_bot_type varchar(50) := NULL;
+    v_pattern_name varchar(100) := NULL;
+    v_is_legitimate boolean := NULL;
+    v_confidence smallint := 0;
+    v_detection_reason text := '';
+    v_tmp_is_bot boolean;
+    v_tmp_bot_type varchar(50);
+    v_tmp_pattern_name varchar(100);
+    v_tmp_is_legitimate boolean;
+    v_blocked_cidr cidr;
+BEGIN
+    -- Blocklisted ranges win over user agent patterns (most specific range first)
+    SELECT bl.cidr, bl.bot_type
+    INTO v_blocked_cidr, v_tmp_bot_type
+    FROM bot_ip_blocklist bl
+    WHERE p_ip <<= bl.cidr
+    ORDER BY masklen(bl.cidr) DESC
+    LIMIT 1;
+
+    IF FOUND THEN
+        v_is_bot := true;
+        v_bot_type := v_tmp_bot_type;
+        v_pattern_name := 'ip:' || v_blocked_cidr::text;
+        v_is_legitimate := false;
+        v_confidence := 100;
+        v_detection_reason := 'IP in blocklist: ' || v_blocked_cidr::text;
+    ELSE
+        -- Use temporary variables for SELECT INTO to avoid NULL contamination
+        SELECT kb.is_bot, kb.bot_type, kb.pattern_name, kb.is_legitimate
+        INTO v_tmp_is_bot, v_tmp_bot_type, v_tmp_pattern_name, v_tmp_is_legitimate
+        FROM is_known_bot_ua(p_user_agent) kb;
+
+        -- Only assign if a row was found (avoids NULL override of initialized values)
+        IF FOUND THEN
+            v_is_bot := COALESCE(v_tmp_is_bot, false);
+            v_bot_type := v_tmp_bot_type;
+            v_pattern_name := v_tmp_pattern_name;
+            v_is_legitimate := v_tmp_is_legitimate;
+
+            IF v_is_bot THEN
+                v_confidence := CASE WHEN v_pattern_name != 'generic_bot' THEN 90 ELSE 60 END;
+                v_detection_reason := 'User agent matches known pattern: ' || v_pattern_name;
+            END IF;
+        END IF;
+    END IF;
+
+    INSERT INTO ip_metadata (ip, first_seen, last_seen, total_requests, requests_last_hour, requests_last_minute,
+        is_bot, bot_type, confidence, detection_reason, unique_user_agents, user_agent_sample, country)
+    VALUES (p_ip, NOW(), NOW(), 1, 1, 1, v_is_bot, v_bot_type, v_confidence, v_detection_reason, 1, ARRAY[p_user_agent], p_country)
+    ON CONFLICT (ip) DO UPDATE SET
+        last_seen = NOW(), total_requests = ip_metadata.total_requests + 1,
+        requests_last_hour = CASE WHEN ip_metadata.last_seen < NOW() - INTERVAL '1 hour' THEN 1 ELSE ip_metadata.requests_last_hour + 1 END,
+        requests_last_minute = CASE WHEN ip_metadata.last_seen < NOW() - INTERVAL '1 minute' THEN 1 ELSE ip_metadata.requests_last_minute + 1 END,
+        max_requests_per_minute = GREATEST(ip_metadata.max_requests_per_minute, CASE WHEN ip_metadata.last_seen < NOW() - INTERVAL '1 minute' THEN 1 ELSE ip_metadata.requests_last_minute + 1 END),
+        is_bot = CASE WHEN NOT COALESCE(ip_metadata.is_bot, false) AND v_is_bot THEN true ELSE COALESCE(ip_metadata.is_bot, false) END,
+        bot_type = COALESCE(v_bot_type, ip_metadata.bot_type),
+        confidence = GREATEST(COALESCE(v_confidence, 0), COALESCE(ip_metadata.confidence, 0)),
+        detection_reason = CASE WHEN v_detection_reason != '' THEN v_detection_reason ELSE ip_metadata.detection_reason END,
+        unique_user_agents = CASE WHEN p_user_agent = ANY(ip_metadata.user_agent_sample) THEN ip_metadata.unique_user_agents ELSE ip_metadata.unique_user_agents + 1 END,
+        user_agent_sample = CASE WHEN p_user_agent = ANY(ip_metadata.user_agent_sample) THEN ip_metadata.user_agent_sample
+            WHEN array_length(ip_metadata.user_agent_sample, 1) < 5 THEN array_append(ip_metadata.user_agent_sample, p_user_agent)
+            ELSE ip_metadata.user_agent_sample END,
+        country = COALESCE(p_country, ip_metadata.country), updated_at = NOW();
+
+    IF v_is_bot THEN
+        -- Logging must never fail tracking (e.g. a missing daily partition)
+        BEGIN
+            INSERT INTO bot_detection_log (ip, pattern_type, pattern_name, confidence, user_agent, website_id, url_path, details)
+            VALUES (p_ip, v_bot_type, v_pattern_name, v_confidence, LEFT(p_user_agent, 500), p_website_id,
+                    LEFT(p_url_path, 500), jsonb_build_object('is_legitimate', v_is_legitimate));
+        EXCEPTION WHEN OTHERS THEN
+            RAISE WARNING 'bot_detection_log insert failed: %', SQLERRM;
+        END;
+    END IF;
+
+    RETURN v_is_bot;
+END;
+$$ LANGUAGE plpgsql;
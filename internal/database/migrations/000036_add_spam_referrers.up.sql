@@ -0,0 +1,29 @@
+-- Referrer spam list and drop counters
+-- Migration 000036
+--
+-- The spam referrer list was hard-coded in the tracker handler. It now lives
+-- here, seeded at startup from the list embedded in the binary (source
+-- 'builtin'). Built-in domains are disabled rather than deleted so a restart
+-- doesn't bring them back.
+
+CREATE TABLE IF NOT EXISTS spam_referrers (
+    domain VARCHAR(253) PRIMARY KEY,
+    source VARCHAR(100) NOT NULL DEFAULT 'cli',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_spam_referrers_source ON spam_referrers (source);
+
+COMMENT ON TABLE spam_referrers IS 'Referrer spam domains; a domain also matches its subdomains';
+COMMENT ON COLUMN spam_referrers.source IS 'builtin, cli, or the name of an imported list';
+
+CREATE TABLE IF NOT EXISTS spam_referrer_hits (
+    website_id UUID NOT NULL REFERENCES website(website_id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    domain VARCHAR(253) NOT NULL,
+    hits BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (website_id, day, domain)
+);
+
+COMMENT ON TABLE spam_referrer_hits IS 'Hits dropped as referrer spam per website, day and spam domain';
@@ -0,0 +1,59 @@
+-- Partition archive manifest
+-- Migration 000038
+--
+-- Expiring website_event partitions can be exported to gzipped CSV files in
+-- a local directory or S3-compatible bucket before they are dropped. Each
+-- archived partition is recorded here so it can be found and restored with
+-- 'kaunta db restore-archive'.
+
+CREATE TABLE IF NOT EXISTS partition_archive (
+    partition_name VARCHAR(100) PRIMARY KEY,
+    table_name VARCHAR(63) NOT NULL,
+    range_start DATE NOT NULL,
+    range_end DATE NOT NULL,
+    location TEXT NOT NULL,
+    format VARCHAR(20) NOT NULL DEFAULT 'csv.gz',
+    row_count BIGINT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_partition_archive_range ON partition_archive (table_name, range_start);
+
+COMMENT ON TABLE partition_archive IS 'Manifest of partitions exported before being dropped';
+COMMENT ON COLUMN partition_archive.location IS 'File path or s3://bucket/key of the archive';
+
+-- With require_archive, partitions missing from the manifest are kept
+DROP FUNCTION IF EXISTS cleanup_old_partitions(integer);
+
+CREATE OR REPLACE FUNCTION cleanup_old_partitions(retention_days INTEGER DEFAULT 90, require_archive BOOLEAN DEFAULT false)
+RETURNS TABLE (partition_name TEXT, dropped BOOLEAN) AS $$
+DECLARE
+    cutoff_date DATE := CURRENT_DATE - retention_days;
+    part_name TEXT;
+    dropped_count INTEGER := 0;
+BEGIN
+    FOR part_name IN SELECT tablename FROM pg_tables WHERE schemaname = 'public' AND tablename LIKE 'website_event_%'
+        AND tablename < 'website_event_' || TO_CHAR(cutoff_date, 'YYYY_MM_DD') ORDER BY tablename
+    LOOP
+        partition_name := part_name;
+        IF require_archive AND NOT EXISTS (SELECT 1 FROM partition_archive pa WHERE pa.partition_name = part_name) THEN
+            dropped := FALSE;
+            RETURN NEXT;
+            CONTINUE;
+        END IF;
+
+        BEGIN
+            EXECUTE format('DROP TABLE IF EXISTS %I', part_name);
+            dropped := TRUE;
+            dropped_count := dropped_count + 1;
+            RETURN NEXT;
+        EXCEPTION WHEN OTHERS THEN
+            dropped := FALSE;
+            RETURN NEXT;
+        END;
+    END LOOP;
+    RAISE NOTICE 'Dropped % old partitions', dropped_count;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,51 @@
+-- Require archives when an archive location is configured
+-- Migration 000039
+--
+-- The server records its archive location here at startup (and clears it
+-- when none is configured). cleanup_old_partitions() then keeps partitions
+-- missing from the manifest unless require_archive is passed explicitly, so
+-- a cron job can't drop data the server was set up to archive.
+
+CREATE TABLE IF NOT EXISTS partition_archive_location (
+    singleton BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+    location TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE partition_archive_location IS 'Archive location configured on the server, if any';
+
+DROP FUNCTION IF EXISTS cleanup_old_partitions(integer, boolean);
+
+CREATE OR REPLACE FUNCTION cleanup_old_partitions(retention_days INTEGER DEFAULT 90, require_archive BOOLEAN DEFAULT NULL)
+RETURNS TABLE (partition_name TEXT, dropped BOOLEAN) AS $$
+DECLARE
+    cutoff_date DATE := CURRENT_DATE - retention_days;
+    part_name TEXT;
+    dropped_count INTEGER := 0;
+BEGIN
+    -- Default: require an archive when a location is configured
+    require_archive := COALESCE(require_archive, EXISTS (SELECT 1 FROM partition_archive_location));
+
+    FOR part_name IN SELECT tablename FROM pg_tables WHERE schemaname = 'public' AND tablename LIKE 'website_event_%'
+        AND tablename < 'website_event_' || TO_CHAR(cutoff_date, 'YYYY_MM_DD') ORDER BY tablename
+    LOOP
+        partition_name := part_name;
+        IF require_archive AND NOT EXISTS (SELECT 1 FROM partition_archive pa WHERE pa.partition_name = part_name) THEN
+            dropped := FALSE;
+            RETURN NEXT;
+            CONTINUE;
+        END IF;
+
+        BEGIN
+            EXECUTE format('DROP TABLE IF EXISTS %I', part_name);
+            dropped := TRUE;
+            dropped_count := dropped_count + 1;
+            RETURN NEXT;
+        EXCEPTION WHEN OTHERS THEN
+            dropped := FALSE;
+            RETURN NEXT;
+        END;
+    END LOOP;
+    RAISE NOTICE 'Dropped % old partitions', dropped_count;
+END;
+$$ LANGUAGE plpgsql;
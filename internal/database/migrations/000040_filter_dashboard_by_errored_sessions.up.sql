@@ -0,0 +1,703 @@
+-- Migration 000040: Filter dashboard functions by errored sessions
+-- Adds p_has_error to get_dashboard_stats(), get_timeseries(), get_top_pages()
+-- and get_breakdown(), next to the country/browser/device filters.
+-- TRUE keeps sessions that reported a JavaScript error; NULL keeps all.
+
+DROP FUNCTION IF EXISTS get_dashboard_stats(UUID, INTEGER, VARCHAR, VARCHAR, VARCHAR, VARCHAR);
+DROP FUNCTION IF EXISTS get_timeseries(UUID, INTEGER, VARCHAR, VARCHAR, VARCHAR, VARCHAR);
+DROP FUNCTION IF EXISTS get_top_pages(UUID, INTEGER, INTEGER, INTEGER, VARCHAR, VARCHAR, VARCHAR, VARCHAR, VARCHAR);
+DROP FUNCTION IF EXISTS get_breakdown(UUID, VARCHAR, INTEGER, INTEGER, INTEGER, VARCHAR, VARCHAR, VARCHAR, VARCHAR, VARCHAR, VARCHAR);
+
+-- ============================================================================
+-- 1. get_dashboard_stats()
+-- ============================================================================
+
+CREATE FUNCTION get_dashboard_stats(
+    p_website_id UUID,
+    p_days INTEGER DEFAULT 1,
+    p_country VARCHAR DEFAULT NULL,
+    p_browser VARCHAR DEFAULT NULL,
+    p_device VARCHAR DEFAULT NULL,
+    p_page_path VARCHAR DEFAULT NULL,
+    p_has_error BOOLEAN DEFAULT NULL
+)
+RETURNS TABLE (
+    current_visitors BIGINT,
+    today_pageviews BIGINT,
+    today_visitors BIGINT,
+    bounce_rate NUMERIC(5,2)
+) AS $$
+DECLARE
+    v_current_visitors BIGINT;
+    v_today_pageviews BIGINT;
+    v_today_visitors BIGINT;
+    v_bounce_rate NUMERIC(5,2);
+    v_bounces BIGINT;
+BEGIN
+    -- 1. Current visitors (sessions in last 5 minutes)
+    SELECT COUNT(DISTINCT e.session_id) INTO v_current_visitors
+    FROM website_event e
+    JOIN session s ON e.session_id = s.session_id
+    WHERE e.website_id = p_website_id
+      AND e.created_at >= NOW() - INTERVAL '5 minutes'
+      AND e.event_type = 1
+      AND (p_country IS NULL OR s.country = p_country)
+      AND (p_browser IS NULL OR s.browser = p_browser)
+      AND (p_device IS NULL OR s.device = p_device)
+      AND (p_page_path IS NULL OR e.url_path = p_page_path)
+      AND (p_has_error IS NULL OR s.has_error = p_has_error);
+
+    -- 2. Today's pageviews
+    SELECT COUNT(*) INTO v_today_pageviews
+    FROM website_event e
+    JOIN session s ON e.session_id = s.session_id
+    WHERE e.website_id = p_website_id
+      AND e.created_at >= CURRENT_DATE
+      AND e.event_type = 1
+      AND (p_country IS NULL OR s.country = p_country)
+      AND (p_browser IS NULL OR s.browser = p_browser)
+      AND (p_device IS NULL OR s.device = p_device)
+      AND (p_page_path IS NULL OR e.url_path = p_page_path)
+      AND (p_has_error IS NULL OR s.has_error = p_has_error);
+
+    -- 3. Today's unique visitors
+    SELECT COUNT(DISTINCT e.session_id) INTO v_today_visitors
+    FROM website_event e
+    JOIN session s ON e.session_id = s.session_id
+    WHERE e.website_id = p_website_id
+      AND e.created_at >= CURRENT_DATE
+      AND e.event_type = 1
+      AND (p_country IS NULL OR s.country = p_country)
+      AND (p_browser IS NULL OR s.browser = p_browser)
+      AND (p_device IS NULL OR s.device = p_device)
+      AND (p_page_path IS NULL OR e.url_path = p_page_path)
+      AND (p_has_error IS NULL OR s.has_error = p_has_error);
+
+    -- 4. Bounce rate (sessions with only 1 pageview)
+    v_bounce_rate := 0;
+    IF v_today_visitors > 0 THEN
+        SELECT COUNT(*) INTO v_bounces
+        FROM (
+            SELECT e.session_id
+            FROM website_event e
+            JOIN session s ON e.session_id = s.session_id
+            WHERE e.website_id = p_website_id
+              AND e.created_at >= CURRENT_DATE
+              AND e.event_type = 1
+              AND (p_country IS NULL OR s.country = p_country)
+              AND (p_browser IS NULL OR s.browser = p_browser)
+              AND (p_device IS NULL OR s.device = p_device)
+              AND (p_page_path IS NULL OR e.url_path = p_page_path)
+              AND (p_has_error IS NULL OR s.has_error = p_has_error)
+            GROUP BY e.session_id
+            HAVING COUNT(*) = 1
+        ) bounced_sessions;
+
+        v_bounce_rate := (v_bounces::NUMERIC / v_today_visitors::NUMERIC) * 100;
+    END IF;
+
+    -- Return all stats as a single row
+    RETURN QUERY SELECT v_current_visitors, v_today_pageviews, v_today_visitors, v_bounce_rate;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- ============================================================================
+-- 2. get_timeseries()
+-- ============================================================================
+
+CREATE FUNCTION get_timeseries(
+    p_website_id UUID,
+    p_days INTEGER DEFAULT 7,
+    p_country VARCHAR DEFAULT NULL,
+    p_browser VARCHAR DEFAULT NULL,
+    p_device VARCHAR DEFAULT NULL,
+    p_page_path VARCHAR DEFAULT NULL,
+    p_has_error BOOLEAN DEFAULT NULL
+)
+RETURNS TABLE (
+    hour TIMESTAMPTZ,
+    views BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT
+        DATE_TRUNC('hour', e.created_at)::TIMESTAMPTZ as hour,
+        COUNT(*)::BIGINT as views
+    FROM website_event e
+    JOIN session s ON e.session_id = s.session_id
+    WHERE e.website_id = p_website_id
+      AND e.created_at >= NOW() - (p_days || ' days')::INTERVAL
+      AND e.event_type = 1
+      AND (p_country IS NULL OR s.country = p_country)
+      AND (p_browser IS NULL OR s.browser = p_browser)
+      AND (p_device IS NULL OR s.device = p_device)
+      AND (p_page_path IS NULL OR e.url_path = p_page_path)
+      AND (p_has_error IS NULL OR s.has_error = p_has_error)
+    GROUP BY hour
+    ORDER BY hour ASC;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- ============================================================================
+-- 3. get_top_pages()
+-- ============================================================================
+
+CREATE FUNCTION get_top_pages(
+    p_website_id UUID,
+    p_days INTEGER DEFAULT 1,
+    p_limit INTEGER DEFAULT 10,
+    p_offset INTEGER DEFAULT 0,
+    p_country VARCHAR DEFAULT NULL,
+    p_browser VARCHAR DEFAULT NULL,
+    p_device VARCHAR DEFAULT NULL,
+    p_sort_by VARCHAR DEFAULT 'views',
+    p_sort_order VARCHAR DEFAULT 'desc',
+    p_has_error BOOLEAN DEFAULT NULL
+)
+RETURNS TABLE (
+    path VARCHAR,
+    views BIGINT,
+    unique_visitors BIGINT,
+    avg_engagement_time NUMERIC,
+    total_count BIGINT
+) AS $$
+BEGIN
+    RETURN QUERY
+    WITH filtered_events AS (
+        SELECT e.url_path, e.session_id, e.engagement_time
+        FROM website_event e
+        JOIN session s ON e.session_id = s.session_id
+        WHERE e.website_id = p_website_id
+          AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+          AND e.event_type = 1
+          AND e.url_path IS NOT NULL
+          AND (p_country IS NULL OR s.country = p_country)
+          AND (p_browser IS NULL OR s.browser = p_browser)
+          AND (p_device IS NULL OR s.device = p_device)
+          AND (p_has_error IS NULL OR s.has_error = p_has_error)
+    ),
+    page_stats AS (
+        SELECT
+            fe.url_path,
+            COUNT(*)::BIGINT as view_count,
+            COUNT(DISTINCT fe.session_id)::BIGINT as unique_visitor_count,
+            ROUND(AVG(COALESCE(fe.engagement_time, 0)), 0) as avg_time
+        FROM filtered_events fe
+        GROUP BY fe.url_path
+    ),
+    total_count_cte AS (
+        SELECT COUNT(*)::BIGINT as total FROM page_stats
+    )
+    SELECT
+        ps.url_path::VARCHAR,
+        ps.view_count,
+        ps.unique_visitor_count,
+        ps.avg_time,
+        tc.total as total_count
+    FROM page_stats ps
+    CROSS JOIN total_count_cte tc
+    ORDER BY
+        CASE WHEN p_sort_order = 'desc' THEN
+            CASE p_sort_by
+                WHEN 'views' THEN ps.view_count
+                WHEN 'unique_visitors' THEN ps.unique_visitor_count
+                WHEN 'avg_engagement_time' THEN ps.avg_time::BIGINT
+                ELSE ps.view_count
+            END
+        END DESC NULLS LAST,
+        CASE WHEN p_sort_order = 'asc' THEN
+            CASE p_sort_by
+                WHEN 'views' THEN ps.view_count
+                WHEN 'unique_visitors' THEN ps.unique_visitor_count
+                WHEN 'avg_engagement_time' THEN ps.avg_time::BIGINT
+                ELSE ps.view_count
+            END
+        END ASC NULLS LAST,
+        CASE WHEN p_sort_by = 'path' AND p_sort_order = 'desc' THEN ps.url_path END DESC NULLS LAST,
+        CASE WHEN p_sort_by = 'path' AND p_sort_order = 'asc' THEN ps.url_path END ASC NULLS LAST
+    LIMIT p_limit
+    OFFSET p_offset;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- ============================================================================
+-- 4. get_breakdown()
+-- ============================================================================
+
+CREATE FUNCTION get_breakdown(
+    p_website_id UUID,
+    p_dimension VARCHAR,
+    p_days INTEGER DEFAULT 1,
+    p_limit INTEGER DEFAULT 10,
+    p_offset INTEGER DEFAULT 0,
+    p_country VARCHAR DEFAULT NULL,
+    p_browser VARCHAR DEFAULT NULL,
+    p_device VARCHAR DEFAULT NULL,
+    p_page_path VARCHAR DEFAULT NULL,
+    p_sort_by VARCHAR DEFAULT 'count',
+    p_sort_order VARCHAR DEFAULT 'desc',
+    p_has_error BOOLEAN DEFAULT NULL
+)
+RETURNS TABLE (name VARCHAR, count BIGINT, total_count BIGINT) AS $$
+BEGIN
+    CASE p_dimension
+        WHEN 'country' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(s.country, 'Unknown')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY s.country
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'browser' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(s.browser, 'Unknown')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY s.browser
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'device' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(s.device, 'Unknown')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY s.device
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'os' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(s.os, 'Unknown')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY s.os
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        -- ====================================================================
+        -- REFERRER DIMENSION (MODIFIED)
+        -- ====================================================================
+        WHEN 'referrer' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT
+                    COALESCE(
+                        CASE
+                            WHEN e.referrer_domain IS NOT NULL THEN
+                                e.referrer_domain || COALESCE(e.referrer_path, '')
+                            ELSE 'Direct / None'
+                        END,
+                        'Direct / None'
+                    )::VARCHAR as dim_name,
+                    COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY e.referrer_domain, e.referrer_path
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'city' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(s.city, 'Unknown')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY s.city
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'region' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(s.region, 'Unknown')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY s.region
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'page' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(e.url_path, 'Unknown')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND e.url_path IS NOT NULL
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY e.url_path
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'utm_source' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(e.utm_source, 'Direct / None')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY e.utm_source
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'utm_medium' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(e.utm_medium, 'Direct / None')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY e.utm_medium
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'utm_campaign' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(e.utm_campaign, 'Direct / None')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY e.utm_campaign
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'utm_term' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(e.utm_term, 'Direct / None')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY e.utm_term
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'utm_content' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(e.utm_content, 'Direct / None')::VARCHAR as dim_name, COUNT(*)::BIGINT as dim_count
+                FROM website_event e
+                JOIN session s ON e.session_id = s.session_id
+                WHERE e.website_id = p_website_id
+                  AND e.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND e.event_type = 1
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_page_path IS NULL OR e.url_path = p_page_path)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY e.utm_content
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'entry_page' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(s.entry_page, 'Unknown')::VARCHAR as dim_name, COUNT(DISTINCT s.session_id)::BIGINT as dim_count
+                FROM session s
+                WHERE s.website_id = p_website_id
+                  AND s.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND s.entry_page IS NOT NULL
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY s.entry_page
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        WHEN 'exit_page' THEN
+            RETURN QUERY
+            WITH breakdown_data AS (
+                SELECT COALESCE(s.exit_page, 'Unknown')::VARCHAR as dim_name, COUNT(DISTINCT s.session_id)::BIGINT as dim_count
+                FROM session s
+                WHERE s.website_id = p_website_id
+                  AND s.created_at >= CURRENT_DATE - (p_days || ' days')::INTERVAL
+                  AND s.exit_page IS NOT NULL
+                  AND (p_country IS NULL OR s.country = p_country)
+                  AND (p_browser IS NULL OR s.browser = p_browser)
+                  AND (p_device IS NULL OR s.device = p_device)
+                  AND (p_has_error IS NULL OR s.has_error = p_has_error)
+                GROUP BY s.exit_page
+            ),
+            total_count_cte AS (
+                SELECT COUNT(*)::BIGINT as total FROM breakdown_data
+            )
+            SELECT bd.dim_name, bd.dim_count, tc.total
+            FROM breakdown_data bd
+            CROSS JOIN total_count_cte tc
+            ORDER BY
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'desc' THEN bd.dim_count END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'count' AND p_sort_order = 'asc' THEN bd.dim_count END ASC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'desc' THEN bd.dim_name END DESC NULLS LAST,
+                CASE WHEN p_sort_by = 'name' AND p_sort_order = 'asc' THEN bd.dim_name END ASC NULLS LAST
+            LIMIT p_limit
+            OFFSET p_offset;
+
+        ELSE
+            RAISE EXCEPTION 'Invalid dimension: %. Must be country, browser, device, os, referrer, city, region, page, utm_source, utm_medium, utm_campaign, utm_term, utm_content, entry_page, or exit_page', p_dimension;
+    END CASE;
+END;
+$$ LANGUAGE plpgsql STABLE;
@@ -0,0 +1,131 @@
+-- Skip live IP counters for imported hits
+-- Migration 000042
+--
+-- update_ip_metadata() takes p_seen_at, the time of a historical hit (e.g. an
+-- access log line). Such hits still go through bot detection and the
+-- datacenter policy, but don't touch ip_metadata's request counters or
+-- last_seen, and bots are logged at the hit's time rather than now.
+
+DROP FUNCTION IF EXISTS update_ip_metadata(inet, text, char, uuid, text, integer, text);
+
+CREATE FUNCTION update_ip_metadata(
+    p_ip inet,
+    p_user_agent text,
+    p_country char(2) DEFAULT NULL,
+    p_website_id uuid DEFAULT NULL,
+    p_url_path text DEFAULT NULL,
+    p_asn integer DEFAULT NULL,
+    p_asn_org text DEFAULT NULL,
+    p_seen_at timestamptz DEFAULT NULL
+)
+RETURNS TABLE (drop_hit boolean, flag_hosting boolean) AS $$
+DECLARE
+    v_is_bot boolean := false;
+    v_bot_type varchar(50) := NULL;
+    v_pattern_name varchar(100) := NULL;
+    v_is_legitimate boolean := NULL;
+    v_confidence smallint := 0;
+    v_detection_reason text := '';
+    v_tmp_is_bot boolean;
+    v_tmp_bot_type varchar(50);
+    v_tmp_pattern_name varchar(100);
+    v_tmp_is_legitimate boolean;
+    v_blocked_cidr cidr;
+    v_hosting boolean := false;
+    v_policy varchar(10) := 'keep';
+BEGIN
+    -- Blocklisted ranges win over user agent patterns (most specific range first)
+    SELECT bl.cidr, bl.bot_type
+    INTO v_blocked_cidr, v_tmp_bot_type
+    FROM bot_ip_blocklist bl
+    WHERE p_ip <<= bl.cidr
+    ORDER BY masklen(bl.cidr) DESC
+    LIMIT 1;
+
+    IF FOUND THEN
+        v_is_bot := true;
+        v_bot_type := v_tmp_bot_type;
+        v_pattern_name := 'ip:' || v_blocked_cidr::text;
+        v_is_legitimate := false;
+        v_confidence := 100;
+        v_detection_reason := 'IP in blocklist: ' || v_blocked_cidr::text;
+    ELSE
+        -- Use temporary variables for SELECT INTO to avoid NULL contamination
+        SELECT kb.is_bot, kb.bot_type, kb.pattern_name, kb.is_legitimate
+        INTO v_tmp_is_bot, v_tmp_bot_type, v_tmp_pattern_name, v_tmp_is_legitimate
+        FROM is_known_bot_ua(p_user_agent) kb;
+
+        -- Only assign if a row was found (avoids NULL override of initialized values)
+        IF FOUND THEN
+            v_is_bot := COALESCE(v_tmp_is_bot, false);
+            v_bot_type := v_tmp_bot_type;
+            v_pattern_name := v_tmp_pattern_name;
+            v_is_legitimate := v_tmp_is_legitimate;
+
+            IF v_is_bot THEN
+                v_confidence := CASE WHEN v_pattern_name != 'generic_bot' THEN 90 ELSE 60 END;
+                v_detection_reason := 'User agent matches known pattern: ' || v_pattern_name;
+            END IF;
+        END IF;
+    END IF;
+
+    IF p_asn IS NOT NULL THEN
+        v_hosting := EXISTS (SELECT 1 FROM hosting_asns h WHERE h.asn = p_asn);
+    END IF;
+
+    -- The datacenter policy is per website, so it doesn't mark the IP itself as a bot
+    IF v_hosting AND NOT v_is_bot AND p_website_id IS NOT NULL THEN
+        SELECT w.datacenter_policy INTO v_policy FROM website w WHERE w.website_id = p_website_id;
+        v_policy := COALESCE(v_policy, 'flag');
+    END IF;
+
+    -- Historical hits (log imports) must not bump the live request counters
+    IF p_seen_at IS NULL THEN
+        INSERT INTO ip_metadata (ip, first_seen, last_seen, total_requests, requests_last_hour, requests_last_minute,
+            is_bot, bot_type, confidence, detection_reason, unique_user_agents, user_agent_sample, country,
+            asn, asn_org, is_hosting_provider)
+        VALUES (p_ip, NOW(), NOW(), 1, 1, 1, v_is_bot, v_bot_type, v_confidence, v_detection_reason, 1, ARRAY[p_user_agent], p_country,
+            p_asn, LEFT(p_asn_org, 255), v_hosting)
+        ON CONFLICT (ip) DO UPDATE SET
+            last_seen = NOW(), total_requests = ip_metadata.total_requests + 1,
+            requests_last_hour = CASE WHEN ip_metadata.last_seen < NOW() - INTERVAL '1 hour' THEN 1 ELSE ip_metadata.requests_last_hour + 1 END,
+            requests_last_minute = CASE WHEN ip_metadata.last_seen < NOW() - INTERVAL '1 minute' THEN 1 ELSE ip_metadata.requests_last_minute + 1 END,
+            max_requests_per_minute = GREATEST(ip_metadata.max_requests_per_minute, CASE WHEN ip_metadata.last_seen < NOW() - INTERVAL '1 minute' THEN 1 ELSE ip_metadata.requests_last_minute + 1 END),
+            is_bot = CASE WHEN NOT COALESCE(ip_metadata.is_bot, false) AND v_is_bot THEN true ELSE COALESCE(ip_metadata.is_bot, false) END,
+            bot_type = COALESCE(v_bot_type, ip_metadata.bot_type),
+            confidence = GREATEST(COALESCE(v_confidence, 0), COALESCE(ip_metadata.confidence, 0)),
+            detection_reason = CASE WHEN v_detection_reason != '' THEN v_detection_reason ELSE ip_metadata.detection_reason END,
+            unique_user_agents = CASE WHEN p_user_agent = ANY(ip_metadata.user_agent_sample) THEN ip_metadata.unique_user_agents ELSE ip_metadata.unique_user_agents + 1 END,
+            user_agent_sample = CASE WHEN p_user_agent = ANY(ip_metadata.user_agent_sample) THEN ip_metadata.user_agent_sample
+                WHEN array_length(ip_metadata.user_agent_sample, 1) < 5 THEN array_append(ip_metadata.user_agent_sample, p_user_agent)
+                ELSE ip_metadata.user_agent_sample END,
+            country = COALESCE(p_country, ip_metadata.country),
+            asn = COALESCE(p_asn, ip_metadata.asn),
+            asn_org = COALESCE(LEFT(p_asn_org, 255), ip_metadata.asn_org),
+            is_hosting_provider = CASE WHEN p_asn IS NULL THEN ip_metadata.is_hosting_provider ELSE v_hosting END,
+            updated_at = NOW();
+    END IF;
+
+    IF NOT v_is_bot AND v_policy = 'exclude' THEN
+        v_bot_type := 'datacenter';
+        v_pattern_name := 'asn:' || p_asn::text;
+        v_is_legitimate := false;
+        v_confidence := 50;
+    END IF;
+
+    IF v_is_bot OR v_policy = 'exclude' THEN
+        -- Logging must never fail tracking (e.g. a missing daily partition)
+        BEGIN
+            INSERT INTO bot_detection_log (ip, detected_at, pattern_type, pattern_name, confidence, user_agent, website_id, url_path, details)
+            VALUES (p_ip, COALESCE(p_seen_at, NOW()), v_bot_type, v_pattern_name, v_confidence, LEFT(p_user_agent, 500), p_website_id,
+                    LEFT(p_url_path, 500), jsonb_build_object('is_legitimate', v_is_legitimate, 'asn', p_asn, 'asn_org', p_asn_org));
+        EXCEPTION WHEN OTHERS THEN
+            RAISE WARNING 'bot_detection_log insert failed: %', SQLERRM;
+        END;
+    END IF;
+
+    drop_hit := v_is_bot OR v_policy = 'exclude';
+    flag_hosting := NOT drop_hit AND v_policy = 'flag';
+    RETURN NEXT;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,315 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Daily partitioned tables
+const (
+	EventTable       = "website_event"
+	IdempotencyTable = "event_idempotency"
+	BotLogTable      = "bot_detection_log"
+)
+
+// PartitionedTables lists the tables partitioned by day
+var PartitionedTables = []string{EventTable, IdempotencyTable, BotLogTable}
+
+var partitionNameRe = regexp.MustCompile(`^(website_event|event_idempotency|bot_detection_log)_(\d{4}_\d{2}_\d{2})$`)
+
+// Partition is the daily partition of a table covering Date
+type Partition struct {
+	Table string
+	Date  time.Time
+}
+
+// ParsePartition parses a partition name such as website_event_2026_10_18
+func ParsePartition(name string) (Partition, error) {
+	m := partitionNameRe.FindStringSubmatch(name)
+	if m == nil {
+		return Partition{}, fmt.Errorf("%q is not a daily partition of %v", name, PartitionedTables)
+	}
+	date, err := time.Parse("2006_01_02", m[2])
+	if err != nil {
+		return Partition{}, fmt.Errorf("%q has an invalid date: %w", name, err)
+	}
+	return Partition{Table: m[1], Date: date}, nil
+}
+
+// Name returns the partition's table name, e.g. website_event_2026_10_18
+func (p Partition) Name() string {
+	return fmt.Sprintf("%s_%s", p.Table, p.Date.Format("2006_01_02"))
+}
+
+// bounds returns the partition range as FROM (..) TO (..) literals
+func (p Partition) bounds() string {
+	return fmt.Sprintf("FROM ('%s') TO ('%s')", p.Date.Format("2006-01-02"), p.Date.AddDate(0, 0, 1).Format("2006-01-02"))
+}
+
+// Ensure creates the partition if it doesn't exist
+func (p Partition) Ensure() error {
+	switch p.Table {
+	case EventTable:
+		_, err := EnsureEventPartition(p.Date)
+		return err
+	case BotLogTable:
+		_, err := EnsureBotLogPartition(p.Date)
+		return err
+	case IdempotencyTable:
+		_, err := DB.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES %s`,
+			pq.QuoteIdentifier(p.Name()), p.Table, p.bounds()))
+		return err
+	}
+	return fmt.Errorf("%s is not a partitioned table", p.Table)
+}
+
+// PartitionInfo describes an existing partition table
+type PartitionInfo struct {
+	Partition
+	// Attached is false for tables detached from their parent
+	Attached bool
+	// DetachPending is set when a concurrent detach was interrupted
+	DetachPending bool
+	SizeBytes     int64
+	RowEstimate   int64
+}
+
+// ListPartitions returns the daily partitions of table (all partitioned
+// tables when empty), attached or detached, newest first
+func ListPartitions(ctx context.Context, table string) ([]PartitionInfo, error) {
+	tables := PartitionedTables
+	if table != "" {
+		if !slices.Contains(PartitionedTables, table) {
+			return nil, fmt.Errorf("%s is not a partitioned table (use one of %v)", table, PartitionedTables)
+		}
+		tables = []string{table}
+	}
+
+	// reltuples is -1 until the table is first analyzed
+	rows, err := DB.QueryContext(ctx, `
+		SELECT c.relname,
+		       parent.relname IS NOT NULL,
+		       COALESCE(i.inhdetachpending, false),
+		       pg_total_relation_size(c.oid),
+		       GREATEST(c.reltuples, 0)::bigint
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace AND n.nspname = 'public'
+		LEFT JOIN pg_inherits i ON i.inhrelid = c.oid
+		LEFT JOIN pg_class parent ON parent.oid = i.inhparent
+		WHERE c.relkind = 'r' AND c.relname ~ '^(website_event|event_idempotency|bot_detection_log)_\d{4}_\d{2}_\d{2}$'
+		ORDER BY c.relname DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var partitions []PartitionInfo
+	for rows.Next() {
+		var name string
+		var info PartitionInfo
+		if err := rows.Scan(&name, &info.Attached, &info.DetachPending, &info.SizeBytes, &info.RowEstimate); err != nil {
+			return nil, err
+		}
+		p, err := ParsePartition(name)
+		if err != nil || !slices.Contains(tables, p.Table) {
+			continue
+		}
+		info.Partition = p
+		partitions = append(partitions, info)
+	}
+	return partitions, rows.Err()
+}
+
+// GetPartition returns the partition with the given name
+func GetPartition(ctx context.Context, name string) (*PartitionInfo, error) {
+	p, err := ParsePartition(name)
+	if err != nil {
+		return nil, err
+	}
+	partitions, err := ListPartitions(ctx, p.Table)
+	if err != nil {
+		return nil, err
+	}
+	for i := range partitions {
+		if partitions[i].Name() == name {
+			return &partitions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("partition %s not found", name)
+}
+
+// MissingPartitions lists the partitions of tables from today through
+// today+days that don't exist
+func MissingPartitions(tables []string, days int) ([]Partition, error) {
+	var wanted []Partition
+	today := nowFunc()
+	for i := 0; i <= days; i++ {
+		date := today.AddDate(0, 0, i)
+		for _, table := range tables {
+			wanted = append(wanted, Partition{table, date})
+		}
+	}
+
+	names := make([]string, len(wanted))
+	for i, p := range wanted {
+		names[i] = p.Name()
+	}
+
+	rows, err := DB.Query(`
+		SELECT tablename
+		FROM pg_tables
+		WHERE schemaname = 'public' AND tablename = ANY($1)
+	`, pq.Array(names))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []Partition
+	for _, p := range wanted {
+		if !existing[p.Name()] {
+			missing = append(missing, p)
+		}
+	}
+	return missing, nil
+}
+
+// MissingFuturePartitions lists the website_event and bot_detection_log
+// partitions from today through the scheduler's horizon that don't exist
+func MissingFuturePartitions() ([]Partition, error) {
+	return MissingPartitions([]string{EventTable, BotLogTable}, partitionDaysAhead)
+}
+
+// withLockTimeout runs fn on a dedicated connection with lock_timeout set,
+// so DDL gives up instead of queueing behind (and blocking) live traffic
+func withLockTimeout(ctx context.Context, timeout time.Duration, fn func(conn *sql.Conn) error) error {
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout = %d", timeout.Milliseconds())); err != nil {
+		return err
+	}
+	// The connection goes back to the pool
+	defer func() { _, _ = conn.ExecContext(context.Background(), "RESET lock_timeout") }()
+
+	return fn(conn)
+}
+
+// Create creates the partition, waiting at most lockTimeout for the lock on
+// the parent table
+func (p Partition) Create(ctx context.Context, lockTimeout time.Duration) error {
+	if !slices.Contains(PartitionedTables, p.Table) {
+		return fmt.Errorf("%s is not a partitioned table", p.Table)
+	}
+	return withLockTimeout(ctx, lockTimeout, func(conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES %s`,
+			pq.QuoteIdentifier(p.Name()), p.Table, p.bounds()))
+		return err
+	})
+}
+
+// Detach detaches the partition from its parent without blocking queries on
+// the parent (DETACH ... CONCURRENTLY). An interrupted detach is finalized.
+// Today's and future partitions are refused: inserts for their days would
+// fail, and the scheduler can't recreate a partition whose table still exists.
+func (p PartitionInfo) Detach(ctx context.Context, lockTimeout time.Duration) error {
+	if !p.Attached {
+		return fmt.Errorf("partition %s is already detached", p.Name())
+	}
+	if p.isCurrentOrFuture() {
+		return fmt.Errorf("partition %s: %w", p.Name(), ErrCurrentPartition)
+	}
+	mode := "CONCURRENTLY"
+	if p.DetachPending {
+		mode = "FINALIZE"
+	}
+	return withLockTimeout(ctx, lockTimeout, func(conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s %s`,
+			p.Table, pq.QuoteIdentifier(p.Name()), mode))
+		return err
+	})
+}
+
+// Attach attaches a detached partition back to its parent for its day
+func (p PartitionInfo) Attach(ctx context.Context, lockTimeout time.Duration) error {
+	if p.Attached {
+		return fmt.Errorf("partition %s is already attached", p.Name())
+	}
+	return withLockTimeout(ctx, lockTimeout, func(conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ATTACH PARTITION %s FOR VALUES %s`,
+			p.Table, pq.QuoteIdentifier(p.Name()), p.bounds()))
+		return err
+	})
+}
+
+// Drop detaches the partition if needed and drops it. Dropping an attached
+// partition would lock the parent for the duration, so it is detached
+// concurrently first.
+func (p PartitionInfo) Drop(ctx context.Context, lockTimeout time.Duration) error {
+	if p.Attached {
+		if err := p.Detach(ctx, lockTimeout); err != nil {
+			return fmt.Errorf("failed to detach %s: %w", p.Name(), err)
+		}
+	}
+	return withLockTimeout(ctx, lockTimeout, func(conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, "DROP TABLE IF EXISTS "+pq.QuoteIdentifier(p.Name()))
+		return err
+	})
+}
+
+// ErrCurrentPartition is returned when an operation would remove today's or
+// a future partition
+var ErrCurrentPartition = errors.New("refusing to remove today's or future partitions")
+
+// isCurrentOrFuture reports whether the partition covers today or a later day
+func (p Partition) isCurrentOrFuture() bool {
+	today := nowFunc()
+	todayDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	return !p.Date.Before(todayDate)
+}
+
+// PartitionsBefore returns the partitions of table (all tables when empty)
+// covering days before cutoff. The cutoff can't be after today.
+func PartitionsBefore(ctx context.Context, table string, cutoff time.Time) ([]PartitionInfo, error) {
+	today := nowFunc()
+	todayDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	cutoffDate := time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.UTC)
+	if cutoffDate.After(todayDate) {
+		return nil, ErrCurrentPartition
+	}
+
+	partitions, err := ListPartitions(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	var old []PartitionInfo
+	for _, p := range partitions {
+		if p.Date.Before(cutoffDate) {
+			old = append(old, p)
+		}
+	}
+	return old, nil
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingFuturePartitions(t *testing.T) {
+	mock, cleanup := withMockDB(t)
+	defer cleanup()
+
+	partitionDaysAhead = 1
+	nowFunc = func() time.Time {
+		return time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	t.Cleanup(func() {
+		partitionDaysAhead = 30
+		nowFunc = time.Now
+	})
+
+	mock.ExpectQuery("SELECT tablename\\s+FROM pg_tables").
+		WillReturnRows(sqlmock.NewRows([]string{"tablename"}).
+			AddRow("website_event_2025_01_01").
+			AddRow("bot_detection_log_2025_01_01").
+			AddRow("website_event_2025_01_02"))
+
+	missing, err := MissingFuturePartitions()
+	require.NoError(t, err)
+	require.Len(t, missing, 1)
+	assert.Equal(t, "bot_detection_log_2025_01_02", missing[0].Name())
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS bot_detection_log_2025_01_02\\s+PARTITION OF bot_detection_log").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, missing[0].Ensure())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPartitionEnsureRejectsUnknownTable(t *testing.T) {
+	err := Partition{Table: "session", Date: time.Now()}.Ensure()
+	assert.ErrorContains(t, err, "not a partitioned table")
+}
+
+func TestParsePartition(t *testing.T) {
+	p, err := ParsePartition("event_idempotency_2026_10_18")
+	require.NoError(t, err)
+	assert.Equal(t, IdempotencyTable, p.Table)
+	assert.Equal(t, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), p.Date)
+	assert.Equal(t, "event_idempotency_2026_10_18", p.Name())
+
+	for _, name := range []string{"website_event", "session_2026_10_18", "website_event_2026_13_01", "website_event_2026_10_18; DROP TABLE website"} {
+		_, err := ParsePartition(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func partitionRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"relname", "attached", "detach_pending", "size", "rows"}).
+		AddRow("website_event_2025_01_03", true, false, 8192, 10).
+		AddRow("website_event_2025_01_01", false, false, 4096, 0).
+		AddRow("bot_detection_log_2024_12_31", true, true, 16384, 5)
+}
+
+func TestListPartitions(t *testing.T) {
+	mock, cleanup := withMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM pg_class c").WillReturnRows(partitionRows())
+
+	partitions, err := ListPartitions(context.Background(), EventTable)
+	require.NoError(t, err)
+	require.Len(t, partitions, 2)
+	assert.Equal(t, "website_event_2025_01_03", partitions[0].Name())
+	assert.True(t, partitions[0].Attached)
+	assert.Equal(t, int64(10), partitions[0].RowEstimate)
+	assert.False(t, partitions[1].Attached)
+
+	_, err = ListPartitions(context.Background(), "session")
+	assert.ErrorContains(t, err, "not a partitioned table")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPartitionsBefore(t *testing.T) {
+	mock, cleanup := withMockDB(t)
+	defer cleanup()
+
+	nowFunc = func() time.Time {
+		return time.Date(2025, time.January, 3, 12, 0, 0, 0, time.UTC)
+	}
+	t.Cleanup(func() { nowFunc = time.Now })
+
+	_, err := PartitionsBefore(context.Background(), "", time.Date(2025, time.January, 4, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrCurrentPartition)
+
+	mock.ExpectQuery("FROM pg_class c").WillReturnRows(partitionRows())
+	old, err := PartitionsBefore(context.Background(), "", time.Date(2025, time.January, 3, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, old, 2)
+	assert.Equal(t, "website_event_2025_01_01", old[0].Name())
+	assert.Equal(t, "bot_detection_log_2024_12_31", old[1].Name())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPartitionDDLUsesLockTimeout(t *testing.T) {
+	mock, cleanup := withMockDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	date := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec("SET lock_timeout = 5000").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "event_idempotency_2025_01_01" PARTITION OF event_idempotency FOR VALUES FROM \('2025-01-01'\) TO \('2025-01-02'\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, Partition{IdempotencyTable, date}.Create(ctx, 5*time.Second))
+
+	// Dropping an attached partition detaches it concurrently first
+	attached := PartitionInfo{Partition: Partition{EventTable, date}, Attached: true}
+	mock.ExpectExec("SET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE website_event DETACH PARTITION "website_event_2025_01_01" CONCURRENTLY`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DROP TABLE IF EXISTS "website_event_2025_01_01"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, attached.Drop(ctx, time.Second))
+
+	pending := PartitionInfo{Partition: Partition{BotLogTable, date}, Attached: true, DetachPending: true}
+	mock.ExpectExec("SET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DETACH PARTITION "bot_detection_log_2025_01_01" FINALIZE`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, pending.Detach(ctx, time.Second))
+
+	detached := PartitionInfo{Partition: Partition{EventTable, date}}
+	assert.ErrorContains(t, detached.Detach(ctx, time.Second), "already detached")
+
+	// Today's and future partitions stay attached: inserts would have nowhere to go
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	current := PartitionInfo{Partition: Partition{EventTable, today}, Attached: true}
+	assert.ErrorIs(t, current.Detach(ctx, time.Second), ErrCurrentPartition)
+	future := PartitionInfo{Partition: Partition{EventTable, today.AddDate(0, 0, 3)}, Attached: true}
+	assert.ErrorIs(t, future.Drop(ctx, time.Second), ErrCurrentPartition)
+	mock.ExpectExec("SET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE website_event ATTACH PARTITION "website_event_2025_01_01" FOR VALUES FROM \('2025-01-01'\) TO \('2025-01-02'\)`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, detached.Attach(ctx, time.Second))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
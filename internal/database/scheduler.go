@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -11,13 +12,16 @@ import (
 var (
 	nowFunc             = time.Now
 	partitionDaysAhead  = 30
-	retentionPeriodDays = 90
+	botLogRetentionDays = 30
+	cleanupLockTimeout  = 5 * time.Second
 )
 
 // PartitionScheduler manages automatic partition creation and cleanup
 type PartitionScheduler struct {
-	databaseURL string
-	stopChan    chan struct{}
+	databaseURL   string
+	stopChan      chan struct{}
+	retentionDays int
+	archive       func(ctx context.Context, p Partition) error
 }
 
 // NewPartitionScheduler creates a new partition scheduler
@@ -28,6 +32,19 @@ func NewPartitionScheduler(databaseURL string) *PartitionScheduler {
 	}
 }
 
+// SetRetention enables weekly cleanup: website_event partitions older than
+// days and bot log partitions older than 30 days are dropped. Without a
+// retention the scheduler only creates partitions.
+func (ps *PartitionScheduler) SetRetention(days int) {
+	ps.retentionDays = days
+}
+
+// SetArchiver makes cleanup archive each expiring website_event partition
+// with fn before dropping it. Partitions that fail to archive are kept.
+func (ps *PartitionScheduler) SetArchiver(fn func(ctx context.Context, p Partition) error) {
+	ps.archive = fn
+}
+
 // Start begins the partition management tasks
 func (ps *PartitionScheduler) Start() {
 	logging.L().Info("starting partition scheduler")
@@ -35,8 +52,10 @@ func (ps *PartitionScheduler) Start() {
 	// Create future partitions daily at 2 AM
 	go ps.schedulePartitionCreation()
 
-	// Clean up old partitions weekly
-	go ps.schedulePartitionCleanup()
+	// Clean up old partitions weekly, only when a retention is set
+	if ps.retentionDays > 0 {
+		go ps.schedulePartitionCleanup()
+	}
 }
 
 // Stop gracefully stops the scheduler
@@ -51,11 +70,13 @@ func (ps *PartitionScheduler) schedulePartitionCreation() {
 
 	// Run immediately on start
 	ps.createFuturePartitions()
+	ps.createFutureBotLogPartitions()
 
 	for {
 		select {
 		case <-ticker.C:
 			ps.createFuturePartitions()
+			ps.createFutureBotLogPartitions()
 		case <-ps.stopChan:
 			return
 		}
@@ -68,17 +89,7 @@ func (ps *PartitionScheduler) createFuturePartitions() {
 
 	for i := 1; i <= partitionDaysAhead; i++ {
 		date := nowFunc().AddDate(0, 0, i)
-		partitionName := fmt.Sprintf("website_event_%s", date.Format("2006_01_02"))
-		startDate := date.Format("2006-01-02")
-		endDate := date.AddDate(0, 0, 1).Format("2006-01-02")
-
-		query := fmt.Sprintf(`
-			CREATE TABLE IF NOT EXISTS %s
-			PARTITION OF website_event
-			FOR VALUES FROM ('%s') TO ('%s')
-		`, partitionName, startDate, endDate)
-
-		_, err := DB.Exec(query)
+		partitionName, err := EnsureEventPartition(date)
 		if err != nil {
 			logging.L().Warn("failed to create partition", zap.String("partition", partitionName), zap.Error(err))
 			continue
@@ -88,7 +99,52 @@ func (ps *PartitionScheduler) createFuturePartitions() {
 	}
 }
 
-// schedulePartitionCleanup removes partitions older than 90 days
+// EnsureEventPartition creates the daily website_event partition covering date
+// Used by the scheduler for future days and by importers for historical days
+func EnsureEventPartition(date time.Time) (string, error) {
+	partitionName := fmt.Sprintf("website_event_%s", date.Format("2006_01_02"))
+	startDate := date.Format("2006-01-02")
+	endDate := date.AddDate(0, 0, 1).Format("2006-01-02")
+
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s
+		PARTITION OF website_event
+		FOR VALUES FROM ('%s') TO ('%s')
+	`, partitionName, startDate, endDate)
+
+	_, err := DB.Exec(query)
+	return partitionName, err
+}
+
+// createFutureBotLogPartitions creates bot_detection_log partitions for today
+// and the next 30 days, so logging bot hits never runs out of partitions
+func (ps *PartitionScheduler) createFutureBotLogPartitions() {
+	for i := 0; i <= partitionDaysAhead; i++ {
+		date := nowFunc().AddDate(0, 0, i)
+		partitionName, err := EnsureBotLogPartition(date)
+		if err != nil {
+			logging.L().Warn("failed to create partition", zap.String("partition", partitionName), zap.Error(err))
+		}
+	}
+}
+
+// EnsureBotLogPartition creates the daily bot_detection_log partition covering date
+func EnsureBotLogPartition(date time.Time) (string, error) {
+	partitionName := fmt.Sprintf("bot_detection_log_%s", date.Format("2006_01_02"))
+	startDate := date.Format("2006-01-02")
+	endDate := date.AddDate(0, 0, 1).Format("2006-01-02")
+
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s
+		PARTITION OF bot_detection_log
+		FOR VALUES FROM ('%s') TO ('%s')
+	`, partitionName, startDate, endDate)
+
+	_, err := DB.Exec(query)
+	return partitionName, err
+}
+
+// schedulePartitionCleanup removes partitions older than the retention
 func (ps *PartitionScheduler) schedulePartitionCleanup() {
 	ticker := time.NewTicker(7 * 24 * time.Hour) // Weekly
 	defer ticker.Stop()
@@ -97,54 +153,44 @@ func (ps *PartitionScheduler) schedulePartitionCleanup() {
 		select {
 		case <-ticker.C:
 			ps.cleanupOldPartitions()
+			ps.cleanupOldBotLogs()
 		case <-ps.stopChan:
 			return
 		}
 	}
 }
 
-// cleanupOldPartitions drops partitions older than retention period
+// cleanupOldPartitions drops website_event partitions older than the
+// retention. Attached partitions are detached concurrently first, so live
+// traffic on website_event isn't blocked.
 func (ps *PartitionScheduler) cleanupOldPartitions() {
-	cutoffDate := nowFunc().AddDate(0, 0, -retentionPeriodDays)
+	ctx := context.Background()
+	cutoffDate := nowFunc().AddDate(0, 0, -ps.retentionDays)
 
 	logging.L().Info("cleaning up old partitions", zap.String("cutoff", cutoffDate.Format("2006-01-02")))
 
-	// Find old partitions
-	rows, err := DB.Query(`
-		SELECT tablename
-		FROM pg_tables
-		WHERE schemaname = 'public'
-		  AND tablename LIKE 'website_event_%'
-		  AND tablename < $1
-		ORDER BY tablename
-	`, fmt.Sprintf("website_event_%s", cutoffDate.Format("2006_01_02")))
-
+	partitions, err := PartitionsBefore(ctx, EventTable, cutoffDate)
 	if err != nil {
 		logging.L().Warn("failed to query old partitions", zap.Error(err))
 		return
 	}
-	defer func() {
-		if err := rows.Close(); err != nil {
-			logging.L().Warn("failed to close partition rows", zap.Error(err))
-		}
-	}()
 
 	droppedCount := 0
-	for rows.Next() {
-		var tableName string
-		if err := rows.Scan(&tableName); err != nil {
-			continue
+	for _, p := range partitions {
+		if ps.archive != nil {
+			if err := ps.archive(ctx, p.Partition); err != nil {
+				logging.L().Warn("failed to archive partition, keeping it", zap.String("partition", p.Name()), zap.Error(err))
+				continue
+			}
+			logging.L().Info("archived partition", zap.String("partition", p.Name()))
 		}
 
-		// Drop old partition
-		query := fmt.Sprintf("DROP TABLE IF EXISTS %s", tableName)
-		_, err := DB.Exec(query)
-		if err != nil {
-			logging.L().Warn("failed to drop partition", zap.String("partition", tableName), zap.Error(err))
+		if err := p.Drop(ctx, cleanupLockTimeout); err != nil {
+			logging.L().Warn("failed to drop partition", zap.String("partition", p.Name()), zap.Error(err))
 			continue
 		}
 
-		logging.L().Info("dropped old partition", zap.String("partition", tableName))
+		logging.L().Info("dropped old partition", zap.String("partition", p.Name()))
 		droppedCount++
 	}
 
@@ -153,6 +199,18 @@ func (ps *PartitionScheduler) cleanupOldPartitions() {
 	}
 }
 
+// cleanupOldBotLogs drops bot_detection_log partitions older than 30 days
+func (ps *PartitionScheduler) cleanupOldBotLogs() {
+	var dropped int
+	if err := DB.QueryRow(`SELECT cleanup_old_bot_logs($1)`, botLogRetentionDays).Scan(&dropped); err != nil {
+		logging.L().Warn("failed to clean up bot log partitions", zap.Error(err))
+		return
+	}
+	if dropped > 0 {
+		logging.L().Info("bot log cleanup complete", zap.Int("dropped_count", dropped))
+	}
+}
+
 // MaterializedViewScheduler manages concurrent refreshes
 type MaterializedViewScheduler struct {
 	stopChan chan struct{}
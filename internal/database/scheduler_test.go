@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -129,30 +131,104 @@ func TestPartitionSchedulerCleanupOldPartitions(t *testing.T) {
 	mock, cleanup := withMockDB(t)
 	defer cleanup()
 
-	retentionPeriodDays = 30
 	nowFunc = func() time.Time {
 		return time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
 	}
-	t.Cleanup(func() {
-		retentionPeriodDays = 90
-		nowFunc = time.Now
+	t.Cleanup(func() { nowFunc = time.Now })
+
+	mock.ExpectQuery("FROM pg_class c").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "attached", "detach_pending", "size", "rows"}).
+			AddRow("website_event_2025_02_15", true, false, 8192, 10).
+			AddRow("website_event_2025_01_02", true, false, 8192, 10).
+			AddRow("website_event_2025_01_01", false, false, 4096, 0))
+
+	// Attached partitions are detached concurrently before the drop
+	mock.ExpectExec("SET lock_timeout = 5000").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`ALTER TABLE website_event DETACH PARTITION "website_event_2025_01_02" CONCURRENTLY`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SET lock_timeout = 5000").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DROP TABLE IF EXISTS "website_event_2025_01_02"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SET lock_timeout = 5000").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DROP TABLE IF EXISTS "website_event_2025_01_01"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ps := &PartitionScheduler{}
+	ps.SetRetention(30)
+	ps.cleanupOldPartitions()
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPartitionSchedulerCleanupKeepsUnarchivedPartitions(t *testing.T) {
+	mock, cleanup := withMockDB(t)
+	defer cleanup()
+
+	nowFunc = func() time.Time {
+		return time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	}
+	t.Cleanup(func() { nowFunc = time.Now })
+
+	mock.ExpectQuery("FROM pg_class c").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "attached", "detach_pending", "size", "rows"}).
+			AddRow("website_event_2025_01_02", false, false, 8192, 10).
+			AddRow("website_event_2025_01_01", false, false, 8192, 10))
+	// Only the partition that was archived is dropped
+	mock.ExpectExec("SET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DROP TABLE IF EXISTS "website_event_2025_01_02"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var archived []string
+	ps := &PartitionScheduler{}
+	ps.SetRetention(90)
+	ps.SetArchiver(func(ctx context.Context, p Partition) error {
+		if p.Name() == "website_event_2025_01_01" {
+			return errors.New("bucket unreachable")
+		}
+		archived = append(archived, p.Name())
+		return nil
 	})
+	ps.cleanupOldPartitions()
+
+	assert.Equal(t, []string{"website_event_2025_01_02"}, archived)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
 
-	rows := sqlmock.NewRows([]string{"tablename"}).
-		AddRow("website_event_2025_01_01").
-		AddRow("website_event_2025_01_02")
+func TestPartitionSchedulerCreatesFutureBotLogPartitions(t *testing.T) {
+	mock, cleanup := withMockDB(t)
+	defer cleanup()
 
-	mock.ExpectQuery("SELECT\\s+tablename").
-		WithArgs("website_event_2025_01_30").
-		WillReturnRows(rows)
+	partitionDaysAhead = 1
+	nowFunc = func() time.Time {
+		return time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	t.Cleanup(func() {
+		partitionDaysAhead = 30
+		nowFunc = time.Now
+	})
 
-	mock.ExpectExec("DROP TABLE IF EXISTS website_event_2025_01_01").
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS bot_detection_log_2025_01_01").
 		WillReturnResult(sqlmock.NewResult(0, 0))
-	mock.ExpectExec("DROP TABLE IF EXISTS website_event_2025_01_02").
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS bot_detection_log_2025_01_02").
 		WillReturnResult(sqlmock.NewResult(0, 0))
 
 	ps := &PartitionScheduler{}
-	ps.cleanupOldPartitions()
+	ps.createFutureBotLogPartitions()
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPartitionSchedulerCleanupOldBotLogs(t *testing.T) {
+	mock, cleanup := withMockDB(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT cleanup_old_bot_logs").
+		WithArgs(30).
+		WillReturnRows(sqlmock.NewRows([]string{"cleanup_old_bot_logs"}).AddRow(2))
+
+	ps := &PartitionScheduler{}
+	ps.cleanupOldBotLogs()
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
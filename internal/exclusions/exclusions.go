@@ -0,0 +1,134 @@
+// Package exclusions matches internal traffic (office and VPN networks, staff
+// browsers and monitors) that should not be recorded for a website.
+package exclusions
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Kinds of exclusion rules
+const (
+	KindIP        = "ip"         // CIDR or single address
+	KindUserAgent = "user_agent" // case-insensitive substring of the User-Agent
+)
+
+// Reasons reported when a request is excluded
+const (
+	ReasonIP        = "ip"
+	ReasonUserAgent = "user_agent"
+	ReasonOptOut    = "opt_out"
+)
+
+const maxUserAgentPattern = 255
+
+// Rule is a single exclusion
+type Rule struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Matcher checks requests against a website's compiled rules.
+// A nil Matcher excludes nothing.
+type Matcher struct {
+	prefixes   []netip.Prefix
+	userAgents []string
+}
+
+// ParseKind accepts the kind names used on the command line
+func ParseKind(kind string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "ip", "cidr":
+		return KindIP, true
+	case "user_agent", "user-agent", "ua":
+		return KindUserAgent, true
+	}
+	return "", false
+}
+
+// Normalize validates a rule and returns it in stored form: IP rules become
+// canonical prefixes (a bare address is a /32 or /128), user agent patterns
+// are trimmed
+func Normalize(r Rule) (Rule, error) {
+	value := strings.TrimSpace(r.Value)
+	switch r.Kind {
+	case KindIP:
+		prefix, err := parsePrefix(value)
+		if err != nil {
+			return r, err
+		}
+		return Rule{Kind: KindIP, Value: prefix.String()}, nil
+	case KindUserAgent:
+		if value == "" {
+			return r, fmt.Errorf("user agent pattern is required")
+		}
+		if len(value) > maxUserAgentPattern {
+			return r, fmt.Errorf("user agent pattern exceeds %d characters", maxUserAgentPattern)
+		}
+		return Rule{Kind: KindUserAgent, Value: value}, nil
+	}
+	return r, fmt.Errorf("invalid exclusion kind %q (valid: ip, user_agent)", r.Kind)
+}
+
+// Compile builds a Matcher, returning nil when there are no rules
+func Compile(rules []Rule) (*Matcher, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	m := &Matcher{}
+	for _, r := range rules {
+		normalized, err := Normalize(r)
+		if err != nil {
+			return nil, err
+		}
+		switch normalized.Kind {
+		case KindIP:
+			prefix, _ := parsePrefix(normalized.Value)
+			m.prefixes = append(m.prefixes, prefix)
+		case KindUserAgent:
+			m.userAgents = append(m.userAgents, strings.ToLower(normalized.Value))
+		}
+	}
+	return m, nil
+}
+
+// Match reports whether a request from ip with userAgent is excluded, and why
+func (m *Matcher) Match(ip, userAgent string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSpace(ip)); err == nil {
+		addr = addr.Unmap()
+		for _, prefix := range m.prefixes {
+			if prefix.Contains(addr) {
+				return ReasonIP, true
+			}
+		}
+	}
+	if userAgent != "" {
+		ua := strings.ToLower(userAgent)
+		for _, pattern := range m.userAgents {
+			if strings.Contains(ua, pattern) {
+				return ReasonUserAgent, true
+			}
+		}
+	}
+	return "", false
+}
+
+func parsePrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid CIDR %q: %w", value, err)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q: %w", value, err)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,76 @@
+package exclusions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	r, err := Normalize(Rule{Kind: KindIP, Value: " 10.1.2.3/8 "})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.0/8", r.Value)
+
+	r, err = Normalize(Rule{Kind: KindIP, Value: "192.0.2.10"})
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.10/32", r.Value)
+
+	r, err = Normalize(Rule{Kind: KindIP, Value: "2001:db8::1"})
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::1/128", r.Value)
+
+	_, err = Normalize(Rule{Kind: KindIP, Value: "10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = Normalize(Rule{Kind: KindUserAgent, Value: "  "})
+	assert.Error(t, err)
+	_, err = Normalize(Rule{Kind: "cookie", Value: "x"})
+	assert.Error(t, err)
+}
+
+func TestMatch(t *testing.T) {
+	m, err := Compile([]Rule{
+		{Kind: KindIP, Value: "10.0.0.0/8"},
+		{Kind: KindIP, Value: "2001:db8::/32"},
+		{Kind: KindUserAgent, Value: "UptimeRobot"},
+	})
+	require.NoError(t, err)
+
+	reason, ok := m.Match("10.20.30.40", "Mozilla/5.0")
+	assert.True(t, ok)
+	assert.Equal(t, ReasonIP, reason)
+
+	_, ok = m.Match("::ffff:10.0.0.1", "")
+	assert.True(t, ok)
+
+	_, ok = m.Match("2001:db8:1::5", "")
+	assert.True(t, ok)
+
+	reason, ok = m.Match("203.0.113.5", "Mozilla/5.0 (compatible; uptimerobot/2.0)")
+	assert.True(t, ok)
+	assert.Equal(t, ReasonUserAgent, reason)
+
+	_, ok = m.Match("203.0.113.5", "Mozilla/5.0")
+	assert.False(t, ok)
+	_, ok = m.Match("not-an-ip", "")
+	assert.False(t, ok)
+}
+
+func TestNilMatcher(t *testing.T) {
+	m, err := Compile(nil)
+	require.NoError(t, err)
+	assert.Nil(t, m)
+	_, ok := m.Match("10.0.0.1", "UptimeRobot")
+	assert.False(t, ok)
+}
+
+func TestParseKind(t *testing.T) {
+	kind, ok := ParseKind("UA")
+	assert.True(t, ok)
+	assert.Equal(t, KindUserAgent, kind)
+	kind, ok = ParseKind("cidr")
+	assert.True(t, ok)
+	assert.Equal(t, KindIP, kind)
+	_, ok = ParseKind("cookie")
+	assert.False(t, ok)
+}
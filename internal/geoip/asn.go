@@ -0,0 +1,136 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	"go.uber.org/zap"
+
+	"github.com/seuros/kaunta/internal/logging"
+)
+
+// ASNDatabaseFile is the name of the optional ASN database inside the data directory
+const ASNDatabaseFile = "GeoLite2-ASN.mmdb"
+
+var (
+	asnReader *geoip2.Reader // guarded by mu, like reader
+	asnLoaded time.Time
+	asnPath   string
+)
+
+// ASNPath returns the ASN database path for opts
+func ASNPath(opts Options) string {
+	if opts.ASNPath != "" {
+		return opts.ASNPath
+	}
+	return filepath.Join(opts.DataDir, ASNDatabaseFile)
+}
+
+// initASN loads the ASN database when present. It isn't downloaded: install
+// it with geoipupdate or copy it next to the City database.
+func initASN(configured bool) {
+	mu.RLock()
+	path := asnPath
+	mu.RUnlock()
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if configured {
+			logging.L().Warn("asn database not found; asn enrichment disabled", zap.String("path", path))
+		}
+		return
+	}
+	if err := reloadASN(); err != nil {
+		logging.L().Warn("could not load asn database; asn enrichment disabled", zap.Error(err))
+		return
+	}
+	logging.L().Info("asn database loaded", zap.String("path", path))
+}
+
+// LookupASN returns the autonomous system number and organisation of an IP
+// address, or 0 when no ASN database is loaded or the address is unknown
+func LookupASN(ipStr string) (asn uint, org string) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if asnReader == nil {
+		return 0, ""
+	}
+
+	ip := net.ParseIP(ipStr)
+	if ip == nil {
+		return 0, ""
+	}
+
+	record, err := asnReader.ASN(ip)
+	if err != nil {
+		logging.L().Warn("asn lookup error", zap.String("ip", ipStr), zap.Error(err))
+		return 0, ""
+	}
+	return record.AutonomousSystemNumber, record.AutonomousSystemOrganization
+}
+
+// reloadASN reopens the ASN database file
+func reloadASN() error {
+	mu.RLock()
+	path := asnPath
+	mu.RUnlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	r, err := verifyASN(path)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	old := asnReader
+	asnReader = r
+	asnLoaded = info.ModTime()
+	mu.Unlock()
+
+	if old != nil {
+		if err := old.Close(); err != nil {
+			logging.L().Warn("failed to close previous asn reader", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// reloadASNIfChanged reopens the ASN database when it appeared or changed
+func reloadASNIfChanged() {
+	mu.RLock()
+	path, loadedAt := asnPath, asnLoaded
+	mu.RUnlock()
+
+	if path == "" {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().Equal(loadedAt) {
+		return
+	}
+	if err := reloadASN(); err != nil {
+		logging.L().Warn("failed to reload asn database; keeping current one", zap.Error(err))
+		return
+	}
+	logging.L().Info("asn database reloaded from disk", zap.String("path", path))
+}
+
+// verifyASN opens a database and checks it answers ASN lookups
+func verifyASN(path string) (*geoip2.Reader, error) {
+	r, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid asn database: %w", err)
+	}
+	if _, err := r.ASN(net.ParseIP("8.8.8.8")); err != nil {
+		_ = r.Close()
+		return nil, fmt.Errorf("asn database lookup failed: %w", err)
+	}
+	return r, nil
+}
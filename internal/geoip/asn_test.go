@@ -0,0 +1,43 @@
+package geoip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestASNPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("data", ASNDatabaseFile), ASNPath(Options{DataDir: "data"}))
+	assert.Equal(t, "/srv/asn.mmdb", ASNPath(Options{DataDir: "data", ASNPath: "/srv/asn.mmdb"}))
+}
+
+func TestLookupASNWithoutDatabase(t *testing.T) {
+	asn, org := LookupASN("8.8.8.8")
+	assert.Zero(t, asn)
+	assert.Empty(t, org)
+}
+
+func TestReloadASNRejectsInvalidDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ASNDatabaseFile)
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0644))
+
+	mu.Lock()
+	asnPath = path
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		asnPath = ""
+		mu.Unlock()
+	})
+
+	err := reloadASN()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid asn database")
+
+	// Nothing is loaded, so lookups keep returning nothing
+	asn, _ := LookupASN("8.8.8.8")
+	assert.Zero(t, asn)
+}
@@ -1,13 +1,12 @@
 package geoip
 
 import (
-	"compress/gzip"
-	"fmt"
-	"io"
+	"context"
 	"net"
-	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/oschwald/geoip2-golang"
 	"go.uber.org/zap"
@@ -15,20 +14,46 @@ import (
 	"github.com/seuros/kaunta/internal/logging"
 )
 
+// DatabaseFile is the name of the database inside the data directory
+const DatabaseFile = "GeoLite2-City.mmdb"
+
+// DefaultSource is the GeoLite2-City mirror used without a license key or source
+// Source: https://www.npmjs.com/package/geolite2-city
+const DefaultSource = "https://cdn.jsdelivr.net/npm/geolite2-city/GeoLite2-City.mmdb.gz"
+
+// Options selects where the database is stored and where updates come from
+type Options struct {
+	DataDir    string
+	Source     string // URL, .mmdb / .mmdb.gz / .tar.gz file, or directory holding GeoLite2-City.mmdb
+	LicenseKey string // MaxMind license key; downloads GeoLite2-City from MaxMind when Source is empty
+	ASNPath    string // GeoLite2-ASN compatible database; defaults to GeoLite2-ASN.mmdb in DataDir
+}
+
 var (
-	reader *geoip2.Reader
-	dbPath string
+	mu      sync.RWMutex // guards reader; lookups hold it for reading while the reader is in use
+	reader  *geoip2.Reader
+	loaded  time.Time // modification time of the file reader was opened from
+	dbPath  string
+	options Options
 )
 
 // Init initializes the GeoIP database
 // Downloads GeoLite2-City if not present locally (optional - warns if missing)
-func Init(dataDir string) error {
-	dbPath = filepath.Join(dataDir, "GeoLite2-City.mmdb")
+func Init(opts Options) error {
+	mu.Lock()
+	options = opts
+	dbPath = filepath.Join(opts.DataDir, DatabaseFile)
+	asnPath = ASNPath(opts)
+	mu.Unlock()
+
+	initASN(opts.ASNPath != "")
 
 	// Download if missing
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		logging.L().Info("geoip database not found; attempting download", zap.String("path", dbPath))
-		if err := downloadDatabase(dbPath); err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+		defer cancel()
+		if _, err := Update(ctx, opts, false); err != nil {
 			logging.L().Warn("geoip database download failed", zap.Error(err))
 			logging.L().Warn("geoip lookups will return 'Unknown' until database is installed manually")
 			logging.L().Info("download GeoIP from https://geoip.maxmind.com/ and place file", zap.String("path", dbPath))
@@ -36,12 +61,11 @@ func Init(dataDir string) error {
 			return nil
 		}
 		logging.L().Info("geoip database downloaded successfully")
+		return nil
 	}
 
 	// Open database
-	var err error
-	reader, err = geoip2.Open(dbPath)
-	if err != nil {
+	if err := Reload(); err != nil {
 		logging.L().Warn("could not load geoip database", zap.Error(err))
 		logging.L().Warn("geoip lookups will return 'Unknown'")
 		// Don't fail - continue without GeoIP
@@ -54,6 +78,9 @@ func Init(dataDir string) error {
 
 // LookupIP returns country, city, and region for an IP address
 func LookupIP(ipStr string) (country, city, region string) {
+	mu.RLock()
+	defer mu.RUnlock()
+
 	if reader == nil {
 		return "", "", ""
 	}
@@ -84,66 +111,36 @@ func LookupIP(ipStr string) (country, city, region string) {
 
 // Close closes the GeoIP database
 func Close() error {
-	if reader != nil {
-		return reader.Close()
-	}
-	return nil
-}
-
-// downloadDatabase downloads GeoLite2-City database from jsDelivr CDN
-// Using the geolite2-city package mirror hosted by jsDelivr
-func downloadDatabase(dbPath string) error {
-	// Create directory if needed
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
-	}
-
-	// Use jsDelivr CDN mirror of geolite2-city
-	// Source: https://www.npmjs.com/package/geolite2-city
-	url := "https://cdn.jsdelivr.net/npm/geolite2-city/GeoLite2-City.mmdb.gz"
-
-	logging.L().Info("downloading geoip database", zap.String("url", url))
+	mu.Lock()
+	defer mu.Unlock()
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
+	var err error
+	if asnReader != nil {
+		err = asnReader.Close()
+		asnReader = nil
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			logging.L().Warn("failed to close geoip response body", zap.Error(err))
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	if reader == nil {
+		return err
 	}
-
-	// Decompress gzip stream
-	gzReader, err := gzip.NewReader(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to create gzip reader: %w", err)
+	if closeErr := reader.Close(); closeErr != nil {
+		err = closeErr
 	}
-	defer func() {
-		if err := gzReader.Close(); err != nil {
-			logging.L().Warn("failed to close geoip gzip reader", zap.Error(err))
-		}
-	}()
+	reader = nil
+	return err
+}
 
-	// Write to file
-	out, err := os.Create(dbPath)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if err := out.Close(); err != nil {
-			logging.L().Warn("failed to close geoip output file", zap.Error(err))
+// swap installs r as the active reader and closes the previous one. Taking
+// the write lock waits for in-flight lookups on the old reader.
+func swap(r *geoip2.Reader, modTime time.Time) {
+	mu.Lock()
+	old := reader
+	reader = r
+	loaded = modTime
+	mu.Unlock()
+
+	if old != nil {
+		if err := old.Close(); err != nil {
+			logging.L().Warn("failed to close previous geoip reader", zap.Error(err))
 		}
-	}()
-
-	if _, err := io.Copy(out, gzReader); err != nil {
-		return fmt.Errorf("failed to write database: %w", err)
 	}
-
-	return nil
 }
@@ -0,0 +1,381 @@
+package geoip
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	"go.uber.org/zap"
+
+	"github.com/seuros/kaunta/internal/logging"
+)
+
+const (
+	downloadTimeout   = 10 * time.Minute
+	diskCheckInterval = time.Minute
+	retryInterval     = time.Hour
+
+	maxmindDownloadURL = "https://download.maxmind.com/app/geoip_download?edition_id=GeoLite2-City&suffix=tar.gz&license_key="
+)
+
+// Status describes a database file
+type Status struct {
+	Path         string    `json:"path"`
+	DatabaseType string    `json:"database_type"`
+	BuildTime    time.Time `json:"build_time"`
+	Size         int64     `json:"size"`
+	ModTime      time.Time `json:"modified_at"`
+}
+
+// UpdateResult reports what Update did
+type UpdateResult struct {
+	Updated       bool      `json:"updated"`
+	Source        string    `json:"source"`
+	PreviousBuild time.Time `json:"previous_build,omitzero"`
+	Build         time.Time `json:"build"`
+}
+
+// SourceName describes where updates come from, without the license key
+func SourceName(opts Options) string {
+	switch {
+	case opts.Source != "":
+		return opts.Source
+	case opts.LicenseKey != "":
+		return "MaxMind GeoLite2-City (license key)"
+	default:
+		return DefaultSource
+	}
+}
+
+// ReadStatus reads the metadata of a database file
+func ReadStatus(path string) (*Status, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	md := r.Metadata()
+	return &Status{
+		Path:         path,
+		DatabaseType: md.DatabaseType,
+		BuildTime:    time.Unix(int64(md.BuildEpoch), 0).UTC(),
+		Size:         info.Size(),
+		ModTime:      info.ModTime(),
+	}, nil
+}
+
+// Update fetches the database from the configured source, verifies it and
+// replaces the file in the data directory. A download that isn't newer than
+// the current file is discarded unless force is set. When this process serves
+// lookups from that file, the reader is swapped; on any failure the current
+// file and reader are kept.
+func Update(ctx context.Context, opts Options, force bool) (*UpdateResult, error) {
+	target := filepath.Join(opts.DataDir, DatabaseFile)
+	if err := os.MkdirAll(opts.DataDir, 0755); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(opts.DataDir, ".GeoLite2-City-*.mmdb")
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	err = fetch(ctx, opts, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := verify(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UpdateResult{
+		Source: SourceName(opts),
+		Build:  time.Unix(int64(r.Metadata().BuildEpoch), 0).UTC(),
+	}
+	if current, err := ReadStatus(target); err == nil {
+		result.PreviousBuild = current.BuildTime
+		if !force && !result.Build.After(current.BuildTime) {
+			_ = r.Close()
+			return result, nil
+		}
+	}
+
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	result.Updated = true
+
+	mu.RLock()
+	active := dbPath == target
+	mu.RUnlock()
+	if !active {
+		_ = r.Close()
+		return result, nil
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	swap(r, info.ModTime())
+	return result, nil
+}
+
+// Reload reopens the database file, e.g. after another process replaced it
+func Reload() error {
+	mu.RLock()
+	path := dbPath
+	mu.RUnlock()
+	if path == "" {
+		return errors.New("geoip is not initialized")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	r, err := verify(path)
+	if err != nil {
+		return err
+	}
+	swap(r, info.ModTime())
+	return nil
+}
+
+// StartRefresher keeps the loaded database current until ctx is done: it
+// reloads the file when another process (kaunta geoip update, geoipupdate)
+// replaces it, and downloads a new build every interval. An interval of 0
+// only watches the file.
+func StartRefresher(ctx context.Context, interval time.Duration) {
+	mu.RLock()
+	opts := options
+	path := dbPath
+	mu.RUnlock()
+
+	// Download right away when the current build is already older than interval
+	nextDownload := time.Now().Add(interval)
+	if status, err := ReadStatus(path); err != nil || time.Since(status.BuildTime) > interval {
+		nextDownload = time.Now()
+	}
+
+	go func() {
+		ticker := time.NewTicker(diskCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			if interval > 0 && !time.Now().Before(nextDownload) {
+				nextDownload = time.Now().Add(interval)
+				if err := refresh(ctx, opts); err != nil {
+					logging.L().Warn("geoip update failed; keeping current database", zap.Error(err))
+					nextDownload = time.Now().Add(min(interval, retryInterval))
+				}
+			} else {
+				reloadIfChanged()
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func refresh(ctx context.Context, opts Options) error {
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+
+	result, err := Update(ctx, opts, false)
+	if err != nil {
+		return err
+	}
+	if result.Updated {
+		logging.L().Info("geoip database updated",
+			zap.Time("previous_build", result.PreviousBuild),
+			zap.Time("build", result.Build))
+	}
+	return nil
+}
+
+// reloadIfChanged reopens the database when the file changed since it was loaded
+func reloadIfChanged() {
+	mu.RLock()
+	path, loadedAt := dbPath, loaded
+	mu.RUnlock()
+
+	reloadASNIfChanged()
+
+	info, err := os.Stat(path)
+	if err != nil || info.ModTime().Equal(loadedAt) {
+		return
+	}
+	if err := Reload(); err != nil {
+		logging.L().Warn("failed to reload geoip database; keeping current one", zap.Error(err))
+		return
+	}
+	logging.L().Info("geoip database reloaded from disk", zap.String("path", path))
+}
+
+// verify opens a database and checks it is a working City database
+func verify(path string) (*geoip2.Reader, error) {
+	r, err := geoip2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid geoip database: %w", err)
+	}
+	md := r.Metadata()
+	if !strings.Contains(md.DatabaseType, "City") {
+		_ = r.Close()
+		return nil, fmt.Errorf("unexpected geoip database type %q (want a City database)", md.DatabaseType)
+	}
+	if md.BuildEpoch == 0 {
+		_ = r.Close()
+		return nil, errors.New("geoip database has no build date")
+	}
+	if _, err := r.City(net.ParseIP("8.8.8.8")); err != nil {
+		_ = r.Close()
+		return nil, fmt.Errorf("geoip database lookup failed: %w", err)
+	}
+	return r, nil
+}
+
+// fetch writes the database from the configured source to w
+func fetch(ctx context.Context, opts Options, w io.Writer) error {
+	source := opts.Source
+	if source == "" {
+		source = DefaultSource
+		if opts.LicenseKey != "" {
+			source = maxmindDownloadURL + url.QueryEscape(opts.LicenseKey)
+		}
+	}
+
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return download(ctx, source, w)
+	}
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		source = filepath.Join(source, DatabaseFile)
+	}
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return extract(f, w)
+}
+
+func download(ctx context.Context, source string, w io.Writer) error {
+	logging.L().Info("downloading geoip database", zap.String("source", redactURL(source)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return fmt.Errorf("invalid geoip source: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// url.Error repeats the URL, which may carry the license key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logging.L().Warn("failed to close geoip response body", zap.Error(err))
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+	return extract(resp.Body, w)
+}
+
+// redactURL hides the license_key query parameter
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	if q.Has("license_key") {
+		q.Set("license_key", "REDACTED")
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+// extract copies a database to w from a raw .mmdb, gzip (.mmdb.gz) or
+// gzipped tar (.tar.gz, as served by MaxMind) stream
+func extract(r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		br = bufio.NewReader(gz)
+	}
+
+	// tar headers carry "ustar" at offset 257
+	if header, _ := br.Peek(262); len(header) == 262 && string(header[257:262]) == "ustar" {
+		tr := tar.NewReader(br)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return errors.New("no .mmdb file in archive")
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read archive: %w", err)
+			}
+			if hdr.Typeflag == tar.TypeReg && strings.HasSuffix(hdr.Name, ".mmdb") {
+				if _, err := io.Copy(w, tr); err != nil {
+					return fmt.Errorf("failed to write database: %w", err)
+				}
+				return nil
+			}
+		}
+	}
+
+	if _, err := io.Copy(w, br); err != nil {
+		return fmt.Errorf("failed to write database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package geoip
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func tarBytes(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	db := []byte("mmdb-bytes")
+
+	cases := map[string][]byte{
+		"raw":    db,
+		"gzip":   gzipBytes(t, db),
+		"tar.gz": gzipBytes(t, tarBytes(t, map[string][]byte{"GeoLite2-City_20261014/GeoLite2-City.mmdb": db})),
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, extract(bytes.NewReader(input), &out))
+			assert.Equal(t, db, out.Bytes())
+		})
+	}
+
+	var out bytes.Buffer
+	err := extract(bytes.NewReader(gzipBytes(t, tarBytes(t, map[string][]byte{"LICENSE.txt": []byte("x")}))), &out)
+	assert.ErrorContains(t, err, "no .mmdb file")
+}
+
+func TestRedactURL(t *testing.T) {
+	redacted := redactURL(maxmindDownloadURL + "secret123")
+	assert.NotContains(t, redacted, "secret123")
+	assert.Contains(t, redacted, "license_key=REDACTED")
+	assert.Equal(t, DefaultSource, redactURL(DefaultSource))
+}
+
+func TestSourceName(t *testing.T) {
+	assert.Equal(t, DefaultSource, SourceName(Options{}))
+	assert.NotContains(t, SourceName(Options{LicenseKey: "secret123"}), "secret123")
+	assert.Equal(t, "/usr/share/GeoIP", SourceName(Options{Source: "/usr/share/GeoIP", LicenseKey: "secret123"}))
+}
+
+func TestUpdateRejectsInvalidDatabase(t *testing.T) {
+	dataDir := t.TempDir()
+	target := filepath.Join(dataDir, DatabaseFile)
+	require.NoError(t, os.WriteFile(target, []byte("current"), 0644))
+
+	sourceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, DatabaseFile), []byte("not a database"), 0644))
+
+	_, err := Update(context.Background(), Options{DataDir: dataDir, Source: sourceDir}, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid geoip database")
+
+	// The current file is kept and the temporary download removed
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "current", string(data))
+	entries, err := os.ReadDir(dataDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestUpdateMissingSource(t *testing.T) {
+	_, err := Update(context.Background(), Options{DataDir: t.TempDir(), Source: filepath.Join(t.TempDir(), "missing.mmdb")}, false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/httpx"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/middleware"
+	"go.uber.org/zap"
+)
+
+// Attribution models
+const (
+	AttributionFirstTouch    = "first_touch"
+	AttributionLastTouch     = "last_touch"
+	AttributionLinear        = "linear"
+	AttributionPositionBased = "position_based" // 40% first, 40% last, 20% spread over the middle
+)
+
+// AttributionModels lists the accepted models
+var AttributionModels = []string{AttributionFirstTouch, AttributionLastTouch, AttributionLinear, AttributionPositionBased}
+
+// attributionDimensions maps a dimension to the touch value of an event. A visit
+// only counts as a touch when it has a value; referrers from the site itself are
+// internal navigation and never count.
+var attributionDimensions = map[string]string{
+	"campaign": "NULLIF(e.utm_campaign, '')",
+	"source":   "COALESCE(NULLIF(e.utm_source, ''), " + externalReferrerSQL + ")",
+	"medium":   "NULLIF(e.utm_medium, '')",
+	"referrer": externalReferrerSQL,
+}
+
+const externalReferrerSQL = "CASE WHEN e.referrer_domain IS DISTINCT FROM regexp_replace(COALESCE(e.hostname, ''), '^www\\.', '') THEN NULLIF(e.referrer_domain, '') END"
+
+// attributionDirect credits conversions without any touch in the lookback window
+const attributionDirect = "(direct)"
+
+// AttributionQuery selects the conversions and touches of an attribution report
+type AttributionQuery struct {
+	Model     string
+	Dimension string // campaign, source, medium or referrer
+	Scope     string // session, or visitor (sessions sharing a distinct_id)
+	GoalID    *uuid.UUID
+	Days      int // conversions in the last N days
+	Lookback  int // touches up to N days before each conversion
+}
+
+// AttributionRow is the credit a touch value received
+type AttributionRow struct {
+	Name        string  `json:"name"`
+	Conversions float64 `json:"conversions"` // fractional for linear / position-based
+	Share       float64 `json:"share"`       // percent of all conversions
+}
+
+// AttributionReport credits goal completions to touches
+type AttributionReport struct {
+	Model       string           `json:"model"`
+	Dimension   string           `json:"dimension"`
+	Scope       string           `json:"scope"`
+	GoalID      *uuid.UUID       `json:"goal_id,omitempty"`
+	Days        int              `json:"days"`
+	Lookback    int              `json:"lookback_days"`
+	Conversions int64            `json:"conversions"`
+	Rows        []AttributionRow `json:"rows"`
+}
+
+// Normalize applies defaults and validates model, dimension and scope
+func (q *AttributionQuery) Normalize() error {
+	if q.Model == "" {
+		q.Model = AttributionLastTouch
+	}
+	if q.Dimension == "" {
+		q.Dimension = "source"
+	}
+	if q.Scope == "" {
+		q.Scope = "session"
+	}
+	valid := false
+	for _, m := range AttributionModels {
+		valid = valid || m == q.Model
+	}
+	if !valid {
+		return fmt.Errorf("invalid model %q (valid: %s)", q.Model, strings.Join(AttributionModels, ", "))
+	}
+	if _, ok := attributionDimensions[q.Dimension]; !ok {
+		return fmt.Errorf("invalid dimension %q (valid: campaign, source, medium, referrer)", q.Dimension)
+	}
+	if q.Scope != "session" && q.Scope != "visitor" {
+		return fmt.Errorf("invalid scope %q (valid: session, visitor)", q.Scope)
+	}
+	q.Days = min(max(q.Days, 1), 365)
+	if q.Lookback <= 0 {
+		q.Lookback = 30
+	}
+	q.Lookback = min(q.Lookback, 90)
+	return nil
+}
+
+// LoadAttribution credits each goal completion to the touches (one per visit,
+// oldest first) that preceded it within the lookback window
+func LoadAttribution(ctx context.Context, db *sql.DB, websiteID uuid.UUID, q AttributionQuery) (*AttributionReport, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{websiteID, q.Days, q.Lookback}
+	goalFilter := ""
+	if q.GoalID != nil {
+		args = append(args, *q.GoalID)
+		goalFilter = fmt.Sprintf(" AND gc.goal_id = $%d", len(args))
+	}
+
+	sameVisitor := "t.session_id = c.session_id"
+	if q.Scope == "visitor" {
+		sameVisitor = "(t.session_id = c.session_id OR (c.distinct_id <> '' AND t.distinct_id = c.distinct_id))"
+	}
+
+	query := `
+		WITH completions AS (
+			SELECT gc.id, gc.session_id, gc.completed_at, COALESCE(s.distinct_id, '') AS distinct_id
+			FROM goal_completions gc
+			JOIN session s ON s.session_id = gc.session_id
+			WHERE gc.website_id = $1
+			  AND gc.completed_at >= NOW() - INTERVAL '1 day' * $2` + goalFilter + `
+		),
+		touches AS (
+			SELECT DISTINCT ON (e.visit_id)
+				e.session_id, COALESCE(s.distinct_id, '') AS distinct_id, e.created_at,
+				` + attributionDimensions[q.Dimension] + ` AS touch
+			FROM website_event e
+			JOIN session s ON s.session_id = e.session_id
+			WHERE e.website_id = $1
+			  AND e.created_at >= NOW() - INTERVAL '1 day' * ($2 + $3)
+			  AND ` + attributionDimensions[q.Dimension] + ` IS NOT NULL
+			ORDER BY e.visit_id, e.created_at
+		)
+		SELECT c.id, t.touch
+		FROM completions c
+		LEFT JOIN touches t
+			ON ` + sameVisitor + `
+			AND t.created_at <= c.completed_at
+			AND t.created_at >= c.completed_at - INTERVAL '1 day' * $3
+		ORDER BY c.id, t.created_at`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attribution: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var journeys [][]string
+	var current uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		var touch sql.NullString
+		if err := rows.Scan(&id, &touch); err != nil {
+			return nil, err
+		}
+		if len(journeys) == 0 || id != current {
+			journeys = append(journeys, nil)
+			current = id
+		}
+		if touch.Valid {
+			journeys[len(journeys)-1] = append(journeys[len(journeys)-1], touch.String)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := creditConversions(journeys, q.Model)
+	report.Dimension = q.Dimension
+	report.Scope = q.Scope
+	report.GoalID = q.GoalID
+	report.Days = q.Days
+	report.Lookback = q.Lookback
+	return report, nil
+}
+
+// creditConversions distributes one conversion per journey (ordered touches)
+// across its touches according to the model
+func creditConversions(journeys [][]string, model string) *AttributionReport {
+	credit := make(map[string]float64)
+	for _, touches := range journeys {
+		for name, weight := range touchWeights(touches, model) {
+			credit[name] += weight
+		}
+	}
+
+	report := &AttributionReport{
+		Model:       model,
+		Conversions: int64(len(journeys)),
+		Rows:        make([]AttributionRow, 0, len(credit)),
+	}
+	for name, conversions := range credit {
+		row := AttributionRow{Name: name, Conversions: math.Round(conversions*100) / 100}
+		if report.Conversions > 0 {
+			row.Share = math.Round(conversions/float64(report.Conversions)*10000) / 100
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].Conversions != report.Rows[j].Conversions {
+			return report.Rows[i].Conversions > report.Rows[j].Conversions
+		}
+		return report.Rows[i].Name < report.Rows[j].Name
+	})
+	return report
+}
+
+// touchWeights returns each touch value's share of one conversion (summing to 1)
+func touchWeights(touches []string, model string) map[string]float64 {
+	weights := make(map[string]float64)
+	n := len(touches)
+	if n == 0 {
+		weights[attributionDirect] = 1
+		return weights
+	}
+
+	switch model {
+	case AttributionFirstTouch:
+		weights[touches[0]] = 1
+	case AttributionLastTouch:
+		weights[touches[n-1]] = 1
+	case AttributionLinear:
+		for _, t := range touches {
+			weights[t] += 1 / float64(n)
+		}
+	case AttributionPositionBased:
+		switch n {
+		case 1:
+			weights[touches[0]] = 1
+		case 2:
+			weights[touches[0]] += 0.5
+			weights[touches[1]] += 0.5
+		default:
+			weights[touches[0]] += 0.4
+			weights[touches[n-1]] += 0.4
+			for _, t := range touches[1 : n-1] {
+				weights[t] += 0.2 / float64(n-2)
+			}
+		}
+	}
+	return weights
+}
+
+// attributionQueryFromRequest reads the report parameters shared by the dashboard and API
+func attributionQueryFromRequest(r *http.Request) (AttributionQuery, error) {
+	q := AttributionQuery{
+		Model:     httpx.QueryString(r, "model", AttributionLastTouch),
+		Dimension: httpx.QueryString(r, "dimension", "source"),
+		Scope:     httpx.QueryString(r, "scope", "session"),
+		Days:      httpx.QueryInt(r, "days", 30),
+		Lookback:  httpx.QueryInt(r, "lookback", 30),
+	}
+	if goal := r.URL.Query().Get("goal_id"); goal != "" {
+		id, err := uuid.Parse(goal)
+		if err != nil {
+			return q, errors.New("invalid goal_id")
+		}
+		q.GoalID = &id
+	}
+	return q, q.Normalize()
+}
+
+// HandleAttribution returns the attribution card of the Campaigns dashboard via Datastar SSE
+// GET /api/dashboard/attribution?website_id=...&model=first_touch&dimension=campaign&lookback=30
+func HandleAttribution(w http.ResponseWriter, r *http.Request) {
+	websiteIDStr := selectedWebsiteFromRequest(r)
+	if websiteIDStr == "" {
+		websiteIDStr = r.URL.Query().Get("website_id")
+	}
+
+	var parseErr string
+	websiteID, err := uuid.Parse(websiteIDStr)
+	if err != nil {
+		parseErr = "Website ID is required"
+	}
+	q, err := attributionQueryFromRequest(r)
+	if parseErr == "" && err != nil {
+		parseErr = err.Error()
+	}
+
+	var report *AttributionReport
+	var goals []attributionGoal
+	var queryErr error
+	if parseErr == "" {
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+		goals, queryErr = listAttributionGoals(ctx, websiteID)
+		if queryErr == nil {
+			report, queryErr = LoadAttribution(ctx, database.DB, websiteID, q)
+		}
+	}
+
+	streamDatastar(w, func(sse *DatastarSSE) {
+		if parseErr != "" {
+			_ = sse.PatchSignals(map[string]any{
+				"attributionError":   parseErr,
+				"attributionLoading": false,
+			})
+			return
+		}
+
+		if queryErr != nil {
+			logging.L().Warn("failed to load attribution",
+				zap.String("website_id", websiteID.String()),
+				zap.Error(queryErr))
+			_ = sse.PatchSignals(map[string]any{
+				"attributionError":   "Failed to load attribution",
+				"attributionLoading": false,
+			})
+			return
+		}
+
+		_ = sse.PatchElementsWithMode("#attribution-goal", buildAttributionGoalOptions(goals, q.GoalID), "inner")
+		_ = sse.PatchElementsWithMode("#attribution-content", buildAttributionTableHTML(report), "inner")
+		_ = sse.PatchSignals(map[string]any{
+			"attributionLoading": false,
+			"attributionError":   false,
+		})
+	})
+}
+
+// HandleAPIAttribution returns the attribution report as JSON via API key (stats scope)
+// GET /api/v1/stats/:website_id/attribution?model=position_based&dimension=campaign&goal_id=...
+func HandleAPIAttribution(w http.ResponseWriter, r *http.Request) {
+	websiteID, err := uuid.Parse(chi.URLParam(r, "website_id"))
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "Invalid website ID")
+		return
+	}
+
+	apiKey := middleware.GetAPIKey(r)
+	if apiKey == nil {
+		httpx.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if !apiKey.HasScope("stats") {
+		httpx.Error(w, http.StatusForbidden, "API key does not have stats permission")
+		return
+	}
+	if apiKey.WebsiteID != websiteID {
+		httpx.Error(w, http.StatusForbidden, "API key not authorized for this website")
+		return
+	}
+
+	q, err := attributionQueryFromRequest(r)
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	report, err := LoadAttribution(ctx, database.DB, websiteID, q)
+	if err != nil {
+		logging.L().Warn("failed to load attribution", zap.String("website_id", websiteID.String()), zap.Error(err))
+		httpx.Error(w, http.StatusInternalServerError, "Failed to fetch attribution")
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, report)
+}
+
+type attributionGoal struct {
+	ID   uuid.UUID
+	Name string
+}
+
+func listAttributionGoals(ctx context.Context, websiteID uuid.UUID) ([]attributionGoal, error) {
+	rows, err := database.DB.QueryContext(ctx,
+		`SELECT id, name FROM goals WHERE website_id = $1 ORDER BY name`, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var goals []attributionGoal
+	for rows.Next() {
+		var g attributionGoal
+		if err := rows.Scan(&g.ID, &g.Name); err != nil {
+			return nil, err
+		}
+		goals = append(goals, g)
+	}
+	return goals, rows.Err()
+}
+
+func buildAttributionGoalOptions(goals []attributionGoal, selected *uuid.UUID) string {
+	var b strings.Builder
+	b.WriteString(`<option value="">All goals</option>`)
+	for _, g := range goals {
+		attr := ""
+		if selected != nil && *selected == g.ID {
+			attr = " selected"
+		}
+		fmt.Fprintf(&b, `<option value="%s"%s>%s</option>`, g.ID, attr, escapeHTML(g.Name))
+	}
+	return b.String()
+}
+
+func buildAttributionTableHTML(report *AttributionReport) string {
+	if report.Conversions == 0 {
+		return `<div class="empty-state-mini"><div>[=]</div><div>No goal completions in this period</div></div>`
+	}
+
+	label := strings.ToUpper(report.Dimension[:1]) + report.Dimension[1:]
+	var rows strings.Builder
+	for _, row := range report.Rows {
+		fmt.Fprintf(&rows, `<tr><td>%s</td><td style="text-align:right;font-weight:500;color:var(--accent-color)">%s</td><td style="text-align:right">%.1f%%</td></tr>`,
+			escapeHTML(row.Name), strconv.FormatFloat(row.Conversions, 'f', -1, 64), row.Share)
+	}
+
+	return fmt.Sprintf(`<table class="glass card"><thead><tr><th>%s</th><th style="text-align:right">Conversions</th><th style="text-align:right">Share</th></tr></thead><tbody>%s</tbody></table>`,
+		label, rows.String())
+}
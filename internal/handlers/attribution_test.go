@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTouchWeights(t *testing.T) {
+	journey := []string{"google", "newsletter", "twitter", "google"}
+
+	assert.Equal(t, map[string]float64{"google": 1}, touchWeights(journey, AttributionFirstTouch))
+	assert.Equal(t, map[string]float64{"google": 1}, touchWeights(journey[:3], AttributionFirstTouch))
+	assert.Equal(t, map[string]float64{"twitter": 1}, touchWeights(journey[:3], AttributionLastTouch))
+
+	linear := touchWeights(journey, AttributionLinear)
+	assert.InDelta(t, 0.5, linear["google"], 1e-9)
+	assert.InDelta(t, 0.25, linear["newsletter"], 1e-9)
+
+	position := touchWeights(journey, AttributionPositionBased)
+	assert.InDelta(t, 0.8, position["google"], 1e-9)
+	assert.InDelta(t, 0.1, position["newsletter"], 1e-9)
+	assert.InDelta(t, 0.1, position["twitter"], 1e-9)
+
+	assert.Equal(t, map[string]float64{"a": 0.5, "b": 0.5}, touchWeights([]string{"a", "b"}, AttributionPositionBased))
+	assert.Equal(t, map[string]float64{attributionDirect: 1}, touchWeights(nil, AttributionLinear))
+}
+
+func TestCreditConversions(t *testing.T) {
+	report := creditConversions([][]string{
+		{"google", "newsletter"},
+		{"newsletter"},
+		nil,
+	}, AttributionLinear)
+
+	assert.Equal(t, int64(3), report.Conversions)
+	require.Len(t, report.Rows, 3)
+	assert.Equal(t, AttributionRow{Name: "newsletter", Conversions: 1.5, Share: 50}, report.Rows[0])
+	assert.Equal(t, AttributionRow{Name: "(direct)", Conversions: 1, Share: 33.33}, report.Rows[1])
+	assert.Equal(t, AttributionRow{Name: "google", Conversions: 0.5, Share: 16.67}, report.Rows[2])
+}
+
+func TestAttributionQueryNormalize(t *testing.T) {
+	q := AttributionQuery{}
+	require.NoError(t, q.Normalize())
+	assert.Equal(t, AttributionLastTouch, q.Model)
+	assert.Equal(t, "source", q.Dimension)
+	assert.Equal(t, "session", q.Scope)
+	assert.Equal(t, 30, q.Lookback)
+
+	assert.Error(t, (&AttributionQuery{Model: "u_shaped"}).Normalize())
+	assert.Error(t, (&AttributionQuery{Dimension: "browser"}).Normalize())
+	assert.Error(t, (&AttributionQuery{Scope: "account"}).Normalize())
+}
+
+func TestLoadAttributionGroupsTouchesPerCompletion(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	queue := newMockQueue([]mockResponse{{
+		match:   "FROM completions c LEFT JOIN touches t",
+		columns: []string{"id", "touch"},
+		rows: [][]interface{}{
+			{first.String(), "spring-sale"},
+			{first.String(), "retargeting"},
+			{second.String(), nil},
+		},
+	}})
+	driverName, err := registerMockDriver(queue)
+	require.NoError(t, err)
+	db, err := sql.Open(driverName, "")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	report, err := LoadAttribution(context.Background(), db, uuid.New(), AttributionQuery{
+		Model:     AttributionFirstTouch,
+		Dimension: "campaign",
+		Scope:     "visitor",
+		Days:      30,
+	})
+	require.NoError(t, err)
+	require.NoError(t, queue.expectationsMet())
+
+	assert.Equal(t, int64(2), report.Conversions)
+	assert.Equal(t, []AttributionRow{
+		{Name: "(direct)", Conversions: 1, Share: 50},
+		{Name: "spring-sale", Conversions: 1, Share: 50},
+	}, report.Rows)
+	assert.Equal(t, "visitor", report.Scope)
+}
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/geoip"
+	"github.com/seuros/kaunta/internal/httpx"
+	"github.com/seuros/kaunta/internal/logging"
+)
+
+// BotTypeAI is the bot_user_agent_patterns type of AI crawlers and agents
+const BotTypeAI = "llm_crawler"
+
+const (
+	botsUnknownLabel = "(unknown)"
+	maxBotsTop       = 100
+	maxBotsDays      = 30 // bot_detection_log retention
+)
+
+// ipVerdict is what update_ip_metadata decided about a request's IP
+type ipVerdict struct {
+	Bot     bool    // drop the hit: known bot, blocklisted IP, or excluded hosting provider
+	Hosting bool    // hosting provider traffic flagged by the website's datacenter policy
+	ASN     *int64  // nil without an ASN database
+	ASNOrg  *string // autonomous system organisation
+}
+
+// checkRequestIP runs update_ip_metadata, which updates the IP's request
+// counters and ASN, applies the website's datacenter policy and, for bots,
+// logs the hit with the website and page to bot_detection_log. seenAt is the time
+// of a historical hit (log import), which skips the live request counters; nil
+// for live requests
+func checkRequestIP(ctx context.Context, websiteID uuid.UUID, ip, userAgent, pageURL string, seenAt *time.Time) ipVerdict {
+	var v ipVerdict
+	if asn, org := geoip.LookupASN(ip); asn != 0 {
+		n := int64(asn)
+		v.ASN = &n
+		if org != "" {
+			v.ASNOrg = &org
+		}
+	}
+
+	var drop, flag *bool
+	if err := database.DB.QueryRowContext(ctx, `
+		SELECT drop_hit, flag_hosting FROM update_ip_metadata($1::inet, $2, NULL, $3, $4, $5, $6, $7)
+	`, ip, userAgent, websiteID, botURLPath(pageURL), v.ASN, v.ASNOrg, seenAt).Scan(&drop, &flag); err != nil {
+		logging.L().Warn("bot detection error", zap.String("ip", ip), zap.Error(err))
+		return v
+	}
+	v.Bot = drop != nil && *drop
+	v.Hosting = flag != nil && *flag
+	return v
+}
+
+// botURLPath returns the path of a page URL (or request URI), nil when absent
+func botURLPath(pageURL string) *string {
+	if pageURL == "" {
+		return nil
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Path == "" {
+		return nil
+	}
+	return &u.Path
+}
+
+// BotsQuery selects the period and bot type of a bots report
+type BotsQuery struct {
+	Days    int
+	BotType string // empty for all; "ai" is accepted for llm_crawler
+	Top     int    // patterns and pages listed
+}
+
+// BotTypeCount is the number of hits per bot type
+type BotTypeCount struct {
+	BotType string `json:"bot_type"`
+	Hits    int64  `json:"hits"`
+	IPs     int64  `json:"ips"`
+}
+
+// BotPatternCount is the number of hits per matched user agent pattern
+type BotPatternCount struct {
+	Pattern string `json:"pattern"`
+	BotType string `json:"bot_type"`
+	Hits    int64  `json:"hits"`
+	IPs     int64  `json:"ips"`
+}
+
+// BotPageCount is the number of bot hits on a page and the patterns seen
+type BotPageCount struct {
+	Path     string `json:"path"`
+	Hits     int64  `json:"hits"`
+	AIHits   int64  `json:"ai_hits"`
+	Patterns string `json:"patterns"`
+}
+
+// BotDayCount is the number of bot hits per day
+type BotDayCount struct {
+	Day    time.Time `json:"day"`
+	Hits   int64     `json:"hits"`
+	AIHits int64     `json:"ai_hits"`
+}
+
+// BotsReport is the crawler and AI agent traffic of a website
+type BotsReport struct {
+	Days      int               `json:"days"`
+	BotType   string            `json:"bot_type,omitempty"`
+	Hits      int64             `json:"hits"`
+	AIHits    int64             `json:"ai_hits"`
+	ByType    []BotTypeCount    `json:"by_type"`
+	ByPattern []BotPatternCount `json:"by_pattern"`
+	ByPage    []BotPageCount    `json:"by_page"`
+	ByDay     []BotDayCount     `json:"by_day"`
+}
+
+// Normalize applies defaults and bounds
+func (q *BotsQuery) Normalize() {
+	q.Days = min(max(q.Days, 1), maxBotsDays)
+	if q.Top <= 0 {
+		q.Top = 10
+	}
+	q.Top = min(q.Top, maxBotsTop)
+	q.BotType = strings.ToLower(strings.TrimSpace(q.BotType))
+	if q.BotType == "ai" {
+		q.BotType = BotTypeAI
+	}
+}
+
+// LoadBots aggregates bot_detection_log for a website
+func LoadBots(ctx context.Context, db *sql.DB, websiteID uuid.UUID, q BotsQuery) (*BotsReport, error) {
+	q.Normalize()
+
+	args := []any{websiteID, q.Days}
+	where := `website_id = $1 AND detected_at >= NOW() - INTERVAL '1 day' * $2`
+	if q.BotType != "" {
+		args = append(args, q.BotType)
+		where += fmt.Sprintf(" AND pattern_type = $%d", len(args))
+	}
+
+	report := &BotsReport{
+		Days:      q.Days,
+		BotType:   q.BotType,
+		ByType:    []BotTypeCount{},
+		ByPattern: []BotPatternCount{},
+		ByPage:    []BotPageCount{},
+		ByDay:     []BotDayCount{},
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT pattern_type, COUNT(*), COUNT(DISTINCT ip)
+		FROM bot_detection_log
+		WHERE `+where+`
+		GROUP BY pattern_type
+		ORDER BY 2 DESC, 1`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bot types: %w", err)
+	}
+	err = scanBotRows(rows, func() error {
+		var c BotTypeCount
+		if err := rows.Scan(&c.BotType, &c.Hits, &c.IPs); err != nil {
+			return err
+		}
+		report.ByType = append(report.ByType, c)
+		report.Hits += c.Hits
+		if c.BotType == BotTypeAI {
+			report.AIHits += c.Hits
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT COALESCE(pattern_name, '`+botsUnknownLabel+`'), pattern_type, COUNT(*), COUNT(DISTINCT ip)
+		FROM bot_detection_log
+		WHERE `+where+`
+		GROUP BY 1, 2
+		ORDER BY 3 DESC, 1
+		LIMIT `+fmt.Sprint(q.Top), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bot patterns: %w", err)
+	}
+	err = scanBotRows(rows, func() error {
+		var c BotPatternCount
+		if err := rows.Scan(&c.Pattern, &c.BotType, &c.Hits, &c.IPs); err != nil {
+			return err
+		}
+		report.ByPattern = append(report.ByPattern, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT COALESCE(url_path, '`+botsUnknownLabel+`'), COUNT(*),
+		       COUNT(*) FILTER (WHERE pattern_type = '`+BotTypeAI+`'),
+		       COALESCE(string_agg(DISTINCT pattern_name, ', '), '')
+		FROM bot_detection_log
+		WHERE `+where+`
+		GROUP BY 1
+		ORDER BY 2 DESC, 1
+		LIMIT `+fmt.Sprint(q.Top), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bot pages: %w", err)
+	}
+	err = scanBotRows(rows, func() error {
+		var c BotPageCount
+		if err := rows.Scan(&c.Path, &c.Hits, &c.AIHits, &c.Patterns); err != nil {
+			return err
+		}
+		report.ByPage = append(report.ByPage, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT DATE(detected_at), COUNT(*),
+		       COUNT(*) FILTER (WHERE pattern_type = '`+BotTypeAI+`')
+		FROM bot_detection_log
+		WHERE `+where+`
+		GROUP BY 1
+		ORDER BY 1`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bot days: %w", err)
+	}
+	err = scanBotRows(rows, func() error {
+		var c BotDayCount
+		if err := rows.Scan(&c.Day, &c.Hits, &c.AIHits); err != nil {
+			return err
+		}
+		report.ByDay = append(report.ByDay, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func scanBotRows(rows *sql.Rows, scan func() error) error {
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		if err := scan(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// HandleBots returns the bots report via Datastar SSE
+// GET /api/dashboard/bots?website_id=...&days=7&type=llm_crawler
+func HandleBots(w http.ResponseWriter, r *http.Request) {
+	websiteIDStr := selectedWebsiteFromRequest(r)
+	if websiteIDStr == "" {
+		websiteIDStr = r.URL.Query().Get("website_id")
+	}
+
+	var parseErr string
+	var websiteID uuid.UUID
+	if websiteIDStr == "" {
+		parseErr = "Website ID is required"
+	} else {
+		var err error
+		websiteID, err = uuid.Parse(websiteIDStr)
+		if err != nil {
+			parseErr = "Invalid website ID"
+		}
+	}
+
+	q := BotsQuery{
+		Days:    httpx.QueryInt(r, "days", 7),
+		BotType: r.URL.Query().Get("type"),
+		Top:     httpx.QueryInt(r, "top", 20),
+	}
+
+	var report *BotsReport
+	var queryErr error
+	if parseErr == "" {
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+		report, queryErr = LoadBots(ctx, database.DB, websiteID, q)
+	}
+
+	streamDatastar(w, func(sse *DatastarSSE) {
+		if parseErr != "" {
+			_ = sse.PatchSignals(map[string]any{
+				"botsError":   parseErr,
+				"botsLoading": false,
+			})
+			return
+		}
+
+		if queryErr != nil {
+			logging.L().Warn("failed to load bots",
+				zap.String("website_id", websiteID.String()),
+				zap.Error(queryErr))
+			_ = sse.PatchSignals(map[string]any{
+				"botsError":   "Failed to load bot traffic",
+				"botsLoading": false,
+			})
+			return
+		}
+
+		_ = sse.PatchElementsWithMode("#bots-content", buildBotsHTML(report), "inner")
+		_ = sse.PatchSignals(map[string]any{
+			"botsLoading": false,
+			"botsError":   false,
+		})
+	})
+}
+
+// buildBotsHTML renders the summary, per-day bars and the type, pattern and page tables
+func buildBotsHTML(report *BotsReport) string {
+	if report.Hits == 0 {
+		return `<div class="empty-state-mini"><div>[empty]</div><div>No bot hits recorded in the selected period</div></div>`
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div class="bots-summary">`)
+	b.WriteString(fmt.Sprintf(`<div class="bots-stat glass"><div class="bots-stat-value">%s</div><div class="bots-stat-label">Bot hits</div></div>`,
+		formatNumber(int(report.Hits))))
+	b.WriteString(fmt.Sprintf(`<div class="bots-stat glass"><div class="bots-stat-value">%s</div><div class="bots-stat-label">AI crawler and agent hits</div></div>`,
+		formatNumber(int(report.AIHits))))
+	b.WriteString(`</div>`)
+
+	var maxDay int64
+	for _, d := range report.ByDay {
+		maxDay = max(maxDay, d.Hits)
+	}
+	b.WriteString(`<h3>By day</h3><div class="bots-days">`)
+	for _, d := range report.ByDay {
+		height := float64(d.Hits) / float64(maxDay) * 100
+		aiHeight := 0.0
+		if d.Hits > 0 {
+			aiHeight = float64(d.AIHits) / float64(d.Hits) * 100
+		}
+		b.WriteString(fmt.Sprintf(`<div class="bots-day" title="%s: %s hits, %s AI">`+
+			`<div class="bots-day-bar" style="height: %.0f%%"><div class="bots-day-ai" style="height: %.0f%%"></div></div>`+
+			`<div class="bots-day-label">%s</div></div>`,
+			d.Day.Format("2006-01-02"), formatNumber(int(d.Hits)), formatNumber(int(d.AIHits)),
+			height, aiHeight, d.Day.Format("01-02")))
+	}
+	b.WriteString(`</div>`)
+
+	b.WriteString(`<h3>By bot type</h3><table class="glass card"><thead><tr><th>Type</th><th>Hits</th><th>IPs</th></tr></thead><tbody>`)
+	for _, c := range report.ByType {
+		b.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%s</td><td>%s</td></tr>`,
+			escapeHTML(c.BotType), formatNumber(int(c.Hits)), formatNumber(int(c.IPs))))
+	}
+	b.WriteString(`</tbody></table>`)
+
+	b.WriteString(`<h3>By pattern</h3><table class="glass card"><thead><tr><th>Pattern</th><th>Type</th><th>Hits</th><th>IPs</th></tr></thead><tbody>`)
+	for _, c := range report.ByPattern {
+		b.WriteString(fmt.Sprintf(`<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>`,
+			escapeHTML(c.Pattern), escapeHTML(c.BotType), formatNumber(int(c.Hits)), formatNumber(int(c.IPs))))
+	}
+	b.WriteString(`</tbody></table>`)
+
+	b.WriteString(`<h3>By page</h3><table class="glass card"><thead><tr><th>Page</th><th>Hits</th><th>AI hits</th><th>Patterns</th></tr></thead><tbody>`)
+	for _, c := range report.ByPage {
+		b.WriteString(fmt.Sprintf(`<tr><td class="bots-path">%s</td><td>%s</td><td>%s</td><td>%s</td></tr>`,
+			escapeHTML(c.Path), formatNumber(int(c.Hits)), formatNumber(int(c.AIHits)), escapeHTML(c.Patterns)))
+	}
+	b.WriteString(`</tbody></table>`)
+
+	return b.String()
+}
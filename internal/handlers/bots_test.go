@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotsQueryNormalize(t *testing.T) {
+	q := BotsQuery{Days: 400, Top: 0, BotType: " AI "}
+	q.Normalize()
+	assert.Equal(t, maxBotsDays, q.Days)
+	assert.Equal(t, 10, q.Top)
+	assert.Equal(t, BotTypeAI, q.BotType)
+
+	q = BotsQuery{Days: 0, Top: 500, BotType: "scraper"}
+	q.Normalize()
+	assert.Equal(t, 1, q.Days)
+	assert.Equal(t, maxBotsTop, q.Top)
+	assert.Equal(t, "scraper", q.BotType)
+}
+
+func TestBotURLPath(t *testing.T) {
+	assert.Nil(t, botURLPath(""))
+	assert.Nil(t, botURLPath("https://example.com"))
+	require.NotNil(t, botURLPath("/docs?page=2"))
+	assert.Equal(t, "/docs", *botURLPath("/docs?page=2"))
+	assert.Equal(t, "/pricing", *botURLPath("https://example.com/pricing#plans"))
+}
+
+func TestLoadBots(t *testing.T) {
+	day := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	queue := newMockQueue([]mockResponse{
+		{
+			match:   "GROUP BY pattern_type",
+			columns: []string{"pattern_type", "hits", "ips"},
+			rows: [][]interface{}{
+				{"llm_crawler", int64(12), int64(3)},
+				{"generic_bot", int64(8), int64(5)},
+			},
+		},
+		{
+			match:   "COALESCE(pattern_name",
+			columns: []string{"pattern_name", "pattern_type", "hits", "ips"},
+			rows:    [][]interface{}{{"GPTBot", "llm_crawler", int64(12), int64(3)}},
+		},
+		{
+			match:   "COALESCE(url_path",
+			columns: []string{"url_path", "hits", "ai_hits", "patterns"},
+			rows:    [][]interface{}{{"/docs", int64(15), int64(10), "GPTBot, Googlebot"}},
+		},
+		{
+			match:   "DATE(detected_at)",
+			columns: []string{"day", "hits", "ai_hits"},
+			rows:    [][]interface{}{{day, int64(20), int64(12)}},
+		},
+	})
+	driverName, err := registerMockDriver(queue)
+	require.NoError(t, err)
+	db, err := sql.Open(driverName, "")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	report, err := LoadBots(context.Background(), db, uuid.New(), BotsQuery{Days: 7})
+	require.NoError(t, err)
+	require.NoError(t, queue.expectationsMet())
+
+	assert.Equal(t, int64(20), report.Hits)
+	assert.Equal(t, int64(12), report.AIHits)
+	assert.Len(t, report.ByType, 2)
+	assert.Equal(t, "GPTBot", report.ByPattern[0].Pattern)
+	assert.Equal(t, int64(10), report.ByPage[0].AIHits)
+	assert.Equal(t, day, report.ByDay[0].Day)
+}
+
+func TestBuildBotsHTML(t *testing.T) {
+	html := buildBotsHTML(&BotsReport{Days: 7})
+	assert.Contains(t, html, "No bot hits")
+
+	html = buildBotsHTML(&BotsReport{
+		Days:      7,
+		Hits:      5,
+		AIHits:    2,
+		ByType:    []BotTypeCount{{BotType: BotTypeAI, Hits: 2, IPs: 1}},
+		ByPattern: []BotPatternCount{{Pattern: "GPTBot", BotType: BotTypeAI, Hits: 2, IPs: 1}},
+		ByPage:    []BotPageCount{{Path: "/<script>", Hits: 5, AIHits: 2, Patterns: "GPTBot"}},
+	})
+	assert.Contains(t, html, "GPTBot")
+	assert.Contains(t, html, "&lt;script&gt;")
+	assert.NotContains(t, html, "/<script>")
+}
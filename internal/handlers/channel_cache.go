@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/seuros/kaunta/internal/channels"
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/logging"
+	"go.uber.org/zap"
+)
+
+// maxSessionSourceSize matches session.source; referrer hosts and utm_source can be longer
+const maxSessionSourceSize = 100
+
+// channelRuleCache holds each website's custom channel rules
+var channelRuleCache = newTTLCache(settingsCacheTTL, loadChannelRules)
+
+// loadChannelRules fetches a website's custom channel rules in creation order
+func loadChannelRules(ctx context.Context, websiteID uuid.UUID) ([]channels.Rule, error) {
+	rows, err := database.DB.QueryContext(ctx, `
+		SELECT pattern, source, channel
+		FROM channel_rules
+		WHERE website_id = $1
+		ORDER BY created_at
+	`, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var rules []channels.Rule
+	for rows.Next() {
+		var r channels.Rule
+		if err := rows.Scan(&r.Pattern, &r.Source, &r.Channel); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// classifySessionTraffic returns the source and channel stored on a new session.
+// A referrer from the tracked page's own host counts as no referrer.
+func classifySessionTraffic(websiteID uuid.UUID, pageURL, referrer string, utmSource, utmMedium *string) (*string, *string) {
+	touch := channels.Touch{ReferrerDomain: referrerHost(referrer)}
+	if touch.ReferrerDomain != "" && touch.ReferrerDomain == referrerHost(pageURL) {
+		touch.ReferrerDomain = ""
+	}
+	if utmSource != nil {
+		touch.UTMSource = *utmSource
+	}
+	if utmMedium != nil {
+		touch.UTMMedium = *utmMedium
+	}
+
+	rules, err := channelRuleCache.Get(websiteID)
+	if err != nil {
+		// Fall back to the built-in rules
+		logging.L().Warn("failed to load channel rules",
+			zap.String("website_id", websiteID.String()), zap.Error(err))
+	}
+
+	result := channels.Classify(touch, rules)
+	source := truncateString(result.Source, maxSessionSourceSize)
+	return &source, &result.Channel
+}
+
+func referrerHost(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	host := channels.NormalizeHost(u.Hostname())
+	if host == "localhost" {
+		return ""
+	}
+	return host
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReferrerHost(t *testing.T) {
+	assert.Equal(t, "google.com", referrerHost("https://www.google.com/search?q=kaunta"))
+	assert.Equal(t, "com.slack", referrerHost("android-app://com.slack/"))
+	assert.Equal(t, "", referrerHost("http://localhost:3000/"))
+	assert.Equal(t, "", referrerHost(""))
+}
+
+func TestClassifySessionTrafficTruncatesSource(t *testing.T) {
+	websiteID := uuid.New()
+	channelRuleCache.Set(websiteID, nil)
+	t.Cleanup(func() { channelRuleCache.Invalidate(websiteID) })
+
+	longSource := strings.Repeat("é", 300)
+	source, channel := classifySessionTraffic(websiteID, "https://example.com/", "", &longSource, nil)
+
+	require.NotNil(t, source)
+	require.NotNil(t, channel)
+	assert.Equal(t, maxSessionSourceSize, utf8.RuneCountInString(*source))
+	assert.True(t, utf8.ValidString(*source))
+
+	longHost := "https://" + strings.Repeat("a", 60) + "." + strings.Repeat("b", 60) + ".example.org/"
+	source, _ = classifySessionTraffic(websiteID, "https://example.com/", longHost, nil, nil)
+	require.NotNil(t, source)
+	assert.LessOrEqual(t, utf8.RuneCountInString(*source), maxSessionSourceSize)
+}
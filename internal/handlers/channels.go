@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/seuros/kaunta/internal/database"
+)
+
+// ASNLabelColumn labels a session's network, e.g. "AS16509 Amazon.com, Inc.";
+// NULL without an ASN database
+const ASNLabelColumn = "CASE WHEN s.asn IS NOT NULL THEN TRIM('AS' || s.asn || ' ' || COALESCE(s.asn_org, '')) END"
+
+// channelBreakdownColumns maps the session-level traffic dimensions to their column
+var channelBreakdownColumns = map[string]string{
+	"channel": "s.channel",
+	"source":  "s.source",
+	"asn":     ASNLabelColumn,
+}
+
+// queryChannelBreakdown counts sessions per traffic channel or normalized source.
+// It mirrors get_breakdown's session dimensions (entry_page, exit_page) and adds
+// the channel filter, e.g. sources within Social. The page filter keeps
+// sessions that viewed that page.
+func queryChannelBreakdown(websiteID uuid.UUID, dimension string, pagination PaginationParams,
+	channel, country, browser, device, page, hasError interface{}) ([]BreakdownItem, int64, error) {
+	column, ok := channelBreakdownColumns[dimension]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid channel dimension: %s", dimension)
+	}
+
+	orderColumn := "dim_count"
+	if pagination.SortBy == "name" {
+		orderColumn = "dim_name"
+	}
+	orderDirection := "DESC"
+	if pagination.SortOrder == SortAsc {
+		orderDirection = "ASC"
+	}
+	orderBy := orderColumn + " " + orderDirection + ", dim_name"
+
+	rows, err := database.DB.Query(fmt.Sprintf(`
+		WITH breakdown_data AS (
+			SELECT COALESCE(%[1]s, 'Unknown')::VARCHAR AS dim_name, COUNT(*)::BIGINT AS dim_count
+			FROM session s
+			WHERE s.website_id = $1
+			  AND s.created_at >= CURRENT_DATE - INTERVAL '1 day'
+			  AND ($4::VARCHAR IS NULL OR s.channel = $4)
+			  AND ($5::VARCHAR IS NULL OR s.country = $5)
+			  AND ($6::VARCHAR IS NULL OR s.browser = $6)
+			  AND ($7::VARCHAR IS NULL OR s.device = $7)
+			  AND ($8::BOOLEAN IS NULL OR s.has_error = $8)
+			  AND ($9::VARCHAR IS NULL OR s.session_id IN (
+			        SELECT e.session_id FROM website_event e
+			        WHERE e.website_id = $1
+			          AND e.created_at >= CURRENT_DATE - INTERVAL '1 day'
+			          AND e.event_type = 1
+			          AND e.url_path = $9))
+			GROUP BY 1
+		)
+		SELECT dim_name, dim_count, COUNT(*) OVER ()
+		FROM breakdown_data
+		ORDER BY %[2]s
+		LIMIT $2 OFFSET $3
+	`, column, orderBy), websiteID, pagination.Per, pagination.Offset, channel, country, browser, device, hasError, page)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := make([]BreakdownItem, 0)
+	var total int64
+	for rows.Next() {
+		var item BreakdownItem
+		var count int64
+		if err := rows.Scan(&item.Name, &count, &total); err != nil {
+			return nil, 0, err
+		}
+		item.Count = int(count)
+		items = append(items, item)
+	}
+	return items, total, rows.Err()
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"github.com/google/uuid"
+	"github.com/seuros/kaunta/internal/database"
+)
+
+// ungroupedContentLabel names pageviews no content group rule matched
+const ungroupedContentLabel = "(ungrouped)"
+
+// queryContentGroupBreakdown counts pageviews per content group, with the same
+// filters and sorting as get_breakdown
+func queryContentGroupBreakdown(websiteID uuid.UUID, pagination PaginationParams,
+	channel, country, browser, device, page, hasError interface{}) ([]BreakdownItem, int64, error) {
+	orderColumn := "dim_count"
+	if pagination.SortBy == "name" {
+		orderColumn = "dim_name"
+	}
+	orderDirection := "DESC"
+	if pagination.SortOrder == SortAsc {
+		orderDirection = "ASC"
+	}
+
+	rows, err := database.DB.Query(`
+		WITH breakdown_data AS (
+			SELECT COALESCE(e.content_group, '`+ungroupedContentLabel+`')::VARCHAR AS dim_name, COUNT(*)::BIGINT AS dim_count
+			FROM website_event e
+			JOIN session s ON e.session_id = s.session_id
+			WHERE e.website_id = $1
+			  AND e.created_at >= CURRENT_DATE - INTERVAL '1 day'
+			  AND e.event_type = 1
+			  AND ($4::VARCHAR IS NULL OR s.country = $4)
+			  AND ($5::VARCHAR IS NULL OR s.browser = $5)
+			  AND ($6::VARCHAR IS NULL OR s.device = $6)
+			  AND ($7::VARCHAR IS NULL OR e.url_path = $7)
+			  AND ($8::BOOLEAN IS NULL OR s.has_error = $8)
+			  AND ($9::VARCHAR IS NULL OR s.channel = $9)
+			GROUP BY 1
+		)
+		SELECT dim_name, dim_count, COUNT(*) OVER ()
+		FROM breakdown_data
+		ORDER BY `+orderColumn+` `+orderDirection+`, dim_name
+		LIMIT $2 OFFSET $3
+	`, websiteID, pagination.Per, pagination.Offset, country, browser, device, page, hasError, channel)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := make([]BreakdownItem, 0)
+	var total int64
+	for rows.Next() {
+		var item BreakdownItem
+		var count int64
+		if err := rows.Scan(&item.Name, &count, &total); err != nil {
+			return nil, 0, err
+		}
+		item.Count = int(count)
+		items = append(items, item)
+	}
+	return items, total, rows.Err()
+}
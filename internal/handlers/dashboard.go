@@ -7,12 +7,15 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/seuros/kaunta/internal/channels"
 	"github.com/seuros/kaunta/internal/database"
 	"github.com/seuros/kaunta/internal/httpx"
 	"github.com/seuros/kaunta/internal/middleware"
@@ -281,8 +284,17 @@ func HandleDashboardInit(w http.ResponseWriter, r *http.Request) {
 	flush()
 }
 
+// erroredSessionsParam narrows dashboard queries to sessions that reported a
+// JavaScript error (?has_error=true); NULL leaves them unfiltered
+func erroredSessionsParam(query url.Values) interface{} {
+	if hasError, _ := strconv.ParseBool(query.Get("has_error")); hasError {
+		return true
+	}
+	return nil
+}
+
 // HandleDashboardStats returns dashboard stats via Datastar SSE
-// GET /api/dashboard/stats
+// GET /api/dashboard/stats?website=...&country=...&browser=...&device=...&page=...&has_error=true
 func HandleDashboardStats(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	websiteIDStr := query.Get("website_id")
@@ -321,6 +333,7 @@ func HandleDashboardStats(w http.ResponseWriter, r *http.Request) {
 	if page != "" {
 		pageParam = page
 	}
+	hasErrorParam := erroredSessionsParam(query)
 
 	// Query database BEFORE streaming
 	var currentVisitors, todayPageviews, todayVisitors int64
@@ -328,7 +341,7 @@ func HandleDashboardStats(w http.ResponseWriter, r *http.Request) {
 	var queryErr error
 
 	if parseErr == "" {
-		query := `SELECT * FROM get_dashboard_stats($1, 1, $2, $3, $4, $5)`
+		query := `SELECT * FROM get_dashboard_stats($1, 1, $2, $3, $4, $5, $6)`
 		queryErr = database.DB.QueryRow(
 			query,
 			websiteID,
@@ -336,6 +349,7 @@ func HandleDashboardStats(w http.ResponseWriter, r *http.Request) {
 			browserParam,
 			deviceParam,
 			pageParam,
+			hasErrorParam,
 		).Scan(&currentVisitors, &todayPageviews, &todayVisitors, &bounceRateNumeric)
 	}
 
@@ -376,7 +390,7 @@ func HandleDashboardStats(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleTimeSeries returns time series data via Datastar SSE
-// GET /api/dashboard/timeseries-ds?website_id=...&days=7&country=...&browser=...&device=...&page=...
+// GET /api/dashboard/timeseries-ds?website_id=...&days=7&country=...&browser=...&device=...&page=...&has_error=true
 // Also supports: website (alias for website_id)
 func HandleTimeSeries(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
@@ -420,13 +434,14 @@ func HandleTimeSeries(w http.ResponseWriter, r *http.Request) {
 	if page != "" {
 		pageParam = page
 	}
+	hasErrorParam := erroredSessionsParam(query)
 
 	// Query database BEFORE streaming
 	var points []TimeSeriesPoint
 	var queryErr error
 
 	if parseErr == "" {
-		query := `SELECT * FROM get_timeseries($1, $2, $3, $4, $5, $6)`
+		query := `SELECT * FROM get_timeseries($1, $2, $3, $4, $5, $6, $7)`
 		rows, err := database.DB.Query(
 			query,
 			websiteID,
@@ -435,6 +450,7 @@ func HandleTimeSeries(w http.ResponseWriter, r *http.Request) {
 			browserParam,
 			deviceParam,
 			pageParam,
+			hasErrorParam,
 		)
 		if err != nil {
 			queryErr = err
@@ -500,7 +516,7 @@ func HandleTimeSeries(w http.ResponseWriter, r *http.Request) {
 }
 
 // HandleBreakdown returns breakdown data via Datastar SSE
-// GET /api/dashboard/breakdown
+// GET /api/dashboard/breakdown?website=...&tab=...&country=...&browser=...&device=...&page=...&channel=...&has_error=true
 func HandleBreakdown(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	datastarParam := query.Get("datastar")
@@ -556,23 +572,27 @@ func HandleBreakdown(w http.ResponseWriter, r *http.Request) {
 	}
 
 	dimensionMap := map[string]string{
-		"pages":        "pages",
-		"referrers":    "referrer",
-		"browsers":     "browser",
-		"devices":      "device",
-		"countries":    "country",
-		"cities":       "city",
-		"regions":      "region",
-		"os":           "os",
-		"utm_source":   "utm_source",
-		"utm_medium":   "utm_medium",
-		"utm_campaign": "utm_campaign",
-		"utm_term":     "utm_term",
-		"utm_content":  "utm_content",
-		"entry_page":   "entry_page",
-		"exit_page":    "exit_page",
-		"entry-pages":  "entry_page",
-		"exit-pages":   "exit_page",
+		"pages":          "pages",
+		"referrers":      "referrer",
+		"browsers":       "browser",
+		"devices":        "device",
+		"countries":      "country",
+		"cities":         "city",
+		"regions":        "region",
+		"os":             "os",
+		"utm_source":     "utm_source",
+		"utm_medium":     "utm_medium",
+		"utm_campaign":   "utm_campaign",
+		"utm_term":       "utm_term",
+		"utm_content":    "utm_content",
+		"entry_page":     "entry_page",
+		"exit_page":      "exit_page",
+		"entry-pages":    "entry_page",
+		"exit-pages":     "exit_page",
+		"channels":       "channel",
+		"sources":        "source",
+		"content-groups": "content_group",
+		"networks":       "asn",
 	}
 
 	dimension, ok := dimensionMap[breakdownType]
@@ -604,14 +624,33 @@ func HandleBreakdown(w http.ResponseWriter, r *http.Request) {
 	if page != "" {
 		pageParam = page
 	}
+	hasErrorParam := erroredSessionsParam(query)
+
+	var channelParam interface{}
+	if channel := query.Get("channel"); channel != "" {
+		name, ok := channels.Parse(channel)
+		if !ok {
+			streamDatastar(w, func(sse *DatastarSSE) {
+				patchBreakdownErrorState(sse, "Invalid channel: "+channel)
+			})
+			return
+		}
+		channelParam = name
+	}
 
 	var items []BreakdownItem
 	var totalCount int64
 	var queryErr error
 
-	if breakdownType == "pages" {
+	if _, ok := channelBreakdownColumns[dimension]; ok {
+		items, totalCount, queryErr = queryChannelBreakdown(websiteID, dimension, pagination,
+			channelParam, countryParam, browserParam, deviceParam, pageParam, hasErrorParam)
+	} else if dimension == "content_group" {
+		items, totalCount, queryErr = queryContentGroupBreakdown(websiteID, pagination,
+			channelParam, countryParam, browserParam, deviceParam, pageParam, hasErrorParam)
+	} else if breakdownType == "pages" {
 		// Use get_top_pages() for pages breakdown
-		query := `SELECT * FROM get_top_pages($1, 1, $2, $3, $4, $5, $6, $7, $8)`
+		query := `SELECT * FROM get_top_pages($1, 1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
 		rows, err := database.DB.Query(
 			query,
@@ -623,6 +662,8 @@ func HandleBreakdown(w http.ResponseWriter, r *http.Request) {
 			deviceParam,
 			pagination.SortBy,
 			string(pagination.SortOrder),
+			hasErrorParam,
+			channelParam,
 		)
 		if err != nil {
 			queryErr = err
@@ -647,7 +688,7 @@ func HandleBreakdown(w http.ResponseWriter, r *http.Request) {
 		}
 	} else if breakdownType == "countries" {
 		// Special handling for countries to include ISO code and name conversion
-		query := `SELECT * FROM get_breakdown($1, $2, 1, $3, $4, $5, $6, $7, $8, $9, $10)`
+		query := `SELECT * FROM get_breakdown($1, $2, 1, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
 
 		rows, err := database.DB.Query(
 			query,
@@ -661,6 +702,8 @@ func HandleBreakdown(w http.ResponseWriter, r *http.Request) {
 			pageParam,
 			pagination.SortBy,
 			string(pagination.SortOrder),
+			hasErrorParam,
+			channelParam,
 		)
 		if err != nil {
 			queryErr = err
@@ -684,7 +727,7 @@ func HandleBreakdown(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		// Generic breakdown handler
-		query := `SELECT * FROM get_breakdown($1, $2, 1, $3, $4, $5, $6, $7, $8, $9, $10)`
+		query := `SELECT * FROM get_breakdown($1, $2, 1, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
 
 		rows, err := database.DB.Query(
 			query,
@@ -698,6 +741,8 @@ func HandleBreakdown(w http.ResponseWriter, r *http.Request) {
 			pageParam,
 			pagination.SortBy,
 			string(pagination.SortOrder),
+			hasErrorParam,
+			channelParam,
 		)
 		if err != nil {
 			queryErr = err
@@ -1098,27 +1143,31 @@ func buildUTMTableHTML(dimension string, items []BreakdownItem, sortBy, sortOrde
 }
 
 var breakdownLabels = map[string]string{
-	"pages":        "Pages",
-	"referrers":    "Referrers",
-	"referrer":     "Referrers",
-	"browsers":     "Browsers",
-	"browser":      "Browsers",
-	"devices":      "Devices",
-	"device":       "Devices",
-	"countries":    "Countries",
-	"country":      "Countries",
-	"cities":       "Cities",
-	"regions":      "Regions",
-	"os":           "Operating Systems",
-	"entry-pages":  "Entry Pages",
-	"exit-pages":   "Exit Pages",
-	"entry_page":   "Entry Pages",
-	"exit_page":    "Exit Pages",
-	"utm_source":   "UTM Source",
-	"utm_medium":   "UTM Medium",
-	"utm_campaign": "UTM Campaign",
-	"utm_term":     "UTM Term",
-	"utm_content":  "UTM Content",
+	"pages":          "Pages",
+	"referrers":      "Referrers",
+	"referrer":       "Referrers",
+	"browsers":       "Browsers",
+	"browser":        "Browsers",
+	"devices":        "Devices",
+	"device":         "Devices",
+	"countries":      "Countries",
+	"country":        "Countries",
+	"cities":         "Cities",
+	"regions":        "Regions",
+	"os":             "Operating Systems",
+	"entry-pages":    "Entry Pages",
+	"exit-pages":     "Exit Pages",
+	"entry_page":     "Entry Pages",
+	"exit_page":      "Exit Pages",
+	"utm_source":     "UTM Source",
+	"utm_medium":     "UTM Medium",
+	"utm_campaign":   "UTM Campaign",
+	"utm_term":       "UTM Term",
+	"utm_content":    "UTM Content",
+	"channels":       "Channels",
+	"sources":        "Sources",
+	"content-groups": "Content Groups",
+	"networks":       "Networks",
 }
 
 func buildBreakdownTableHTML(breakdownType string, items []BreakdownItem) string {
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDashboardStats_HasErrorFilter(t *testing.T) {
+	websiteID := uuid.New()
+	responses := []mockResponse{
+		{
+			match:   "get_dashboard_stats($1, 1, $2, $3, $4, $5, $6)",
+			args:    []interface{}{websiteID, "DE", nil, nil, nil, true},
+			columns: []string{"current_visitors", "today_pageviews", "today_visitors", "bounce_rate"},
+			rows:    [][]interface{}{{int64(1), int64(4), int64(2), float64(50)}},
+		},
+	}
+
+	handler, queue, cleanup := setupHTTPTest(t, "/api/dashboard/stats", HandleDashboardStats, responses)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dashboard/stats?website="+websiteID.String()+"&country=DE&has_error=true", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	require.NoError(t, queue.expectationsMet())
+}
+
+func TestHandleTimeSeries_NoHasErrorFilter(t *testing.T) {
+	websiteID := uuid.New()
+	responses := []mockResponse{
+		{
+			match:   "get_timeseries($1, $2, $3, $4, $5, $6, $7)",
+			args:    []interface{}{websiteID, 7, nil, nil, nil, nil, nil},
+			columns: []string{"hour", "views"},
+		},
+	}
+
+	handler, queue, cleanup := setupHTTPTest(t, "/api/dashboard/chart", HandleTimeSeries, responses)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/dashboard/chart?website="+websiteID.String()+"&has_error=no", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	require.NoError(t, queue.expectationsMet())
+}
+
+func TestHandleBreakdown_HasErrorFilter(t *testing.T) {
+	websiteID := uuid.New()
+
+	tests := []struct {
+		name     string
+		tab      string
+		response mockResponse
+	}{
+		{
+			name: "pages",
+			tab:  "pages",
+			response: mockResponse{
+				match:   "get_top_pages($1, 1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+				args:    []interface{}{websiteID, 10, 0, nil, nil, nil, "count", "desc", true, nil},
+				columns: []string{"path", "views", "unique_visitors", "avg_engagement_time", "total_count"},
+			},
+		},
+		{
+			name: "generic dimension",
+			tab:  "browsers",
+			response: mockResponse{
+				match:   "get_breakdown($1, $2, 1, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+				args:    []interface{}{websiteID, "browser", 10, 0, nil, nil, nil, nil, "count", "desc", true, nil},
+				columns: []string{"name", "count", "total_count"},
+			},
+		},
+		{
+			name: "session dimension",
+			tab:  "sources",
+			response: mockResponse{
+				match:   "s.has_error = $8",
+				args:    []interface{}{websiteID, 10, 0, nil, nil, nil, nil, true, nil},
+				columns: []string{"dim_name", "dim_count", "total"},
+			},
+		},
+		{
+			name: "content groups",
+			tab:  "content-groups",
+			response: mockResponse{
+				match:   "s.has_error = $8",
+				args:    []interface{}{websiteID, 10, 0, nil, nil, nil, nil, true, nil},
+				columns: []string{"dim_name", "dim_count", "total"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, queue, cleanup := setupHTTPTest(t, "/api/dashboard/breakdown", HandleBreakdown, []mockResponse{tt.response})
+			defer cleanup()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/dashboard/breakdown?website="+websiteID.String()+"&tab="+tt.tab+"&has_error=1", nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.NotContains(t, resp.Body.String(), "Database error")
+			require.NoError(t, queue.expectationsMet())
+		})
+	}
+}
+
+func TestHandleBreakdown_ChannelFilter(t *testing.T) {
+	websiteID := uuid.New()
+
+	tests := []struct {
+		name     string
+		query    string
+		response mockResponse
+	}{
+		{
+			name:  "pages",
+			query: "tab=pages",
+			response: mockResponse{
+				match:   "get_top_pages($1, 1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+				args:    []interface{}{websiteID, 10, 0, nil, nil, nil, "count", "desc", nil, "Social"},
+				columns: []string{"path", "views", "unique_visitors", "avg_engagement_time", "total_count"},
+			},
+		},
+		{
+			name:  "countries",
+			query: "tab=countries",
+			response: mockResponse{
+				match:   "get_breakdown($1, $2, 1, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)",
+				args:    []interface{}{websiteID, "country", 10, 0, nil, nil, nil, nil, "count", "desc", nil, "Social"},
+				columns: []string{"name", "count", "total_count"},
+			},
+		},
+		{
+			name:  "content groups",
+			query: "tab=content-groups",
+			response: mockResponse{
+				match:   "s.channel = $9",
+				args:    []interface{}{websiteID, 10, 0, nil, nil, nil, nil, nil, "Social"},
+				columns: []string{"dim_name", "dim_count", "total"},
+			},
+		},
+		{
+			name:  "sources with a page",
+			query: "tab=sources&page=/pricing",
+			response: mockResponse{
+				match:   "e.url_path = $9",
+				args:    []interface{}{websiteID, 10, 0, "Social", nil, nil, nil, nil, "/pricing"},
+				columns: []string{"dim_name", "dim_count", "total"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, queue, cleanup := setupHTTPTest(t, "/api/dashboard/breakdown", HandleBreakdown, []mockResponse{tt.response})
+			defer cleanup()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/dashboard/breakdown?website="+websiteID.String()+"&channel=social&"+tt.query, nil)
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			assert.Equal(t, http.StatusOK, resp.Code)
+			assert.NotContains(t, resp.Body.String(), "Database error")
+			require.NoError(t, queue.expectationsMet())
+		})
+	}
+}
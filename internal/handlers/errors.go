@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/httpx"
+	"github.com/seuros/kaunta/internal/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	maxErrorMessageSize = 1000  // Longer messages are truncated before fingerprinting
+	maxErrorStackSize   = 10000 // Stack traces beyond this are truncated
+)
+
+// ErrorGroup is a frontend error grouped by fingerprint
+type ErrorGroup struct {
+	ID          string    `json:"id"`
+	Message     string    `json:"message"`
+	Source      string    `json:"source,omitempty"`
+	Line        *int      `json:"line,omitempty"`
+	Column      *int      `json:"column,omitempty"`
+	Browser     string    `json:"browser,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	Occurrences int64     `json:"occurrences"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+var (
+	errorUUIDPattern   = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	errorHexPattern    = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{16,}\b`)
+	errorNumberPattern = regexp.MustCompile(`\d+`)
+	errorQuotedPattern = regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`")
+	errorURLPattern    = regexp.MustCompile(`https?://[^\s)]+`)
+	errorSpacePattern  = regexp.MustCompile(`\s+`)
+	// First "file:line:col" location in a stack trace (Chrome, Firefox and Safari formats)
+	errorFramePattern = regexp.MustCompile(`((?:https?|file)://[^\s()]+?|/[^\s()]+?):\d+(?::\d+)?`)
+)
+
+// validateErrorPayload checks the fields required for an "error" payload
+func validateErrorPayload(p *PayloadData) error {
+	if p.Message == nil || strings.TrimSpace(*p.Message) == "" {
+		return errors.New("Error message is required")
+	}
+	if p.Source != nil && len(*p.Source) > MaxURLSize {
+		return errors.New("Error source too long (max 2000 characters)")
+	}
+	if p.Line != nil && *p.Line < 0 {
+		return errors.New("Error line must be positive")
+	}
+	if p.Column != nil && *p.Column < 0 {
+		return errors.New("Error column must be positive")
+	}
+	return nil
+}
+
+// normalizeErrorMessage strips volatile parts (ids, numbers, quoted values, URLs)
+// so that the same error raised with different data lands in the same group
+func normalizeErrorMessage(message string) string {
+	message = truncateString(strings.TrimSpace(message), maxErrorMessageSize)
+	message = errorURLPattern.ReplaceAllString(message, "<url>")
+	message = errorQuotedPattern.ReplaceAllString(message, "<str>")
+	message = errorUUIDPattern.ReplaceAllString(message, "<uuid>")
+	message = errorHexPattern.ReplaceAllString(message, "<hex>")
+	message = errorNumberPattern.ReplaceAllString(message, "<n>")
+	message = errorSpacePattern.ReplaceAllString(message, " ")
+	return message
+}
+
+// errorFingerprint builds the grouping key for an error from its normalized
+// message and the script path of its top stack frame (or the source URL)
+func errorFingerprint(message string, stack, source *string) string {
+	location := ""
+	if stack != nil {
+		if match := errorFramePattern.FindStringSubmatch(*stack); len(match) > 1 {
+			location = stripURLVolatileParts(match[1])
+		}
+	}
+	if location == "" && source != nil {
+		location = stripURLVolatileParts(*source)
+	}
+
+	hash := md5.Sum([]byte(normalizeErrorMessage(message) + "|" + location))
+	return hex.EncodeToString(hash[:])
+}
+
+// stripURLVolatileParts drops query strings and fragments (cache busters, build hashes in params)
+func stripURLVolatileParts(raw string) string {
+	if u, err := url.Parse(raw); err == nil {
+		u.RawQuery = ""
+		u.Fragment = ""
+		return u.String()
+	}
+	return raw
+}
+
+// truncateString caps s at limit characters, cutting on rune boundaries so the
+// result stays valid UTF-8
+func truncateString(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit])
+}
+
+// recordFrontendError upserts the error group and flags the session as errored;
+// an error from a session with no pageviews yet only counts in the group
+func recordFrontendError(ctx context.Context, websiteID, sessionID uuid.UUID, seenAt time.Time,
+	payload PayloadData, parsedBrowser, urlPath *string) (uuid.UUID, error) {
+
+	// Redaction in drop mode can empty the message entirely
+	message := ""
+	if payload.Message != nil {
+		message = truncateString(strings.TrimSpace(*payload.Message), maxErrorMessageSize)
+	}
+	fingerprint := errorFingerprint(message, payload.Stack, payload.Source)
+
+	var stack *string
+	if payload.Stack != nil {
+		s := truncateString(*payload.Stack, maxErrorStackSize)
+		stack = &s
+	}
+
+	browser := parsedBrowser
+	if payload.Browser != nil && strings.TrimSpace(*payload.Browser) != "" {
+		b := truncateString(strings.TrimSpace(*payload.Browser), 20)
+		browser = &b
+	}
+
+	var errorID uuid.UUID
+	err := database.DB.QueryRowContext(ctx, `
+		INSERT INTO website_error (
+			website_id, fingerprint, message, stack, source_url,
+			line_number, column_number, browser, url_path,
+			occurrences, first_seen, last_seen
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 1, $10, $10)
+		ON CONFLICT (website_id, fingerprint) DO UPDATE SET
+			occurrences = website_error.occurrences + 1,
+			last_seen = GREATEST(website_error.last_seen, EXCLUDED.last_seen),
+			first_seen = LEAST(website_error.first_seen, EXCLUDED.first_seen),
+			stack = COALESCE(EXCLUDED.stack, website_error.stack),
+			browser = COALESCE(EXCLUDED.browser, website_error.browser),
+			url_path = COALESCE(EXCLUDED.url_path, website_error.url_path)
+		RETURNING error_id
+	`, websiteID, fingerprint, message, stack, payload.Source,
+		payload.Line, payload.Column, browser, urlPath, seenAt,
+	).Scan(&errorID)
+	if err != nil {
+		logging.L().Error("failed to record frontend error",
+			zap.String("website_id", websiteID.String()),
+			zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	if _, err := database.DB.ExecContext(ctx,
+		`UPDATE session SET has_error = TRUE WHERE session_id = $1 AND NOT has_error`,
+		sessionID,
+	); err != nil {
+		logging.L().Warn("failed to flag session with error",
+			zap.String("session_id", sessionID.String()),
+			zap.Error(err))
+	}
+
+	return errorID, nil
+}
+
+// LoadErrorGroups returns error groups seen within the last N days, most recent first
+func LoadErrorGroups(ctx context.Context, db *sql.DB, websiteID uuid.UUID, days, limit int) ([]ErrorGroup, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT error_id, message, COALESCE(source_url, ''), line_number, column_number,
+		       COALESCE(browser, ''), COALESCE(url_path, ''), occurrences, first_seen, last_seen
+		FROM website_error
+		WHERE website_id = $1
+		  AND last_seen >= NOW() - INTERVAL '1 day' * $2
+		ORDER BY last_seen DESC
+		LIMIT $3
+	`, websiteID, days, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	groups := make([]ErrorGroup, 0)
+	for rows.Next() {
+		var g ErrorGroup
+		var line, column sql.NullInt64
+		if err := rows.Scan(&g.ID, &g.Message, &g.Source, &line, &column,
+			&g.Browser, &g.Path, &g.Occurrences, &g.FirstSeen, &g.LastSeen); err != nil {
+			return nil, err
+		}
+		if line.Valid {
+			l := int(line.Int64)
+			g.Line = &l
+		}
+		if column.Valid {
+			c := int(column.Int64)
+			g.Column = &c
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+// HandleErrors returns the frontend error list via Datastar SSE
+// GET /api/dashboard/errors?website_id=...&days=7
+func HandleErrors(w http.ResponseWriter, r *http.Request) {
+	websiteIDStr := selectedWebsiteFromRequest(r)
+	if websiteIDStr == "" {
+		websiteIDStr = r.URL.Query().Get("website_id")
+	}
+	days := min(max(httpx.QueryInt(r, "days", 7), 1), 90)
+
+	var parseErr string
+	var websiteID uuid.UUID
+	if websiteIDStr == "" {
+		parseErr = "Website ID is required"
+	} else {
+		var err error
+		websiteID, err = uuid.Parse(websiteIDStr)
+		if err != nil {
+			parseErr = "Invalid website ID"
+		}
+	}
+
+	var groups []ErrorGroup
+	var queryErr error
+	if parseErr == "" {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		groups, queryErr = LoadErrorGroups(ctx, database.DB, websiteID, days, 100)
+	}
+
+	streamDatastar(w, func(sse *DatastarSSE) {
+		if parseErr != "" {
+			_ = sse.PatchSignals(map[string]any{
+				"errorsError":   parseErr,
+				"errorsLoading": false,
+			})
+			return
+		}
+
+		if queryErr != nil {
+			logging.L().Warn("failed to load frontend errors",
+				zap.String("website_id", websiteID.String()),
+				zap.Error(queryErr))
+			_ = sse.PatchSignals(map[string]any{
+				"errorsError":   "Failed to load errors",
+				"errorsLoading": false,
+			})
+			return
+		}
+
+		_ = sse.PatchElementsWithMode("#errors-content", buildErrorsTableHTML(groups), "inner")
+		_ = sse.PatchSignals(map[string]any{
+			"errorsLoading": false,
+			"errorsError":   false,
+		})
+	})
+}
+
+func buildErrorsTableHTML(groups []ErrorGroup) string {
+	if len(groups) == 0 {
+		return `<div class="empty-state-mini"><div>[ok]</div><div>No JavaScript errors in this period</div></div>`
+	}
+
+	var rows strings.Builder
+	for _, g := range groups {
+		location := g.Source
+		if g.Line != nil {
+			location = fmt.Sprintf("%s:%d", location, *g.Line)
+			if g.Column != nil {
+				location = fmt.Sprintf("%s:%d", location, *g.Column)
+			}
+		}
+		fmt.Fprintf(&rows, `<tr><td><div class="error-message">%s</div><div class="error-meta"><code>%s</code></div></td><td>%s</td><td>%s</td><td style="text-align:right;font-weight:500;color:var(--accent-color)">%s</td><td>%s</td><td>%s</td></tr>`,
+			escapeHTML(g.Message),
+			escapeHTML(location),
+			escapeHTML(g.Path),
+			escapeHTML(g.Browser),
+			formatNumber(int(g.Occurrences)),
+			escapeHTML(g.FirstSeen.Format("2006-01-02 15:04")),
+			escapeHTML(g.LastSeen.Format("2006-01-02 15:04")),
+		)
+	}
+
+	return fmt.Sprintf(`<table class="glass card errors-table"><thead><tr><th>Error</th><th>Page</th><th>Browser</th><th style="text-align:right">Count</th><th>First Seen</th><th>Last Seen</th></tr></thead><tbody>%s</tbody></table>`, rows.String())
+}
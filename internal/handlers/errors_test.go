@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestValidateErrorPayload(t *testing.T) {
+	negative := -1
+
+	tests := []struct {
+		name     string
+		payload  PayloadData
+		errorMsg string
+	}{
+		{
+			name:    "valid error",
+			payload: PayloadData{Message: strPtr("TypeError: x is undefined")},
+		},
+		{
+			name:     "missing message",
+			payload:  PayloadData{},
+			errorMsg: "Error message is required",
+		},
+		{
+			name:     "blank message",
+			payload:  PayloadData{Message: strPtr("   ")},
+			errorMsg: "Error message is required",
+		},
+		{
+			name:     "negative line",
+			payload:  PayloadData{Message: strPtr("boom"), Line: &negative},
+			errorMsg: "Error line must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateErrorPayload(&tt.payload)
+			if tt.errorMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		})
+	}
+}
+
+func TestNormalizeErrorMessage(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Cannot read properties of undefined (reading 'id')", "Cannot read properties of undefined (reading <str>)"},
+		{"Request 42 failed", "Request <n> failed"},
+		{"Order 0190a4b2-8d3c-7e1f-9a2b-3c4d5e6f7a8b not found", "Order <uuid> not found"},
+		{"Failed to fetch https://api.example.com/items?page=2", "Failed to fetch <url>"},
+		{"  too   many\n spaces ", "too many spaces"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, normalizeErrorMessage(tt.input), tt.input)
+	}
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	stackA := "TypeError: x\n    at render (https://example.com/app.js?v=1:10:5)\n    at main (https://example.com/app.js?v=1:2:1)"
+	stackB := "TypeError: x\n    at render (https://example.com/app.js?v=2:11:7)"
+	stackOther := "TypeError: x\n    at render (https://example.com/vendor.js:10:5)"
+
+	a := errorFingerprint("Request 1 failed", &stackA, nil)
+	b := errorFingerprint("Request 2 failed", &stackB, nil)
+	other := errorFingerprint("Request 1 failed", &stackOther, nil)
+
+	assert.Len(t, a, 32)
+	assert.Equal(t, a, b, "same message shape and script should group together")
+	assert.NotEqual(t, a, other, "different scripts should not group together")
+
+	// Source URL is used when no stack frame is available
+	withSource := errorFingerprint("boom", nil, strPtr("https://example.com/app.js?cache=123"))
+	sameSource := errorFingerprint("boom", nil, strPtr("https://example.com/app.js"))
+	assert.Equal(t, withSource, sameSource)
+}
+
+func TestTruncateStringKeepsRunes(t *testing.T) {
+	assert.Equal(t, "héllo", truncateString("héllo", 10))
+	assert.Equal(t, "hé", truncateString("héllo", 2))
+	assert.Equal(t, "日本", truncateString("日本語", 2))
+	assert.True(t, utf8.ValidString(truncateString("ééééé", 3)))
+}
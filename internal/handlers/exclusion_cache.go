@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/exclusions"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/models"
+)
+
+// exclusionCache holds each website's internal traffic matcher; a nil matcher
+// excludes nothing
+var exclusionCache = newTTLCache(settingsCacheTTL, func(ctx context.Context, websiteID uuid.UUID) (*exclusions.Matcher, error) {
+	return models.LoadExclusionMatcher(ctx, database.DB, websiteID)
+})
+
+// excludedTraffic reports whether a hit should be dropped as internal traffic:
+// the visitor opted out on /optout/{website_id} (when r carries the cookie),
+// or the client IP / User-Agent matches one of the website's rules
+func excludedTraffic(r *http.Request, websiteID uuid.UUID, ip, userAgent string) (string, bool) {
+	if r != nil && hasOptOutCookie(r, websiteID) {
+		return exclusions.ReasonOptOut, true
+	}
+
+	matcher, err := exclusionCache.Get(websiteID)
+	if err != nil {
+		logging.L().Warn("failed to load traffic exclusions",
+			zap.String("website_id", websiteID.String()), zap.Error(err))
+		return "", false
+	}
+	return matcher.Match(ip, userAgent)
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/httpx"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/middleware"
+	"github.com/seuros/kaunta/internal/models"
+	"go.uber.org/zap"
+)
+
+// experimentSignificance is the p-value threshold for a significant result (95% confidence)
+const experimentSignificance = 0.05
+
+// VariantResult is one variant's outcome. Uplift and significance compare it to
+// the control (first variant) and are omitted for the control itself.
+type VariantResult struct {
+	Name           string   `json:"name"`
+	Tag            string   `json:"tag"`
+	Control        bool     `json:"control"`
+	Visitors       int64    `json:"visitors"`
+	Conversions    int64    `json:"conversions"`
+	ConversionRate float64  `json:"conversion_rate"`   // percent
+	Uplift         *float64 `json:"uplift,omitempty"`  // percent, relative to control
+	PValue         *float64 `json:"p_value,omitempty"` // two-sided two-proportion z-test
+	Significant    bool     `json:"significant"`       // p < 0.05
+}
+
+// ExperimentReport is the result of an experiment over its running window
+type ExperimentReport struct {
+	Experiment string          `json:"experiment"`
+	Goal       string          `json:"goal"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	Running    bool            `json:"running"`
+	Variants   []VariantResult `json:"variants"`
+}
+
+// LoadExperimentReport counts visitors and goal conversions per variant.
+//
+// A session belongs to the variant of the first tagged event it sent in the
+// experiment window; it converts when the goal was completed after that exposure.
+func LoadExperimentReport(ctx context.Context, db *sql.DB, exp *models.Experiment, now time.Time) (*ExperimentReport, error) {
+	to := now
+	if exp.EndedAt != nil {
+		to = *exp.EndedAt
+	}
+
+	tags := make([]string, len(exp.Variants))
+	for i, v := range exp.Variants {
+		tags[i] = v.Tag
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		WITH exposures AS (
+			SELECT DISTINCT ON (e.session_id) e.session_id, e.tag, e.created_at
+			FROM website_event e
+			WHERE e.website_id = $1
+			  AND e.tag = ANY($2)
+			  AND e.created_at >= $3
+			  AND e.created_at < $4
+			ORDER BY e.session_id, e.created_at
+		)
+		SELECT x.tag, COUNT(*), COUNT(gc.session_id)
+		FROM exposures x
+		LEFT JOIN goal_completions gc
+			ON gc.goal_id = $5
+			AND gc.session_id = x.session_id
+			AND gc.completed_at >= x.created_at
+			AND gc.completed_at < $4
+		GROUP BY x.tag
+	`, exp.WebsiteID, pq.Array(tags), exp.StartedAt, to, exp.GoalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query experiment: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	type counts struct{ visitors, conversions int64 }
+	byTag := make(map[string]counts)
+	for rows.Next() {
+		var tag string
+		var c counts
+		if err := rows.Scan(&tag, &c.visitors, &c.conversions); err != nil {
+			return nil, err
+		}
+		byTag[tag] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]VariantResult, len(exp.Variants))
+	for i, v := range exp.Variants {
+		results[i] = VariantResult{
+			Name:        v.Name,
+			Tag:         v.Tag,
+			Visitors:    byTag[v.Tag].visitors,
+			Conversions: byTag[v.Tag].conversions,
+		}
+	}
+
+	return &ExperimentReport{
+		Experiment: exp.Name,
+		Goal:       exp.GoalName,
+		From:       exp.StartedAt,
+		To:         to,
+		Running:    exp.EndedAt == nil,
+		Variants:   compareVariants(results),
+	}, nil
+}
+
+// compareVariants fills in conversion rates, uplift and significance against
+// the first variant (control)
+func compareVariants(results []VariantResult) []VariantResult {
+	for i := range results {
+		results[i].ConversionRate = conversionRate(results[i].Conversions, results[i].Visitors)
+	}
+	if len(results) == 0 {
+		return results
+	}
+
+	control := &results[0]
+	control.Control = true
+	for i := 1; i < len(results); i++ {
+		v := &results[i]
+		if control.ConversionRate > 0 {
+			uplift := math.Round((v.ConversionRate-control.ConversionRate)/control.ConversionRate*10000) / 100
+			v.Uplift = &uplift
+		}
+		if p, ok := twoProportionPValue(control.Conversions, control.Visitors, v.Conversions, v.Visitors); ok {
+			v.PValue = &p
+			v.Significant = p < experimentSignificance
+		}
+	}
+	return results
+}
+
+func conversionRate(conversions, visitors int64) float64 {
+	if visitors == 0 {
+		return 0
+	}
+	return math.Round(float64(conversions)/float64(visitors)*10000) / 100
+}
+
+// twoProportionPValue runs a pooled two-sided z-test on two conversion rates.
+// ok is false when either group is empty or nobody (or everybody) converted.
+func twoProportionPValue(c1, n1, c2, n2 int64) (float64, bool) {
+	if n1 == 0 || n2 == 0 {
+		return 0, false
+	}
+	p1 := float64(c1) / float64(n1)
+	p2 := float64(c2) / float64(n2)
+	pooled := float64(c1+c2) / float64(n1+n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(n1) + 1/float64(n2)))
+	if se == 0 {
+		return 0, false
+	}
+	z := (p2 - p1) / se
+	p := math.Erfc(math.Abs(z) / math.Sqrt2)
+	return math.Round(p*10000) / 10000, true
+}
+
+// HandleAPIExperimentReport returns an experiment report via API key (stats scope)
+// GET /api/v1/stats/:website_id/experiments/:name
+func HandleAPIExperimentReport(w http.ResponseWriter, r *http.Request) {
+	websiteID, err := uuid.Parse(chi.URLParam(r, "website_id"))
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "Invalid website ID")
+		return
+	}
+
+	apiKey := middleware.GetAPIKey(r)
+	if apiKey == nil {
+		httpx.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if !apiKey.HasScope("stats") {
+		httpx.Error(w, http.StatusForbidden, "API key does not have stats permission")
+		return
+	}
+	if apiKey.WebsiteID != websiteID {
+		httpx.Error(w, http.StatusForbidden, "API key not authorized for this website")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	exp, err := models.GetExperimentByName(ctx, database.DB, websiteID, chi.URLParam(r, "name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		httpx.Error(w, http.StatusNotFound, "Experiment not found")
+		return
+	}
+	if err != nil {
+		logging.L().Warn("failed to load experiment", zap.String("website_id", websiteID.String()), zap.Error(err))
+		httpx.Error(w, http.StatusInternalServerError, "Failed to fetch experiment")
+		return
+	}
+
+	report, err := LoadExperimentReport(ctx, database.DB, exp, time.Now())
+	if err != nil {
+		logging.L().Warn("failed to load experiment report", zap.String("website_id", websiteID.String()), zap.Error(err))
+		httpx.Error(w, http.StatusInternalServerError, "Failed to fetch experiment")
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, report)
+}
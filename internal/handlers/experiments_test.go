@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVariants(t *testing.T) {
+	results := compareVariants([]VariantResult{
+		{Name: "control", Tag: "a", Visitors: 1000, Conversions: 100},
+		{Name: "new", Tag: "b", Visitors: 1000, Conversions: 130},
+		{Name: "same", Tag: "c", Visitors: 1000, Conversions: 101},
+	})
+
+	control := results[0]
+	assert.True(t, control.Control)
+	assert.Equal(t, 10.0, control.ConversionRate)
+	assert.Nil(t, control.Uplift)
+	assert.Nil(t, control.PValue)
+
+	winner := results[1]
+	assert.Equal(t, 13.0, winner.ConversionRate)
+	require.NotNil(t, winner.Uplift)
+	assert.Equal(t, 30.0, *winner.Uplift)
+	require.NotNil(t, winner.PValue)
+	assert.InDelta(t, 0.0355, *winner.PValue, 0.0005)
+	assert.True(t, winner.Significant)
+
+	noise := results[2]
+	require.NotNil(t, noise.PValue)
+	assert.Greater(t, *noise.PValue, 0.9)
+	assert.False(t, noise.Significant)
+}
+
+func TestCompareVariantsWithoutData(t *testing.T) {
+	results := compareVariants([]VariantResult{
+		{Name: "control", Tag: "a"},
+		{Name: "new", Tag: "b", Visitors: 10},
+	})
+
+	assert.Zero(t, results[0].ConversionRate)
+	assert.Nil(t, results[1].Uplift, "no uplift against a 0% control")
+	assert.Nil(t, results[1].PValue, "no test with an empty group")
+	assert.False(t, results[1].Significant)
+}
+
+func TestTwoProportionPValueNoVariance(t *testing.T) {
+	_, ok := twoProportionPValue(0, 100, 0, 100)
+	assert.False(t, ok)
+
+	_, ok = twoProportionPValue(100, 100, 50, 50)
+	assert.False(t, ok)
+}
@@ -63,6 +63,10 @@ type IngestPayload struct {
 type IngestContext struct {
 	Locale string `json:"locale" validate:"omitempty,max=20"`
 	Screen string `json:"screen" validate:"omitempty,max=20"`
+	// End-user IP and User-Agent as seen by the calling server.
+	// Only used when the API key has the forward_client_context scope.
+	IP        string `json:"ip" validate:"omitempty,ip"`
+	UserAgent string `json:"user_agent" validate:"omitempty,max=1000"`
 }
 
 // BatchIngestRequest represents a batch of events
@@ -216,33 +220,31 @@ func HandleIngestBatch(w http.ResponseWriter, r *http.Request) {
 func processIngestEvent(ctx context.Context, r *http.Request, apiKey *models.APIKey, payload *IngestPayload) (map[string]any, error) {
 	websiteID := apiKey.WebsiteID
 
-	// Get website proxy_mode for IP resolution
-	var proxyMode string
-	err := database.DB.QueryRowContext(ctx,
-		"SELECT COALESCE(proxy_mode, 'none') FROM website WHERE website_id = $1",
-		websiteID,
-	).Scan(&proxyMode)
+	// Get website proxy settings for IP resolution
+	proxy, err := loadProxySettings(ctx, websiteID)
 	if err != nil {
 		return nil, fmt.Errorf("website not found: %w", err)
 	}
 
-	// Get client IP from headers (NEVER from payload for security)
-	ip := clientIPFromRequest(r, proxyMode)
-	userAgent := r.Header.Get("User-Agent")
+	ip, userAgent := ingestClientContext(r, proxy, apiKey, payload)
 
-	// Bot detection
-	var isBot *bool
-	err = database.DB.QueryRowContext(ctx, `
-		SELECT update_ip_metadata($1::inet, $2, NULL)
-	`, ip, userAgent).Scan(&isBot)
+	// Internal traffic (opt-out cookies only apply to browser requests)
+	if reason, excluded := excludedTraffic(nil, websiteID, ip, userAgent); excluded {
+		return map[string]any{"status": "accepted", "excluded": reason}, nil
+	}
 
-	if err != nil {
-		logging.L().Warn("bot detection error", zap.String("ip", ip), zap.Error(err))
-		isBotVal := false
-		isBot = &isBotVal
+	// Determine timestamp
+	createdAt := time.Now()
+	if payload.Timestamp != nil && timestampInWindow(*payload.Timestamp, createdAt) {
+		createdAt = time.Unix(*payload.Timestamp, 0)
 	}
 
-	if isBot != nil && *isBot {
+	// Redact personal data before anything is stored
+	redactIngestPayload(ctx, websiteID, createdAt, payload)
+
+	// Bot detection
+	network := checkRequestIP(ctx, websiteID, ip, userAgent, payload.URL, nil)
+	if network.Bot {
 		return map[string]any{"status": "accepted", "bot_detected": true}, nil
 	}
 
@@ -255,17 +257,6 @@ func processIngestEvent(ctx context.Context, r *http.Request, apiKey *models.API
 	region := &regionStr
 	city := &cityStr
 
-	// Determine timestamp
-	createdAt := time.Now()
-	if payload.Timestamp != nil {
-		t := time.Unix(*payload.Timestamp, 0)
-		now := time.Now()
-		// Validate timestamp within ±30 days
-		if !t.Before(now.Add(-30*24*time.Hour)) && !t.After(now.Add(30*24*time.Hour)) {
-			createdAt = t
-		}
-	}
-
 	// Generate or use provided session ID
 	sessionID := resolveSessionID(payload, websiteID, ip, userAgent, createdAt)
 
@@ -302,9 +293,15 @@ func processIngestEvent(ctx context.Context, r *http.Request, apiKey *models.API
 		language = &payload.Context.Locale
 	}
 
+	source, channel := classifySessionTraffic(websiteID, payload.URL, payload.Referrer,
+		payload.UTMSource, payload.UTMMedium)
+
+	// Apply the website's URL rules; goals keep matching the raw path
+	pagePath, pageQuery, contentGroup := normalizePageURL(websiteID, urlPath, urlQuery)
+
 	// Upsert session
 	err = upsertSessionForIngest(ctx, sessionID, websiteID, browser, os, device,
-		screen, language, country, region, city, payload.UserID, urlPath)
+		screen, language, country, region, city, payload.UserID, pagePath, source, channel, network)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
@@ -315,7 +312,7 @@ func processIngestEvent(ctx context.Context, r *http.Request, apiKey *models.API
 
 	// Save event
 	eventID, err := saveIngestEvent(ctx, websiteID, sessionID, visitID, createdAt, payload,
-		browser, os, device, country, region, city, hostname, urlPath, urlQuery)
+		browser, os, device, country, region, city, hostname, pagePath, pageQuery, contentGroup)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save event: %w", err)
 	}
@@ -339,7 +336,7 @@ func processIngestEvent(ctx context.Context, r *http.Request, apiKey *models.API
 		eventName = nil
 	}
 
-	goalID := checkAndRecordGoalCompletion(ctx, websiteID, sessionID, eventID, eventType, urlPath, eventName)
+	goalID := checkAndRecordGoalCompletion(ctx, websiteID, sessionID, eventID, eventType, urlPath, eventName, createdAt)
 	if goalID != nil {
 		_, _ = database.DB.ExecContext(ctx,
 			`UPDATE website_event SET goal_id = $1 WHERE event_id = $2 AND created_at = $3`,
@@ -354,6 +351,26 @@ func processIngestEvent(ctx context.Context, r *http.Request, apiKey *models.API
 	}, nil
 }
 
+// ingestClientContext resolves the end user's IP and User-Agent.
+// By default they come from the request (i.e. the calling server); keys with the
+// forward_client_context scope may supply the end user's values in payload.context.
+func ingestClientContext(r *http.Request, proxy proxySettings, apiKey *models.APIKey, payload *IngestPayload) (string, string) {
+	ip := clientIPFromRequest(r, proxy)
+	userAgent := r.Header.Get("User-Agent")
+
+	if payload.Context == nil || !apiKey.HasScope(models.ScopeForwardClientContext) {
+		return ip, userAgent
+	}
+
+	if payload.Context.IP != "" {
+		ip = payload.Context.IP
+	}
+	if payload.Context.UserAgent != "" {
+		userAgent = payload.Context.UserAgent
+	}
+	return ip, userAgent
+}
+
 // validateIngestPayload validates the ingest payload using go-playground/validator
 func validateIngestPayload(p *IngestPayload) error {
 	// Struct validation
@@ -365,12 +382,8 @@ func validateIngestPayload(p *IngestPayload) error {
 	}
 
 	// Timestamp validation (custom)
-	if p.Timestamp != nil {
-		t := time.Unix(*p.Timestamp, 0)
-		now := time.Now()
-		if t.Before(now.Add(-30*24*time.Hour)) || t.After(now.Add(30*24*time.Hour)) {
-			return errors.New("timestamp must be within 30 days of now")
-		}
+	if p.Timestamp != nil && !timestampInWindow(*p.Timestamp, time.Now()) {
+		return errors.New("timestamp must be within 30 days of now")
 	}
 
 	// Properties validation
@@ -383,6 +396,16 @@ func validateIngestPayload(p *IngestPayload) error {
 	return nil
 }
 
+// maxTimestampSkew bounds client-supplied event timestamps, keeping writes
+// out of partitions that were never meant to receive them
+const maxTimestampSkew = 30 * 24 * time.Hour
+
+// timestampInWindow reports whether a unix timestamp is within ±30 days of now
+func timestampInWindow(ts int64, now time.Time) bool {
+	t := time.Unix(ts, 0)
+	return !t.Before(now.Add(-maxTimestampSkew)) && !t.After(now.Add(maxTimestampSkew))
+}
+
 // formatIngestValidationError converts validator errors to user-friendly messages
 func formatIngestValidationError(fe validator.FieldError) error {
 	switch fe.Tag() {
@@ -479,27 +502,57 @@ func generateDeterministicUUID(parts ...string) uuid.UUID {
 	return id
 }
 
+// redactIngestPayload redacts the URL, referrer, title and properties of an
+// ingested event in place
+func redactIngestPayload(ctx context.Context, websiteID uuid.UUID, createdAt time.Time, payload *IngestPayload) {
+	ev := redactableEvent{Props: payload.Properties}
+	if payload.URL != "" {
+		ev.URL = &payload.URL
+	}
+	if payload.Referrer != "" {
+		ev.Referrer = &payload.Referrer
+	}
+	if payload.Title != "" {
+		ev.Title = &payload.Title
+	}
+	redactEvent(ctx, websiteID, createdAt, &ev)
+
+	payload.URL, payload.Referrer, payload.Title = "", "", ""
+	if ev.URL != nil {
+		payload.URL = *ev.URL
+	}
+	if ev.Referrer != nil {
+		payload.Referrer = *ev.Referrer
+	}
+	if ev.Title != nil {
+		payload.Title = *ev.Title
+	}
+	payload.Properties = ev.Props
+}
+
 // upsertSessionForIngest creates or updates a session for ingested events
 func upsertSessionForIngest(ctx context.Context, sessionID, websiteID uuid.UUID,
 	browser, os, device, screen, language, country, region, city *string,
-	distinctID *string, urlPath *string) error {
+	distinctID *string, urlPath *string, source, channel *string, network ipVerdict) error {
 
 	query := `
 		INSERT INTO session (
 			session_id, website_id, browser, os, device, screen, language,
-			country, region, city, created_at, distinct_id, entry_page, exit_page
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), $11, $12, $12)
+			country, region, city, created_at, distinct_id, entry_page, exit_page,
+			source, channel, asn, asn_org, is_hosting_provider
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), $11, $12, $12, $13, $14, $15, $16, $17)
 		ON CONFLICT (session_id) DO UPDATE SET exit_page = EXCLUDED.entry_page
 	`
 	_, err := database.DB.ExecContext(ctx, query, sessionID, websiteID, browser, os, device,
-		screen, language, country, region, city, distinctID, urlPath)
+		screen, language, country, region, city, distinctID, urlPath, source, channel,
+		network.ASN, network.ASNOrg, network.Hosting)
 	return err
 }
 
 // saveIngestEvent saves an event from the ingest API
 func saveIngestEvent(ctx context.Context, websiteID, sessionID, visitID uuid.UUID, createdAt time.Time,
 	payload *IngestPayload, browser, os, device, country, region, city *string,
-	hostname, urlPath, urlQuery *string) (uuid.UUID, error) {
+	hostname, urlPath, urlQuery, contentGroup *string) (uuid.UUID, error) {
 
 	eventID := uuid.New()
 	eventType := 1 // pageview
@@ -527,6 +580,12 @@ func saveIngestEvent(ctx context.Context, websiteID, sessionID, visitID uuid.UUI
 		}
 	}
 
+	// Title
+	var title *string
+	if payload.Title != "" {
+		title = &payload.Title
+	}
+
 	// Convert properties to JSON
 	var propsJSON interface{}
 	if len(payload.Properties) > 0 {
@@ -534,25 +593,21 @@ func saveIngestEvent(ctx context.Context, websiteID, sessionID, visitID uuid.UUI
 		propsJSON = jsonBytes
 	}
 
-	// Title
-	var title *string
-	if payload.Title != "" {
-		title = &payload.Title
-	}
-
 	query := `
 		INSERT INTO website_event (
 			event_id, website_id, session_id, visit_id, created_at,
 			page_title, hostname, url_path, url_query,
 			referrer_path, referrer_query, referrer_domain,
 			event_name, event_type, props,
-			utm_source, utm_medium, utm_campaign, utm_term, utm_content
+			utm_source, utm_medium, utm_campaign, utm_term, utm_content,
+			content_group
 		) VALUES (
 			$1, $2, $3, $4, $5,
 			$6, $7, $8, $9,
 			$10, $11, $12,
 			$13, $14, $15,
-			$16, $17, $18, $19, $20
+			$16, $17, $18, $19, $20,
+			$21
 		)
 	`
 
@@ -562,6 +617,7 @@ func saveIngestEvent(ctx context.Context, websiteID, sessionID, visitID uuid.UUI
 		referrerPath, referrerQuery, referrerDomain,
 		eventName, eventType, propsJSON,
 		payload.UTMSource, payload.UTMMedium, payload.UTMCampaign, payload.UTMTerm, payload.UTMContent,
+		contentGroup,
 	)
 
 	if err != nil {
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/models"
 )
 
 func TestValidateIngestPayload(t *testing.T) {
@@ -372,3 +376,39 @@ func generateManyProperties(count int) map[string]interface{} {
 	}
 	return props
 }
+
+func TestIngestClientContext(t *testing.T) {
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/api/ingest", nil)
+		req.RemoteAddr = "10.0.0.5:4321"
+		req.Header.Set("User-Agent", "rails-backend/1.0")
+		return req
+	}
+	payload := &IngestPayload{
+		Event:     "signup",
+		VisitorID: "v1",
+		Context:   &IngestContext{IP: "203.0.113.50", UserAgent: "Mozilla/5.0 (iPhone)"},
+	}
+
+	t.Run("ingest scope ignores payload context", func(t *testing.T) {
+		key := &models.APIKey{Scopes: []string{"ingest"}}
+		ip, ua := ingestClientContext(newRequest(), proxySettings{Mode: "none"}, key, payload)
+		assert.Equal(t, "10.0.0.5", ip)
+		assert.Equal(t, "rails-backend/1.0", ua)
+	})
+
+	t.Run("forward_client_context uses payload context", func(t *testing.T) {
+		key := &models.APIKey{Scopes: []string{"ingest", models.ScopeForwardClientContext}}
+		ip, ua := ingestClientContext(newRequest(), proxySettings{Mode: "none"}, key, payload)
+		assert.Equal(t, "203.0.113.50", ip)
+		assert.Equal(t, "Mozilla/5.0 (iPhone)", ua)
+	})
+
+	t.Run("forward_client_context falls back per field", func(t *testing.T) {
+		key := &models.APIKey{Scopes: []string{"ingest", models.ScopeForwardClientContext}}
+		partial := &IngestPayload{Context: &IngestContext{IP: "203.0.113.50"}}
+		ip, ua := ingestClientContext(newRequest(), proxySettings{Mode: "none"}, key, partial)
+		assert.Equal(t, "203.0.113.50", ip)
+		assert.Equal(t, "rails-backend/1.0", ua)
+	})
+}
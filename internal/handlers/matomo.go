@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/httpx"
+	"github.com/seuros/kaunta/internal/logging"
+	"go.uber.org/zap"
+)
+
+// HandleMatomoTracking is the matomo.php / piwik.php endpoint - compatible with
+// Matomo's tracking HTTP API (GET query string or form-encoded POST).
+// Like the pixel, it always answers with the GIF so embedded trackers never break.
+func HandleMatomoTracking(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		serveMatomoResponse(w, r)
+		return
+	}
+	params := r.Form
+
+	// Matomo only records requests carrying rec=1. Heartbeat pings send rec=1
+	// too but only extend the visit, which engagement tracking already covers.
+	if params.Get("rec") != "1" || params.Get("ping") == "1" {
+		serveMatomoResponse(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	websiteID, err := lookupMatomoSite(ctx, params.Get("idsite"))
+	if err != nil {
+		logging.L().Debug("matomo tracking: unknown idsite",
+			zap.String("idsite", params.Get("idsite")),
+			zap.String("ip", httpx.ClientIP(r)),
+		)
+		serveMatomoResponse(w, r)
+		return
+	}
+
+	payload := buildMatomoPayload(r, params, websiteID)
+	recorder := newTrackingResponseRecorder()
+	HandleTracking(recorder, withPixelPayload(r, payload))
+
+	if recorder.status >= 400 {
+		logging.L().Debug("matomo tracking failed",
+			zap.String("website_id", websiteID),
+			zap.Int("status", recorder.status),
+		)
+	}
+
+	serveMatomoResponse(w, r)
+}
+
+// lookupMatomoSite maps Matomo's numeric idsite onto a Kaunta website.
+// A website UUID is accepted as idsite as well.
+func lookupMatomoSite(ctx context.Context, idsite string) (string, error) {
+	idsite = strings.TrimSpace(idsite)
+	if idsite == "" {
+		return "", errors.New("idsite is required")
+	}
+	if id, err := uuid.Parse(idsite); err == nil {
+		return id.String(), nil
+	}
+
+	siteID, err := strconv.Atoi(idsite)
+	if err != nil || siteID <= 0 {
+		return "", errors.New("invalid idsite")
+	}
+
+	var websiteID string
+	err = database.DB.QueryRowContext(ctx, `
+		SELECT website_id FROM website
+		WHERE matomo_site_id = $1 AND deleted_at IS NULL
+		LIMIT 1
+	`, siteID).Scan(&websiteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errors.New("no website mapped to idsite")
+	}
+	return websiteID, err
+}
+
+// buildMatomoPayload converts Matomo tracking parameters into a /api/send payload
+// https://developer.matomo.org/api-reference/tracking-api
+func buildMatomoPayload(r *http.Request, params url.Values, websiteID string) TrackingPayload {
+	payload := TrackingPayload{
+		Type: "event",
+		Payload: PayloadData{
+			Website: websiteID,
+		},
+	}
+
+	pageURL := params.Get("url")
+	if pageURL == "" {
+		pageURL = r.Header.Get("Referer")
+	}
+	if pageURL != "" {
+		payload.Payload.URL = &pageURL
+		if u, err := url.Parse(pageURL); err == nil && u.Hostname() != "" {
+			h := u.Hostname()
+			payload.Payload.Hostname = &h
+		}
+	}
+
+	if title := params.Get("action_name"); title != "" {
+		payload.Payload.Title = &title
+	}
+
+	if referrer := params.Get("urlref"); referrer != "" {
+		payload.Payload.Referrer = &referrer
+	}
+
+	if res := params.Get("res"); res != "" {
+		payload.Payload.Screen = &res
+	}
+
+	if lang := params.Get("lang"); lang != "" {
+		payload.Payload.Language = &lang
+	} else if lang := r.Header.Get("Accept-Language"); lang != "" {
+		payload.Payload.Language = &lang
+	}
+
+	// uid is the site's own user ID; _id is the tracker's 16-hex visitor ID
+	if uid := params.Get("uid"); uid != "" {
+		payload.Payload.ID = &uid
+	} else if visitorID := params.Get("_id"); visitorID != "" {
+		payload.Payload.ID = &visitorID
+	}
+
+	// Events: category and action are required by Matomo, name and value optional
+	if category, action := params.Get("e_c"), params.Get("e_a"); category != "" && action != "" {
+		name := category + ":" + action
+		payload.Payload.Name = &name
+
+		props := map[string]interface{}{
+			"category": category,
+			"action":   action,
+		}
+		if label := params.Get("e_n"); label != "" {
+			props["name"] = label
+		}
+		if value := params.Get("e_v"); value != "" {
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				props["value"] = f
+			}
+		}
+		payload.Payload.Props = props
+	} else if link := params.Get("link"); link != "" {
+		// Outlink and download clicks are events on the current page, not pageviews
+		name := "Outbound Link: Click"
+		payload.Payload.Name = &name
+		payload.Payload.Props = map[string]interface{}{"url": link}
+	} else if download := params.Get("download"); download != "" {
+		name := "File Download"
+		payload.Payload.Name = &name
+		payload.Payload.Props = map[string]interface{}{"url": download}
+	}
+
+	// Campaign: utm_* in the page URL first, then Matomo's own campaign parameters
+	var query url.Values
+	if payload.Payload.URL != nil {
+		if u, err := url.Parse(*payload.Payload.URL); err == nil {
+			query = u.Query()
+		}
+	}
+	campaignParam := func(keys ...string) *string {
+		for _, key := range keys {
+			if v := query.Get(key); v != "" {
+				return &v
+			}
+		}
+		return nil
+	}
+	payload.Payload.UTMSource = campaignParam("utm_source", "mtm_source", "pk_source")
+	payload.Payload.UTMMedium = campaignParam("utm_medium", "mtm_medium", "pk_medium")
+	payload.Payload.UTMCampaign = campaignParam("utm_campaign", "mtm_campaign", "pk_campaign")
+	payload.Payload.UTMTerm = campaignParam("utm_term", "mtm_keyword", "pk_keyword")
+	payload.Payload.UTMContent = campaignParam("utm_content", "mtm_content", "pk_content")
+
+	// _rcn/_rck carry the campaign attributed on an earlier visit
+	if payload.Payload.UTMCampaign == nil {
+		if rcn := params.Get("_rcn"); rcn != "" {
+			payload.Payload.UTMCampaign = &rcn
+		}
+	}
+	if payload.Payload.UTMTerm == nil {
+		if rck := params.Get("_rck"); rck != "" {
+			payload.Payload.UTMTerm = &rck
+		}
+	}
+
+	return payload
+}
+
+// serveMatomoResponse answers like Matomo: the GIF, or 204 when send_image=0
+func serveMatomoResponse(w http.ResponseWriter, r *http.Request) {
+	if r.Form.Get("send_image") == "0" {
+		w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, private")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	servePixel(w)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMatomoPayloadPageview(t *testing.T) {
+	params := url.Values{
+		"idsite":      {"3"},
+		"rec":         {"1"},
+		"url":         {"https://example.com/blog/post?mtm_campaign=launch&utm_source=newsletter"},
+		"urlref":      {"https://duckduckgo.com/"},
+		"action_name": {"Blog / Post"},
+		"res":         {"1920x1080"},
+		"_id":         {"0123456789abcdef"},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/matomo.php?"+params.Encode(), nil)
+
+	payload := buildMatomoPayload(req, params, "11111111-1111-4111-8111-111111111111")
+
+	assert.Equal(t, "event", payload.Type)
+	assert.Nil(t, payload.Payload.Name)
+	require.NotNil(t, payload.Payload.URL)
+	assert.Equal(t, params.Get("url"), *payload.Payload.URL)
+	assert.Equal(t, "example.com", *payload.Payload.Hostname)
+	assert.Equal(t, "Blog / Post", *payload.Payload.Title)
+	assert.Equal(t, "https://duckduckgo.com/", *payload.Payload.Referrer)
+	assert.Equal(t, "1920x1080", *payload.Payload.Screen)
+	assert.Equal(t, "0123456789abcdef", *payload.Payload.ID)
+	assert.Equal(t, "newsletter", *payload.Payload.UTMSource)
+	assert.Equal(t, "launch", *payload.Payload.UTMCampaign)
+	assert.Nil(t, payload.Payload.UTMMedium)
+}
+
+func TestBuildMatomoPayloadEvent(t *testing.T) {
+	params := url.Values{
+		"url":  {"https://example.com/video"},
+		"e_c":  {"Video"},
+		"e_a":  {"Play"},
+		"e_n":  {"intro.mp4"},
+		"e_v":  {"12.5"},
+		"uid":  {"user-7"},
+		"_id":  {"0123456789abcdef"},
+		"_rcn": {"spring"},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/matomo.php", nil)
+
+	payload := buildMatomoPayload(req, params, "11111111-1111-4111-8111-111111111111")
+
+	require.NotNil(t, payload.Payload.Name)
+	assert.Equal(t, "Video:Play", *payload.Payload.Name)
+	assert.Equal(t, "Video", payload.Payload.Props["category"])
+	assert.Equal(t, "intro.mp4", payload.Payload.Props["name"])
+	assert.Equal(t, 12.5, payload.Payload.Props["value"])
+	assert.Equal(t, "user-7", *payload.Payload.ID)
+	assert.Equal(t, "spring", *payload.Payload.UTMCampaign)
+}
+
+func TestBuildMatomoPayloadLinkAndDownload(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/matomo.php", nil)
+
+	link := buildMatomoPayload(req, url.Values{
+		"url":  {"https://example.com/blog"},
+		"link": {"https://github.com/seuros/kaunta"},
+	}, "11111111-1111-4111-8111-111111111111")
+	require.NotNil(t, link.Payload.Name)
+	assert.Equal(t, "Outbound Link: Click", *link.Payload.Name)
+	assert.Equal(t, "https://github.com/seuros/kaunta", link.Payload.Props["url"])
+	assert.Equal(t, "https://example.com/blog", *link.Payload.URL)
+
+	download := buildMatomoPayload(req, url.Values{
+		"url":      {"https://example.com/docs"},
+		"download": {"https://example.com/files/guide.pdf"},
+	}, "11111111-1111-4111-8111-111111111111")
+	require.NotNil(t, download.Payload.Name)
+	assert.Equal(t, "File Download", *download.Payload.Name)
+	assert.Equal(t, "https://example.com/files/guide.pdf", download.Payload.Props["url"])
+}
+
+func TestHandleMatomoTrackingSkipsWithoutRecording(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"missing rec", "idsite=1&url=https://example.com/", http.StatusOK},
+		{"heartbeat ping", "idsite=1&rec=1&ping=1&url=https://example.com/", http.StatusOK},
+		{"no image", "idsite=1&send_image=0", http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/matomo.php?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			HandleMatomoTracking(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "image/gif", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestHandleMatomoTrackingPostForm(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/matomo.php", strings.NewReader("idsite=1&rec=0"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	HandleMatomoTracking(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", req.Form.Get("idsite"))
+}
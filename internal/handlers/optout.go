@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/seuros/kaunta/internal/database"
+)
+
+// optOutCookieMaxAge keeps the opt-out for two years
+const optOutCookieMaxAge = 2 * 365 * 24 * time.Hour
+
+// optOutCookieName is per website so opting out of one site leaves the
+// others served by the same Kaunta instance untouched
+func optOutCookieName(websiteID uuid.UUID) string {
+	return "kaunta_optout_" + websiteID.String()
+}
+
+func hasOptOutCookie(r *http.Request, websiteID uuid.UUID) bool {
+	cookie, err := r.Cookie(optOutCookieName(websiteID))
+	return err == nil && cookie.Value == "1"
+}
+
+// OptOutPageData loads the data shown on GET /optout/{website_id}.
+// It returns http.StatusNotFound for unknown websites.
+func OptOutPageData(r *http.Request) (map[string]any, int) {
+	websiteID, err := uuid.Parse(chi.URLParam(r, "website_id"))
+	if err != nil {
+		return nil, http.StatusNotFound
+	}
+
+	var domain string
+	if err := database.DB.QueryRowContext(r.Context(),
+		`SELECT domain FROM website WHERE website_id = $1 AND deleted_at IS NULL`,
+		websiteID,
+	).Scan(&domain); err != nil {
+		return nil, http.StatusNotFound
+	}
+
+	return map[string]any{
+		"Title":     "Analytics opt-out - " + domain,
+		"Domain":    domain,
+		"WebsiteID": websiteID.String(),
+		"OptedOut":  hasOptOutCookie(r, websiteID),
+		"Updated":   r.URL.Query().Get("updated") == "1",
+	}, http.StatusOK
+}
+
+// HandleOptOut is POST /optout/{website_id}. The form field "action" is
+// "optout" (set the exclusion cookie) or "optin" (clear it); the visitor is
+// redirected back to the opt-out page.
+func HandleOptOut(w http.ResponseWriter, r *http.Request) {
+	websiteID, err := uuid.Parse(chi.URLParam(r, "website_id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	secure := secureCookiesEnabled()
+	cookie := &http.Cookie{
+		Name:     optOutCookieName(websiteID),
+		Value:    "1",
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if secure {
+		// Sent with cross-site tracker and pixel requests
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+
+	switch r.FormValue("action") {
+	case "optout":
+		cookie.Expires = time.Now().Add(optOutCookieMaxAge)
+	case "optin":
+		cookie.Value = ""
+		cookie.MaxAge = -1
+	default:
+		http.Error(w, "action must be optout or optin", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, cookie)
+
+	http.Redirect(w, r, "/optout/"+websiteID.String()+"?updated=1", http.StatusSeeOther)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/exclusions"
+)
+
+func newOptOutRequest(t *testing.T, websiteID, action string) *http.Request {
+	t.Helper()
+	form := url.Values{"action": {action}}
+	req := httptest.NewRequest(http.MethodPost, "/optout/"+websiteID, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("website_id", websiteID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestHandleOptOut(t *testing.T) {
+	t.Setenv("SECURE_COOKIES", "true")
+	websiteID := uuid.New()
+
+	rec := httptest.NewRecorder()
+	HandleOptOut(rec, newOptOutRequest(t, websiteID.String(), "optout"))
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	assert.Equal(t, "/optout/"+websiteID.String()+"?updated=1", rec.Header().Get("Location"))
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, optOutCookieName(websiteID), cookies[0].Name)
+	assert.Equal(t, "1", cookies[0].Value)
+	assert.Equal(t, http.SameSiteNoneMode, cookies[0].SameSite)
+	assert.True(t, cookies[0].Secure)
+
+	rec = httptest.NewRecorder()
+	HandleOptOut(rec, newOptOutRequest(t, websiteID.String(), "optin"))
+	cookies = rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, -1, cookies[0].MaxAge)
+
+	rec = httptest.NewRecorder()
+	HandleOptOut(rec, newOptOutRequest(t, websiteID.String(), "maybe"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	HandleOptOut(rec, newOptOutRequest(t, "not-a-uuid", "optout"))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestExcludedTraffic(t *testing.T) {
+	websiteID := uuid.New()
+	matcher, err := exclusions.Compile([]exclusions.Rule{
+		{Kind: exclusions.KindIP, Value: "10.0.0.0/8"},
+		{Kind: exclusions.KindUserAgent, Value: "UptimeRobot"},
+	})
+	require.NoError(t, err)
+
+	exclusionCache.Set(websiteID, matcher)
+	t.Cleanup(func() { exclusionCache.Invalidate(websiteID) })
+
+	req := httptest.NewRequest(http.MethodPost, "/api/send", nil)
+
+	reason, excluded := excludedTraffic(req, websiteID, "10.1.2.3", "Mozilla/5.0")
+	assert.True(t, excluded)
+	assert.Equal(t, exclusions.ReasonIP, reason)
+
+	reason, excluded = excludedTraffic(nil, websiteID, "203.0.113.5", "UptimeRobot/2.0")
+	assert.True(t, excluded)
+	assert.Equal(t, exclusions.ReasonUserAgent, reason)
+
+	_, excluded = excludedTraffic(req, websiteID, "203.0.113.5", "Mozilla/5.0")
+	assert.False(t, excluded)
+
+	req.AddCookie(&http.Cookie{Name: optOutCookieName(websiteID), Value: "1"})
+	reason, excluded = excludedTraffic(req, websiteID, "203.0.113.5", "Mozilla/5.0")
+	assert.True(t, excluded)
+	assert.Equal(t, exclusions.ReasonOptOut, reason)
+
+	// Another website's opt-out does not apply
+	otherID := uuid.New()
+	exclusionCache.Set(otherID, nil)
+	t.Cleanup(func() { exclusionCache.Invalidate(otherID) })
+
+	_, excluded = excludedTraffic(req, otherID, "203.0.113.5", "Mozilla/5.0")
+	assert.False(t, excluded)
+}
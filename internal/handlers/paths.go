@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/httpx"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/middleware"
+	"go.uber.org/zap"
+)
+
+// Paths report anchors and directions
+const (
+	PathsFromEntry        = "entry"  // start from each visit's landing page
+	PathsEventPrefix      = "event:" // "event:signup" anchors on a custom event
+	PathsDirectionNext    = "next"
+	PathsDirectionPrev    = "previous"
+	pathsOtherStep        = "(other)"
+	maxPathsDepth         = 10
+	maxPathsStepsPerLevel = 50
+)
+
+// PathsQuery selects the visits and steps of a paths report
+type PathsQuery struct {
+	From      string // page path, "event:<name>" or "entry"
+	Direction string // next or previous
+	Depth     int    // levels after (or before) the anchor, 1-10
+	Top       int    // steps kept per level, the rest is folded into "(other)"
+	Days      int
+	Country   string
+	Browser   string
+	Device    string
+}
+
+// PathNode is a step at a given distance from the anchor (level 0)
+type PathNode struct {
+	ID      string `json:"id"` // "<level>:<step>", unique across levels
+	Level   int    `json:"level"`
+	Step    string `json:"step"`
+	Visits  int64  `json:"visits"`
+	DropOff int64  `json:"drop_off"` // visits that ended here (or started here, for previous)
+}
+
+// PathLink is a transition between two nodes of consecutive levels
+type PathLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Visits int64  `json:"visits"`
+}
+
+// PathsReport is a Sankey-ready node/link graph
+type PathsReport struct {
+	From      string     `json:"from"`
+	Direction string     `json:"direction"`
+	Depth     int        `json:"depth"`
+	Days      int        `json:"days"`
+	Visits    int64      `json:"visits"` // visits reaching the anchor
+	Nodes     []PathNode `json:"nodes"`
+	Links     []PathLink `json:"links"`
+}
+
+// pathTransition is one aggregated row of the paths query
+type pathTransition struct {
+	Level  int
+	Prev   string // empty at level 0
+	Step   string
+	Visits int64
+}
+
+// Normalize applies defaults and bounds, and validates the anchor
+func (q *PathsQuery) Normalize() error {
+	q.From = strings.TrimSpace(q.From)
+	if q.From == "" {
+		q.From = PathsFromEntry
+	}
+	if q.Direction == "" {
+		q.Direction = PathsDirectionNext
+	}
+	if q.Direction == "prev" {
+		q.Direction = PathsDirectionPrev
+	}
+	if q.Direction != PathsDirectionNext && q.Direction != PathsDirectionPrev {
+		return fmt.Errorf("invalid direction %q (valid: next, previous)", q.Direction)
+	}
+	if q.From == PathsFromEntry && q.Direction == PathsDirectionPrev {
+		return errors.New("nothing precedes the entry page: use --direction next with --from entry")
+	}
+	if strings.HasPrefix(q.From, PathsEventPrefix) && strings.TrimPrefix(q.From, PathsEventPrefix) == "" {
+		return errors.New("event name is required after \"event:\"")
+	}
+	q.Depth = min(max(q.Depth, 1), maxPathsDepth)
+	if q.Top <= 0 {
+		q.Top = 5
+	}
+	q.Top = min(q.Top, maxPathsStepsPerLevel)
+	q.Days = min(max(q.Days, 1), 365)
+	return nil
+}
+
+// LoadPaths builds the paths report from website_event, ordered by created_at within visit_id.
+// Consecutive repeats of the same step (reloads) count once.
+func LoadPaths(ctx context.Context, db *sql.DB, websiteID uuid.UUID, q PathsQuery) (*PathsReport, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{websiteID, q.Days, q.Depth}
+	filters := ""
+	for _, f := range []struct{ column, value string }{
+		{"s.country", q.Country},
+		{"s.browser", q.Browser},
+		{"s.device", q.Device},
+	} {
+		if f.value != "" {
+			args = append(args, f.value)
+			filters += fmt.Sprintf(" AND %s = $%d", f.column, len(args))
+		}
+	}
+
+	anchor := "d.n = 1"
+	if q.From != PathsFromEntry {
+		args = append(args, q.From)
+		anchor = fmt.Sprintf("d.step = $%d", len(args))
+	}
+
+	// Distance from the anchor: positive for next, negated for previous
+	distance := "d.n - a.n"
+	if q.Direction == PathsDirectionPrev {
+		distance = "a.n - d.n"
+	}
+
+	query := `
+		WITH steps AS (
+			SELECT e.visit_id, e.created_at, e.event_id,
+				CASE WHEN e.event_type = 2 THEN '` + PathsEventPrefix + `' || e.event_name ELSE e.url_path END AS step
+			FROM website_event e
+			JOIN session s ON s.session_id = e.session_id
+			WHERE e.website_id = $1
+			  AND e.created_at >= NOW() - INTERVAL '1 day' * $2
+			  AND e.event_type IN (1, 2)
+			  AND (e.event_type = 2 OR e.url_path IS NOT NULL)` + filters + `
+		),
+		deduped AS (
+			SELECT visit_id, step, created_at, event_id
+			FROM (
+				SELECT *, LAG(step) OVER (PARTITION BY visit_id ORDER BY created_at, event_id) AS prev
+				FROM steps
+			) t
+			WHERE prev IS DISTINCT FROM step
+		),
+		numbered AS (
+			SELECT visit_id, step,
+				ROW_NUMBER() OVER (PARTITION BY visit_id ORDER BY created_at, event_id) AS n
+			FROM deduped
+		),
+		anchors AS (
+			SELECT d.visit_id, MIN(d.n) AS n
+			FROM numbered d
+			WHERE ` + anchor + `
+			GROUP BY d.visit_id
+		),
+		walked AS (
+			SELECT d.visit_id, ` + distance + ` AS level, d.step
+			FROM numbered d
+			JOIN anchors a ON a.visit_id = d.visit_id
+			WHERE ` + distance + ` BETWEEN 0 AND $3
+		)
+		SELECT level, COALESCE(prev_step, ''), step, COUNT(*)
+		FROM (
+			SELECT level, step, LAG(step) OVER (PARTITION BY visit_id ORDER BY level) AS prev_step
+			FROM walked
+		) w
+		GROUP BY level, prev_step, step`
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query paths: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var transitions []pathTransition
+	for rows.Next() {
+		var t pathTransition
+		if err := rows.Scan(&t.Level, &t.Prev, &t.Step, &t.Visits); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report := buildPathsReport(transitions, q.Depth, q.Top)
+	report.From = q.From
+	report.Direction = q.Direction
+	report.Days = q.Days
+	return report, nil
+}
+
+// buildPathsReport keeps the top steps of each level, folds the rest into
+// "(other)" and derives links and drop-offs
+func buildPathsReport(transitions []pathTransition, depth, top int) *PathsReport {
+	report := &PathsReport{Depth: depth, Nodes: []PathNode{}, Links: []PathLink{}}
+
+	// Visits per (level, step) before pruning
+	levelCounts := make([]map[string]int64, depth+1)
+	for i := range levelCounts {
+		levelCounts[i] = make(map[string]int64)
+	}
+	for _, t := range transitions {
+		if t.Level >= 0 && t.Level <= depth {
+			levelCounts[t.Level][t.Step] += t.Visits
+		}
+	}
+
+	// Pick the top steps of each level
+	kept := make([]map[string]bool, depth+1)
+	for level, counts := range levelCounts {
+		kept[level] = make(map[string]bool)
+		steps := make([]string, 0, len(counts))
+		for step := range counts {
+			steps = append(steps, step)
+		}
+		sort.Slice(steps, func(i, j int) bool {
+			if counts[steps[i]] != counts[steps[j]] {
+				return counts[steps[i]] > counts[steps[j]]
+			}
+			return steps[i] < steps[j]
+		})
+		for i, step := range steps {
+			if i >= top {
+				break
+			}
+			kept[level][step] = true
+		}
+	}
+
+	label := func(level int, step string) string {
+		if kept[level][step] {
+			return step
+		}
+		return pathsOtherStep
+	}
+	nodeID := func(level int, step string) string {
+		return fmt.Sprintf("%d:%s", level, step)
+	}
+
+	nodeVisits := make(map[string]int64)
+	outgoing := make(map[string]int64)
+	links := make(map[[2]string]int64)
+	for _, t := range transitions {
+		if t.Level < 0 || t.Level > depth {
+			continue
+		}
+		target := nodeID(t.Level, label(t.Level, t.Step))
+		nodeVisits[target] += t.Visits
+		if t.Level == 0 {
+			continue
+		}
+		source := nodeID(t.Level-1, label(t.Level-1, t.Prev))
+		links[[2]string{source, target}] += t.Visits
+		outgoing[source] += t.Visits
+	}
+
+	for level := 0; level <= depth; level++ {
+		var levelNodes []PathNode
+		for id, visits := range nodeVisits {
+			prefix := fmt.Sprintf("%d:", level)
+			if !strings.HasPrefix(id, prefix) {
+				continue
+			}
+			node := PathNode{
+				ID:     id,
+				Level:  level,
+				Step:   strings.TrimPrefix(id, prefix),
+				Visits: visits,
+			}
+			if level < depth {
+				node.DropOff = visits - outgoing[id]
+			}
+			levelNodes = append(levelNodes, node)
+		}
+		sort.Slice(levelNodes, func(i, j int) bool {
+			// "(other)" always last within its level
+			if (levelNodes[i].Step == pathsOtherStep) != (levelNodes[j].Step == pathsOtherStep) {
+				return levelNodes[j].Step == pathsOtherStep
+			}
+			if levelNodes[i].Visits != levelNodes[j].Visits {
+				return levelNodes[i].Visits > levelNodes[j].Visits
+			}
+			return levelNodes[i].Step < levelNodes[j].Step
+		})
+		report.Nodes = append(report.Nodes, levelNodes...)
+		if level == 0 {
+			for _, n := range levelNodes {
+				report.Visits += n.Visits
+			}
+		}
+	}
+
+	for key, visits := range links {
+		report.Links = append(report.Links, PathLink{Source: key[0], Target: key[1], Visits: visits})
+	}
+	sort.Slice(report.Links, func(i, j int) bool {
+		if report.Links[i].Visits != report.Links[j].Visits {
+			return report.Links[i].Visits > report.Links[j].Visits
+		}
+		if report.Links[i].Source != report.Links[j].Source {
+			return report.Links[i].Source < report.Links[j].Source
+		}
+		return report.Links[i].Target < report.Links[j].Target
+	})
+
+	return report
+}
+
+// pathsQueryFromRequest reads the report parameters shared by the dashboard and API
+func pathsQueryFromRequest(r *http.Request) PathsQuery {
+	return PathsQuery{
+		From:      httpx.QueryString(r, "from", PathsFromEntry),
+		Direction: httpx.QueryString(r, "direction", PathsDirectionNext),
+		Depth:     httpx.QueryInt(r, "depth", 3),
+		Top:       httpx.QueryInt(r, "top", 5),
+		Days:      httpx.QueryInt(r, "days", 7),
+		Country:   r.URL.Query().Get("country"),
+		Browser:   r.URL.Query().Get("browser"),
+		Device:    r.URL.Query().Get("device"),
+	}
+}
+
+// HandlePaths returns the paths report via Datastar SSE
+// GET /api/dashboard/paths?website_id=...&from=/pricing&direction=next&depth=3&days=7
+func HandlePaths(w http.ResponseWriter, r *http.Request) {
+	websiteIDStr := selectedWebsiteFromRequest(r)
+	if websiteIDStr == "" {
+		websiteIDStr = r.URL.Query().Get("website_id")
+	}
+
+	var parseErr string
+	var websiteID uuid.UUID
+	if websiteIDStr == "" {
+		parseErr = "Website ID is required"
+	} else {
+		var err error
+		websiteID, err = uuid.Parse(websiteIDStr)
+		if err != nil {
+			parseErr = "Invalid website ID"
+		}
+	}
+
+	q := pathsQueryFromRequest(r)
+	if parseErr == "" {
+		if err := q.Normalize(); err != nil {
+			parseErr = err.Error()
+		}
+	}
+
+	var report *PathsReport
+	var queryErr error
+	if parseErr == "" {
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+		report, queryErr = LoadPaths(ctx, database.DB, websiteID, q)
+	}
+
+	streamDatastar(w, func(sse *DatastarSSE) {
+		if parseErr != "" {
+			_ = sse.PatchSignals(map[string]any{
+				"pathsError":   parseErr,
+				"pathsLoading": false,
+			})
+			return
+		}
+
+		if queryErr != nil {
+			logging.L().Warn("failed to load paths",
+				zap.String("website_id", websiteID.String()),
+				zap.Error(queryErr))
+			_ = sse.PatchSignals(map[string]any{
+				"pathsError":   "Failed to load paths",
+				"pathsLoading": false,
+			})
+			return
+		}
+
+		_ = sse.PatchElementsWithMode("#paths-content", buildPathsHTML(report), "inner")
+		_ = sse.PatchSignals(map[string]any{
+			"pathsLoading": false,
+			"pathsError":   false,
+		})
+	})
+}
+
+// HandleAPIPaths returns the paths report as JSON via API key (stats scope)
+// GET /api/v1/stats/:website_id/paths?from=/pricing&direction=next&depth=4
+func HandleAPIPaths(w http.ResponseWriter, r *http.Request) {
+	websiteID, err := uuid.Parse(chi.URLParam(r, "website_id"))
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, "Invalid website ID")
+		return
+	}
+
+	apiKey := middleware.GetAPIKey(r)
+	if apiKey == nil {
+		httpx.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if !apiKey.HasScope("stats") {
+		httpx.Error(w, http.StatusForbidden, "API key does not have stats permission")
+		return
+	}
+	if apiKey.WebsiteID != websiteID {
+		httpx.Error(w, http.StatusForbidden, "API key not authorized for this website")
+		return
+	}
+
+	q := pathsQueryFromRequest(r)
+	if err := q.Normalize(); err != nil {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	report, err := LoadPaths(ctx, database.DB, websiteID, q)
+	if err != nil {
+		logging.L().Warn("failed to load paths", zap.String("website_id", websiteID.String()), zap.Error(err))
+		httpx.Error(w, http.StatusInternalServerError, "Failed to fetch paths")
+		return
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, report)
+}
+
+// buildPathsHTML renders one column per level, each step with visits and drop-off
+func buildPathsHTML(report *PathsReport) string {
+	if report.Visits == 0 {
+		return `<div class="empty-state-mini"><div>[empty]</div><div>No visits reached this step in the selected period</div></div>`
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div class="paths-flow">`)
+	for level := 0; level <= report.Depth; level++ {
+		var nodes []PathNode
+		for _, n := range report.Nodes {
+			if n.Level == level {
+				nodes = append(nodes, n)
+			}
+		}
+		if len(nodes) == 0 {
+			break
+		}
+
+		heading := "Start"
+		if level > 0 {
+			if report.Direction == PathsDirectionPrev {
+				heading = fmt.Sprintf("%d step(s) before", level)
+			} else {
+				heading = fmt.Sprintf("Step %d", level)
+			}
+		}
+
+		b.WriteString(`<div class="paths-level"><div class="paths-level-title">` + heading + `</div>`)
+		for _, n := range nodes {
+			share := float64(n.Visits) / float64(report.Visits) * 100
+			b.WriteString(`<div class="paths-node glass">`)
+			b.WriteString(`<div class="paths-step" title="` + escapeHTML(n.Step) + `">` + escapeHTML(n.Step) + `</div>`)
+			b.WriteString(fmt.Sprintf(`<div class="paths-meta">%s visits · %.1f%%</div>`, formatNumber(int(n.Visits)), share))
+			if n.DropOff > 0 {
+				b.WriteString(fmt.Sprintf(`<div class="paths-dropoff">%s drop-off</div>`, formatNumber(int(n.DropOff))))
+			}
+			b.WriteString(`</div>`)
+		}
+		b.WriteString(`</div>`)
+	}
+	b.WriteString(`</div>`)
+	return b.String()
+}
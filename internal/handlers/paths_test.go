@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathsQueryNormalize(t *testing.T) {
+	q := PathsQuery{Depth: 50, Top: 0, Days: 0}
+	require.NoError(t, q.Normalize())
+	assert.Equal(t, PathsFromEntry, q.From)
+	assert.Equal(t, PathsDirectionNext, q.Direction)
+	assert.Equal(t, maxPathsDepth, q.Depth)
+	assert.Equal(t, 5, q.Top)
+	assert.Equal(t, 1, q.Days)
+
+	q = PathsQuery{From: "/pricing", Direction: "prev", Depth: 2, Days: 7}
+	require.NoError(t, q.Normalize())
+	assert.Equal(t, PathsDirectionPrev, q.Direction)
+
+	q = PathsQuery{From: PathsFromEntry, Direction: PathsDirectionPrev}
+	assert.Error(t, q.Normalize())
+
+	q = PathsQuery{From: "/pricing", Direction: "sideways"}
+	assert.Error(t, q.Normalize())
+
+	q = PathsQuery{From: "event:"}
+	assert.Error(t, q.Normalize())
+}
+
+func TestBuildPathsReport(t *testing.T) {
+	transitions := []pathTransition{
+		{Level: 0, Step: "/pricing", Visits: 10},
+		{Level: 1, Prev: "/pricing", Step: "/signup", Visits: 6},
+		{Level: 1, Prev: "/pricing", Step: "/docs", Visits: 2},
+		{Level: 1, Prev: "/pricing", Step: "/blog", Visits: 1},
+		{Level: 2, Prev: "/signup", Step: "event:signup", Visits: 4},
+		{Level: 2, Prev: "/blog", Step: "/about", Visits: 1},
+	}
+
+	report := buildPathsReport(transitions, 2, 2)
+
+	assert.Equal(t, int64(10), report.Visits)
+
+	nodes := make(map[string]PathNode)
+	for _, n := range report.Nodes {
+		nodes[n.ID] = n
+	}
+	require.Len(t, nodes, 6)
+
+	// Anchor: 9 of 10 visits continued
+	assert.Equal(t, int64(1), nodes["0:/pricing"].DropOff)
+
+	// /blog falls outside the top 2 and is folded into (other)
+	assert.Equal(t, int64(6), nodes["1:/signup"].Visits)
+	assert.Equal(t, int64(2), nodes["1:/signup"].DropOff)
+	assert.Equal(t, int64(1), nodes["1:(other)"].Visits)
+	assert.Equal(t, int64(0), nodes["1:(other)"].DropOff)
+	assert.Equal(t, int64(2), nodes["1:/docs"].DropOff)
+
+	// Last level has no drop-off: the walk stops there
+	assert.Equal(t, int64(0), nodes["2:event:signup"].DropOff)
+
+	// (other) sorts last within its level
+	var level1 []string
+	for _, n := range report.Nodes {
+		if n.Level == 1 {
+			level1 = append(level1, n.Step)
+		}
+	}
+	assert.Equal(t, []string{"/signup", "/docs", "(other)"}, level1)
+
+	assert.Contains(t, report.Links, PathLink{Source: "0:/pricing", Target: "1:/signup", Visits: 6})
+	assert.Contains(t, report.Links, PathLink{Source: "1:(other)", Target: "2:/about", Visits: 1})
+	assert.Equal(t, "0:/pricing", report.Links[0].Source)
+}
+
+func TestBuildPathsReportEmpty(t *testing.T) {
+	report := buildPathsReport(nil, 3, 5)
+	assert.Zero(t, report.Visits)
+	assert.Empty(t, report.Nodes)
+	assert.NotNil(t, report.Links)
+	assert.Contains(t, buildPathsHTML(report), "No visits")
+}
+
+func TestBuildPathsHTMLEscapesSteps(t *testing.T) {
+	report := buildPathsReport([]pathTransition{
+		{Level: 0, Step: "/<script>", Visits: 3},
+	}, 1, 5)
+	html := buildPathsHTML(report)
+	assert.NotContains(t, html, "<script>")
+	assert.Contains(t, html, "3 visits")
+}
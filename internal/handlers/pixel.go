@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bytes"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -151,6 +152,7 @@ func servePixel(w http.ResponseWriter) {
 type trackingResponseRecorder struct {
 	header http.Header
 	status int
+	body   bytes.Buffer
 }
 
 func newTrackingResponseRecorder() *trackingResponseRecorder {
@@ -165,7 +167,7 @@ func (r *trackingResponseRecorder) Header() http.Header {
 }
 
 func (r *trackingResponseRecorder) Write(b []byte) (int, error) {
-	return len(b), nil
+	return r.body.Write(b)
 }
 
 func (r *trackingResponseRecorder) WriteHeader(status int) {
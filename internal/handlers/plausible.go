@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/seuros/kaunta/internal/httpx"
+	"github.com/seuros/kaunta/internal/logging"
+	"go.uber.org/zap"
+)
+
+// PlausiblePayload matches Plausible's /api/event body.
+// The Plausible script sends single-letter keys (n, u, d, r, p, $),
+// the Events API documents the long form; both are accepted.
+type PlausiblePayload struct {
+	Name     string            `json:"name"`
+	URL      string            `json:"url"`
+	Domain   string            `json:"domain"`
+	Referrer *string           `json:"referrer,omitempty"`
+	Props    json.RawMessage   `json:"props,omitempty"`
+	Revenue  *PlausibleRevenue `json:"revenue,omitempty"`
+
+	ShortName     string            `json:"n"`
+	ShortURL      string            `json:"u"`
+	ShortDomain   string            `json:"d"`
+	ShortReferrer *string           `json:"r,omitempty"`
+	ShortProps    json.RawMessage   `json:"p,omitempty"`
+	ShortRevenue  *PlausibleRevenue `json:"$,omitempty"`
+}
+
+// PlausibleRevenue is the revenue attached to a goal conversion
+type PlausibleRevenue struct {
+	Currency string          `json:"currency"`
+	Amount   json.RawMessage `json:"amount"` // number or decimal string
+}
+
+// HandlePlausibleEvent is the /api/event endpoint - compatible with Plausible
+// The payload is mapped onto /api/send so origin checks, bot detection
+// and goal matching behave exactly as for the Umami-compatible tracker.
+func HandlePlausibleEvent(w http.ResponseWriter, r *http.Request) {
+	// Plausible's script posts JSON as text/plain, so don't check Content-Type
+	var p PlausiblePayload
+	if err := httpx.ReadJSON(r, &p); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	p.normalize()
+
+	if p.Name == "" || p.URL == "" || p.Domain == "" {
+		httpx.Error(w, http.StatusBadRequest, "name, url and domain are required")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	website, err := lookupPlausibleDomain(ctx, p.Domain)
+	if err != nil {
+		httpx.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	payload, err := buildPlausibleTrackingPayload(&p, website.WebsiteID)
+	if err != nil {
+		httpx.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if lang := r.Header.Get("Accept-Language"); lang != "" && payload.Payload.Language == nil {
+		payload.Payload.Language = &lang
+	}
+
+	recorder := newTrackingResponseRecorder()
+	HandleTracking(recorder, withPixelPayload(r, payload))
+
+	if allowOrigin := recorder.header.Get("Access-Control-Allow-Origin"); allowOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+	}
+
+	if recorder.status >= 400 {
+		logging.L().Debug("plausible event rejected",
+			zap.String("domain", p.Domain),
+			zap.Int("status", recorder.status),
+		)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(recorder.status)
+		_, _ = w.Write(recorder.body.Bytes())
+		return
+	}
+
+	// Plausible replies 202 "ok" for accepted, ignored (bot) and dropped events alike
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// normalize folds the short-key form into the long-key fields
+func (p *PlausiblePayload) normalize() {
+	if p.Name == "" {
+		p.Name = p.ShortName
+	}
+	if p.URL == "" {
+		p.URL = p.ShortURL
+	}
+	if p.Domain == "" {
+		p.Domain = p.ShortDomain
+	}
+	if p.Referrer == nil {
+		p.Referrer = p.ShortReferrer
+	}
+	if len(p.Props) == 0 {
+		p.Props = p.ShortProps
+	}
+	if p.Revenue == nil {
+		p.Revenue = p.ShortRevenue
+	}
+}
+
+// lookupPlausibleDomain maps Plausible's data-domain onto a Kaunta website.
+// Plausible allows a comma-separated list for roll-ups; the first domain wins.
+func lookupPlausibleDomain(ctx context.Context, domain string) (*WebsiteDetail, error) {
+	domain = strings.TrimSpace(strings.Split(domain, ",")[0])
+
+	website, err := getWebsiteByDomain(ctx, domain)
+	if err != nil && strings.HasPrefix(strings.ToLower(domain), "www.") {
+		website, err = getWebsiteByDomain(ctx, domain[len("www."):])
+	}
+	return website, err
+}
+
+// buildPlausibleTrackingPayload converts a Plausible event into a /api/send payload
+func buildPlausibleTrackingPayload(p *PlausiblePayload, websiteID string) (TrackingPayload, error) {
+	payload := TrackingPayload{
+		Type: "event",
+		Payload: PayloadData{
+			Website: websiteID,
+			URL:     &p.URL,
+		},
+	}
+
+	if u, err := url.Parse(p.URL); err == nil && u.Hostname() != "" {
+		h := u.Hostname()
+		payload.Payload.Hostname = &h
+	}
+
+	// "pageview" is Plausible's built-in pageview event; anything else is custom
+	if p.Name != "pageview" {
+		name := p.Name
+		payload.Payload.Name = &name
+	}
+
+	if p.Referrer != nil && *p.Referrer != "" {
+		payload.Payload.Referrer = p.Referrer
+	}
+
+	props, err := decodePlausibleProps(p.Props)
+	if err != nil {
+		return payload, err
+	}
+
+	if p.Revenue != nil && p.Revenue.Currency != "" && len(p.Revenue.Amount) > 0 {
+		if props == nil {
+			props = make(map[string]interface{})
+		}
+		var amount interface{}
+		_ = json.Unmarshal(p.Revenue.Amount, &amount)
+		props["revenue"] = map[string]interface{}{
+			"currency": strings.ToUpper(p.Revenue.Currency),
+			"amount":   amount,
+		}
+	}
+
+	if len(props) > 0 {
+		payload.Payload.Props = props
+	}
+
+	// UTM parameters live in the page URL for Plausible
+	if u, err := url.Parse(p.URL); err == nil {
+		q := u.Query()
+		for key, dst := range map[string]**string{
+			"utm_source":   &payload.Payload.UTMSource,
+			"utm_medium":   &payload.Payload.UTMMedium,
+			"utm_campaign": &payload.Payload.UTMCampaign,
+			"utm_term":     &payload.Payload.UTMTerm,
+			"utm_content":  &payload.Payload.UTMContent,
+		} {
+			if v := q.Get(key); v != "" {
+				*dst = &v
+			}
+		}
+	}
+
+	return payload, nil
+}
+
+// decodePlausibleProps accepts props as an object or as a JSON-encoded string
+// (older Plausible scripts stringify props before sending)
+func decodePlausibleProps(raw json.RawMessage) (map[string]interface{}, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var props map[string]interface{}
+	if err := json.Unmarshal(raw, &props); err == nil {
+		return props, nil
+	}
+
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		if err := json.Unmarshal([]byte(encoded), &props); err == nil {
+			return props, nil
+		}
+	}
+
+	return nil, errors.New("props must be an object")
+}
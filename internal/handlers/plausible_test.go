@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlausiblePayloadShortKeys(t *testing.T) {
+	var p PlausiblePayload
+	body := `{"n":"pageview","u":"https://example.com/pricing?utm_source=newsletter","d":"example.com","r":"https://news.ycombinator.com/","p":{"plan":"pro"}}`
+	require.NoError(t, json.Unmarshal([]byte(body), &p))
+	p.normalize()
+
+	assert.Equal(t, "pageview", p.Name)
+	assert.Equal(t, "example.com", p.Domain)
+	require.NotNil(t, p.Referrer)
+	assert.Equal(t, "https://news.ycombinator.com/", *p.Referrer)
+
+	payload, err := buildPlausibleTrackingPayload(&p, "site-id")
+	require.NoError(t, err)
+
+	assert.Equal(t, "event", payload.Type)
+	assert.Equal(t, "site-id", payload.Payload.Website)
+	assert.Nil(t, payload.Payload.Name, "pageview maps to a plain pageview")
+	require.NotNil(t, payload.Payload.Hostname)
+	assert.Equal(t, "example.com", *payload.Payload.Hostname)
+	require.NotNil(t, payload.Payload.UTMSource)
+	assert.Equal(t, "newsletter", *payload.Payload.UTMSource)
+	assert.Equal(t, "pro", payload.Payload.Props["plan"])
+}
+
+func TestPlausiblePayloadCustomEventWithRevenue(t *testing.T) {
+	var p PlausiblePayload
+	body := `{"name":"Purchase","url":"https://example.com/checkout","domain":"example.com","revenue":{"currency":"eur","amount":"29.99"}}`
+	require.NoError(t, json.Unmarshal([]byte(body), &p))
+	p.normalize()
+
+	payload, err := buildPlausibleTrackingPayload(&p, "site-id")
+	require.NoError(t, err)
+
+	require.NotNil(t, payload.Payload.Name)
+	assert.Equal(t, "Purchase", *payload.Payload.Name)
+	assert.Equal(t, map[string]interface{}{"currency": "EUR", "amount": "29.99"}, payload.Payload.Props["revenue"])
+}
+
+func TestDecodePlausibleProps(t *testing.T) {
+	props, err := decodePlausibleProps(json.RawMessage(`{"a":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), props["a"])
+
+	props, err = decodePlausibleProps(json.RawMessage(`"{\"author\":\"jane\"}"`))
+	require.NoError(t, err)
+	assert.Equal(t, "jane", props["author"])
+
+	props, err = decodePlausibleProps(nil)
+	require.NoError(t, err)
+	assert.Nil(t, props)
+
+	_, err = decodePlausibleProps(json.RawMessage(`[1,2]`))
+	assert.Error(t, err)
+}
+
+func TestHandlePlausibleEventValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", `{`},
+		{"missing domain", `{"name":"pageview","url":"https://example.com/"}`},
+		{"missing name", `{"url":"https://example.com/","domain":"example.com"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/event", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "text/plain")
+			rec := httptest.NewRecorder()
+
+			HandlePlausibleEvent(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
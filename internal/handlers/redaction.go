@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/models"
+	"github.com/seuros/kaunta/internal/redact"
+	"go.uber.org/zap"
+)
+
+// redactorCache holds each website's redactor (nil when redaction is off)
+var redactorCache = newTTLCache(settingsCacheTTL, func(ctx context.Context, websiteID uuid.UUID) (*redact.Redactor, error) {
+	cfg, err := models.GetRedactionConfig(ctx, database.DB, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	return redact.New(cfg), nil
+})
+
+// redactableEvent holds the event fields scanned for personal data. URLs are
+// redacted whole so the path feeds session entry/exit pages, errors and the
+// realtime stream already cleaned.
+type redactableEvent struct {
+	URL      *string
+	Referrer *string
+	Title    *string
+	Props    map[string]any
+	Data     map[string]any
+
+	// Frontend error fields
+	Message *string
+	Stack   *string
+	Source  *string
+}
+
+// redactPayload redacts a /api/send payload in place (also used by the pixel,
+// the compatibility endpoints and the access-log importer)
+func redactPayload(ctx context.Context, websiteID uuid.UUID, createdAt time.Time, p *PayloadData) {
+	ev := redactableEvent{
+		URL:      p.URL,
+		Referrer: p.Referrer,
+		Title:    p.Title,
+		Props:    p.Props,
+		Data:     p.Data,
+		Message:  p.Message,
+		Stack:    p.Stack,
+		Source:   p.Source,
+	}
+	redactEvent(ctx, websiteID, createdAt, &ev)
+	p.URL, p.Referrer, p.Title, p.Props, p.Data = ev.URL, ev.Referrer, ev.Title, ev.Props, ev.Data
+	p.Message, p.Stack, p.Source = ev.Message, ev.Stack, ev.Source
+}
+
+// redactEvent masks or drops personal data in ev before anything is stored or
+// broadcast (shared by /api/send, the pixel and /api/ingest) and counts what
+// was redacted. When the settings can't be loaded the default (mask) is applied.
+func redactEvent(ctx context.Context, websiteID uuid.UUID, createdAt time.Time, ev *redactableEvent) {
+	redactor, err := redactorCache.Get(websiteID)
+	if err != nil {
+		logging.L().Warn("failed to load redaction settings, using defaults",
+			zap.String("website_id", websiteID.String()), zap.Error(err))
+		redactor = redact.New(redact.Config{Mode: redact.ModeMask})
+	}
+	if redactor == nil {
+		return
+	}
+
+	counts := redact.Counts{}
+	ev.URL = redactOptional(ev.URL, redactor.URL, counts)
+	ev.Referrer = redactOptional(ev.Referrer, redactor.URL, counts)
+	ev.Title = redactOptional(ev.Title, redactor.Text, counts)
+	if ev.Props != nil {
+		ev.Props = redactor.Props(ev.Props, counts)
+	}
+	if ev.Data != nil {
+		ev.Data = redactor.Props(ev.Data, counts)
+	}
+	ev.Message = redactOptional(ev.Message, redactor.Text, counts)
+	ev.Stack = redactOptional(ev.Stack, redactor.Text, counts)
+	ev.Source = redactOptional(ev.Source, redactor.URL, counts)
+
+	if counts.Total() == 0 {
+		return
+	}
+	if err := models.RecordRedactions(ctx, database.DB, websiteID, createdAt, counts); err != nil {
+		logging.L().Warn("failed to record redactions",
+			zap.String("website_id", websiteID.String()), zap.Error(err))
+	}
+}
+
+// redactOptional applies fn to a nullable field; an emptied value becomes NULL
+func redactOptional(value *string, fn func(string, redact.Counts) string, counts redact.Counts) *string {
+	if value == nil {
+		return nil
+	}
+	redacted := fn(*value, counts)
+	if redacted == "" {
+		return nil
+	}
+	return &redacted
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/redact"
+)
+
+func TestRedactEvent(t *testing.T) {
+	driverName, err := registerMockDriver(newMockQueue(nil))
+	require.NoError(t, err)
+	db, err := sql.Open(driverName, "")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	websiteID := uuid.New()
+	redactorCache.Set(websiteID, redact.New(redact.Config{Mode: redact.ModeDrop, Params: []string{"invite"}}))
+	t.Cleanup(func() { redactorCache.Invalidate(websiteID) })
+
+	pageURL := "https://example.com/users/jane@example.com?invite=abc&page=2"
+	referrer := "https://mail.example.com/?email=jane@example.com"
+	title := "Hello jane@example.com"
+	ev := redactableEvent{
+		URL:      &pageURL,
+		Referrer: &referrer,
+		Title:    &title,
+		Props:    map[string]any{"token": "abc", "plan": "pro"},
+	}
+	redactEvent(context.Background(), websiteID, time.Now(), &ev)
+
+	require.NotNil(t, ev.URL)
+	assert.Equal(t, "https://example.com/users/?page=2", *ev.URL)
+	require.NotNil(t, ev.Referrer)
+	assert.Equal(t, "https://mail.example.com/", *ev.Referrer)
+	require.NotNil(t, ev.Title)
+	assert.Equal(t, "Hello ", *ev.Title)
+	assert.Equal(t, map[string]any{"plan": "pro"}, ev.Props)
+}
+
+func TestRedactPayloadCoversErrorFields(t *testing.T) {
+	websiteID := uuid.New()
+	redactorCache.Set(websiteID, redact.New(redact.Config{Mode: redact.ModeMask}))
+	t.Cleanup(func() { redactorCache.Invalidate(websiteID) })
+
+	// Mock driver without expectations: recording the counts fails and is only logged
+	driverName, err := registerMockDriver(newMockQueue(nil))
+	require.NoError(t, err)
+	db, err := sql.Open(driverName, "")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	originalDB := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = originalDB })
+
+	p := PayloadData{
+		URL:     strPtr("https://example.com/account/jane@example.com"),
+		Message: strPtr("User jane@example.com not found"),
+		Stack:   strPtr("Error: jane@example.com\n    at https://example.com/app.js:1:2"),
+		Source:  strPtr("https://example.com/app.js?token=abc"),
+		Data:    map[string]any{"email": "jane@example.com"},
+	}
+	redactPayload(context.Background(), websiteID, time.Now(), &p)
+
+	assert.Equal(t, "https://example.com/account/%5Bemail%5D", *p.URL)
+	assert.Equal(t, "User [email] not found", *p.Message)
+	assert.NotContains(t, *p.Stack, "jane@example.com")
+	assert.Equal(t, "https://example.com/app.js?token=%5Bredacted%5D", *p.Source)
+	assert.Equal(t, "[redacted]", p.Data["email"])
+}
+
+func TestRedactEventOff(t *testing.T) {
+	websiteID := uuid.New()
+	redactorCache.Set(websiteID, nil)
+	t.Cleanup(func() { redactorCache.Invalidate(websiteID) })
+
+	pageURL := "https://example.com/?email=jane@example.com"
+	ev := redactableEvent{URL: &pageURL}
+	redactEvent(context.Background(), websiteID, time.Now(), &ev)
+	assert.Equal(t, "https://example.com/?email=jane@example.com", *ev.URL)
+}
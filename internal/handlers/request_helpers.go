@@ -1,23 +1,231 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"net/http"
+	"net/netip"
 	"strings"
+	"sync"
 
-	"github.com/seuros/kaunta/internal/httpx"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/seuros/kaunta/internal/database"
+)
+
+// ProxyModes lists the accepted website.proxy_mode values (see check_proxy_mode)
+var ProxyModes = []string{"none", "xforwarded", "cloudflare", "fastly", "akamai", "bunny"}
+
+// cdnClientIPHeaders are the documented client IP headers of each CDN proxy mode
+var cdnClientIPHeaders = map[string]string{
+	"cloudflare": "CF-Connecting-IP",
+	"fastly":     "Fastly-Client-IP",
+	"akamai":     "True-Client-IP",
+	"bunny":      "CDN-ClientIP",
+}
+
+// proxySettings is how a website's requests reach Kaunta
+type proxySettings struct {
+	Mode           string
+	TrustedProxies []netip.Prefix // per-website, in addition to the global list
+}
+
+var (
+	globalTrustedProxies   []netip.Prefix
+	globalTrustedProxiesMu sync.RWMutex
 )
 
-// clientIPFromRequest derives the client IP according to proxy mode configuration.
-func clientIPFromRequest(r *http.Request, proxyMode string) string {
-	switch proxyMode {
-	case "cloudflare":
-		if cfIP := r.Header.Get("CF-Connecting-IP"); cfIP != "" {
-			return strings.TrimSpace(strings.Split(cfIP, ",")[0])
+// SetTrustedProxies configures the global trusted proxy list (IPs or CIDRs).
+// When non-empty, forwarding headers are only honored from these peers.
+func SetTrustedProxies(entries []string) error {
+	prefixes, err := ParseTrustedProxies(entries)
+	if err != nil {
+		return err
+	}
+	globalTrustedProxiesMu.Lock()
+	globalTrustedProxies = prefixes
+	globalTrustedProxiesMu.Unlock()
+	return nil
+}
+
+// ParseTrustedProxies parses IPs and CIDRs; a bare IP is a single-host prefix
+func ParseTrustedProxies(entries []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy CIDR %q", entry)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
 		}
-	case "xforwarded":
-		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-			return strings.TrimSpace(strings.Split(xff, ",")[0])
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy IP %q", entry)
 		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// loadProxySettings reads the website's proxy mode and trusted proxies
+func loadProxySettings(ctx context.Context, websiteID uuid.UUID) (proxySettings, error) {
+	var settings proxySettings
+	var trusted []string
+	err := database.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(proxy_mode, 'none'), COALESCE(trusted_proxies::text[], '{}')
+		FROM website WHERE website_id = $1
+	`, websiteID).Scan(&settings.Mode, pq.Array(&trusted))
+	if err != nil {
+		return settings, err
+	}
+	// Stored as CIDR[] so entries are already validated by PostgreSQL
+	settings.TrustedProxies, _ = ParseTrustedProxies(trusted)
+	return settings, nil
+}
+
+// clientIPFromRequest derives the client IP according to proxy configuration.
+//
+// When trusted proxies are configured (globally or for the website), forwarding
+// headers are ignored unless the direct peer is one of them. CDN modes read their
+// documented client IP header; otherwise the Forwarded / X-Forwarded-For chain is
+// walked right to left, skipping trusted hops, falling back to X-Real-IP and
+// True-Client-IP.
+func clientIPFromRequest(r *http.Request, settings proxySettings) string {
+	remote := remoteAddrIP(r)
+
+	globalTrustedProxiesMu.RLock()
+	trusted := append(append([]netip.Prefix{}, globalTrustedProxies...), settings.TrustedProxies...)
+	globalTrustedProxiesMu.RUnlock()
+
+	if len(trusted) > 0 && !prefixesContain(trusted, remote) {
+		return remote
+	}
+
+	if header, ok := cdnClientIPHeaders[settings.Mode]; ok {
+		if ip := firstValidIP(r.Header.Get(header)); ip != "" {
+			return ip
+		}
+	}
+
+	if ip := walkForwardedChain(forwardedChain(r), trusted); ip != "" {
+		return ip
+	}
+
+	for _, header := range []string{"X-Real-IP", "True-Client-IP"} {
+		if ip := firstValidIP(r.Header.Get(header)); ip != "" {
+			return ip
+		}
+	}
+
+	return remote
+}
+
+// forwardedChain returns the proxy chain (client first) from the RFC 7239
+// Forwarded header, or from X-Forwarded-For when Forwarded is absent
+func forwardedChain(r *http.Request) []string {
+	if values := r.Header.Values("Forwarded"); len(values) > 0 {
+		if hops := parseForwardedHeader(strings.Join(values, ",")); len(hops) > 0 {
+			return hops
+		}
+	}
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				hops = append(hops, part)
+			}
+		}
+	}
+	return hops
+}
+
+// parseForwardedHeader extracts the for= node of each RFC 7239 forwarded-element.
+// Obfuscated and "unknown" nodes are kept (as-is) so the chain keeps its shape.
+func parseForwardedHeader(value string) []string {
+	var hops []string
+	for _, element := range strings.Split(value, ",") {
+		for _, pair := range strings.Split(element, ";") {
+			key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || !strings.EqualFold(key, "for") {
+				continue
+			}
+			hops = append(hops, normalizeForwardedNode(val))
+		}
+	}
+	return hops
+}
+
+// normalizeForwardedNode turns `"[2001:db8::1]:4711"` or `192.0.2.1:80` into a bare IP
+func normalizeForwardedNode(node string) string {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+	if strings.HasPrefix(node, "[") {
+		if end := strings.Index(node, "]"); end > 0 {
+			return node[1:end]
+		}
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return node
+}
+
+// walkForwardedChain returns the rightmost hop that isn't a trusted proxy.
+// The rightmost entry was appended by the proxy closest to Kaunta, so unlike the
+// leftmost one it can't be forged by the client.
+func walkForwardedChain(hops []string, trusted []netip.Prefix) string {
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			// Unknown/obfuscated node: nothing further left can be trusted
+			if i < len(hops)-1 {
+				return hops[i+1]
+			}
+			return ""
+		}
+		if i == 0 || !prefixesContain(trusted, addr.Unmap().String()) {
+			return addr.Unmap().String()
+		}
+	}
+	return ""
+}
+
+func prefixesContain(prefixes []netip.Prefix, ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstValidIP returns the first entry of a (possibly comma-separated) header if it is an IP
+func firstValidIP(value string) string {
+	first := strings.TrimSpace(strings.Split(value, ",")[0])
+	if addr, err := netip.ParseAddr(first); err == nil {
+		return addr.Unmap().String()
+	}
+	return ""
+}
+
+func remoteAddrIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
 	}
-	return httpx.ClientIP(r)
+	return host
 }
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/httpx"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/middleware"
+	"github.com/seuros/kaunta/internal/models"
+	"go.uber.org/zap"
+)
+
+// SegmentMessage is a Segment HTTP Tracking API call (track, page, screen or identify)
+// https://segment.com/docs/connections/sources/catalog/libraries/server/http-api/
+type SegmentMessage struct {
+	Type        string                 `json:"type"`
+	MessageID   string                 `json:"messageId"`
+	AnonymousID string                 `json:"anonymousId"`
+	UserID      string                 `json:"userId"`
+	Event       string                 `json:"event"`
+	Name        string                 `json:"name"`
+	Properties  map[string]interface{} `json:"properties"`
+	Traits      map[string]interface{} `json:"traits"`
+	Context     *SegmentContext        `json:"context"`
+	Timestamp   string                 `json:"timestamp"`
+}
+
+// SegmentContext holds the subset of Segment's context object Kaunta understands
+type SegmentContext struct {
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+	Locale    string `json:"locale"`
+	Page      *struct {
+		URL      string `json:"url"`
+		Path     string `json:"path"`
+		Referrer string `json:"referrer"`
+		Title    string `json:"title"`
+		Search   string `json:"search"`
+	} `json:"page"`
+	Screen *struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"screen"`
+	Campaign *struct {
+		Name    string `json:"name"`
+		Source  string `json:"source"`
+		Medium  string `json:"medium"`
+		Term    string `json:"term"`
+		Content string `json:"content"`
+	} `json:"campaign"`
+}
+
+// SegmentBatchRequest is the body of POST /v1/batch
+type SegmentBatchRequest struct {
+	Batch []SegmentMessage `json:"batch"`
+}
+
+// HandleSegmentTrack handles POST /v1/track
+func HandleSegmentTrack(w http.ResponseWriter, r *http.Request) {
+	handleSegmentSingle(w, r, "track")
+}
+
+// HandleSegmentPage handles POST /v1/page
+func HandleSegmentPage(w http.ResponseWriter, r *http.Request) {
+	handleSegmentSingle(w, r, "page")
+}
+
+// HandleSegmentIdentify handles POST /v1/identify
+func HandleSegmentIdentify(w http.ResponseWriter, r *http.Request) {
+	handleSegmentSingle(w, r, "identify")
+}
+
+// HandleSegmentBatch handles POST /v1/batch (up to 100 messages)
+func HandleSegmentBatch(w http.ResponseWriter, r *http.Request) {
+	apiKey := middleware.GetAPIKey(r)
+	if apiKey == nil {
+		httpx.Error(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	var request SegmentBatchRequest
+	if err := httpx.ReadJSON(r, &request); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	if len(request.Batch) == 0 {
+		httpx.Error(w, http.StatusBadRequest, "batch array is required")
+		return
+	}
+
+	if len(request.Batch) > 100 {
+		httpx.Error(w, http.StatusBadRequest, "Maximum 100 messages per batch")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	response := BatchIngestResponse{
+		Errors: []BatchError{},
+	}
+
+	for i := range request.Batch {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := processSegmentMessage(ctx, r, apiKey, &request.Batch[i]); err != nil {
+			response.Failed++
+			response.Errors = append(response.Errors, BatchError{Index: i, Error: err.Error()})
+			continue
+		}
+		response.Accepted++
+	}
+
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{
+		"success":  response.Failed == 0,
+		"accepted": response.Accepted,
+		"failed":   response.Failed,
+		"errors":   response.Errors,
+	})
+}
+
+func handleSegmentSingle(w http.ResponseWriter, r *http.Request, messageType string) {
+	apiKey := middleware.GetAPIKey(r)
+	if apiKey == nil {
+		httpx.Error(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	var msg SegmentMessage
+	if err := httpx.ReadJSON(r, &msg); err != nil {
+		httpx.Error(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	msg.Type = messageType
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	if err := processSegmentMessage(ctx, r, apiKey, &msg); err != nil {
+		var invalid segmentValidationError
+		if errors.As(err, &invalid) {
+			httpx.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		logging.L().Error("failed to process segment message",
+			zap.String("website_id", apiKey.WebsiteID.String()),
+			zap.String("type", messageType),
+			zap.Error(err))
+		httpx.Error(w, http.StatusInternalServerError, "Failed to process event")
+		return
+	}
+
+	// Segment clients only check for 200 + success
+	httpx.WriteJSON(w, http.StatusOK, map[string]any{"success": true})
+}
+
+// segmentValidationError marks client errors (400) as opposed to storage failures
+type segmentValidationError struct{ error }
+
+// processSegmentMessage maps a Segment message onto the /api/ingest pipeline
+func processSegmentMessage(ctx context.Context, r *http.Request, apiKey *models.APIKey, msg *SegmentMessage) error {
+	if msg.Type == "identify" {
+		return identifySegmentUser(ctx, apiKey, msg)
+	}
+
+	payload, err := segmentToIngestPayload(msg, apiKey.WebsiteID)
+	if err != nil {
+		return segmentValidationError{err}
+	}
+
+	if err := validateIngestPayload(payload); err != nil {
+		return segmentValidationError{err}
+	}
+
+	if payload.EventID != nil {
+		if eventUUID, err := uuid.Parse(*payload.EventID); err == nil {
+			if exists, _ := models.CheckEventIDExists(eventUUID, apiKey.WebsiteID); exists {
+				return nil
+			}
+		}
+	}
+
+	_, err = processIngestEvent(ctx, r, apiKey, payload)
+	return err
+}
+
+// segmentToIngestPayload converts track/page/screen calls into an IngestPayload
+func segmentToIngestPayload(msg *SegmentMessage, websiteID uuid.UUID) (*IngestPayload, error) {
+	visitorID := msg.AnonymousID
+	if visitorID == "" {
+		visitorID = msg.UserID
+	}
+	if visitorID == "" {
+		return nil, errors.New("anonymousId or userId is required")
+	}
+
+	payload := &IngestPayload{
+		VisitorID:  visitorID,
+		Properties: msg.Properties,
+	}
+
+	if msg.UserID != "" {
+		userID := msg.UserID
+		payload.UserID = &userID
+	}
+
+	switch msg.Type {
+	case "track":
+		if strings.TrimSpace(msg.Event) == "" {
+			return nil, errors.New("event is required for track calls")
+		}
+		payload.Event = msg.Event
+	case "page", "screen":
+		payload.Event = "page_view"
+	default:
+		return nil, fmt.Errorf("unsupported message type: %q", msg.Type)
+	}
+
+	// Page data: properties take precedence over context.page (analytics.js sends both)
+	payload.URL = segmentString(msg.Properties, "url")
+	payload.Title = segmentString(msg.Properties, "title")
+	payload.Referrer = segmentString(msg.Properties, "referrer")
+	if msg.Context != nil && msg.Context.Page != nil {
+		page := msg.Context.Page
+		if payload.URL == "" {
+			payload.URL = page.URL
+		}
+		if payload.URL == "" && page.Path != "" {
+			payload.URL = page.Path + page.Search
+		}
+		if payload.Title == "" {
+			payload.Title = page.Title
+		}
+		if payload.Referrer == "" {
+			payload.Referrer = page.Referrer
+		}
+	}
+	if payload.Event == "page_view" && payload.URL == "" {
+		if path := segmentString(msg.Properties, "path"); path != "" {
+			payload.URL = path
+		} else if msg.Type == "screen" && msg.Name != "" {
+			payload.URL = "/" + strings.TrimPrefix(msg.Name, "/")
+		}
+	}
+	if payload.Title == "" && msg.Name != "" {
+		payload.Title = msg.Name
+	}
+
+	if msg.Timestamp != "" {
+		t, err := time.Parse(time.RFC3339Nano, msg.Timestamp)
+		if err != nil {
+			return nil, errors.New("timestamp must be ISO 8601")
+		}
+		ts := t.Unix()
+		payload.Timestamp = &ts
+	}
+
+	if msg.MessageID != "" {
+		eventID := segmentMessageUUID(websiteID, msg.MessageID).String()
+		payload.EventID = &eventID
+	}
+
+	if c := msg.Context; c != nil {
+		payload.Context = &IngestContext{
+			Locale:    c.Locale,
+			IP:        c.IP,
+			UserAgent: c.UserAgent,
+		}
+		if c.Screen != nil && c.Screen.Width > 0 && c.Screen.Height > 0 {
+			payload.Context.Screen = fmt.Sprintf("%dx%d", c.Screen.Width, c.Screen.Height)
+		}
+		if camp := c.Campaign; camp != nil {
+			payload.UTMSource = nonEmpty(camp.Source)
+			payload.UTMMedium = nonEmpty(camp.Medium)
+			payload.UTMCampaign = nonEmpty(camp.Name)
+			payload.UTMTerm = nonEmpty(camp.Term)
+			payload.UTMContent = nonEmpty(camp.Content)
+		}
+	}
+
+	return payload, nil
+}
+
+// identifySegmentUser links userId to the visitor's existing session.
+// Traits are not stored: Kaunta keeps no user profiles.
+func identifySegmentUser(ctx context.Context, apiKey *models.APIKey, msg *SegmentMessage) error {
+	if msg.UserID == "" {
+		return segmentValidationError{errors.New("userId is required for identify calls")}
+	}
+	if msg.AnonymousID == "" {
+		// Nothing to link; subsequent calls carrying userId are attributed directly
+		return nil
+	}
+
+	createdAt := time.Now()
+	if msg.Timestamp != "" {
+		if t, err := time.Parse(time.RFC3339Nano, msg.Timestamp); err == nil {
+			createdAt = t
+		}
+	}
+
+	sessionID := resolveSessionID(&IngestPayload{VisitorID: msg.AnonymousID}, apiKey.WebsiteID, "", "", createdAt)
+	_, err := database.DB.ExecContext(ctx,
+		`UPDATE session SET distinct_id = $1 WHERE session_id = $2 AND website_id = $3`,
+		msg.UserID, sessionID, apiKey.WebsiteID,
+	)
+	return err
+}
+
+// segmentMessageUUID derives a stable UUIDv4-shaped idempotency key from
+// Segment's messageId, which is usually not a UUID ("ajs-...")
+func segmentMessageUUID(websiteID uuid.UUID, messageID string) uuid.UUID {
+	if id, err := uuid.Parse(messageID); err == nil && id.Version() == 4 {
+		return id
+	}
+	hash := md5.Sum([]byte(websiteID.String() + "|" + messageID))
+	hash[6] = (hash[6] & 0x0f) | 0x40 // version 4
+	hash[8] = (hash[8] & 0x3f) | 0x80 // RFC 4122 variant
+	id, _ := uuid.FromBytes(hash[:])
+	return id
+}
+
+func segmentString(props map[string]interface{}, key string) string {
+	if v, ok := props[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+func nonEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
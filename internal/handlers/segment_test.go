@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentTrackToIngestPayload(t *testing.T) {
+	var msg SegmentMessage
+	body := `{
+		"type": "track",
+		"messageId": "ajs-next-1700000000000-abc",
+		"anonymousId": "anon-123",
+		"userId": "user-42",
+		"event": "Order Completed",
+		"properties": {"total": 49.9, "currency": "USD"},
+		"timestamp": "2026-10-01T12:00:00.000Z",
+		"context": {
+			"ip": "203.0.113.7",
+			"userAgent": "Mozilla/5.0",
+			"locale": "en-US",
+			"screen": {"width": 1440, "height": 900},
+			"page": {"url": "https://example.com/checkout", "referrer": "https://google.com/", "title": "Checkout"},
+			"campaign": {"name": "fall", "source": "newsletter", "medium": "email"}
+		}
+	}`
+	require.NoError(t, json.Unmarshal([]byte(body), &msg))
+
+	websiteID := uuid.New()
+	payload, err := segmentToIngestPayload(&msg, websiteID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Order Completed", payload.Event)
+	assert.Equal(t, "anon-123", payload.VisitorID)
+	require.NotNil(t, payload.UserID)
+	assert.Equal(t, "user-42", *payload.UserID)
+	assert.Equal(t, "https://example.com/checkout", payload.URL)
+	assert.Equal(t, "Checkout", payload.Title)
+	assert.Equal(t, "https://google.com/", payload.Referrer)
+	assert.Equal(t, 49.9, payload.Properties["total"])
+	require.NotNil(t, payload.Timestamp)
+	assert.Equal(t, int64(1790856000), *payload.Timestamp)
+	require.NotNil(t, payload.Context)
+	assert.Equal(t, "1440x900", payload.Context.Screen)
+	assert.Equal(t, "en-US", payload.Context.Locale)
+	assert.Equal(t, "203.0.113.7", payload.Context.IP)
+	require.NotNil(t, payload.UTMSource)
+	assert.Equal(t, "newsletter", *payload.UTMSource)
+	assert.Equal(t, "fall", *payload.UTMCampaign)
+	assert.Nil(t, payload.UTMTerm)
+	require.NotNil(t, payload.EventID)
+	assert.Equal(t, segmentMessageUUID(websiteID, msg.MessageID).String(), *payload.EventID)
+}
+
+func TestSegmentPageToIngestPayload(t *testing.T) {
+	msg := SegmentMessage{
+		Type:        "page",
+		AnonymousID: "anon-1",
+		Name:        "Pricing",
+		Properties:  map[string]interface{}{"path": "/pricing"},
+	}
+
+	payload, err := segmentToIngestPayload(&msg, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, "page_view", payload.Event)
+	assert.Equal(t, "/pricing", payload.URL)
+	assert.Equal(t, "Pricing", payload.Title)
+	assert.Nil(t, payload.UserID)
+}
+
+func TestSegmentToIngestPayloadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  SegmentMessage
+	}{
+		{"missing ids", SegmentMessage{Type: "track", Event: "Signup"}},
+		{"track without event", SegmentMessage{Type: "track", AnonymousID: "a"}},
+		{"unsupported type", SegmentMessage{Type: "alias", AnonymousID: "a"}},
+		{"bad timestamp", SegmentMessage{Type: "track", Event: "x", AnonymousID: "a", Timestamp: "yesterday"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := segmentToIngestPayload(&tt.msg, uuid.New())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSegmentMessageUUID(t *testing.T) {
+	websiteID := uuid.New()
+
+	id := segmentMessageUUID(websiteID, "ajs-abc")
+	assert.Equal(t, id, segmentMessageUUID(websiteID, "ajs-abc"))
+	assert.NotEqual(t, id, segmentMessageUUID(websiteID, "ajs-abd"))
+	assert.NotEqual(t, id, segmentMessageUUID(uuid.New(), "ajs-abc"))
+	assert.Equal(t, uuid.Version(4), id.Version())
+	assert.Equal(t, uuid.RFC4122, id.Variant())
+
+	existing := uuid.New()
+	assert.Equal(t, existing, segmentMessageUUID(websiteID, existing.String()))
+}
+
+func TestHandleSegmentRequiresAPIKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/track", nil)
+	rec := httptest.NewRecorder()
+
+	HandleSegmentTrack(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
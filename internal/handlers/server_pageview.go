@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/models"
+)
+
+// ServerPageview is a page request observed server-side (e.g. in an access log)
+// rather than reported by the JavaScript tracker
+type ServerPageview struct {
+	Time      time.Time
+	IP        string
+	UserAgent string
+	Hostname  string
+	URI       string // path + query
+	Referrer  string
+}
+
+// Outcomes of RecordServerPageview
+const (
+	ServerPageviewRecorded = "recorded"
+	ServerPageviewBot      = "bot"
+	ServerPageviewSpam     = "spam_referrer"
+	ServerPageviewExcluded = "excluded"
+	// ServerPageviewDuplicate is a hit recorded by an earlier import (within the
+	// event_idempotency retention)
+	ServerPageviewDuplicate = "duplicate"
+)
+
+// serverPageviewKey identifies a log line's hit in event_idempotency, so
+// re-importing a file doesn't record it twice
+func serverPageviewKey(websiteID uuid.UUID, pv ServerPageview) uuid.UUID {
+	return generateUUID("server_pageview", websiteID.String(), pv.Time.UTC().Format(time.RFC3339Nano),
+		pv.IP, pv.UserAgent, pv.URI)
+}
+
+// RecordServerPageview stores a server-side pageview using the same bot detection,
+// user agent parsing, GeoIP lookup and session/visit ID scheme as /api/send,
+// so imported hits and tracker hits from the same visitor land in the same session
+func RecordServerPageview(ctx context.Context, websiteID uuid.UUID, pv ServerPageview) (string, error) {
+	key := serverPageviewKey(websiteID, pv)
+	if seen, err := models.CheckEventIDExists(key, websiteID); err != nil {
+		return "", err
+	} else if seen {
+		return ServerPageviewDuplicate, nil
+	}
+
+	if _, excluded := excludedTraffic(nil, websiteID, pv.IP, pv.UserAgent); excluded {
+		return ServerPageviewExcluded, nil
+	}
+
+	// Redact personal data before anything is stored
+	payload := PayloadData{
+		Website: websiteID.String(),
+		URL:     &pv.URI,
+	}
+	if pv.Referrer != "" {
+		payload.Referrer = &pv.Referrer
+	}
+	redactPayload(ctx, websiteID, pv.Time, &payload)
+	if payload.URL != nil {
+		pv.URI = *payload.URL
+	} else {
+		pv.URI = "/"
+	}
+	pv.Referrer = ""
+	if payload.Referrer != nil {
+		pv.Referrer = *payload.Referrer
+	}
+
+	network := checkRequestIP(ctx, websiteID, pv.IP, pv.UserAgent, pv.URI, &pv.Time)
+	if network.Bot {
+		return ServerPageviewBot, nil
+	}
+
+	if isSpamReferrer(ctx, websiteID, pv.Referrer, pv.Time) {
+		return ServerPageviewSpam, nil
+	}
+
+	browser, osName, device := parseUserAgent(pv.UserAgent)
+	countryStr, cityStr, regionStr := geoIPLookup(pv.IP)
+	country := &countryStr
+	region := &regionStr
+	city := &cityStr
+
+	createdAt := pv.Time
+	sessionSalt := hashDate(createdAt, "month")
+	sessionID := generateUUID(websiteID.String(), pv.IP, pv.UserAgent, sessionSalt)
+
+	if pv.Hostname != "" {
+		payload.Hostname = &pv.Hostname
+	}
+
+	var urlPath, utmSource, utmMedium *string
+	if u, err := url.Parse(pv.URI); err == nil {
+		path := u.Path
+		urlPath = &path
+		if v := u.Query().Get("utm_source"); v != "" {
+			utmSource = &v
+		}
+		if v := u.Query().Get("utm_medium"); v != "" {
+			utmMedium = &v
+		}
+	}
+
+	pageURL := pv.URI
+	if pv.Hostname != "" {
+		pageURL = "//" + pv.Hostname + pv.URI
+	}
+	entryPath, _, _ := normalizePageURL(websiteID, urlPath, nil)
+	source, channel := classifySessionTraffic(websiteID, pageURL, pv.Referrer, utmSource, utmMedium)
+
+	if err := upsertSession(sessionID, websiteID, browser, osName, device, nil, nil,
+		country, region, city, nil, entryPath, source, channel, network, createdAt); err != nil {
+		return "", err
+	}
+
+	visitSalt := hashDate(createdAt, "hour")
+	visitID := generateUUID(sessionID.String(), visitSalt)
+
+	eventID, err := saveEvent(websiteID, sessionID, visitID, createdAt, payload,
+		browser, osName, device, country, region, city)
+	if err != nil {
+		return "", err
+	}
+
+	if err := models.InsertEventID(key, websiteID); err != nil {
+		logging.L().Warn("failed to record imported pageview key",
+			zap.String("website_id", websiteID.String()),
+			zap.Error(err))
+	}
+
+	goalID := checkAndRecordGoalCompletion(ctx, websiteID, sessionID, eventID, 1, urlPath, nil, createdAt)
+	if goalID != nil {
+		_, _ = database.DB.ExecContext(ctx,
+			`UPDATE website_event SET goal_id = $1 WHERE event_id = $2 AND created_at = $3`,
+			goalID, eventID, createdAt,
+		)
+	}
+
+	return ServerPageviewRecorded, nil
+}
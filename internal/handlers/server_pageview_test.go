@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerPageviewKey(t *testing.T) {
+	websiteID := uuid.New()
+	pv := ServerPageview{
+		Time:      time.Date(2025, 10, 10, 13, 55, 36, 0, time.UTC),
+		IP:        "203.0.113.7",
+		UserAgent: "Mozilla/5.0 Chrome/120.0",
+		URI:       "/pricing?ref=nav",
+	}
+
+	key := serverPageviewKey(websiteID, pv)
+	assert.Equal(t, key, serverPageviewKey(websiteID, pv), "the same log line maps to the same key")
+
+	inZone := pv
+	inZone.Time = pv.Time.In(time.FixedZone("CEST", 2*60*60))
+	assert.Equal(t, key, serverPageviewKey(websiteID, inZone), "the log's time zone doesn't matter")
+
+	// The referrer and host aren't part of the hit's identity
+	withReferrer := pv
+	withReferrer.Referrer = "https://example.org/"
+	withReferrer.Hostname = "example.com"
+	assert.Equal(t, key, serverPageviewKey(websiteID, withReferrer))
+
+	later := pv
+	later.Time = pv.Time.Add(time.Second)
+	assert.NotEqual(t, key, serverPageviewKey(websiteID, later))
+
+	otherPage := pv
+	otherPage.URI = "/pricing"
+	assert.NotEqual(t, key, serverPageviewKey(websiteID, otherPage))
+
+	assert.NotEqual(t, key, serverPageviewKey(uuid.New(), pv))
+}
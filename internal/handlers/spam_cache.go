@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/models"
+	"github.com/seuros/kaunta/internal/spam"
+)
+
+var (
+	// spamListCache holds the referrer spam list shared by all websites
+	spamListCache = newTTLCache(settingsCacheTTL, func(ctx context.Context, _ struct{}) (*spam.List, error) {
+		if database.DB == nil {
+			return builtinSpamList, nil
+		}
+		return models.LoadSpamList(ctx, database.DB)
+	})
+	builtinSpamList = spam.NewList(spam.Builtin())
+)
+
+// spamList returns the cached spam list. Until the database list has been
+// loaded, the embedded list is used.
+func spamList() *spam.List {
+	list, err := spamListCache.Get(struct{}{})
+	if err != nil {
+		logging.L().Warn("failed to load spam referrer list", zap.Error(err))
+		return builtinSpamList
+	}
+	return list
+}
+
+// isSpamReferrer reports whether the referrer is a listed spam domain (or a
+// subdomain of one), counting the dropped hit for the website on day
+func isSpamReferrer(ctx context.Context, websiteID uuid.UUID, referrer string, day time.Time) bool {
+	if referrer == "" {
+		return false
+	}
+	domain, ok := spamList().Match(referrer)
+	if !ok {
+		return false
+	}
+	if database.DB != nil {
+		if err := models.RecordSpamHit(ctx, database.DB, websiteID, day, domain); err != nil {
+			logging.L().Warn("failed to record spam referrer hit",
+				zap.String("website_id", websiteID.String()), zap.Error(err))
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/spam"
+)
+
+func TestSpamListCacheFallsBackToBuiltin(t *testing.T) {
+	originalDB := database.DB
+	database.DB = nil
+	t.Cleanup(func() { database.DB = originalDB })
+
+	spamListCache.Invalidate(struct{}{})
+	assert.Same(t, builtinSpamList, spamList())
+}
+
+func TestIsSpamReferrer(t *testing.T) {
+	originalDB := database.DB
+	database.DB = nil
+	t.Cleanup(func() { database.DB = originalDB })
+
+	t.Cleanup(func() { spamListCache.Invalidate(struct{}{}) })
+	spamListCache.Set(struct{}{}, spam.NewList([]string{"semalt.com"}))
+
+	ctx := context.Background()
+	websiteID := uuid.New()
+	assert.True(t, isSpamReferrer(ctx, websiteID, "https://www.semalt.com/", time.Now()))
+	assert.True(t, isSpamReferrer(ctx, websiteID, "https://site3.semalt.com/", time.Now()))
+	assert.False(t, isSpamReferrer(ctx, websiteID, "https://notsemalt.com/", time.Now()))
+	assert.False(t, isSpamReferrer(ctx, websiteID, "", time.Now()))
+
+	// An invalidated list is reloaded; without a database the embedded list applies
+	spamListCache.Invalidate(struct{}{})
+	assert.Same(t, builtinSpamList, spamList())
+}
@@ -5,9 +5,11 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -24,25 +26,9 @@ import (
 
 const MaxURLSize = 2000 // Max URL length (Plausible standard)
 
-// Spam referrer domains (from Plausible patterns)
-var spamReferrers = []string{
-	"semalt.com",
-	"buttons-for-website.com",
-	"darodar.com",
-	"best-seo-offer.com",
-	"free-share-buttons.com",
-	"blackhatworth.com",
-	"hulfingtonpost.com",
-	"o-o-6-o-o.com",
-	"priceg.com",
-	"make-money-online",
-	"simple-share-buttons.com",
-	"kambasoft.com",
-}
-
 // TrackingPayload matches Umami's /api/send payload
 type TrackingPayload struct {
-	Type    string      `json:"type"` // "event" or "identify"
+	Type    string      `json:"type"` // "event", "identify" or "error"
 	Payload PayloadData `json:"payload"`
 }
 
@@ -79,6 +65,14 @@ type PayloadData struct {
 	UTMCampaign *string `json:"utm_campaign,omitempty"` // e.g., spring_sale
 	UTMTerm     *string `json:"utm_term,omitempty"`     // paid search keywords
 	UTMContent  *string `json:"utm_content,omitempty"`  // ad variant identifier
+
+	// Frontend error tracking (type "error")
+	Message *string `json:"message,omitempty"` // error message
+	Stack   *string `json:"stack,omitempty"`   // stack trace
+	Source  *string `json:"source,omitempty"`  // script URL that raised the error
+	Line    *int    `json:"line,omitempty"`    // line number in source
+	Column  *int    `json:"column,omitempty"`  // column number in source
+	Browser *string `json:"browser,omitempty"` // overrides the browser parsed from User-Agent
 }
 
 // getTrackingPayload extracts TrackingPayload from either JSON POST body or pixel query params
@@ -112,11 +106,8 @@ func HandleTracking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var proxyMode string
-	if err := database.DB.QueryRow(
-		"SELECT COALESCE(proxy_mode, 'none') FROM website WHERE website_id = $1",
-		websiteID,
-	).Scan(&proxyMode); err != nil {
+	proxy, err := loadProxySettings(r.Context(), websiteID)
+	if err != nil {
 		httpx.Error(w, http.StatusNotFound, "Website not found")
 		return
 	}
@@ -172,7 +163,16 @@ func HandleTracking(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 	}
 
-	ip := clientIPFromRequest(r, proxyMode)
+	if rejected := sanitizeClientFields(r, websiteID, &payload.Payload, time.Now()); len(rejected) > 0 {
+		total := rejectedClientFields.Add(int64(len(rejected)))
+		logging.L().Warn("rejected client-supplied tracking fields",
+			zap.String("website_id", websiteID.String()),
+			zap.Strings("fields", rejected),
+			zap.Int64("total_rejected", total),
+		)
+	}
+
+	ip := clientIPFromRequest(r, proxy)
 	userAgent := r.Header.Get("User-Agent")
 	if payload.Payload.IP != nil {
 		ip = *payload.Payload.IP
@@ -181,17 +181,8 @@ func HandleTracking(w http.ResponseWriter, r *http.Request) {
 		userAgent = *payload.Payload.UserAgent
 	}
 
-	var isBot *bool
-	if err := database.DB.QueryRow(`
-		SELECT update_ip_metadata($1::inet, $2, NULL)
-	`, ip, userAgent).Scan(&isBot); err != nil {
-		logging.L().Warn("bot detection error", zap.String("ip", ip), zap.Error(err))
-		isBotVal := false
-		isBot = &isBotVal
-	}
-
-	if isBot != nil && *isBot {
-		httpx.WriteJSON(w, http.StatusAccepted, map[string]any{"beep": "boop", "bot_detected": true})
+	if reason, excluded := excludedTraffic(r, websiteID, ip, userAgent); excluded {
+		httpx.WriteJSON(w, http.StatusAccepted, map[string]any{"dropped": "excluded", "reason": reason})
 		return
 	}
 
@@ -200,7 +191,34 @@ func HandleTracking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if payload.Payload.Referrer != nil && isSpamReferrer(*payload.Payload.Referrer) {
+	if payload.Type == "error" {
+		if err := validateErrorPayload(&payload.Payload); err != nil {
+			httpx.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	createdAt := time.Now()
+	if payload.Payload.Timestamp != nil {
+		createdAt = time.Unix(*payload.Payload.Timestamp, 0)
+	}
+
+	// Redact personal data before anything is stored or broadcast
+	redactCtx, cancelRedact := context.WithTimeout(r.Context(), 2*time.Second)
+	redactPayload(redactCtx, websiteID, createdAt, &payload.Payload)
+	cancelRedact()
+
+	pageURL := ""
+	if payload.Payload.URL != nil {
+		pageURL = *payload.Payload.URL
+	}
+	network := checkRequestIP(r.Context(), websiteID, ip, userAgent, pageURL, nil)
+	if network.Bot {
+		httpx.WriteJSON(w, http.StatusAccepted, map[string]any{"beep": "boop", "bot_detected": true})
+		return
+	}
+
+	if payload.Payload.Referrer != nil && isSpamReferrer(r.Context(), websiteID, *payload.Payload.Referrer, time.Now()) {
 		httpx.WriteJSON(w, http.StatusAccepted, map[string]any{"dropped": "spam_referrer"})
 		return
 	}
@@ -211,11 +229,6 @@ func HandleTracking(w http.ResponseWriter, r *http.Request) {
 	region := &regionStr
 	city := &cityStr
 
-	createdAt := time.Now()
-	if payload.Payload.Timestamp != nil {
-		createdAt = time.Unix(*payload.Payload.Timestamp, 0)
-	}
-
 	sessionSalt := hashDate(createdAt, "month")
 	sessionID := generateUUID(websiteID.String(), ip, userAgent, sessionSalt)
 
@@ -226,10 +239,38 @@ func HandleTracking(w http.ResponseWriter, r *http.Request) {
 			entryPath = &path
 		}
 	}
+	entryPath, _, _ = normalizePageURL(websiteID, entryPath, nil)
+
+	// Errors only flag a session the pageviews already created; they must not
+	// create one or move its exit page
+	if payload.Type == "error" {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		errorID, err := recordFrontendError(ctx, websiteID, sessionID, createdAt, payload.Payload, browser, entryPath)
+		if err != nil {
+			httpx.Error(w, http.StatusInternalServerError, "Failed to save error: "+err.Error())
+			return
+		}
+
+		httpx.WriteJSON(w, http.StatusAccepted, map[string]any{
+			"sessionId": sessionID.String(),
+			"errorId":   errorID.String(),
+		})
+		return
+	}
+
+	var referrer string
+	if payload.Payload.Referrer != nil {
+		referrer = *payload.Payload.Referrer
+	}
+	source, channel := classifySessionTraffic(websiteID, pageURL, referrer,
+		payload.Payload.UTMSource, payload.Payload.UTMMedium)
 
 	distinctID := payload.Payload.ID
 	if err := upsertSession(sessionID, websiteID, browser, osName, device,
-		payload.Payload.Screen, payload.Payload.Language, country, region, city, distinctID, entryPath); err != nil {
+		payload.Payload.Screen, payload.Payload.Language, country, region, city, distinctID, entryPath,
+		source, channel, network, createdAt); err != nil {
 		logging.L().Error("session creation error",
 			zap.String("website_id", websiteID.String()),
 			zap.String("session_id", sessionID.String()),
@@ -273,6 +314,7 @@ func HandleTracking(w http.ResponseWriter, r *http.Request) {
 			eventType,
 			urlPath,
 			payload.Payload.Name,
+			createdAt,
 		)
 
 		if goalID != nil {
@@ -321,26 +363,81 @@ func HandleTracking(w http.ResponseWriter, r *http.Request) {
 	httpx.Error(w, http.StatusBadRequest, "Invalid type")
 }
 
+// rejectedClientFields counts fields dropped by sanitizeClientFields since startup
+var rejectedClientFields atomic.Int64
+
+// lookupTrackingAPIKey returns the API key sent with a tracking request (stubbed in tests)
+var lookupTrackingAPIKey = middleware.OptionalAPIKey
+
+// sanitizeClientFields drops fields an anonymous browser must not control and
+// returns the names of the rejected ones:
+//   - ip / userAgent overrides need an ingest API key for this website
+//   - timestamps must be within ±30 days, as on /api/ingest
+//   - props / data get the /api/ingest size, key and depth limits
+func sanitizeClientFields(r *http.Request, websiteID uuid.UUID, p *PayloadData, now time.Time) []string {
+	var rejected []string
+
+	if p.IP != nil || p.UserAgent != nil {
+		apiKey := lookupTrackingAPIKey(r)
+		trusted := apiKey != nil && apiKey.WebsiteID == websiteID && apiKey.HasScope("ingest")
+
+		if p.IP != nil && (!trusted || net.ParseIP(*p.IP) == nil) {
+			p.IP = nil
+			rejected = append(rejected, "ip")
+		}
+		if p.UserAgent != nil && (!trusted || len(*p.UserAgent) > 1000) {
+			p.UserAgent = nil
+			rejected = append(rejected, "userAgent")
+		}
+	}
+
+	if p.Timestamp != nil && !timestampInWindow(*p.Timestamp, now) {
+		p.Timestamp = nil
+		rejected = append(rejected, "timestamp")
+	}
+
+	if p.Props != nil {
+		if err := validateIngestProperties(p.Props); err != nil {
+			p.Props = nil
+			rejected = append(rejected, "props")
+		}
+	}
+
+	if p.Data != nil {
+		if err := validateIngestProperties(p.Data); err != nil {
+			p.Data = nil
+			rejected = append(rejected, "data")
+		}
+	}
+
+	return rejected
+}
+
 // upsertSession creates or updates a session
-// On INSERT: sets entry_page and exit_page to the first page visited
-// On UPDATE: only updates exit_page (entry_page remains the original landing page)
+// On INSERT: sets entry_page and exit_page to the first page visited, created_at to the hit time
+// On UPDATE: only updates exit_page (entry_page and source/channel keep the first touch)
 func upsertSession(
 	sessionID, websiteID uuid.UUID,
-	browser, os, device, screen, language, country, region, city, distinctID, urlPath *string,
+	browser, os, device, screen, language, country, region, city, distinctID, urlPath,
+	source, channel *string,
+	network ipVerdict,
+	createdAt time.Time,
 ) error {
 	query := `
 		INSERT INTO session (
 			session_id, website_id, browser, os, device, screen, language,
-			country, region, city, created_at, distinct_id, entry_page, exit_page
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), $11, $12, $12)
+			country, region, city, created_at, distinct_id, entry_page, exit_page,
+			source, channel, asn, asn_org, is_hosting_provider
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $18, $11, $12, $12, $13, $14, $15, $16, $17)
 		ON CONFLICT (session_id) DO UPDATE SET exit_page = EXCLUDED.entry_page
 	`
 	_, err := database.DB.Exec(query, sessionID, websiteID, browser, os, device,
-		screen, language, country, region, city, distinctID, urlPath)
+		screen, language, country, region, city, distinctID, urlPath, source, channel,
+		network.ASN, network.ASNOrg, network.Hosting, createdAt)
 	return err
 }
 
-// saveEvent saves a pageview or custom event
+// saveEvent saves a pageview or custom event; callers redact the payload first
 func saveEvent(websiteID, sessionID, visitID uuid.UUID, createdAt time.Time,
 	payload PayloadData, browser, os, device, country, region, city *string) (uuid.UUID, error) {
 
@@ -369,6 +466,10 @@ func saveEvent(websiteID, sessionID, visitID uuid.UUID, createdAt time.Time,
 		}
 	}
 
+	// Apply the website's URL rules (rewrites, query lists, content group)
+	var contentGroup *string
+	urlPath, urlQuery, contentGroup = normalizePageURL(websiteID, urlPath, urlQuery)
+
 	// Parse referrer
 	if payload.Referrer != nil {
 		if u, err := url.Parse(*payload.Referrer); err == nil {
@@ -385,10 +486,10 @@ func saveEvent(websiteID, sessionID, visitID uuid.UUID, createdAt time.Time,
 		}
 	}
 
-	// Convert props/data to JSON (Phase 2)
-	var propsJSON interface{}
+	// Combine props/data (Phase 2)
+	var combined map[string]interface{}
 	if payload.Props != nil || payload.Data != nil {
-		combined := make(map[string]interface{})
+		combined = make(map[string]interface{})
 		if payload.Props != nil {
 			for key, value := range payload.Props {
 				combined[key] = value
@@ -399,10 +500,13 @@ func saveEvent(websiteID, sessionID, visitID uuid.UUID, createdAt time.Time,
 				combined[key] = value
 			}
 		}
-		if len(combined) > 0 {
-			jsonBytes, _ := json.Marshal(combined)
-			propsJSON = jsonBytes
-		}
+	}
+
+	// Convert props/data to JSON
+	var propsJSON interface{}
+	if len(combined) > 0 {
+		jsonBytes, _ := json.Marshal(combined)
+		propsJSON = jsonBytes
 	}
 
 	// Enhanced tracking: scroll_depth and engagement_time (Phase 2)
@@ -431,14 +535,16 @@ func saveEvent(websiteID, sessionID, visitID uuid.UUID, createdAt time.Time,
 			referrer_path, referrer_query, referrer_domain,
 			event_name, tag, event_type,
 			scroll_depth, engagement_time, props,
-			utm_source, utm_medium, utm_campaign, utm_term, utm_content
+			utm_source, utm_medium, utm_campaign, utm_term, utm_content,
+			content_group
 		) VALUES (
 			$1, $2, $3, $4, $5,
 			$6, $7, $8, $9,
 			$10, $11, $12,
 			$13, $14, $15,
 			$16, $17, $18,
-			$19, $20, $21, $22, $23
+			$19, $20, $21, $22, $23,
+			$24
 		)
 	`
 
@@ -457,6 +563,7 @@ func saveEvent(websiteID, sessionID, visitID uuid.UUID, createdAt time.Time,
 		payload.Name, payload.Tag, eventType,
 		scrollDepth, engagementTime, propsJSON,
 		payload.UTMSource, payload.UTMMedium, payload.UTMCampaign, payload.UTMTerm, payload.UTMContent,
+		contentGroup,
 	)
 
 	if err != nil {
@@ -468,7 +575,7 @@ func saveEvent(websiteID, sessionID, visitID uuid.UUID, createdAt time.Time,
 }
 
 // checkAndRecordGoalCompletion matches the event against active goals and records completions
-// Returns the matched goal_id (if any) for tagging the event
+// at the event's time. Returns the matched goal_id (if any) for tagging the event
 // Handles deduplication via goal_completions table
 func checkAndRecordGoalCompletion(
 	ctx context.Context,
@@ -478,6 +585,7 @@ func checkAndRecordGoalCompletion(
 	eventType int,
 	urlPath *string,
 	eventName *string,
+	completedAt time.Time,
 ) *uuid.UUID {
 	// Fetch goals for this website from cache
 	goals, err := GetGoalsForWebsite(websiteID)
@@ -555,9 +663,9 @@ func checkAndRecordGoalCompletion(
 	_, err = database.DB.ExecContext(ctx,
 		`INSERT INTO goal_completions
             (id, goal_id, session_id, event_id, website_id, completed_at)
-         VALUES ($1, $2, $3, $4, $5, NOW())
+         VALUES ($1, $2, $3, $4, $5, $6)
          ON CONFLICT (goal_id, session_id) DO NOTHING`, // Safety net for race conditions
-		completionID, matchedGoalID, sessionID, eventID, websiteID,
+		completionID, matchedGoalID, sessionID, eventID, websiteID, completedAt,
 	)
 
 	if err != nil {
@@ -602,30 +710,6 @@ func hashDate(t time.Time, period string) string {
 // See database/migrations/000005_add_bot_detection.up.sql
 // Kept as comment for reference - DO NOT USE, call update_ip_metadata() instead
 
-// isSpamReferrer checks if referrer is from known spam domain
-func isSpamReferrer(referrer string) bool {
-	if referrer == "" {
-		return false
-	}
-
-	// Parse referrer URL to get domain
-	u, err := url.Parse(referrer)
-	if err != nil {
-		return false
-	}
-
-	domain := strings.ToLower(u.Hostname())
-	domain = strings.TrimPrefix(domain, "www.")
-
-	// Check against spam list
-	for _, spam := range spamReferrers {
-		if strings.Contains(domain, spam) {
-			return true
-		}
-	}
-	return false
-}
-
 // parseUserAgent extracts browser, OS, device from UA string
 func parseUserAgent(ua string) (browser, os, device *string) {
 	// Simple parsing (TODO: use proper UA parser library)
@@ -1,130 +1,275 @@
 package handlers
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/models"
 )
 
-// TestGetClientIPLogic tests the IP extraction logic without Fiber dependency
-func TestGetClientIPLogic(t *testing.T) {
+// TestClientIPFromRequest tests IP resolution for each proxy mode
+func TestClientIPFromRequest(t *testing.T) {
 	tests := []struct {
-		name               string
-		proxyMode          string
-		cfConnectingIP     string
-		xForwardedFor      string
-		expectedStartsWith string
-		description        string
+		name       string
+		mode       string
+		trusted    []string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
 	}{
 		{
-			name:           "none mode ignores headers",
-			proxyMode:      "none",
-			cfConnectingIP: "203.0.113.1",
-			xForwardedFor:  "198.51.100.1",
-			description:    "Should use c.IP() fallback when proxy_mode is 'none'",
+			name:       "no headers uses remote address",
+			mode:       "none",
+			remoteAddr: "198.51.100.7:5555",
+			expected:   "198.51.100.7",
+		},
+		{
+			name:       "cloudflare mode uses CF header",
+			mode:       "cloudflare",
+			remoteAddr: "172.70.1.1:443",
+			headers:    map[string]string{"CF-Connecting-IP": "203.0.113.1", "X-Forwarded-For": "198.51.100.1"},
+			expected:   "203.0.113.1",
+		},
+		{
+			name:       "cloudflare multiple IPs takes first",
+			mode:       "cloudflare",
+			remoteAddr: "172.70.1.1:443",
+			headers:    map[string]string{"CF-Connecting-IP": "102.97.33.165 , 2400:cb00:40:1000:abaf:890d:d2f:bbf4"},
+			expected:   "102.97.33.165",
+		},
+		{
+			name:       "cloudflare falls back to XFF when header empty",
+			mode:       "cloudflare",
+			remoteAddr: "172.70.1.1:443",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "fastly mode",
+			mode:       "fastly",
+			remoteAddr: "151.101.1.1:443",
+			headers:    map[string]string{"Fastly-Client-IP": "203.0.113.4"},
+			expected:   "203.0.113.4",
+		},
+		{
+			name:       "akamai mode",
+			mode:       "akamai",
+			remoteAddr: "23.0.0.1:443",
+			headers:    map[string]string{"True-Client-IP": "203.0.113.5"},
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "bunny mode",
+			mode:       "bunny",
+			remoteAddr: "185.93.1.1:443",
+			headers:    map[string]string{"CDN-ClientIP": "203.0.113.6"},
+			expected:   "203.0.113.6",
 		},
 		{
-			name:               "cloudflare mode uses CF header",
-			proxyMode:          "cloudflare",
-			cfConnectingIP:     "203.0.113.1",
-			xForwardedFor:      "198.51.100.1",
-			expectedStartsWith: "203.0.113.1",
-			description:        "Should extract CF-Connecting-IP when proxy_mode is 'cloudflare'",
+			name:       "xforwarded takes rightmost hop, not the client-supplied leftmost",
+			mode:       "xforwarded",
+			remoteAddr: "127.0.0.1:80",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.2"},
+			expected:   "203.0.113.2",
 		},
 		{
-			name:           "cloudflare mode fallback when header empty",
-			proxyMode:      "cloudflare",
-			cfConnectingIP: "",
-			xForwardedFor:  "198.51.100.1",
-			description:    "Should fallback to c.IP() when CF-Connecting-IP is empty",
+			name:       "xforwarded skips trusted hops",
+			mode:       "xforwarded",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:80",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.2, 10.1.1.1"},
+			expected:   "203.0.113.2",
 		},
 		{
-			name:               "cloudflare multiple IPs takes first",
-			proxyMode:          "cloudflare",
-			cfConnectingIP:     "102.97.33.165, 2400:cb00:40:1000:abaf:890d:d2f:bbf4",
-			xForwardedFor:      "198.51.100.1",
-			expectedStartsWith: "102.97.33.165",
-			description:        "Should extract first IP from CF-Connecting-IP when multiple IPs (IPv4 and IPv6)",
+			name:       "untrusted peer cannot set headers",
+			mode:       "cloudflare",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "198.51.100.9:80",
+			headers:    map[string]string{"CF-Connecting-IP": "1.2.3.4", "X-Forwarded-For": "1.2.3.4"},
+			expected:   "198.51.100.9",
 		},
 		{
-			name:               "cloudflare trims whitespace",
-			proxyMode:          "cloudflare",
-			cfConnectingIP:     "102.97.33.165 , 198.51.100.1",
-			xForwardedFor:      "",
-			expectedStartsWith: "102.97.33.165",
-			description:        "Should trim whitespace from CF-Connecting-IP",
+			name:       "all hops trusted returns leftmost",
+			mode:       "xforwarded",
+			trusted:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.0.0.2:80",
+			headers:    map[string]string{"X-Forwarded-For": "10.9.9.9, 10.1.1.1"},
+			expected:   "10.9.9.9",
 		},
 		{
-			name:               "xforwarded takes first IP",
-			proxyMode:          "xforwarded",
-			cfConnectingIP:     "203.0.113.1",
-			xForwardedFor:      "203.0.113.2, 198.51.100.1, 192.0.2.1",
-			expectedStartsWith: "203.0.113.2",
-			description:        "Should extract first IP from X-Forwarded-For list",
+			name:       "Forwarded header takes precedence",
+			mode:       "none",
+			remoteAddr: "127.0.0.1:80",
+			headers: map[string]string{
+				"Forwarded":       `for=192.0.2.60;proto=http;by=203.0.113.43, for="[2001:db8:cafe::17]:4711"`,
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			expected: "2001:db8:cafe::17",
 		},
 		{
-			name:               "xforwarded single IP",
-			proxyMode:          "xforwarded",
-			cfConnectingIP:     "203.0.113.1",
-			xForwardedFor:      "203.0.113.2",
-			expectedStartsWith: "203.0.113.2",
-			description:        "Should handle single IP in X-Forwarded-For",
+			name:       "Forwarded with unknown node stops at the hop after it",
+			mode:       "none",
+			trusted:    []string{"127.0.0.1", "10.0.0.0/8"},
+			remoteAddr: "127.0.0.1:80",
+			headers:    map[string]string{"Forwarded": "for=unknown, for=10.0.0.3"},
+			expected:   "10.0.0.3",
 		},
 		{
-			name:               "xforwarded trims whitespace",
-			proxyMode:          "xforwarded",
-			cfConnectingIP:     "203.0.113.1",
-			xForwardedFor:      "203.0.113.2 , 198.51.100.1",
-			expectedStartsWith: "203.0.113.2",
-			description:        "Should trim whitespace from X-Forwarded-For",
+			name:       "X-Real-IP used without a chain",
+			mode:       "none",
+			remoteAddr: "127.0.0.1:80",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.8"},
+			expected:   "203.0.113.8",
+		},
+		{
+			name:       "True-Client-IP used without a chain",
+			mode:       "none",
+			remoteAddr: "127.0.0.1:80",
+			headers:    map[string]string{"True-Client-IP": "203.0.113.9"},
+			expected:   "203.0.113.9",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			t.Log(tt.description)
-
-			// Test the logic directly (simulating what getClientIP does)
-			var ip string
-
-			switch tt.proxyMode {
-			case "cloudflare":
-				if tt.cfConnectingIP != "" {
-					ip = strings.TrimSpace(strings.Split(tt.cfConnectingIP, ",")[0])
-				} else {
-					ip = "127.0.0.1" // fallback
-				}
-			case "xforwarded":
-				if tt.xForwardedFor != "" {
-					ip = strings.TrimSpace(strings.Split(tt.xForwardedFor, ",")[0])
-				} else {
-					ip = "127.0.0.1" // fallback
-				}
-			default:
-				ip = "127.0.0.1" // default fallback
-			}
+			prefixes, err := ParseTrustedProxies(tt.trusted)
+			require.NoError(t, err)
 
-			if tt.expectedStartsWith != "" && !strings.HasPrefix(ip, tt.expectedStartsWith) {
-				t.Errorf("proxyMode=%s: expected IP starting with %q, got %q", tt.proxyMode, tt.expectedStartsWith, ip)
+			req := httptest.NewRequest(http.MethodPost, "/api/send", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
 			}
+
+			ip := clientIPFromRequest(req, proxySettings{Mode: tt.mode, TrustedProxies: prefixes})
+			assert.Equal(t, tt.expected, ip)
 		})
 	}
 }
 
+func TestClientIPFromRequestGlobalTrustedProxies(t *testing.T) {
+	require.NoError(t, SetTrustedProxies([]string{"10.0.0.0/8"}))
+	t.Cleanup(func() { _ = SetTrustedProxies(nil) })
+
+	req := httptest.NewRequest(http.MethodPost, "/api/send", nil)
+	req.RemoteAddr = "198.51.100.9:80"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+	assert.Equal(t, "198.51.100.9", clientIPFromRequest(req, proxySettings{Mode: "xforwarded"}))
+
+	req.RemoteAddr = "10.0.0.2:80"
+	assert.Equal(t, "1.2.3.4", clientIPFromRequest(req, proxySettings{Mode: "xforwarded"}))
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.0.2.1 ", "2001:db8::/32", ""})
+	require.NoError(t, err)
+	require.Len(t, prefixes, 3)
+	assert.Equal(t, "192.0.2.1/32", prefixes[1].String())
+
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+}
+
 // TestProxyModeValues tests valid proxy mode values
 func TestProxyModeValues(t *testing.T) {
-	validModes := map[string]bool{
-		"none":       true,
-		"cloudflare": true,
-		"xforwarded": true,
-		"invalid":    false,
+	for _, mode := range []string{"none", "xforwarded", "cloudflare", "fastly", "akamai", "bunny"} {
+		assert.Contains(t, ProxyModes, mode)
 	}
+	assert.NotContains(t, ProxyModes, "invalid")
+}
 
-	for mode, shouldBeValid := range validModes {
-		t.Run("proxy_mode="+mode, func(t *testing.T) {
-			isValid := mode == "none" || mode == "cloudflare" || mode == "xforwarded"
-			if isValid != shouldBeValid {
-				t.Errorf("proxy_mode %q validity mismatch: expected %v, got %v", mode, shouldBeValid, isValid)
-			}
-		})
+func stubTrackingAPIKey(t *testing.T, key *models.APIKey) {
+	t.Helper()
+	original := lookupTrackingAPIKey
+	lookupTrackingAPIKey = func(*http.Request) *models.APIKey { return key }
+	t.Cleanup(func() { lookupTrackingAPIKey = original })
+}
+
+func TestSanitizeClientFieldsAnonymousOverrides(t *testing.T) {
+	stubTrackingAPIKey(t, nil)
+
+	now := time.Now()
+	old := now.Add(-90 * 24 * time.Hour).Unix()
+	p := PayloadData{
+		IP:        strPtr("203.0.113.9"),
+		UserAgent: strPtr("curl/8.0"),
+		Timestamp: &old,
+	}
+
+	rejected := sanitizeClientFields(httptest.NewRequest(http.MethodPost, "/api/send", nil), uuid.New(), &p, now)
+
+	assert.Equal(t, []string{"ip", "userAgent", "timestamp"}, rejected)
+	assert.Nil(t, p.IP)
+	assert.Nil(t, p.UserAgent)
+	assert.Nil(t, p.Timestamp)
+}
+
+func TestSanitizeClientFieldsTrustedAPIKey(t *testing.T) {
+	websiteID := uuid.New()
+	stubTrackingAPIKey(t, &models.APIKey{KeyID: uuid.New(), WebsiteID: websiteID, Scopes: []string{"ingest"}})
+
+	now := time.Now()
+	recent := now.Add(-time.Hour).Unix()
+	p := PayloadData{
+		IP:        strPtr("203.0.113.9"),
+		UserAgent: strPtr("Mozilla/5.0"),
+		Timestamp: &recent,
+	}
+
+	rejected := sanitizeClientFields(httptest.NewRequest(http.MethodPost, "/api/send", nil), websiteID, &p, now)
+
+	assert.Empty(t, rejected)
+	assert.Equal(t, "203.0.113.9", *p.IP)
+	assert.Equal(t, "Mozilla/5.0", *p.UserAgent)
+	assert.Equal(t, recent, *p.Timestamp)
+}
+
+func TestSanitizeClientFieldsKeyForOtherWebsite(t *testing.T) {
+	stubTrackingAPIKey(t, &models.APIKey{KeyID: uuid.New(), WebsiteID: uuid.New(), Scopes: []string{"ingest"}})
+
+	p := PayloadData{IP: strPtr("203.0.113.9")}
+	rejected := sanitizeClientFields(httptest.NewRequest(http.MethodPost, "/api/send", nil), uuid.New(), &p, time.Now())
+
+	assert.Equal(t, []string{"ip"}, rejected)
+	assert.Nil(t, p.IP)
+}
+
+func TestSanitizeClientFieldsInvalidIPWithKey(t *testing.T) {
+	websiteID := uuid.New()
+	stubTrackingAPIKey(t, &models.APIKey{KeyID: uuid.New(), WebsiteID: websiteID, Scopes: []string{"ingest"}})
+
+	p := PayloadData{IP: strPtr("not-an-ip")}
+	rejected := sanitizeClientFields(httptest.NewRequest(http.MethodPost, "/api/send", nil), websiteID, &p, time.Now())
+
+	assert.Equal(t, []string{"ip"}, rejected)
+}
+
+func TestSanitizeClientFieldsProps(t *testing.T) {
+	stubTrackingAPIKey(t, nil)
+
+	deep := map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{
+		"c": map[string]interface{}{"d": map[string]interface{}{"e": map[string]interface{}{"f": 1}}},
+	}}}
+	p := PayloadData{
+		Props: map[string]interface{}{"plan": "pro"},
+		Data:  deep,
 	}
+
+	rejected := sanitizeClientFields(httptest.NewRequest(http.MethodPost, "/api/send", nil), uuid.New(), &p, time.Now())
+	assert.Equal(t, []string{"data"}, rejected)
+	assert.NotNil(t, p.Props)
+	assert.Nil(t, p.Data)
+
+	p = PayloadData{Props: map[string]interface{}{"blob": strings.Repeat("x", 101*1024)}}
+	rejected = sanitizeClientFields(httptest.NewRequest(http.MethodPost, "/api/send", nil), uuid.New(), &p, time.Now())
+	assert.Equal(t, []string{"props"}, rejected)
 }
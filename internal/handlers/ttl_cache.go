@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// settingsCacheTTL is how long per-website settings are cached; CLI edits
+// apply after expiry
+const settingsCacheTTL = 5 * time.Minute
+
+// ttlCache holds values loaded from the database and reloads each one once
+// its TTL expires. A failed load is timestamped as well, so the database
+// isn't queried on every hit while it's down: until the TTL expires again
+// the last good value is served, or the error when there is none.
+type ttlCache[K comparable, V any] struct {
+	ttl     time.Duration
+	load    func(ctx context.Context, key K) (V, error)
+	entries map[K]ttlCacheEntry[V]
+	mu      sync.RWMutex
+}
+
+type ttlCacheEntry[V any] struct {
+	value     V
+	err       error
+	lastFetch time.Time
+}
+
+func newTTLCache[K comparable, V any](ttl time.Duration, load func(ctx context.Context, key K) (V, error)) *ttlCache[K, V] {
+	return &ttlCache[K, V]{
+		ttl:     ttl,
+		load:    load,
+		entries: make(map[K]ttlCacheEntry[V]),
+	}
+}
+
+// Get returns the cached value for key, loading it when missing or expired.
+// The load's error is returned to the caller that triggered it.
+func (c *ttlCache[K, V]) Get(key K) (V, error) {
+	c.mu.RLock()
+	entry, exists := c.entries[key]
+	c.mu.RUnlock()
+	if exists && time.Since(entry.lastFetch) < c.ttl {
+		return entry.value, entry.err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	value, err := c.load(ctx, key)
+	next := ttlCacheEntry[V]{value: value, err: err, lastFetch: time.Now()}
+	if err != nil && exists && entry.err == nil {
+		// Keep serving the last good value until the next reload
+		next = ttlCacheEntry[V]{value: entry.value, lastFetch: next.lastFetch}
+	}
+
+	c.mu.Lock()
+	c.entries[key] = next
+	c.mu.Unlock()
+
+	return value, err
+}
+
+// Set stores a value as freshly loaded
+func (c *ttlCache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = ttlCacheEntry[V]{value: value, lastFetch: time.Now()}
+}
+
+// Invalidate forces the next Get for key to reload it
+func (c *ttlCache[K, V]) Invalidate(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLCacheReloadsAfterExpiry(t *testing.T) {
+	loads := 0
+	cache := newTTLCache(time.Hour, func(ctx context.Context, key string) (int, error) {
+		loads++
+		return loads, nil
+	})
+
+	v, err := cache.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v)
+	v, _ = cache.Get("a")
+	assert.Equal(t, 1, v, "served from cache")
+
+	cache.Invalidate("a")
+	v, _ = cache.Get("a")
+	assert.Equal(t, 2, v)
+
+	cache.ttl = 0
+	v, _ = cache.Get("a")
+	assert.Equal(t, 3, v)
+	assert.Equal(t, 3, loads)
+}
+
+func TestTTLCacheRecordsFailedLoads(t *testing.T) {
+	loadErr := errors.New("database down")
+	loads := 0
+	fail := true
+	cache := newTTLCache(time.Hour, func(ctx context.Context, key string) (string, error) {
+		loads++
+		if fail {
+			return "", loadErr
+		}
+		return "rules", nil
+	})
+
+	// Without a previous value the error is cached until the TTL expires
+	_, err := cache.Get("a")
+	assert.ErrorIs(t, err, loadErr)
+	_, err = cache.Get("a")
+	assert.ErrorIs(t, err, loadErr)
+	assert.Equal(t, 1, loads)
+
+	// With one, the caller that hit the failure sees it, later callers get the last good value
+	cache.entries["b"] = ttlCacheEntry[string]{value: "old rules", lastFetch: time.Now().Add(-2 * time.Hour)}
+	_, err = cache.Get("b")
+	assert.ErrorIs(t, err, loadErr)
+	v, err := cache.Get("b")
+	require.NoError(t, err)
+	assert.Equal(t, "old rules", v)
+	assert.Equal(t, 2, loads)
+
+	fail = false
+	cache.Invalidate("a")
+	v, err = cache.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, "rules", v)
+}
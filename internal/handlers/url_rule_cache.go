@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/seuros/kaunta/internal/database"
+	"github.com/seuros/kaunta/internal/logging"
+	"github.com/seuros/kaunta/internal/models"
+	"github.com/seuros/kaunta/internal/urlrules"
+	"go.uber.org/zap"
+)
+
+// urlRuleCache holds each website's compiled URL normalization rules; a nil
+// ruleset leaves URLs unchanged
+var urlRuleCache = newTTLCache(settingsCacheTTL, func(ctx context.Context, websiteID uuid.UUID) (*urlrules.Ruleset, error) {
+	ruleset, err := models.LoadURLRuleset(ctx, database.DB, websiteID)
+	if err != nil || ruleset.Empty() {
+		return nil, err
+	}
+	return ruleset, nil
+})
+
+// normalizePageURL applies the website's URL rules to a parsed path and query.
+// It returns the stored path, query (nil when empty) and content group.
+func normalizePageURL(websiteID uuid.UUID, path, query *string) (*string, *string, *string) {
+	if path == nil {
+		return path, query, nil
+	}
+
+	ruleset, err := urlRuleCache.Get(websiteID)
+	if err != nil {
+		logging.L().Warn("failed to load url rules",
+			zap.String("website_id", websiteID.String()), zap.Error(err))
+		return path, query, nil
+	}
+	if ruleset == nil {
+		return path, query, nil
+	}
+
+	rawQuery := ""
+	if query != nil {
+		rawQuery = *query
+	}
+	result := ruleset.Apply(*path, rawQuery)
+
+	var normalizedQuery, group *string
+	if result.Query != "" {
+		normalizedQuery = &result.Query
+	}
+	if result.ContentGroup != "" {
+		group = &result.ContentGroup
+	}
+	return &result.Path, normalizedQuery, group
+}
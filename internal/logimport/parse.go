@@ -0,0 +1,256 @@
+package logimport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported access log formats
+const (
+	FormatNginx             = "nginx"
+	FormatApacheCombined    = "apache-combined"
+	FormatCaddyJSON         = "caddy-json"
+	FormatCloudflareLogpush = "cloudflare-logpush"
+)
+
+// Formats lists the accepted --format values
+var Formats = []string{FormatNginx, FormatApacheCombined, FormatCaddyJSON, FormatCloudflareLogpush}
+
+// ErrUnknownFormat is returned for an unsupported log format
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// Hit is a single HTTP request read from an access log
+type Hit struct {
+	Time        time.Time
+	IP          string
+	Method      string
+	Host        string
+	URI         string // path + query as requested
+	Status      int
+	Referrer    string
+	UserAgent   string
+	ContentType string // empty when the log format doesn't record it
+}
+
+// combinedPattern matches the NCSA combined format shared by nginx ("combined")
+// and Apache ("%h %l %u %t \"%r\" %>s %b \"%{Referer}i\" \"%{User-agent}i\"")
+var combinedPattern = regexp.MustCompile(
+	`^(\S+) \S+ \S+ \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) \S+(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`,
+)
+
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// ParseLine parses one access log line in the given format
+func ParseLine(format, line string) (*Hit, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, errors.New("empty line")
+	}
+
+	switch format {
+	case FormatNginx, FormatApacheCombined:
+		return parseCombined(line)
+	case FormatCaddyJSON:
+		return parseCaddy(line)
+	case FormatCloudflareLogpush:
+		return parseCloudflare(line)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+}
+
+func parseCombined(line string) (*Hit, error) {
+	m := combinedPattern.FindStringSubmatch(line)
+	if m == nil {
+		return nil, errors.New("line does not match combined log format")
+	}
+
+	ts, err := time.Parse(combinedTimeLayout, m[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q: %w", m[2], err)
+	}
+
+	status, _ := strconv.Atoi(m[4])
+
+	// Request line: "GET /path?q=1 HTTP/1.1"
+	parts := strings.Fields(m[3])
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid request line %q", m[3])
+	}
+
+	return &Hit{
+		Time:      ts,
+		IP:        m[1],
+		Method:    parts[0],
+		URI:       parts[1],
+		Status:    status,
+		Referrer:  dashToEmpty(m[5]),
+		UserAgent: dashToEmpty(m[6]),
+	}, nil
+}
+
+type caddyEntry struct {
+	TS      float64 `json:"ts"`
+	Status  int     `json:"status"`
+	Request struct {
+		RemoteIP string              `json:"remote_ip"`
+		ClientIP string              `json:"client_ip"`
+		Method   string              `json:"method"`
+		Host     string              `json:"host"`
+		URI      string              `json:"uri"`
+		Headers  map[string][]string `json:"headers"`
+	} `json:"request"`
+	RespHeaders map[string][]string `json:"resp_headers"`
+}
+
+func parseCaddy(line string) (*Hit, error) {
+	var e caddyEntry
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		return nil, fmt.Errorf("invalid caddy JSON: %w", err)
+	}
+	if e.Request.Method == "" || e.Request.URI == "" {
+		return nil, errors.New("not a caddy access log entry")
+	}
+
+	ip := e.Request.ClientIP
+	if ip == "" {
+		ip = e.Request.RemoteIP
+	}
+
+	sec, frac := splitFloat(e.TS)
+
+	return &Hit{
+		Time:        time.Unix(sec, frac).UTC(),
+		IP:          ip,
+		Method:      e.Request.Method,
+		Host:        e.Request.Host,
+		URI:         e.Request.URI,
+		Status:      e.Status,
+		Referrer:    firstHeader(e.Request.Headers, "Referer"),
+		UserAgent:   firstHeader(e.Request.Headers, "User-Agent"),
+		ContentType: firstHeader(e.RespHeaders, "Content-Type"),
+	}, nil
+}
+
+type cloudflareEntry struct {
+	ClientIP                string          `json:"ClientIP"`
+	ClientRequestHost       string          `json:"ClientRequestHost"`
+	ClientRequestMethod     string          `json:"ClientRequestMethod"`
+	ClientRequestURI        string          `json:"ClientRequestURI"`
+	ClientRequestReferer    string          `json:"ClientRequestReferer"`
+	ClientRequestUserAgent  string          `json:"ClientRequestUserAgent"`
+	EdgeResponseStatus      int             `json:"EdgeResponseStatus"`
+	EdgeResponseContentType string          `json:"EdgeResponseContentType"`
+	EdgeStartTimestamp      json.RawMessage `json:"EdgeStartTimestamp"`
+}
+
+func parseCloudflare(line string) (*Hit, error) {
+	var e cloudflareEntry
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		return nil, fmt.Errorf("invalid logpush JSON: %w", err)
+	}
+	if e.ClientRequestMethod == "" || e.ClientRequestURI == "" {
+		return nil, errors.New("not a cloudflare http_requests entry")
+	}
+
+	ts, err := parseLogpushTimestamp(e.EdgeStartTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Hit{
+		Time:        ts,
+		IP:          e.ClientIP,
+		Method:      e.ClientRequestMethod,
+		Host:        e.ClientRequestHost,
+		URI:         e.ClientRequestURI,
+		Status:      e.EdgeResponseStatus,
+		Referrer:    e.ClientRequestReferer,
+		UserAgent:   e.ClientRequestUserAgent,
+		ContentType: e.EdgeResponseContentType,
+	}, nil
+}
+
+// parseLogpushTimestamp handles the three Logpush timestamp_format options:
+// rfc3339 (string), unix (seconds) and unixnano (nanoseconds)
+func parseLogpushTimestamp(raw json.RawMessage) (time.Time, error) {
+	if len(raw) == 0 {
+		return time.Time{}, errors.New("missing EdgeStartTimestamp")
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, nil
+		}
+		raw = json.RawMessage(s)
+	}
+
+	n, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid EdgeStartTimestamp %s", string(raw))
+	}
+	if n > 1e15 {
+		return time.Unix(0, n).UTC(), nil
+	}
+	return time.Unix(n, 0).UTC(), nil
+}
+
+// assetExtensions are never counted as pageviews
+var assetExtensions = map[string]bool{
+	".css": true, ".js": true, ".mjs": true, ".map": true, ".json": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".avif": true,
+	".svg": true, ".ico": true, ".bmp": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp4": true, ".webm": true, ".mp3": true, ".ogg": true, ".wav": true,
+	".pdf": true, ".zip": true, ".gz": true, ".tar": true, ".dmg": true, ".exe": true,
+	".xml": true, ".txt": true, ".rss": true, ".atom": true, ".wasm": true,
+}
+
+// IsPageview reports whether a hit looks like a successful HTML page request
+func (h *Hit) IsPageview() bool {
+	if h.Method != "GET" {
+		return false
+	}
+	if h.Status < 200 || (h.Status >= 300 && h.Status != 304) {
+		return false
+	}
+	if h.ContentType != "" && !strings.HasPrefix(strings.ToLower(h.ContentType), "text/html") {
+		return false
+	}
+
+	p := h.URI
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p = p[:i]
+	}
+	if !strings.HasPrefix(p, "/") {
+		return false
+	}
+
+	return !assetExtensions[strings.ToLower(path.Ext(p))]
+}
+
+func dashToEmpty(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+func firstHeader(headers map[string][]string, name string) string {
+	if values := headers[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func splitFloat(ts float64) (int64, int64) {
+	sec := int64(ts)
+	return sec, int64((ts - float64(sec)) * 1e9)
+}
@@ -0,0 +1,108 @@
+package logimport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLineCombined(t *testing.T) {
+	line := `203.0.113.7 - - [10/Oct/2025:13:55:36 +0200] "GET /pricing?plan=pro HTTP/1.1" 200 5120 "https://www.google.com/" "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0"`
+
+	for _, format := range []string{FormatNginx, FormatApacheCombined} {
+		hit, err := ParseLine(format, line)
+		require.NoError(t, err, format)
+
+		assert.Equal(t, "203.0.113.7", hit.IP)
+		assert.Equal(t, "GET", hit.Method)
+		assert.Equal(t, "/pricing?plan=pro", hit.URI)
+		assert.Equal(t, 200, hit.Status)
+		assert.Equal(t, "https://www.google.com/", hit.Referrer)
+		assert.Contains(t, hit.UserAgent, "Chrome")
+		assert.True(t, hit.Time.Equal(time.Date(2025, 10, 10, 11, 55, 36, 0, time.UTC)))
+		assert.True(t, hit.IsPageview())
+	}
+}
+
+func TestParseLineCombinedDashReferrer(t *testing.T) {
+	hit, err := ParseLine(FormatNginx, `198.51.100.1 - - [10/Oct/2025:13:55:36 +0000] "GET / HTTP/2.0" 304 0 "-" "-"`)
+	require.NoError(t, err)
+	assert.Empty(t, hit.Referrer)
+	assert.Empty(t, hit.UserAgent)
+	assert.True(t, hit.IsPageview())
+}
+
+func TestParseLineCaddyJSON(t *testing.T) {
+	line := `{"level":"info","ts":1760104536.5,"logger":"http.log.access","msg":"handled request","request":{"remote_ip":"10.0.0.2","client_ip":"203.0.113.9","method":"GET","host":"example.com","uri":"/blog/post","headers":{"User-Agent":["Mozilla/5.0 Firefox/130.0"],"Referer":["https://news.ycombinator.com/"]}},"status":200,"resp_headers":{"Content-Type":["text/html; charset=utf-8"]}}`
+
+	hit, err := ParseLine(FormatCaddyJSON, line)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.9", hit.IP)
+	assert.Equal(t, "example.com", hit.Host)
+	assert.Equal(t, "/blog/post", hit.URI)
+	assert.Equal(t, "https://news.ycombinator.com/", hit.Referrer)
+	assert.Equal(t, int64(1760104536), hit.Time.Unix())
+	assert.True(t, hit.IsPageview())
+}
+
+func TestParseLineCloudflareLogpush(t *testing.T) {
+	tests := []struct {
+		name      string
+		timestamp string
+	}{
+		{"rfc3339", `"2025-10-10T11:55:36Z"`},
+		{"unix", `1760097336`},
+		{"unixnano", `1760097336000000000`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := `{"ClientIP":"203.0.113.4","ClientRequestHost":"example.com","ClientRequestMethod":"GET","ClientRequestURI":"/docs","ClientRequestReferer":"","ClientRequestUserAgent":"Mozilla/5.0 Safari","EdgeResponseStatus":200,"EdgeResponseContentType":"text/html","EdgeStartTimestamp":` + tt.timestamp + `}`
+
+			hit, err := ParseLine(FormatCloudflareLogpush, line)
+			require.NoError(t, err)
+			assert.Equal(t, "203.0.113.4", hit.IP)
+			assert.Equal(t, "/docs", hit.URI)
+			assert.Equal(t, int64(1760097336), hit.Time.Unix())
+			assert.True(t, hit.IsPageview())
+		})
+	}
+}
+
+func TestParseLineErrors(t *testing.T) {
+	_, err := ParseLine("iis", "anything")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	_, err = ParseLine(FormatNginx, "not a log line")
+	assert.Error(t, err)
+
+	_, err = ParseLine(FormatCaddyJSON, `{"level":"info","msg":"server running"}`)
+	assert.Error(t, err)
+}
+
+func TestHitIsPageview(t *testing.T) {
+	tests := []struct {
+		name string
+		hit  Hit
+		want bool
+	}{
+		{"html page", Hit{Method: "GET", Status: 200, URI: "/about"}, true},
+		{"not modified", Hit{Method: "GET", Status: 304, URI: "/"}, true},
+		{"stylesheet", Hit{Method: "GET", Status: 200, URI: "/assets/app.css?v=3"}, false},
+		{"image", Hit{Method: "GET", Status: 200, URI: "/logo.PNG"}, false},
+		{"robots", Hit{Method: "GET", Status: 200, URI: "/robots.txt"}, false},
+		{"post", Hit{Method: "POST", Status: 200, URI: "/contact"}, false},
+		{"not found", Hit{Method: "GET", Status: 404, URI: "/missing"}, false},
+		{"redirect", Hit{Method: "GET", Status: 301, URI: "/old"}, false},
+		{"json response", Hit{Method: "GET", Status: 200, URI: "/api/items", ContentType: "application/json"}, false},
+		{"absolute uri", Hit{Method: "GET", Status: 200, URI: "http://proxy.test/"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.hit.IsPageview())
+		})
+	}
+}
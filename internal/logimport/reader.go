@@ -0,0 +1,133 @@
+package logimport
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxLineSize bounds a single log line (long query strings, large JSON entries)
+const maxLineSize = 1024 * 1024
+
+// Open opens a log file for reading, transparently decompressing .gz files
+func Open(filePath string) (io.ReadCloser, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(filePath, ".gz") {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to open gzip stream: %w", err)
+	}
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	_ = g.Reader.Close()
+	return g.file.Close()
+}
+
+// ScanLines calls fn for each line of r until EOF or ctx is cancelled
+func ScanLines(ctx context.Context, r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Follow tails filePath like `tail -F`, starting at the current end of file.
+// Truncation and rotation (file replaced at the same path) are detected and
+// the new file is read from the beginning. Returns when ctx is cancelled.
+func Follow(ctx context.Context, filePath string, pollInterval time.Duration, fn func(line string) error) error {
+	if strings.HasSuffix(filePath, ".gz") {
+		return errors.New("--follow cannot be used with compressed files")
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+	var partial strings.Builder
+
+	for {
+		chunk, err := reader.ReadString('\n')
+		if chunk != "" {
+			offset += int64(len(chunk))
+			partial.WriteString(chunk)
+		}
+
+		if err == nil {
+			line := strings.TrimRight(partial.String(), "\r\n")
+			partial.Reset()
+			if err := fn(line); err != nil {
+				return err
+			}
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		// At EOF: wait for more data, then check for rotation/truncation
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+
+		current, statErr := os.Stat(filePath)
+		if statErr != nil {
+			// File is being rotated; try again on the next tick
+			continue
+		}
+		opened, statErr := f.Stat()
+		if statErr != nil {
+			return statErr
+		}
+
+		if !os.SameFile(current, opened) || current.Size() < offset {
+			newFile, err := os.Open(filePath)
+			if err != nil {
+				continue
+			}
+			_ = f.Close()
+			f = newFile
+			offset = 0
+			partial.Reset()
+			reader = bufio.NewReader(f)
+		}
+	}
+}
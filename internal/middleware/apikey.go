@@ -81,8 +81,27 @@ func apiKeyAuthWithScope(next http.Handler, requiredScope string) http.Handler {
 	})
 }
 
+// OptionalAPIKey returns the valid API key sent with the request, or nil.
+// For endpoints that accept anonymous requests but grant extra trust to
+// authenticated callers; it never writes a response.
+func OptionalAPIKey(r *http.Request) *models.APIKey {
+	key := extractAPIKey(r)
+	if key == "" || !strings.HasPrefix(key, "kaunta_live_") {
+		return nil
+	}
+
+	apiKey, err := apiKeyValidator(models.HashAPIKey(key))
+	if err != nil || !apiKey.IsValid() {
+		return nil
+	}
+
+	go models.UpdateAPIKeyLastUsed(apiKey.KeyID)
+	return apiKey
+}
+
 // extractAPIKey extracts the API key from request headers
-// Supports: Authorization: Bearer <key> or X-API-Key: <key>
+// Supports: Authorization: Bearer <key>, X-API-Key: <key>, or
+// Authorization: Basic with the key as username (Segment write key style)
 func extractAPIKey(r *http.Request) string {
 	authHeader := r.Header.Get("Authorization")
 	if strings.HasPrefix(authHeader, "Bearer ") {
@@ -93,6 +112,10 @@ func extractAPIKey(r *http.Request) string {
 		return apiKey
 	}
 
+	if username, _, ok := r.BasicAuth(); ok {
+		return username
+	}
+
 	return ""
 }
 
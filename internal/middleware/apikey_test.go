@@ -194,6 +194,60 @@ func TestAPIKeyAuthXAPIKeyHeader(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 }
 
+func TestAPIKeyAuthBasicAuthUsername(t *testing.T) {
+	rawKey := "kaunta_live_abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"
+	expectedKey := &models.APIKey{
+		KeyID:              uuid.New(),
+		WebsiteID:          uuid.New(),
+		Scopes:             []string{"ingest"},
+		RateLimitPerMinute: 1000,
+	}
+
+	stubAPIKeyValidator(t, func(keyHash string) (*models.APIKey, error) {
+		assert.Equal(t, models.HashAPIKey(rawKey), keyHash)
+		return expectedKey, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.SetBasicAuth(rawKey, "")
+
+	resp := executeAPIKeyMiddleware(t, nil, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestOptionalAPIKey(t *testing.T) {
+	rawKey := "kaunta_live_abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"
+	expectedKey := &models.APIKey{
+		KeyID:     uuid.New(),
+		WebsiteID: uuid.New(),
+		Scopes:    []string{"ingest"},
+	}
+
+	stubAPIKeyValidator(t, func(keyHash string) (*models.APIKey, error) {
+		if keyHash == models.HashAPIKey(rawKey) {
+			return expectedKey, nil
+		}
+		return nil, sql.ErrNoRows
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/send", nil)
+	assert.Nil(t, OptionalAPIKey(req), "no key")
+
+	req.Header.Set("Authorization", "Bearer not_a_kaunta_key")
+	assert.Nil(t, OptionalAPIKey(req), "wrong prefix")
+
+	req.Header.Set("Authorization", "Bearer kaunta_live_unknown")
+	assert.Nil(t, OptionalAPIKey(req), "unknown key")
+
+	req.Header.Set("Authorization", "Bearer "+rawKey)
+	assert.Equal(t, expectedKey, OptionalAPIKey(req))
+
+	revoked := time.Now()
+	expectedKey.RevokedAt = &revoked
+	assert.Nil(t, OptionalAPIKey(req), "revoked key")
+}
+
 func TestGetAPIKeyWithoutContext(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	assert.Nil(t, GetAPIKey(req))
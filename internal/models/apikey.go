@@ -37,6 +37,10 @@ type APIKeyCreateResult struct {
 	APIKey  *APIKey `json:"key"`
 }
 
+// ScopeForwardClientContext lets ingest calls supply the end user's IP and
+// User-Agent in payload.context instead of using the calling server's
+const ScopeForwardClientContext = "forward_client_context"
+
 const (
 	apiKeyPrefix = "kaunta_live_"
 	keyByteLen   = 32 // 256-bit entropy
@@ -50,10 +54,10 @@ func GenerateAPIKey(websiteID uuid.UUID, createdBy *uuid.UUID, name *string) (*A
 // GenerateAPIKeyWithScopes creates a new API key for a website with custom scopes
 func GenerateAPIKeyWithScopes(websiteID uuid.UUID, createdBy *uuid.UUID, name *string, scopes []string) (*APIKeyCreateResult, error) {
 	// Validate scopes
-	validScopes := map[string]bool{"ingest": true, "stats": true}
+	validScopes := map[string]bool{"ingest": true, "stats": true, ScopeForwardClientContext: true}
 	for _, scope := range scopes {
 		if !validScopes[scope] {
-			return nil, fmt.Errorf("invalid scope: %s (valid: ingest, stats)", scope)
+			return nil, fmt.Errorf("invalid scope: %s (valid: ingest, stats, %s)", scope, ScopeForwardClientContext)
 		}
 	}
 	if len(scopes) == 0 {
@@ -0,0 +1,348 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/seuros/kaunta/internal/exclusions"
+)
+
+// Bot types understood by the bots report
+var BotTypes = []string{"llm_crawler", "generic_bot", "scraper", "headless_browser"}
+
+// Pattern sources managed outside of imports
+const (
+	BotPatternSourceBuiltin = "builtin"
+	BotPatternSourceCLI     = "cli"
+)
+
+const maxBotPatternName = 100
+
+// BotPattern is a user agent regex from bot_user_agent_patterns
+type BotPattern struct {
+	ID           int       `json:"pattern_id"`
+	Name         string    `json:"name"`
+	Regex        string    `json:"regex"`
+	BotType      string    `json:"bot_type"`
+	IsLegitimate bool      `json:"is_legitimate"`
+	Notes        string    `json:"notes,omitempty"`
+	Source       string    `json:"source"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// BlockedIP is an IP range whose requests are always treated as bots
+type BlockedIP struct {
+	CIDR      string    `json:"cidr"`
+	BotType   string    `json:"bot_type"`
+	Notes     string    `json:"notes,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BotPatternImport counts the outcome of ImportBotPatterns
+type BotPatternImport struct {
+	Added   int      `json:"added"`
+	Removed int      `json:"removed"`
+	Skipped int      `json:"skipped"` // regex already imported from this source
+	Invalid []string `json:"invalid,omitempty"`
+}
+
+// ParseBotType validates a bot type; "ai" is accepted for llm_crawler
+func ParseBotType(botType string) (string, error) {
+	botType = strings.ToLower(strings.TrimSpace(botType))
+	if botType == "ai" {
+		return "llm_crawler", nil
+	}
+	for _, t := range BotTypes {
+		if t == botType {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("invalid bot type %q (valid: ai, %s)", botType, strings.Join(BotTypes, ", "))
+}
+
+// ValidateBotRegex compiles a pattern with PostgreSQL's regex engine, which is
+// the one is_known_bot_ua uses; an invalid pattern there would break bot
+// detection for every request
+func ValidateBotRegex(ctx context.Context, db *sql.DB, regex string) error {
+	if strings.TrimSpace(regex) == "" {
+		return fmt.Errorf("pattern regex is required")
+	}
+	var ok bool
+	if err := db.QueryRowContext(ctx, `SELECT ''::text ~* $1`, regex).Scan(&ok); err != nil {
+		return fmt.Errorf("invalid pattern regex %q: %w", regex, err)
+	}
+	return nil
+}
+
+func scanBotPatterns(rows *sql.Rows) ([]*BotPattern, error) {
+	defer func() { _ = rows.Close() }()
+
+	var list []*BotPattern
+	for rows.Next() {
+		var p BotPattern
+		var notes sql.NullString
+		if err := rows.Scan(&p.ID, &p.Name, &p.Regex, &p.BotType, &p.IsLegitimate, &notes, &p.Source, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		p.Notes = notes.String
+		list = append(list, &p)
+	}
+	return list, rows.Err()
+}
+
+// ListBotPatterns returns the user agent patterns, optionally of one source
+func ListBotPatterns(ctx context.Context, db *sql.DB, source string) ([]*BotPattern, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT pattern_id, pattern_name, pattern_regex, bot_type, COALESCE(is_legitimate, false), notes, source, created_at
+		FROM bot_user_agent_patterns
+		WHERE $1 = '' OR source = $1
+		ORDER BY source, bot_type, pattern_name
+	`, source)
+	if err != nil {
+		return nil, err
+	}
+	return scanBotPatterns(rows)
+}
+
+// UpsertBotPattern adds a pattern, replacing the one with the same name
+func UpsertBotPattern(ctx context.Context, db *sql.DB, p BotPattern) (*BotPattern, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return nil, fmt.Errorf("pattern name is required")
+	}
+	if len(p.Name) > maxBotPatternName {
+		return nil, fmt.Errorf("pattern name exceeds %d characters", maxBotPatternName)
+	}
+	botType, err := ParseBotType(p.BotType)
+	if err != nil {
+		return nil, err
+	}
+	p.BotType = botType
+	if err := ValidateBotRegex(ctx, db, p.Regex); err != nil {
+		return nil, err
+	}
+	if p.Source == "" {
+		p.Source = BotPatternSourceCLI
+	}
+
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO bot_user_agent_patterns (pattern_name, pattern_regex, bot_type, is_legitimate, notes, source)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		ON CONFLICT (pattern_name) DO UPDATE SET
+			pattern_regex = EXCLUDED.pattern_regex,
+			bot_type = EXCLUDED.bot_type,
+			is_legitimate = EXCLUDED.is_legitimate,
+			notes = EXCLUDED.notes,
+			source = EXCLUDED.source
+		RETURNING pattern_id, created_at
+	`, p.Name, p.Regex, p.BotType, p.IsLegitimate, p.Notes, p.Source).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DeleteBotPattern removes a pattern by name
+func DeleteBotPattern(ctx context.Context, db *sql.DB, name string) error {
+	result, err := db.ExecContext(ctx, `DELETE FROM bot_user_agent_patterns WHERE pattern_name = $1`, name)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// MatchBotPatterns returns the patterns matching a user agent, the one
+// is_known_bot_ua picks first
+func MatchBotPatterns(ctx context.Context, db *sql.DB, userAgent string) ([]*BotPattern, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT pattern_id, pattern_name, pattern_regex, bot_type, COALESCE(is_legitimate, false), notes, source, created_at
+		FROM bot_user_agent_patterns
+		WHERE $1 ~* pattern_regex
+		ORDER BY CASE WHEN pattern_regex = $1 THEN 1 WHEN pattern_name != 'generic_bot' THEN 2 ELSE 3 END, pattern_name
+	`, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return scanBotPatterns(rows)
+}
+
+// ImportBotPatterns stores regexes from a crawler list under source. With
+// replace, patterns previously imported from the same source are removed
+// first. Names are "<source>:<hash of regex>"; regexes PostgreSQL rejects are
+// reported and skipped.
+func ImportBotPatterns(ctx context.Context, db *sql.DB, source, botType string, legitimate bool, regexes []string, replace bool) (*BotPatternImport, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+	if source == BotPatternSourceBuiltin || source == BotPatternSourceCLI {
+		return nil, fmt.Errorf("source %q is reserved", source)
+	}
+	if len(importedBotPatternName(source, "")) > maxBotPatternName {
+		return nil, fmt.Errorf("source exceeds %d characters", maxBotPatternName-botPatternHashSize-1)
+	}
+	botType, err := ParseBotType(botType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BotPatternImport{}
+	var valid []string
+	for _, regex := range regexes {
+		if err := ValidateBotRegex(ctx, db, regex); err != nil {
+			result.Invalid = append(result.Invalid, regex)
+			continue
+		}
+		valid = append(valid, regex)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if replace {
+		res, err := tx.ExecContext(ctx, `DELETE FROM bot_user_agent_patterns WHERE source = $1`, source)
+		if err != nil {
+			return nil, err
+		}
+		removed, _ := res.RowsAffected()
+		result.Removed = int(removed)
+	}
+
+	for _, regex := range valid {
+		name := importedBotPatternName(source, regex)
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO bot_user_agent_patterns (pattern_name, pattern_regex, bot_type, is_legitimate, source)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (pattern_name) DO NOTHING
+		`, name, regex, botType, legitimate, source)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			result.Skipped++
+		} else {
+			result.Added++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// botPatternHashSize is the number of hex digits of the regex hash in imported names
+const botPatternHashSize = 12
+
+// importedBotPatternName names an imported regex after its source and a short
+// hash of the regex, so long regexes never collide or exceed the name column
+func importedBotPatternName(source, regex string) string {
+	sum := sha256.Sum256([]byte(regex))
+	return source + ":" + hex.EncodeToString(sum[:])[:botPatternHashSize]
+}
+
+// normalizeBlockedCIDR returns the canonical range of an IP or CIDR
+func normalizeBlockedCIDR(value string) (string, error) {
+	rule, err := exclusions.Normalize(exclusions.Rule{Kind: exclusions.KindIP, Value: value})
+	if err != nil {
+		return "", err
+	}
+	return rule.Value, nil
+}
+
+// ListBlockedIPs returns the IP blocklist
+func ListBlockedIPs(ctx context.Context, db *sql.DB) ([]*BlockedIP, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT cidr::text, bot_type, COALESCE(notes, ''), created_at
+		FROM bot_ip_blocklist
+		ORDER BY cidr
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []*BlockedIP
+	for rows.Next() {
+		var b BlockedIP
+		if err := rows.Scan(&b.CIDR, &b.BotType, &b.Notes, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &b)
+	}
+	return list, rows.Err()
+}
+
+// BlockIP adds an IP or CIDR to the blocklist, updating an existing entry
+func BlockIP(ctx context.Context, db *sql.DB, value, botType, notes string) (*BlockedIP, error) {
+	cidr, err := normalizeBlockedCIDR(value)
+	if err != nil {
+		return nil, err
+	}
+	botType, err = ParseBotType(botType)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &BlockedIP{CIDR: cidr, BotType: botType, Notes: notes}
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO bot_ip_blocklist (cidr, bot_type, notes)
+		VALUES ($1::cidr, $2, NULLIF($3, ''))
+		ON CONFLICT (cidr) DO UPDATE SET bot_type = EXCLUDED.bot_type, notes = EXCLUDED.notes
+		RETURNING created_at
+	`, cidr, botType, notes).Scan(&b.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// UnblockIP removes an IP or CIDR from the blocklist
+func UnblockIP(ctx context.Context, db *sql.DB, value string) (string, error) {
+	cidr, err := normalizeBlockedCIDR(value)
+	if err != nil {
+		return "", err
+	}
+	result, err := db.ExecContext(ctx, `DELETE FROM bot_ip_blocklist WHERE cidr = $1::cidr`, cidr)
+	if err != nil {
+		return "", err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return cidr, sql.ErrNoRows
+	}
+	return cidr, nil
+}
+
+// MatchBlockedIP returns the most specific blocklist range containing ip, nil
+// when it isn't blocked
+func MatchBlockedIP(ctx context.Context, db *sql.DB, ip string) (*BlockedIP, error) {
+	var b BlockedIP
+	err := db.QueryRowContext(ctx, `
+		SELECT cidr::text, bot_type, COALESCE(notes, ''), created_at
+		FROM bot_ip_blocklist
+		WHERE $1::inet <<= cidr
+		ORDER BY masklen(cidr) DESC
+		LIMIT 1
+	`, ip).Scan(&b.CIDR, &b.BotType, &b.Notes, &b.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
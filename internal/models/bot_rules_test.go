@@ -0,0 +1,108 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBotType(t *testing.T) {
+	botType, err := ParseBotType(" AI ")
+	require.NoError(t, err)
+	assert.Equal(t, "llm_crawler", botType)
+
+	botType, err = ParseBotType("scraper")
+	require.NoError(t, err)
+	assert.Equal(t, "scraper", botType)
+
+	_, err = ParseBotType("robot")
+	assert.Error(t, err)
+}
+
+func TestUpsertBotPatternValidatesRegex(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery(`SELECT ''::text`).WithArgs("Scrapy(").
+		WillReturnError(errors.New("invalid regular expression: parentheses () not balanced"))
+
+	_, err = UpsertBotPattern(context.Background(), db, BotPattern{Name: "scrapy", Regex: "Scrapy(", BotType: "scraper"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid pattern regex")
+
+	mock.ExpectQuery(`SELECT ''::text`).WithArgs("Scrapy/").
+		WillReturnRows(sqlmock.NewRows([]string{"match"}).AddRow(false))
+	mock.ExpectQuery("INSERT INTO bot_user_agent_patterns").
+		WithArgs("scrapy", "Scrapy/", "scraper", false, "", BotPatternSourceCLI).
+		WillReturnRows(sqlmock.NewRows([]string{"pattern_id", "created_at"}).AddRow(42, time.Now()))
+
+	p, err := UpsertBotPattern(context.Background(), db, BotPattern{Name: "scrapy", Regex: "Scrapy/", BotType: "scraper"})
+	require.NoError(t, err)
+	assert.Equal(t, 42, p.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportBotPatterns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery(`SELECT ''::text`).WithArgs("bingbot").
+		WillReturnRows(sqlmock.NewRows([]string{"match"}).AddRow(false))
+	mock.ExpectQuery(`SELECT ''::text`).WithArgs("(?<=x)").
+		WillReturnError(errors.New("invalid regular expression"))
+	mock.ExpectQuery(`SELECT ''::text`).WithArgs("AhrefsBot").
+		WillReturnRows(sqlmock.NewRows([]string{"match"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM bot_user_agent_patterns WHERE source").WithArgs("crawlers").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("INSERT INTO bot_user_agent_patterns").
+		WithArgs(importedBotPatternName("crawlers", "bingbot"), "bingbot", "generic_bot", true, "crawlers").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO bot_user_agent_patterns").
+		WithArgs(importedBotPatternName("crawlers", "AhrefsBot"), "AhrefsBot", "generic_bot", true, "crawlers").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	result, err := ImportBotPatterns(context.Background(), db, "crawlers", "generic_bot", true,
+		[]string{"bingbot", "(?<=x)", "AhrefsBot"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, &BotPatternImport{Added: 1, Removed: 3, Skipped: 1, Invalid: []string{"(?<=x)"}}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = ImportBotPatterns(context.Background(), db, BotPatternSourceBuiltin, "generic_bot", false, nil, true)
+	assert.Error(t, err)
+}
+
+func TestImportedBotPatternName(t *testing.T) {
+	prefix := strings.Repeat("a", 150)
+	first := importedBotPatternName("crawlers", prefix+"one")
+	second := importedBotPatternName("crawlers", prefix+"two")
+
+	assert.NotEqual(t, first, second)
+	assert.Len(t, first, len("crawlers:")+botPatternHashSize)
+	assert.Equal(t, first, importedBotPatternName("crawlers", prefix+"one"))
+	assert.True(t, strings.HasPrefix(first, "crawlers:"))
+}
+
+func TestBlockIPNormalizes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("INSERT INTO bot_ip_blocklist").
+		WithArgs("203.0.113.0/24", "scraper", "farm").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+
+	b, err := BlockIP(context.Background(), db, "203.0.113.77/24", "scraper", "farm")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.0/24", b.CIDR)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
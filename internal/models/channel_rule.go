@@ -0,0 +1,93 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/seuros/kaunta/internal/channels"
+)
+
+// ChannelRule is a per-website referrer rule, checked before the built-in rules
+type ChannelRule struct {
+	RuleID    uuid.UUID `json:"rule_id"`
+	WebsiteID uuid.UUID `json:"website_id"`
+	Pattern   string    `json:"pattern"`
+	Source    string    `json:"source"`
+	Channel   string    `json:"channel"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Rule returns the rule in the classifier's form
+func (r *ChannelRule) Rule() channels.Rule {
+	return channels.Rule{Pattern: r.Pattern, Source: r.Source, Channel: r.Channel}
+}
+
+// UpsertChannelRule stores a rule, replacing the website's rule for the same pattern
+func UpsertChannelRule(ctx context.Context, db *sql.DB, websiteID uuid.UUID, rule channels.Rule) (*ChannelRule, error) {
+	rule.Pattern = strings.ToLower(strings.TrimSpace(rule.Pattern))
+	rule.Source = strings.TrimSpace(rule.Source)
+	if err := channels.ValidateRule(rule); err != nil {
+		return nil, err
+	}
+
+	r := &ChannelRule{
+		WebsiteID: websiteID,
+		Pattern:   rule.Pattern,
+		Source:    rule.Source,
+		Channel:   rule.Channel,
+	}
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO channel_rules (website_id, pattern, source, channel)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (website_id, pattern) DO UPDATE
+			SET source = EXCLUDED.source, channel = EXCLUDED.channel
+		RETURNING rule_id, created_at
+	`, websiteID, r.Pattern, r.Source, r.Channel).Scan(&r.RuleID, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ListChannelRules returns the website's custom rules in creation order
+func ListChannelRules(ctx context.Context, db *sql.DB, websiteID uuid.UUID) ([]*ChannelRule, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT rule_id, website_id, pattern, source, channel, created_at
+		FROM channel_rules
+		WHERE website_id = $1
+		ORDER BY created_at
+	`, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var rules []*ChannelRule
+	for rows.Next() {
+		var r ChannelRule
+		if err := rows.Scan(&r.RuleID, &r.WebsiteID, &r.Pattern, &r.Source, &r.Channel, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, &r)
+	}
+	return rules, rows.Err()
+}
+
+// DeleteChannelRule removes the website's rule for pattern
+func DeleteChannelRule(ctx context.Context, db *sql.DB, websiteID uuid.UUID, pattern string) error {
+	result, err := db.ExecContext(ctx,
+		`DELETE FROM channel_rules WHERE website_id = $1 AND pattern = $2`,
+		websiteID, strings.ToLower(strings.TrimSpace(pattern)))
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/channels"
+)
+
+func TestUpsertChannelRuleNormalizesPattern(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := uuid.New()
+	ruleID := uuid.New()
+	mock.ExpectQuery("INSERT INTO channel_rules").
+		WithArgs(websiteID, "news.example.org", "Example News", channels.Referral).
+		WillReturnRows(sqlmock.NewRows([]string{"rule_id", "created_at"}).AddRow(ruleID, time.Now()))
+
+	rule, err := UpsertChannelRule(context.Background(), db, websiteID, channels.Rule{
+		Pattern: " News.Example.org ",
+		Source:  "Example News",
+		Channel: channels.Referral,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ruleID, rule.RuleID)
+	assert.Equal(t, "news.example.org", rule.Pattern)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpsertChannelRuleRejectsInvalid(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	_, err = UpsertChannelRule(context.Background(), db, uuid.New(), channels.Rule{
+		Pattern: "example.com",
+		Source:  "Example",
+		Channel: "Television",
+	})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteChannelRuleNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("DELETE FROM channel_rules").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = DeleteChannelRule(context.Background(), db, uuid.New(), "example.com")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
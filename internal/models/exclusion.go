@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/seuros/kaunta/internal/exclusions"
+)
+
+// TrafficExclusion is a stored internal traffic rule
+type TrafficExclusion struct {
+	ExclusionID uuid.UUID `json:"exclusion_id"`
+	WebsiteID   uuid.UUID `json:"website_id"`
+	Kind        string    `json:"kind"`
+	Value       string    `json:"value"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Rule returns the exclusion in the matcher's form
+func (e *TrafficExclusion) Rule() exclusions.Rule {
+	return exclusions.Rule{Kind: e.Kind, Value: e.Value}
+}
+
+// ListTrafficExclusions returns the website's exclusion rules
+func ListTrafficExclusions(ctx context.Context, db *sql.DB, websiteID uuid.UUID) ([]*TrafficExclusion, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT exclusion_id, website_id, kind, value, created_at
+		FROM traffic_exclusions
+		WHERE website_id = $1
+		ORDER BY kind, created_at
+	`, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []*TrafficExclusion
+	for rows.Next() {
+		var e TrafficExclusion
+		if err := rows.Scan(&e.ExclusionID, &e.WebsiteID, &e.Kind, &e.Value, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &e)
+	}
+	return list, rows.Err()
+}
+
+// AddTrafficExclusion stores a rule in normalized form; adding an existing
+// rule returns the stored one
+func AddTrafficExclusion(ctx context.Context, db *sql.DB, websiteID uuid.UUID, rule exclusions.Rule) (*TrafficExclusion, error) {
+	rule, err := exclusions.Normalize(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &TrafficExclusion{WebsiteID: websiteID, Kind: rule.Kind, Value: rule.Value}
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO traffic_exclusions (website_id, kind, value)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (website_id, kind, value) DO UPDATE SET value = EXCLUDED.value
+		RETURNING exclusion_id, created_at
+	`, websiteID, e.Kind, e.Value).Scan(&e.ExclusionID, &e.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// DeleteTrafficExclusion removes the website's rule of the given kind and value
+func DeleteTrafficExclusion(ctx context.Context, db *sql.DB, websiteID uuid.UUID, rule exclusions.Rule) error {
+	rule, err := exclusions.Normalize(rule)
+	if err != nil {
+		return err
+	}
+	result, err := db.ExecContext(ctx,
+		`DELETE FROM traffic_exclusions WHERE website_id = $1 AND kind = $2 AND value = $3`,
+		websiteID, rule.Kind, rule.Value)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// LoadExclusionMatcher compiles the website's exclusion rules
+func LoadExclusionMatcher(ctx context.Context, db *sql.DB, websiteID uuid.UUID) (*exclusions.Matcher, error) {
+	stored, err := ListTrafficExclusions(ctx, db, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]exclusions.Rule, len(stored))
+	for i, e := range stored {
+		rules[i] = e.Rule()
+	}
+	return exclusions.Compile(rules)
+}
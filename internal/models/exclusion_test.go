@@ -0,0 +1,53 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/exclusions"
+)
+
+func TestLoadExclusionMatcher(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := uuid.New()
+	mock.ExpectQuery("FROM traffic_exclusions").WithArgs(websiteID).
+		WillReturnRows(sqlmock.NewRows([]string{"exclusion_id", "website_id", "kind", "value", "created_at"}).
+			AddRow(uuid.New(), websiteID, "ip", "10.0.0.0/8", time.Now()).
+			AddRow(uuid.New(), websiteID, "user_agent", "UptimeRobot", time.Now()))
+
+	matcher, err := LoadExclusionMatcher(context.Background(), db, websiteID)
+	require.NoError(t, err)
+
+	reason, ok := matcher.Match("10.1.1.1", "")
+	assert.True(t, ok)
+	assert.Equal(t, exclusions.ReasonIP, reason)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddTrafficExclusionNormalizes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := uuid.New()
+	mock.ExpectQuery("INSERT INTO traffic_exclusions").
+		WithArgs(websiteID, "ip", "192.0.2.10/32").
+		WillReturnRows(sqlmock.NewRows([]string{"exclusion_id", "created_at"}).AddRow(uuid.New(), time.Now()))
+
+	e, err := AddTrafficExclusion(context.Background(), db, websiteID, exclusions.Rule{Kind: exclusions.KindIP, Value: "192.0.2.10"})
+	require.NoError(t, err)
+	assert.Equal(t, "192.0.2.10/32", e.Value)
+
+	_, err = AddTrafficExclusion(context.Background(), db, websiteID, exclusions.Rule{Kind: exclusions.KindIP, Value: "office"})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
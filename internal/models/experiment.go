@@ -0,0 +1,201 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrGoalNotFound is returned when an experiment references an unknown goal
+var ErrGoalNotFound = errors.New("goal not found")
+
+// maxTagLength matches website_event.tag VARCHAR(50)
+const maxTagLength = 50
+
+// ExperimentVariant maps a variant name to the event tag the tracker sends (data-tag)
+type ExperimentVariant struct {
+	Name string `json:"name"`
+	Tag  string `json:"tag"`
+}
+
+// Experiment is an A/B test: visitors are assigned to a variant by event tag and
+// converted by completing the goal. The first variant is the control.
+type Experiment struct {
+	ExperimentID uuid.UUID           `json:"experiment_id"`
+	WebsiteID    uuid.UUID           `json:"website_id"`
+	Name         string              `json:"name"`
+	GoalID       uuid.UUID           `json:"goal_id"`
+	GoalName     string              `json:"goal_name"`
+	Variants     []ExperimentVariant `json:"variants"`
+	StartedAt    time.Time           `json:"started_at"`
+	EndedAt      *time.Time          `json:"ended_at,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// ValidateExperimentVariants requires at least two variants with unique names and tags
+func ValidateExperimentVariants(variants []ExperimentVariant) error {
+	if len(variants) < 2 {
+		return errors.New("an experiment needs at least two variants")
+	}
+	names := make(map[string]bool)
+	tags := make(map[string]bool)
+	for _, v := range variants {
+		if strings.TrimSpace(v.Name) == "" || strings.TrimSpace(v.Tag) == "" {
+			return errors.New("variant name and tag are required")
+		}
+		if len(v.Tag) > maxTagLength {
+			return fmt.Errorf("tag %q exceeds %d characters", v.Tag, maxTagLength)
+		}
+		if names[v.Name] {
+			return fmt.Errorf("duplicate variant name %q", v.Name)
+		}
+		if tags[v.Tag] {
+			return fmt.Errorf("tag %q is used by more than one variant", v.Tag)
+		}
+		names[v.Name] = true
+		tags[v.Tag] = true
+	}
+	return nil
+}
+
+// CreateExperiment stores an experiment for the website's goal named goalName
+func CreateExperiment(ctx context.Context, db *sql.DB, websiteID uuid.UUID, name, goalName string, variants []ExperimentVariant) (*Experiment, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("experiment name is required")
+	}
+	if err := ValidateExperimentVariants(variants); err != nil {
+		return nil, err
+	}
+	variantsJSON, err := json.Marshal(variants)
+	if err != nil {
+		return nil, err
+	}
+
+	exp := &Experiment{
+		WebsiteID: websiteID,
+		Name:      name,
+		GoalName:  goalName,
+		Variants:  variants,
+	}
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO experiments (website_id, name, goal_id, variants)
+		SELECT $1, $2, g.id, $4
+		FROM goals g
+		WHERE g.website_id = $1 AND g.name = $3
+		RETURNING experiment_id, goal_id, started_at, created_at
+	`, websiteID, name, goalName, variantsJSON).Scan(&exp.ExperimentID, &exp.GoalID, &exp.StartedAt, &exp.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrGoalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+const experimentColumns = `
+	x.experiment_id, x.website_id, x.name, x.goal_id, g.name,
+	x.variants, x.started_at, x.ended_at, x.created_at`
+
+// ListExperiments returns the website's experiments, newest first
+func ListExperiments(ctx context.Context, db *sql.DB, websiteID uuid.UUID) ([]*Experiment, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+experimentColumns+`
+		FROM experiments x
+		JOIN goals g ON g.id = x.goal_id
+		WHERE x.website_id = $1
+		ORDER BY x.created_at DESC
+	`, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var experiments []*Experiment
+	for rows.Next() {
+		exp, err := scanExperiment(rows)
+		if err != nil {
+			return nil, err
+		}
+		experiments = append(experiments, exp)
+	}
+	return experiments, rows.Err()
+}
+
+// GetExperimentByName returns the named experiment or sql.ErrNoRows
+func GetExperimentByName(ctx context.Context, db *sql.DB, websiteID uuid.UUID, name string) (*Experiment, error) {
+	row := db.QueryRowContext(ctx, `
+		SELECT `+experimentColumns+`
+		FROM experiments x
+		JOIN goals g ON g.id = x.goal_id
+		WHERE x.website_id = $1 AND x.name = $2
+	`, websiteID, name)
+	return scanExperiment(row)
+}
+
+// StopExperiment closes the report window of a running experiment
+func StopExperiment(ctx context.Context, db *sql.DB, websiteID uuid.UUID, name string) error {
+	result, err := db.ExecContext(ctx,
+		`UPDATE experiments SET ended_at = NOW() WHERE website_id = $1 AND name = $2 AND ended_at IS NULL`,
+		websiteID, name)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteExperiment removes an experiment; tagged events and goal completions are kept
+func DeleteExperiment(ctx context.Context, db *sql.DB, websiteID uuid.UUID, name string) error {
+	result, err := db.ExecContext(ctx,
+		`DELETE FROM experiments WHERE website_id = $1 AND name = $2`,
+		websiteID, name)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanExperiment(row rowScanner) (*Experiment, error) {
+	var exp Experiment
+	var variantsJSON []byte
+	var endedAt sql.NullTime
+	err := row.Scan(
+		&exp.ExperimentID,
+		&exp.WebsiteID,
+		&exp.Name,
+		&exp.GoalID,
+		&exp.GoalName,
+		&variantsJSON,
+		&exp.StartedAt,
+		&endedAt,
+		&exp.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(variantsJSON, &exp.Variants); err != nil {
+		return nil, fmt.Errorf("invalid variants for experiment %q: %w", exp.Name, err)
+	}
+	if endedAt.Valid {
+		exp.EndedAt = &endedAt.Time
+	}
+	return &exp, nil
+}
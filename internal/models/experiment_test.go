@@ -0,0 +1,69 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateExperimentVariants(t *testing.T) {
+	valid := []ExperimentVariant{{Name: "control", Tag: "a"}, {Name: "new", Tag: "b"}}
+	assert.NoError(t, ValidateExperimentVariants(valid))
+
+	tests := map[string][]ExperimentVariant{
+		"single variant": {{Name: "control", Tag: "a"}},
+		"empty tag":      {{Name: "control", Tag: "a"}, {Name: "new", Tag: " "}},
+		"duplicate name": {{Name: "x", Tag: "a"}, {Name: "x", Tag: "b"}},
+		"duplicate tag":  {{Name: "x", Tag: "a"}, {Name: "y", Tag: "a"}},
+		"tag too long":   {{Name: "x", Tag: "a"}, {Name: "y", Tag: string(make([]byte, 51))}},
+	}
+	for name, variants := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, ValidateExperimentVariants(variants))
+		})
+	}
+}
+
+func TestCreateExperimentUnknownGoal(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("INSERT INTO experiments").WillReturnError(sql.ErrNoRows)
+
+	_, err = CreateExperiment(context.Background(), db, uuid.New(), "pricing", "Signup",
+		[]ExperimentVariant{{Name: "control", Tag: "a"}, {Name: "new", Tag: "b"}})
+	assert.ErrorIs(t, err, ErrGoalNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetExperimentByName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := uuid.New()
+	started := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM experiments x").
+		WithArgs(websiteID, "pricing").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"experiment_id", "website_id", "name", "goal_id", "goal_name",
+			"variants", "started_at", "ended_at", "created_at",
+		}).AddRow(
+			uuid.New(), websiteID, "pricing", uuid.New(), "Signup",
+			[]byte(`[{"name":"control","tag":"a"},{"name":"new","tag":"b"}]`), started, nil, started,
+		))
+
+	exp, err := GetExperimentByName(context.Background(), db, websiteID, "pricing")
+	require.NoError(t, err)
+	assert.Equal(t, "Signup", exp.GoalName)
+	assert.Equal(t, []ExperimentVariant{{Name: "control", Tag: "a"}, {Name: "new", Tag: "b"}}, exp.Variants)
+	assert.Nil(t, exp.EndedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Datacenter policies of a website for hosting provider traffic
+const (
+	DatacenterFlag    = "flag"    // record and mark the session
+	DatacenterExclude = "exclude" // drop and log as a datacenter bot hit
+	DatacenterKeep    = "keep"    // record as regular traffic
+)
+
+// DatacenterPolicies lists the valid website.datacenter_policy values
+var DatacenterPolicies = []string{DatacenterFlag, DatacenterExclude, DatacenterKeep}
+
+// HostingASN is an autonomous system of a hosting or cloud provider
+type HostingASN struct {
+	ASN       int64     `json:"asn"`
+	Name      string    `json:"name"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ParseASN parses an AS number, with or without the "AS" prefix
+func ParseASN(value string) (int64, error) {
+	trimmed := strings.TrimSpace(value)
+	if len(trimmed) > 2 && strings.EqualFold(trimmed[:2], "AS") {
+		trimmed = trimmed[2:]
+	}
+	asn, err := strconv.ParseInt(trimmed, 10, 64)
+	if err != nil || asn <= 0 || asn > 4294967295 {
+		return 0, fmt.Errorf("invalid ASN %q", value)
+	}
+	return asn, nil
+}
+
+// ParseDatacenterPolicy validates a datacenter policy
+func ParseDatacenterPolicy(policy string) (string, error) {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	for _, p := range DatacenterPolicies {
+		if p == policy {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("invalid datacenter policy %q (valid: %s)", policy, strings.Join(DatacenterPolicies, ", "))
+}
+
+// ListHostingASNs returns the hosting provider ASNs
+func ListHostingASNs(ctx context.Context, db *sql.DB) ([]*HostingASN, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT asn, name, source, created_at
+		FROM hosting_asns
+		ORDER BY name, asn
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []*HostingASN
+	for rows.Next() {
+		var h HostingASN
+		if err := rows.Scan(&h.ASN, &h.Name, &h.Source, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &h)
+	}
+	return list, rows.Err()
+}
+
+// AddHostingASN marks an ASN as a hosting provider, renaming an existing entry
+func AddHostingASN(ctx context.Context, db *sql.DB, value, name string) (*HostingASN, error) {
+	asn, err := ParseASN(value)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = fmt.Sprintf("AS%d", asn)
+	}
+
+	h := &HostingASN{ASN: asn, Name: name}
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO hosting_asns (asn, name, source)
+		VALUES ($1, $2, 'cli')
+		ON CONFLICT (asn) DO UPDATE SET name = EXCLUDED.name
+		RETURNING source, created_at
+	`, asn, name).Scan(&h.Source, &h.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// RemoveHostingASN unmarks an ASN
+func RemoveHostingASN(ctx context.Context, db *sql.DB, value string) (int64, error) {
+	asn, err := ParseASN(value)
+	if err != nil {
+		return 0, err
+	}
+	result, err := db.ExecContext(ctx, `DELETE FROM hosting_asns WHERE asn = $1`, asn)
+	if err != nil {
+		return asn, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return asn, sql.ErrNoRows
+	}
+	return asn, nil
+}
+
+// SetDatacenterPolicy sets how a website handles hosting provider traffic
+func SetDatacenterPolicy(ctx context.Context, db *sql.DB, websiteID uuid.UUID, policy string) error {
+	policy, err := ParseDatacenterPolicy(policy)
+	if err != nil {
+		return err
+	}
+	result, err := db.ExecContext(ctx, `
+		UPDATE website SET datacenter_policy = $1, updated_at = NOW()
+		WHERE website_id = $2 AND deleted_at IS NULL
+	`, policy, websiteID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
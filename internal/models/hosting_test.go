@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseASN(t *testing.T) {
+	for input, want := range map[string]int64{"16509": 16509, "AS24940": 24940, " as396982 ": 396982} {
+		asn, err := ParseASN(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, asn, input)
+	}
+	for _, input := range []string{"", "AS", "ASX", "-1", "0", "4294967296"} {
+		_, err := ParseASN(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestParseDatacenterPolicy(t *testing.T) {
+	policy, err := ParseDatacenterPolicy(" Exclude ")
+	require.NoError(t, err)
+	assert.Equal(t, DatacenterExclude, policy)
+
+	_, err = ParseDatacenterPolicy("block")
+	assert.Error(t, err)
+}
+
+func TestAddHostingASN(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("INSERT INTO hosting_asns").WithArgs(int64(64500), "AS64500").
+		WillReturnRows(sqlmock.NewRows([]string{"source", "created_at"}).AddRow("cli", time.Now()))
+
+	h, err := AddHostingASN(context.Background(), db, "AS64500", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(64500), h.ASN)
+	assert.Equal(t, "AS64500", h.Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemoveHostingASNNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectExec("DELETE FROM hosting_asns").WithArgs(int64(64500)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	asn, err := RemoveHostingASN(context.Background(), db, "64500")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Equal(t, int64(64500), asn)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetDatacenterPolicy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := uuid.New()
+	mock.ExpectExec("UPDATE website SET datacenter_policy").WithArgs("keep", websiteID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, SetDatacenterPolicy(context.Background(), db, websiteID, "KEEP"))
+	assert.Error(t, SetDatacenterPolicy(context.Background(), db, websiteID, "drop"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
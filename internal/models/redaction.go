@@ -0,0 +1,94 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/seuros/kaunta/internal/redact"
+)
+
+// RedactionCount is the number of values redacted for a website on a day
+type RedactionCount struct {
+	Day   time.Time `json:"day"`
+	Kind  string    `json:"kind"`
+	Count int64     `json:"count"`
+}
+
+// GetRedactionConfig returns the website's redaction settings
+func GetRedactionConfig(ctx context.Context, db *sql.DB, websiteID uuid.UUID) (redact.Config, error) {
+	var cfg redact.Config
+	err := db.QueryRowContext(ctx, `
+		SELECT pii_redaction, COALESCE(pii_params, '{}')
+		FROM website
+		WHERE website_id = $1
+	`, websiteID).Scan(&cfg.Mode, pq.Array(&cfg.Params))
+	return cfg, err
+}
+
+// UpdateRedactionConfig stores the website's redaction settings
+func UpdateRedactionConfig(ctx context.Context, db *sql.DB, websiteID uuid.UUID, cfg redact.Config) error {
+	if err := redact.ValidateMode(cfg.Mode); err != nil {
+		return err
+	}
+	result, err := db.ExecContext(ctx, `
+		UPDATE website
+		SET pii_redaction = $2, pii_params = $3, updated_at = NOW()
+		WHERE website_id = $1
+	`, websiteID, cfg.Mode, nullableArray(cfg.Params))
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordRedactions adds counts to the website's counters for day
+func RecordRedactions(ctx context.Context, db *sql.DB, websiteID uuid.UUID, day time.Time, counts redact.Counts) error {
+	for kind, n := range counts {
+		if n == 0 {
+			continue
+		}
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO pii_redactions (website_id, day, kind, count)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (website_id, day, kind) DO UPDATE
+				SET count = pii_redactions.count + EXCLUDED.count
+		`, websiteID, day.UTC().Format("2006-01-02"), kind, n)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRedactionCounts returns the website's counters for the last days, newest first
+func ListRedactionCounts(ctx context.Context, db *sql.DB, websiteID uuid.UUID, days int) ([]RedactionCount, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT day, kind, count
+		FROM pii_redactions
+		WHERE website_id = $1
+		  AND day > CURRENT_DATE - $2::int
+		ORDER BY day DESC, kind
+	`, websiteID, days)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var counts []RedactionCount
+	for rows.Next() {
+		var c RedactionCount
+		if err := rows.Scan(&c.Day, &c.Kind, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
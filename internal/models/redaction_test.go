@@ -0,0 +1,40 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/redact"
+)
+
+func TestRecordRedactions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := uuid.New()
+	day := time.Date(2026, 3, 2, 23, 30, 0, 0, time.UTC)
+	mock.ExpectExec("INSERT INTO pii_redactions").
+		WithArgs(websiteID, "2026-03-02", "email", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = RecordRedactions(context.Background(), db, websiteID, day, redact.Counts{"email": 2, "jwt": 0})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateRedactionConfigRejectsInvalidMode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	err = UpdateRedactionConfig(context.Background(), db, uuid.New(), redact.Config{Mode: "scramble"})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
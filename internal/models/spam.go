@@ -0,0 +1,237 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/seuros/kaunta/internal/spam"
+)
+
+// Spam list sources managed outside of imports
+const (
+	SpamSourceBuiltin = "builtin"
+	SpamSourceCLI     = "cli"
+)
+
+// SpamReferrer is a referrer spam domain
+type SpamReferrer struct {
+	Domain    string    `json:"domain"`
+	Source    string    `json:"source"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SpamImport counts the outcome of ImportSpamReferrers
+type SpamImport struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Skipped int `json:"skipped"` // already listed
+}
+
+// SpamHitCount is the number of hits dropped for a spam domain on a day
+type SpamHitCount struct {
+	Day    time.Time `json:"day"`
+	Domain string    `json:"domain"`
+	Hits   int64     `json:"hits"`
+}
+
+// SeedSpamReferrers inserts the built-in domains that aren't stored yet and
+// returns how many were added. Disabled built-ins stay disabled.
+func SeedSpamReferrers(ctx context.Context, db *sql.DB, domains []string) (int64, error) {
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO spam_referrers (domain, source)
+		SELECT d, $2 FROM unnest($1::text[]) AS d
+		ON CONFLICT (domain) DO NOTHING
+	`, pq.Array(domains), SpamSourceBuiltin)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListSpamReferrers returns the spam domains, optionally of one source and
+// including disabled built-ins
+func ListSpamReferrers(ctx context.Context, db *sql.DB, source string, includeDisabled bool) ([]*SpamReferrer, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT domain, source, enabled, created_at
+		FROM spam_referrers
+		WHERE ($1 = '' OR source = $1)
+		  AND (enabled OR $2)
+		ORDER BY domain
+	`, source, includeDisabled)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var list []*SpamReferrer
+	for rows.Next() {
+		var s SpamReferrer
+		if err := rows.Scan(&s.Domain, &s.Source, &s.Enabled, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &s)
+	}
+	return list, rows.Err()
+}
+
+// LoadSpamList compiles the enabled spam domains
+func LoadSpamList(ctx context.Context, db *sql.DB) (*spam.List, error) {
+	rows, err := db.QueryContext(ctx, `SELECT domain FROM spam_referrers WHERE enabled`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var domains []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			return nil, err
+		}
+		domains = append(domains, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return spam.NewList(domains), nil
+}
+
+// AddSpamReferrer lists a domain; adding a disabled built-in re-enables it
+func AddSpamReferrer(ctx context.Context, db *sql.DB, value string) (*SpamReferrer, error) {
+	domain, err := spam.NormalizeDomain(value)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SpamReferrer{Domain: domain}
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO spam_referrers (domain, source)
+		VALUES ($1, $2)
+		ON CONFLICT (domain) DO UPDATE SET enabled = true
+		RETURNING source, enabled, created_at
+	`, domain, SpamSourceCLI).Scan(&s.Source, &s.Enabled, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// RemoveSpamReferrer unlists a domain. Built-in domains are disabled so the
+// startup seed doesn't add them back; others are deleted.
+func RemoveSpamReferrer(ctx context.Context, db *sql.DB, value string) (string, error) {
+	domain, err := spam.NormalizeDomain(value)
+	if err != nil {
+		return "", err
+	}
+
+	var removed bool
+	err = db.QueryRowContext(ctx, `
+		WITH disabled AS (
+			UPDATE spam_referrers SET enabled = false
+			WHERE domain = $1 AND source = $2 AND enabled
+			RETURNING domain
+		), deleted AS (
+			DELETE FROM spam_referrers
+			WHERE domain = $1 AND source <> $2
+			RETURNING domain
+		)
+		SELECT EXISTS (SELECT 1 FROM disabled) OR EXISTS (SELECT 1 FROM deleted)
+	`, domain, SpamSourceBuiltin).Scan(&removed)
+	if err != nil {
+		return domain, err
+	}
+	if !removed {
+		return domain, sql.ErrNoRows
+	}
+	return domain, nil
+}
+
+// ImportSpamReferrers lists domains under source. With replace, domains
+// previously imported from the same source are removed first. Domains that
+// are already listed keep their source.
+func ImportSpamReferrers(ctx context.Context, db *sql.DB, source string, domains []string, replace bool) (*SpamImport, error) {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+	if source == SpamSourceBuiltin || source == SpamSourceCLI {
+		return nil, fmt.Errorf("source %q is reserved", source)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result := &SpamImport{}
+	if replace {
+		res, err := tx.ExecContext(ctx, `DELETE FROM spam_referrers WHERE source = $1`, source)
+		if err != nil {
+			return nil, err
+		}
+		removed, _ := res.RowsAffected()
+		result.Removed = int(removed)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO spam_referrers (domain, source)
+		SELECT d, $2 FROM unnest($1::text[]) AS d
+		ON CONFLICT (domain) DO NOTHING
+	`, pq.Array(domains), source)
+	if err != nil {
+		return nil, err
+	}
+	added, _ := res.RowsAffected()
+	result.Added = int(added)
+	result.Skipped = len(domains) - result.Added
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RecordSpamHit counts a hit dropped for the website because of domain
+func RecordSpamHit(ctx context.Context, db *sql.DB, websiteID uuid.UUID, day time.Time, domain string) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO spam_referrer_hits (website_id, day, domain, hits)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (website_id, day, domain) DO UPDATE
+			SET hits = spam_referrer_hits.hits + 1
+	`, websiteID, day.UTC().Format("2006-01-02"), domain)
+	return err
+}
+
+// ListSpamHits returns the website's dropped spam hits for the last days,
+// newest first
+func ListSpamHits(ctx context.Context, db *sql.DB, websiteID uuid.UUID, days int) ([]SpamHitCount, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT day, domain, hits
+		FROM spam_referrer_hits
+		WHERE website_id = $1
+		  AND day > CURRENT_DATE - $2::int
+		ORDER BY day DESC, hits DESC, domain
+	`, websiteID, days)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var counts []SpamHitCount
+	for rows.Next() {
+		var c SpamHitCount
+		if err := rows.Scan(&c.Day, &c.Domain, &c.Hits); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddSpamReferrerNormalizes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("INSERT INTO spam_referrers").
+		WithArgs("spam-site.example", SpamSourceCLI).
+		WillReturnRows(sqlmock.NewRows([]string{"source", "enabled", "created_at"}).AddRow(SpamSourceCLI, true, time.Now()))
+
+	s, err := AddSpamReferrer(context.Background(), db, "https://www.Spam-Site.example/landing")
+	require.NoError(t, err)
+	assert.Equal(t, "spam-site.example", s.Domain)
+
+	_, err = AddSpamReferrer(context.Background(), db, "localhost")
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemoveSpamReferrerNotListed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery("UPDATE spam_referrers SET enabled = false").
+		WithArgs("semalt.com", SpamSourceBuiltin).
+		WillReturnRows(sqlmock.NewRows([]string{"removed"}).AddRow(false))
+
+	domain, err := RemoveSpamReferrer(context.Background(), db, "www.semalt.com")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.Equal(t, "semalt.com", domain)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportSpamReferrers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM spam_referrers WHERE source").WithArgs("matomo").
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectExec("INSERT INTO spam_referrers").
+		WithArgs(sqlmock.AnyArg(), "matomo").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	result, err := ImportSpamReferrers(context.Background(), db, "matomo", []string{"a.example", "b.example", "semalt.com"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, &SpamImport{Added: 2, Removed: 5, Skipped: 1}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = ImportSpamReferrers(context.Background(), db, SpamSourceBuiltin, []string{"a.example"}, true)
+	assert.Error(t, err)
+}
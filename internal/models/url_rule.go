@@ -0,0 +1,150 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/seuros/kaunta/internal/urlrules"
+)
+
+// URLRule is a stored rewrite or content group rule
+type URLRule struct {
+	RuleID    uuid.UUID `json:"rule_id"`
+	WebsiteID uuid.UUID `json:"website_id"`
+	Kind      string    `json:"kind"`
+	Pattern   string    `json:"pattern"`
+	Value     string    `json:"value"`
+	Position  int       `json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Rule returns the rule in the normalizer's form
+func (r *URLRule) Rule() urlrules.Rule {
+	return urlrules.Rule{Kind: r.Kind, Pattern: r.Pattern, Value: r.Value}
+}
+
+// GetURLSettings returns the website's normalization settings
+func GetURLSettings(ctx context.Context, db *sql.DB, websiteID uuid.UUID) (urlrules.Settings, error) {
+	var s urlrules.Settings
+	err := db.QueryRowContext(ctx, `
+		SELECT url_lowercase, url_trailing_slash,
+		       COALESCE(url_query_allow, '{}'), COALESCE(url_query_deny, '{}')
+		FROM website
+		WHERE website_id = $1
+	`, websiteID).Scan(&s.Lowercase, &s.TrailingSlash, pq.Array(&s.QueryAllow), pq.Array(&s.QueryDeny))
+	return s, err
+}
+
+// UpdateURLSettings stores the website's normalization settings
+func UpdateURLSettings(ctx context.Context, db *sql.DB, websiteID uuid.UUID, s urlrules.Settings) error {
+	if s.TrailingSlash == "" {
+		s.TrailingSlash = urlrules.TrailingSlashKeep
+	}
+	if err := urlrules.ValidateSettings(s); err != nil {
+		return err
+	}
+	result, err := db.ExecContext(ctx, `
+		UPDATE website
+		SET url_lowercase = $2, url_trailing_slash = $3,
+		    url_query_allow = $4, url_query_deny = $5, updated_at = NOW()
+		WHERE website_id = $1
+	`, websiteID, s.Lowercase, s.TrailingSlash, nullableArray(s.QueryAllow), nullableArray(s.QueryDeny))
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListURLRules returns the website's rules in the order they are applied
+func ListURLRules(ctx context.Context, db *sql.DB, websiteID uuid.UUID) ([]*URLRule, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT rule_id, website_id, kind, pattern, value, position, created_at
+		FROM url_rules
+		WHERE website_id = $1
+		ORDER BY position, created_at
+	`, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var rules []*URLRule
+	for rows.Next() {
+		var r URLRule
+		if err := rows.Scan(&r.RuleID, &r.WebsiteID, &r.Kind, &r.Pattern, &r.Value, &r.Position, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, &r)
+	}
+	return rules, rows.Err()
+}
+
+// AddURLRule appends a rule after the website's existing rules, replacing the
+// value of an existing rule with the same kind and pattern
+func AddURLRule(ctx context.Context, db *sql.DB, websiteID uuid.UUID, rule urlrules.Rule) (*URLRule, error) {
+	rule.Value = strings.TrimSpace(rule.Value)
+	if err := urlrules.ValidateRule(rule); err != nil {
+		return nil, err
+	}
+
+	r := &URLRule{WebsiteID: websiteID, Kind: rule.Kind, Pattern: rule.Pattern, Value: rule.Value}
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO url_rules (website_id, kind, pattern, value, position)
+		VALUES ($1, $2, $3, $4,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM url_rules WHERE website_id = $1))
+		ON CONFLICT (website_id, kind, pattern) DO UPDATE SET value = EXCLUDED.value
+		RETURNING rule_id, position, created_at
+	`, websiteID, r.Kind, r.Pattern, r.Value).Scan(&r.RuleID, &r.Position, &r.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DeleteURLRule removes the website's rule of the given kind and pattern
+func DeleteURLRule(ctx context.Context, db *sql.DB, websiteID uuid.UUID, kind, pattern string) error {
+	result, err := db.ExecContext(ctx,
+		`DELETE FROM url_rules WHERE website_id = $1 AND kind = $2 AND pattern = $3`,
+		websiteID, kind, pattern)
+	if err != nil {
+		return err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// LoadURLRuleset compiles the website's settings and rules
+func LoadURLRuleset(ctx context.Context, db *sql.DB, websiteID uuid.UUID) (*urlrules.Ruleset, error) {
+	settings, err := GetURLSettings(ctx, db, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := ListURLRules(ctx, db, websiteID)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]urlrules.Rule, len(stored))
+	for i, r := range stored {
+		rules[i] = r.Rule()
+	}
+	return urlrules.Compile(settings, rules)
+}
+
+func nullableArray(values []string) any {
+	if len(values) == 0 {
+		return nil
+	}
+	return pq.Array(values)
+}
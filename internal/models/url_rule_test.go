@@ -0,0 +1,46 @@
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/seuros/kaunta/internal/urlrules"
+)
+
+func TestLoadURLRuleset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	websiteID := uuid.New()
+	mock.ExpectQuery("SELECT url_lowercase").WithArgs(websiteID).
+		WillReturnRows(sqlmock.NewRows([]string{"url_lowercase", "url_trailing_slash", "url_query_allow", "url_query_deny"}).
+			AddRow(true, "strip", "{}", "{token}"))
+	mock.ExpectQuery("FROM url_rules").WithArgs(websiteID).
+		WillReturnRows(sqlmock.NewRows([]string{"rule_id", "website_id", "kind", "pattern", "value", "position", "created_at"}).
+			AddRow(uuid.New(), websiteID, "rewrite", `^/users/\d+$`, "/users/:id", 1, time.Now()).
+			AddRow(uuid.New(), websiteID, "group", `^/users/`, "Account", 2, time.Now()))
+
+	ruleset, err := LoadURLRuleset(context.Background(), db, websiteID)
+	require.NoError(t, err)
+
+	result := ruleset.Apply("/Users/42/", "token=x&a=1")
+	assert.Equal(t, urlrules.Result{Path: "/users/:id", Query: "a=1", ContentGroup: "Account", Rewritten: true}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddURLRuleRejectsInvalid(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	_, err = AddURLRule(context.Background(), db, uuid.New(), urlrules.Rule{Kind: urlrules.KindGroup, Pattern: "^/docs", Value: ""})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,259 @@
+// Package redact removes personal data from tracked events before they are
+// stored: email addresses, JWTs, credit-card-like numbers and sensitive query
+// parameters are masked or dropped.
+package redact
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Modes
+const (
+	ModeOff  = "off"  // store values verbatim
+	ModeMask = "mask" // replace matches with a placeholder (default)
+	ModeDrop = "drop" // remove matching parameters and text
+)
+
+// Kinds of redacted data, used as counter keys
+const (
+	KindEmail = "email"
+	KindJWT   = "jwt"
+	KindCard  = "card"
+	KindParam = "param"
+)
+
+// Kinds lists the counter keys in display order
+var Kinds = []string{KindEmail, KindJWT, KindCard, KindParam}
+
+// DefaultParams are query parameter and property names always treated as sensitive
+var DefaultParams = []string{
+	"email", "e-mail",
+	"password", "passwd", "pwd",
+	"token", "access_token", "refresh_token", "id_token", "auth", "authorization",
+	"api_key", "apikey", "secret", "client_secret",
+	"ssn", "phone", "card", "cvv",
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	jwtPattern   = regexp.MustCompile(`eyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`)
+	cardPattern  = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// Placeholders written in mask mode
+var placeholders = map[string]string{
+	KindEmail: "[email]",
+	KindJWT:   "[jwt]",
+	KindCard:  "[card]",
+	KindParam: "[redacted]",
+}
+
+// Config is a website's redaction settings
+type Config struct {
+	Mode   string   `json:"mode"`
+	Params []string `json:"params,omitempty"` // extra sensitive parameter names
+}
+
+// Counts tallies redactions per kind
+type Counts map[string]int
+
+// Total returns the number of redactions
+func (c Counts) Total() int {
+	total := 0
+	for _, n := range c {
+		total += n
+	}
+	return total
+}
+
+// Redactor applies a Config. A nil Redactor (mode off) returns values unchanged.
+type Redactor struct {
+	drop   bool
+	params map[string]bool
+}
+
+// ValidateMode checks a redaction mode
+func ValidateMode(mode string) error {
+	switch mode {
+	case ModeOff, ModeMask, ModeDrop:
+		return nil
+	}
+	return fmt.Errorf("invalid redaction mode %q (valid: off, mask, drop)", mode)
+}
+
+// New returns a Redactor for cfg, or nil when redaction is off
+func New(cfg Config) *Redactor {
+	if cfg.Mode == ModeOff {
+		return nil
+	}
+	r := &Redactor{drop: cfg.Mode == ModeDrop, params: make(map[string]bool)}
+	for _, p := range append(append([]string{}, DefaultParams...), cfg.Params...) {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			r.params[p] = true
+		}
+	}
+	return r
+}
+
+// Text masks (or removes) emails, JWTs and card numbers in free text
+func (r *Redactor) Text(s string, counts Counts) string {
+	if r == nil || s == "" {
+		return s
+	}
+	s = r.replace(s, jwtPattern, KindJWT, counts, nil)
+	s = r.replace(s, emailPattern, KindEmail, counts, nil)
+	s = r.replace(s, cardPattern, KindCard, counts, luhnValid)
+	return s
+}
+
+// Query redacts a raw query string, keeping parameter order and encoding of
+// untouched parameters. Sensitive parameter names are masked or dropped;
+// other values are scanned like Text (in drop mode the whole parameter goes).
+func (r *Redactor) Query(raw string, counts Counts) string {
+	if r == nil || raw == "" {
+		return raw
+	}
+	parts := strings.Split(raw, "&")
+	kept := parts[:0]
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		rawKey, rawValue, hasValue := strings.Cut(part, "=")
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		value, err := url.QueryUnescape(rawValue)
+		if err != nil {
+			value = rawValue
+		}
+
+		if r.params[strings.ToLower(key)] && value != "" {
+			counts[KindParam]++
+			if r.drop {
+				continue
+			}
+			kept = append(kept, rawKey+"="+url.QueryEscape(placeholders[KindParam]))
+			continue
+		}
+
+		if !hasValue {
+			// Bare values (?jane@example.com) are scanned like parameter values
+			value = key
+		}
+		before := counts.Total()
+		redacted := r.Text(value, counts)
+		if counts.Total() == before {
+			kept = append(kept, part)
+			continue
+		}
+		if r.drop {
+			continue
+		}
+		if hasValue {
+			kept = append(kept, rawKey+"="+url.QueryEscape(redacted))
+		} else {
+			kept = append(kept, url.QueryEscape(redacted))
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// URL redacts a page or referrer URL: the path and fragment are scanned like
+// Text and the query string like Query. Unchanged URLs keep their encoding.
+func (r *Redactor) URL(raw string, counts Counts) string {
+	if r == nil || raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return r.Text(raw, counts)
+	}
+
+	before := counts.Total()
+	path := r.Text(u.Path, counts)
+	if counts.Total() != before {
+		u.Path = path
+		u.RawPath = ""
+	}
+	u.RawQuery = r.Query(u.RawQuery, counts)
+	u.Fragment = r.Text(u.Fragment, counts)
+	if counts.Total() == before {
+		return raw
+	}
+	return u.String()
+}
+
+// Props redacts event properties in place: sensitive keys are masked or
+// removed and string values (including nested ones) are scanned like Text
+func (r *Redactor) Props(props map[string]any, counts Counts) map[string]any {
+	if r == nil {
+		return props
+	}
+	for key, value := range props {
+		if r.params[strings.ToLower(key)] {
+			counts[KindParam]++
+			if r.drop {
+				delete(props, key)
+			} else {
+				props[key] = placeholders[KindParam]
+			}
+			continue
+		}
+		props[key] = r.value(value, counts)
+	}
+	return props
+}
+
+func (r *Redactor) value(v any, counts Counts) any {
+	switch val := v.(type) {
+	case string:
+		return r.Text(val, counts)
+	case map[string]any:
+		return r.Props(val, counts)
+	case []any:
+		for i := range val {
+			val[i] = r.value(val[i], counts)
+		}
+		return val
+	}
+	return v
+}
+
+func (r *Redactor) replace(s string, re *regexp.Regexp, kind string, counts Counts, valid func(string) bool) string {
+	return re.ReplaceAllStringFunc(s, func(match string) string {
+		if valid != nil && !valid(match) {
+			return match
+		}
+		counts[kind]++
+		if r.drop {
+			return ""
+		}
+		return placeholders[kind]
+	})
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
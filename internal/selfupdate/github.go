@@ -4,6 +4,7 @@ package selfupdate
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"runtime"
@@ -27,6 +28,9 @@ type Release struct {
 	CreatedAt  time.Time `json:"created_at"`
 	Assets     []Asset   `json:"assets"`
 	Version    semver.Version
+
+	// checksums is the signed SHA256SUMS, set by Verify
+	checksums map[string][]byte
 }
 
 // Asset represents a release asset (downloadable file).
@@ -37,29 +41,38 @@ type Asset struct {
 	ContentType        string `json:"content_type"`
 }
 
+// Release channels
+const (
+	ChannelStable     = "stable"
+	ChannelPrerelease = "prerelease"
+)
+
 // Client handles GitHub API requests for release detection.
 type Client struct {
 	httpClient *http.Client
-	owner      string
-	repo       string
+	baseURL    string
 }
 
 // NewClient creates a new GitHub client for the specified repository.
 func NewClient(owner, repo string) *Client {
+	return NewClientWithURL(fmt.Sprintf("%s/repos/%s/%s", githubAPIURL, owner, repo))
+}
+
+// NewClientWithURL creates a client for a GitHub compatible releases API
+// rooted at baseURL (e.g. https://api.github.com/repos/seuros/kaunta, a
+// Gitea/Forgejo mirror or a local test server).
+func NewClientWithURL(baseURL string) *Client {
 	return &Client{
 		httpClient: &http.Client{Timeout: 30 * time.Second},
-		owner:      owner,
-		repo:       repo,
+		baseURL:    strings.TrimRight(baseURL, "/"),
 	}
 }
 
-// DetectLatest fetches the latest non-draft, non-prerelease version from GitHub.
-func (c *Client) DetectLatest() (*Release, error) {
-	url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", githubAPIURL, c.owner, c.repo)
-
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+// get fetches path below the base URL and decodes the JSON response into v.
+func (c *Client) get(path string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/vnd.github+json")
@@ -68,31 +81,114 @@ func (c *Client) DetectLatest() (*Release, error) {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+		return fmt.Errorf("failed to fetch releases: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("no releases found for %s/%s", c.owner, c.repo)
+		return ErrReleaseNotFound
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error: %s", resp.Status)
+		return fmt.Errorf("GitHub API error: %s", resp.Status)
 	}
 
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// ErrReleaseNotFound is returned when the requested release doesn't exist.
+var ErrReleaseNotFound = errors.New("release not found")
+
+// parseVersion sets Version from the release tag.
+func (r *Release) parseVersion() error {
+	version, err := semver.Parse(strings.TrimPrefix(r.TagName, "v"))
+	if err != nil {
+		return fmt.Errorf("invalid version tag %q: %w", r.TagName, err)
+	}
+	r.Version = version
+	return nil
+}
+
+// Detect finds the release to upgrade to: the given version when set,
+// otherwise the newest release on channel.
+func (c *Client) Detect(channel, version string) (*Release, error) {
+	if version != "" {
+		return c.DetectVersion(version)
+	}
+	switch channel {
+	case "", ChannelStable:
+		return c.DetectLatest()
+	case ChannelPrerelease:
+		return c.DetectLatestPrerelease()
+	default:
+		return nil, fmt.Errorf("unknown channel %q (use %s or %s)", channel, ChannelStable, ChannelPrerelease)
+	}
+}
+
+// DetectLatest fetches the latest non-draft, non-prerelease version from GitHub.
+func (c *Client) DetectLatest() (*Release, error) {
 	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.get("/releases/latest", &release); err != nil {
+		if errors.Is(err, ErrReleaseNotFound) {
+			return nil, fmt.Errorf("no releases found at %s", c.baseURL)
+		}
+		return nil, err
+	}
+	if err := release.parseVersion(); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// DetectLatestPrerelease fetches the highest non-draft version, prereleases
+// included.
+func (c *Client) DetectLatestPrerelease() (*Release, error) {
+	var releases []Release
+	if err := c.get("/releases?per_page=50", &releases); err != nil {
+		return nil, err
 	}
 
-	// Parse version from tag
-	versionStr := strings.TrimPrefix(release.TagName, "v")
-	version, err := semver.Parse(versionStr)
+	var latest *Release
+	for i := range releases {
+		r := &releases[i]
+		// Tags that aren't versions can't be compared, skip them
+		if r.Draft || r.parseVersion() != nil {
+			continue
+		}
+		if latest == nil || r.Version.GT(latest.Version) {
+			latest = r
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no releases found at %s", c.baseURL)
+	}
+	return latest, nil
+}
+
+// DetectVersion fetches the release tagged with version (with or without
+// the leading v).
+func (c *Client) DetectVersion(version string) (*Release, error) {
+	v, err := semver.Parse(strings.TrimPrefix(version, "v"))
 	if err != nil {
-		return nil, fmt.Errorf("invalid version tag %q: %w", release.TagName, err)
+		return nil, fmt.Errorf("invalid version %q: %w", version, err)
 	}
-	release.Version = version
 
+	var release Release
+	if err := c.get("/releases/tags/v"+v.String(), &release); err != nil {
+		if errors.Is(err, ErrReleaseNotFound) {
+			return nil, fmt.Errorf("release v%s not found", v)
+		}
+		return nil, err
+	}
+	if release.Draft {
+		return nil, fmt.Errorf("release v%s is a draft", v)
+	}
+	if err := release.parseVersion(); err != nil {
+		return nil, err
+	}
 	return &release, nil
 }
 
@@ -129,3 +225,19 @@ func (r *Release) FindAsset() (*Asset, error) {
 
 	return nil, fmt.Errorf("no asset found for %s/%s (expected %s)", runtime.GOOS, runtime.GOARCH, expectedName)
 }
+
+// FindChecksums finds the SHA256SUMS file and its minisign signature.
+func (r *Release) FindChecksums() (sums, sig *Asset, err error) {
+	for i := range r.Assets {
+		switch r.Assets[i].Name {
+		case ChecksumsAsset:
+			sums = &r.Assets[i]
+		case SignatureAsset:
+			sig = &r.Assets[i]
+		}
+	}
+	if sums == nil || sig == nil {
+		return nil, nil, fmt.Errorf("release %s has no signed checksums (%s, %s)", r.TagName, ChecksumsAsset, SignatureAsset)
+	}
+	return sums, sig, nil
+}
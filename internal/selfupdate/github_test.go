@@ -0,0 +1,81 @@
+package selfupdate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// releaseAPI serves a GitHub compatible releases API for releases
+func releaseAPI(t *testing.T, releases []Release) *Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/seuros/kaunta/releases", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(releases)
+	})
+	mux.HandleFunc("GET /repos/seuros/kaunta/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		for _, rel := range releases {
+			if !rel.Draft && !rel.Prerelease {
+				_ = json.NewEncoder(w).Encode(rel)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("GET /repos/seuros/kaunta/releases/tags/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		for _, rel := range releases {
+			if rel.TagName == r.PathValue("tag") {
+				_ = json.NewEncoder(w).Encode(rel)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return NewClientWithURL(srv.URL + "/repos/seuros/kaunta/")
+}
+
+func TestDetect(t *testing.T) {
+	client := releaseAPI(t, []Release{
+		{TagName: "v2.0.0-rc.1", Prerelease: true},
+		{TagName: "v3.0.0", Draft: true},
+		{TagName: "nightly", Prerelease: true},
+		{TagName: "v1.5.0"},
+		{TagName: "v1.4.2"},
+	})
+
+	cases := []struct {
+		channel, version, want string
+	}{
+		{"", "", "1.5.0"},
+		{ChannelStable, "", "1.5.0"},
+		{ChannelPrerelease, "", "2.0.0-rc.1"},
+		{ChannelPrerelease, "1.4.2", "1.4.2"},
+		{ChannelStable, "v1.4.2", "1.4.2"},
+	}
+	for _, tc := range cases {
+		release, err := client.Detect(tc.channel, tc.version)
+		require.NoError(t, err, "%s %s", tc.channel, tc.version)
+		assert.Equal(t, tc.want, release.Version.String())
+	}
+
+	_, err := client.Detect("nightly", "")
+	assert.ErrorContains(t, err, "unknown channel")
+
+	_, err = client.Detect(ChannelStable, "v9.9.9")
+	assert.ErrorContains(t, err, "release v9.9.9 not found")
+
+	_, err = client.Detect(ChannelStable, "latest")
+	assert.ErrorContains(t, err, "invalid version")
+
+	_, err = client.Detect(ChannelStable, "v3.0.0")
+	assert.ErrorContains(t, err, "draft")
+
+	_, err = releaseAPI(t, nil).Detect(ChannelStable, "")
+	assert.ErrorContains(t, err, "no releases found")
+}
@@ -0,0 +1,54 @@
+package selfupdate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// MigrationAsset is the release copy of internal/database/migration_version.gen.go,
+// published so an upgrade can tell whether the target brings new migrations.
+const MigrationAsset = "migration_version.gen.go"
+
+// ErrNoMigrationVersion is returned for releases that don't publish MigrationAsset.
+var ErrNoMigrationVersion = errors.New("release doesn't publish its migration version")
+
+var migrationVersionRe = regexp.MustCompile(`LatestMigrationVersion\s+uint\s*=\s*(\d+)`)
+
+// MigrationVersion returns the latest database migration shipped with release.
+func (u *Updater) MigrationVersion(release *Release) (uint, error) {
+	var asset *Asset
+	for i := range release.Assets {
+		if release.Assets[i].Name == MigrationAsset {
+			asset = &release.Assets[i]
+			break
+		}
+	}
+	if asset == nil {
+		return 0, ErrNoMigrationVersion
+	}
+
+	data, err := u.download(asset.BrowserDownloadURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download %s: %w", MigrationAsset, err)
+	}
+	return parseMigrationVersion(data)
+}
+
+// MigrationVersion returns the latest migration of release using a default Updater.
+func MigrationVersion(release *Release) (uint, error) {
+	return NewUpdater().MigrationVersion(release)
+}
+
+func parseMigrationVersion(data []byte) (uint, error) {
+	m := migrationVersionRe.FindSubmatch(data)
+	if m == nil {
+		return 0, fmt.Errorf("%s has no LatestMigrationVersion", MigrationAsset)
+	}
+	v, err := strconv.ParseUint(string(m[1]), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid migration version %q: %w", m[1], err)
+	}
+	return uint(v), nil
+}
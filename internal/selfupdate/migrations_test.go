@@ -0,0 +1,29 @@
+package selfupdate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationVersion(t *testing.T) {
+	gen := "// Code generated by go generate; DO NOT EDIT.\n\npackage database\n\nconst LatestMigrationVersion uint = 41\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(gen))
+	}))
+	t.Cleanup(srv.Close)
+
+	u := &Updater{httpClient: http.DefaultClient}
+	version, err := u.MigrationVersion(&Release{Assets: []Asset{{Name: MigrationAsset, BrowserDownloadURL: srv.URL}}})
+	require.NoError(t, err)
+	assert.Equal(t, uint(41), version)
+
+	_, err = u.MigrationVersion(&Release{})
+	assert.ErrorIs(t, err, ErrNoMigrationVersion)
+
+	_, err = parseMigrationVersion([]byte("package database\n"))
+	assert.ErrorContains(t, err, "no LatestMigrationVersion")
+}
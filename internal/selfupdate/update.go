@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,52 +19,124 @@ import (
 // Updater handles downloading and applying updates.
 type Updater struct {
 	httpClient *http.Client
+	publicKey  string
 }
 
-// NewUpdater creates a new Updater instance.
+// NewUpdater creates a new Updater instance verifying against the pinned PublicKey.
 func NewUpdater() *Updater {
 	return &Updater{
 		httpClient: &http.Client{Timeout: 5 * time.Minute},
+		publicKey:  PublicKey,
 	}
 }
 
-// UpdateTo downloads and applies the update from the given URL.
-// The URL may be a .tar.gz archive (containing the executable) or a raw platform binary.
-func (u *Updater) UpdateTo(downloadURL, targetPath string) error {
-	// Download the archive
-	resp, err := u.httpClient.Get(downloadURL)
+// ErrNoPrevious is returned by Rollback when no previous binary was kept.
+var ErrNoPrevious = errors.New("no previous binary to roll back to")
+
+// PreviousPath is where the binary replaced by the last update is kept.
+func PreviousPath(targetPath string) string {
+	return targetPath + ".previous"
+}
+
+// Verify downloads SHA256SUMS and its signature and checks them against the
+// pinned key. The signed trusted comment must name the release's version
+// ("kaunta v1.2.3"): the tag and version come from the release API, which
+// could otherwise serve an older signed release under a newer tag. Compare
+// versions only after Verify.
+func (u *Updater) Verify(release *Release) error {
+	if strings.TrimSpace(u.publicKey) == "" {
+		return ErrNoPublicKey
+	}
+
+	sumsAsset, sigAsset, err := release.FindChecksums()
 	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
+		return err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed: %s", resp.Status)
+	sums, err := u.download(sumsAsset.BrowserDownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", ChecksumsAsset, err)
+	}
+	sig, err := u.download(sigAsset.BrowserDownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", SignatureAsset, err)
+	}
+	trusted, err := verifySignature(u.publicKey, sums, sig)
+	if err != nil {
+		return fmt.Errorf("%s: %w", ChecksumsAsset, err)
+	}
+	signed, err := signedVersion(trusted)
+	if err != nil {
+		return fmt.Errorf("%s: %w", SignatureAsset, err)
+	}
+	if !signed.EQ(release.Version) {
+		return fmt.Errorf("release %s is signed as v%s", release.TagName, signed)
 	}
 
-	// Read the entire response into memory for extraction
-	archiveData, err := io.ReadAll(resp.Body)
+	checksums, err := parseChecksums(sums)
 	if err != nil {
-		return fmt.Errorf("failed to read archive: %w", err)
-	}
-
-	// Determine binary format: tar.gz archive or raw binary
-	var binary []byte
-	if looksLikeGzip(archiveData) || strings.HasSuffix(strings.ToLower(downloadURL), ".tar.gz") {
-		b, exErr := extractBinary(archiveData, "kaunta")
-		if exErr != nil {
-			// Fallback: if archive extraction fails, assume raw binary
-			binary = archiveData
-		} else {
-			binary = b
+		return fmt.Errorf("%s: %w", ChecksumsAsset, err)
+	}
+	release.checksums = checksums
+	return nil
+}
+
+// UpdateTo downloads the platform archive of release and replaces targetPath
+// with the binary inside it. The release is verified first unless Verify
+// already accepted it, and the archive is checked against the signed
+// SHA256SUMS before anything is written; any mismatch aborts the update.
+// The replaced binary is kept at PreviousPath(targetPath) for Rollback.
+func (u *Updater) UpdateTo(release *Release, targetPath string) error {
+	if release.checksums == nil {
+		if err := u.Verify(release); err != nil {
+			return err
 		}
-	} else {
-		binary = archiveData
 	}
 
-	// Apply the update using go-update
+	asset, err := release.FindAsset()
+	if err != nil {
+		return err
+	}
+	expected, ok := release.checksums[asset.Name]
+	if !ok {
+		return fmt.Errorf("%s has no entry for %s", ChecksumsAsset, asset.Name)
+	}
+
+	archiveData, err := u.download(asset.BrowserDownloadURL)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	if err := verifyChecksum(archiveData, expected); err != nil {
+		return fmt.Errorf("%s: %w", asset.Name, err)
+	}
+
+	binary, err := extractBinary(archiveData, "kaunta")
+	if err != nil {
+		return err
+	}
+
+	return apply(binary, targetPath, PreviousPath(targetPath))
+}
+
+// download fetches url into memory.
+func (u *Updater) download(url string) ([]byte, error) {
+	resp, err := u.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// apply swaps binary in at targetPath, moving the current file to savePath.
+func apply(binary []byte, targetPath, savePath string) error {
 	opts := update.Options{
-		TargetPath: targetPath,
+		TargetPath:  targetPath,
+		OldSavePath: savePath,
 	}
 
 	// Check permissions before attempting update
@@ -81,13 +154,30 @@ func (u *Updater) UpdateTo(downloadURL, targetPath string) error {
 	return nil
 }
 
+// Verify verifies the release using a default Updater.
+func Verify(release *Release) error {
+	return NewUpdater().Verify(release)
+}
+
 // UpdateTo downloads and applies the update using a default Updater.
-func UpdateTo(downloadURL, targetPath string) error {
-	return NewUpdater().UpdateTo(downloadURL, targetPath)
+func UpdateTo(release *Release, targetPath string) error {
+	return NewUpdater().UpdateTo(release, targetPath)
 }
 
-func looksLikeGzip(b []byte) bool {
-	return len(b) >= 2 && b[0] == 0x1f && b[1] == 0x8b
+// Rollback restores the binary kept by the last update. The two files are
+// swapped, so rolling back twice returns to the newer release.
+func Rollback(targetPath string) error {
+	previous, err := os.ReadFile(PreviousPath(targetPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ErrNoPrevious
+		}
+		return fmt.Errorf("failed to read previous binary: %w", err)
+	}
+	if len(previous) == 0 {
+		return ErrNoPrevious
+	}
+	return apply(previous, targetPath, PreviousPath(targetPath))
 }
 
 // extractBinary extracts the named binary from a tar.gz archive.
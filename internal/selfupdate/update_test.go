@@ -0,0 +1,289 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+// testKey is a minisign key pair for signing fixtures
+type testKey struct {
+	id      [minisignKeyIDLen]byte
+	priv    ed25519.PrivateKey
+	pub     string
+	trusted string // trusted comment of signatures
+}
+
+func newTestKey(t *testing.T) *testKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	k := &testKey{id: [minisignKeyIDLen]byte{1, 2, 3, 4, 5, 6, 7, 8}, priv: priv, trusted: "kaunta v1.2.3"}
+	raw := append([]byte(minisignPure), k.id[:]...)
+	k.pub = base64.StdEncoding.EncodeToString(append(raw, pub...))
+	return k
+}
+
+// sign produces a minisign signature file for message
+func (k *testKey) sign(message []byte, hashed bool) []byte {
+	alg, signed := minisignPure, message
+	if hashed {
+		sum := blake2b.Sum512(message)
+		alg, signed = minisignHashed, sum[:]
+	}
+	sig := ed25519.Sign(k.priv, signed)
+	trusted := k.trusted
+	global := ed25519.Sign(k.priv, append(bytes.Clone(sig), trusted...))
+
+	raw := append(append([]byte(alg), k.id[:]...), sig...)
+	return fmt.Appendf(nil, "untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trusted, base64.StdEncoding.EncodeToString(global))
+}
+
+func archive(t *testing.T, name string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(data)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestVerifySignature(t *testing.T) {
+	key := newTestKey(t)
+	message := []byte("abc  kaunta_linux_amd64.tar.gz\n")
+
+	for _, hashed := range []bool{false, true} {
+		trusted, err := verifySignature(key.pub, message, key.sign(message, hashed))
+		require.NoError(t, err)
+		assert.Equal(t, "kaunta v1.2.3", trusted)
+	}
+
+	// Public key files are accepted as well as the bare key
+	pubFile := "untrusted comment: minisign public key 0102030405060708\n" + key.pub + "\n"
+	_, err := verifySignature(pubFile, message, key.sign(message, true))
+	require.NoError(t, err)
+
+	_, err = verifySignature(key.pub, []byte("tampered"), key.sign(message, true))
+	assert.ErrorContains(t, err, "signature verification failed")
+
+	other := newTestKey(t)
+	_, err = verifySignature(other.pub, message, key.sign(message, true))
+	assert.ErrorContains(t, err, "signature verification failed")
+
+	other.id[0] = 9
+	_, err = verifySignature(key.pub, message, other.sign(message, true))
+	assert.ErrorContains(t, err, "signed with key")
+
+	// A forged trusted comment invalidates the global signature
+	forged := bytes.Replace(key.sign(message, true), []byte("kaunta v1.2.3"), []byte("kaunta v9.2.3"), 1)
+	_, err = verifySignature(key.pub, message, forged)
+	assert.ErrorContains(t, err, "trusted comment signature")
+
+	_, err = verifySignature("", message, key.sign(message, true))
+	assert.ErrorIs(t, err, ErrNoPublicKey)
+
+	_, err = verifySignature(key.pub, message, []byte("not a signature"))
+	assert.ErrorContains(t, err, "malformed")
+}
+
+func TestEmbeddedPublicKey(t *testing.T) {
+	// The committed key must parse; without a key line upgrades are refused
+	_, err := parsePublicKey(PublicKey)
+	if err != nil {
+		assert.ErrorIs(t, err, ErrNoPublicKey)
+	}
+}
+
+func TestSignedVersion(t *testing.T) {
+	v, err := signedVersion("kaunta v1.2.3-rc.1")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3-rc.1", v.String())
+
+	_, err = signedVersion("timestamp:1760000000\tfile:SHA256SUMS")
+	assert.ErrorContains(t, err, "doesn't name a release")
+
+	_, err = signedVersion("kaunta vnext")
+	assert.ErrorContains(t, err, "invalid version")
+}
+
+func TestParseChecksums(t *testing.T) {
+	a := sha256.Sum256([]byte("a"))
+	b := sha256.Sum256([]byte("b"))
+	data := fmt.Appendf(nil, "%x  kaunta_linux_amd64.tar.gz\n\n%x *kaunta_darwin_arm64.tar.gz\n", a, b)
+
+	sums, err := parseChecksums(data)
+	require.NoError(t, err)
+	assert.Equal(t, a[:], sums["kaunta_linux_amd64.tar.gz"])
+	assert.Equal(t, b[:], sums["kaunta_darwin_arm64.tar.gz"])
+
+	_, err = parseChecksums([]byte("nothex  file\n"))
+	assert.ErrorContains(t, err, "invalid sha256")
+
+	_, err = parseChecksums(nil)
+	assert.ErrorContains(t, err, "empty")
+}
+
+// releaseServer serves a release whose archive holds binary; sums overrides
+// the checksum file when not nil
+func releaseServer(t *testing.T, key *testKey, binary, sums []byte) *Release {
+	t.Helper()
+	name := fmt.Sprintf("kaunta_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	archiveData := archive(t, fmt.Sprintf("kaunta-%s-%s", runtime.GOOS, runtime.GOARCH), binary)
+	if sums == nil {
+		sums = fmt.Appendf(nil, "%x  %s\n", sha256.Sum256(archiveData), name)
+	}
+	files := map[string][]byte{
+		name:           archiveData,
+		ChecksumsAsset: sums,
+		SignatureAsset: key.sign(sums, true),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path[1:]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	release := &Release{TagName: "v1.2.3"}
+	require.NoError(t, release.parseVersion())
+	for n := range files {
+		release.Assets = append(release.Assets, Asset{Name: n, BrowserDownloadURL: srv.URL + "/" + n})
+	}
+	return release
+}
+
+func TestUpdateToAndRollback(t *testing.T) {
+	key := newTestKey(t)
+	target := filepath.Join(t.TempDir(), "kaunta")
+	require.NoError(t, os.WriteFile(target, []byte("old"), 0755))
+
+	u := &Updater{httpClient: http.DefaultClient, publicKey: key.pub}
+	require.NoError(t, u.UpdateTo(releaseServer(t, key, []byte("new"), nil), target))
+
+	got, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(got))
+	previous, err := os.ReadFile(PreviousPath(target))
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(previous))
+
+	require.NoError(t, Rollback(target))
+	got, err = os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(got))
+
+	// Rolling back again returns to the newer binary
+	require.NoError(t, Rollback(target))
+	got, err = os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(got))
+}
+
+func TestUpdateToRejectsUnverifiedReleases(t *testing.T) {
+	key := newTestKey(t)
+	badSum := sha256.Sum256([]byte("something else"))
+	name := fmt.Sprintf("kaunta_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+
+	cases := map[string]struct {
+		updater *Updater
+		release func() *Release
+		err     string
+	}{
+		"checksum mismatch": {
+			updater: &Updater{httpClient: http.DefaultClient, publicKey: key.pub},
+			release: func() *Release {
+				return releaseServer(t, key, []byte("new"), fmt.Appendf(nil, "%x  %s\n", badSum, name))
+			},
+			err: "checksum mismatch",
+		},
+		"missing entry": {
+			updater: &Updater{httpClient: http.DefaultClient, publicKey: key.pub},
+			release: func() *Release {
+				return releaseServer(t, key, []byte("new"), fmt.Appendf(nil, "%x  other.tar.gz\n", badSum))
+			},
+			err: "no entry for",
+		},
+		"wrong key": {
+			updater: &Updater{httpClient: http.DefaultClient, publicKey: newTestKey(t).pub},
+			release: func() *Release { return releaseServer(t, key, []byte("new"), nil) },
+			err:     "signature verification failed",
+		},
+		"no pinned key": {
+			updater: &Updater{httpClient: http.DefaultClient},
+			release: func() *Release { return releaseServer(t, key, []byte("new"), nil) },
+			err:     ErrNoPublicKey.Error(),
+		},
+		"replayed under another tag": {
+			updater: &Updater{httpClient: http.DefaultClient, publicKey: key.pub},
+			release: func() *Release {
+				old := newTestKey(t)
+				old.priv, old.pub, old.trusted = key.priv, key.pub, "kaunta v1.0.0"
+				return releaseServer(t, old, []byte("old release"), nil)
+			},
+			err: "release v1.2.3 is signed as v1.0.0",
+		},
+		"default trusted comment": {
+			updater: &Updater{httpClient: http.DefaultClient, publicKey: key.pub},
+			release: func() *Release {
+				unversioned := newTestKey(t)
+				unversioned.priv, unversioned.pub, unversioned.trusted = key.priv, key.pub, "timestamp:1760000000\tfile:SHA256SUMS"
+				return releaseServer(t, unversioned, []byte("new"), nil)
+			},
+			err: "doesn't name a release",
+		},
+		"unsigned release": {
+			updater: &Updater{httpClient: http.DefaultClient, publicKey: key.pub},
+			release: func() *Release {
+				r := releaseServer(t, key, []byte("new"), nil)
+				r.Assets = slices.DeleteFunc(r.Assets, func(a Asset) bool { return a.Name == SignatureAsset })
+				return r
+			},
+			err: "no signed checksums",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "kaunta")
+			require.NoError(t, os.WriteFile(target, []byte("old"), 0755))
+
+			err := tc.updater.UpdateTo(tc.release(), target)
+			assert.ErrorContains(t, err, tc.err)
+
+			got, err := os.ReadFile(target)
+			require.NoError(t, err)
+			assert.Equal(t, "old", string(got))
+			assert.NoFileExists(t, PreviousPath(target))
+		})
+	}
+}
+
+func TestRollbackWithoutPrevious(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "kaunta")
+	require.NoError(t, os.WriteFile(target, []byte("current"), 0755))
+	assert.ErrorIs(t, Rollback(target), ErrNoPrevious)
+}
@@ -0,0 +1,129 @@
+// Package spam matches referrer spam domains (fake referrers sent to get a
+// site listed in analytics reports).
+package spam
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+//go:embed spammers.txt
+var builtinList string
+
+const maxDomainLength = 253
+
+// Builtin returns the embedded spam domains, seeded into the database
+func Builtin() []string {
+	domains, _, _ := ParseList(strings.NewReader(builtinList))
+	return domains
+}
+
+// ParseList reads a list in Matomo's referrer-spam-blacklist format: one
+// domain per line. Blank lines and '#' comments are skipped, duplicates are
+// dropped and lines that aren't domains are returned separately.
+func ParseList(r io.Reader) (domains, invalid []string, err error) {
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domain, err := NormalizeDomain(line)
+		if err != nil {
+			invalid = append(invalid, line)
+			continue
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	return domains, invalid, scanner.Err()
+}
+
+// NormalizeDomain validates a spam domain and returns it lowercased without
+// scheme, path, "www." or trailing dot
+func NormalizeDomain(value string) (string, error) {
+	domain := hostOf(value)
+	if domain == "" {
+		return "", fmt.Errorf("domain is required")
+	}
+	if len(domain) > maxDomainLength {
+		return "", fmt.Errorf("domain exceeds %d characters", maxDomainLength)
+	}
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.Contains(domain, "..") {
+		return "", fmt.Errorf("invalid domain %q", value)
+	}
+	for _, r := range domain {
+		if r != '.' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return "", fmt.Errorf("invalid domain %q", value)
+		}
+	}
+	return domain, nil
+}
+
+// hostOf extracts the lowercased host of a URL or bare domain
+func hostOf(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return ""
+	}
+	if !strings.Contains(value, "://") {
+		value = "//" + value
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimSuffix(u.Hostname(), ".")
+	return strings.TrimPrefix(host, "www.")
+}
+
+// List is a set of spam domains. A nil List matches nothing.
+type List struct {
+	domains map[string]struct{}
+}
+
+// NewList builds a List from normalized domains
+func NewList(domains []string) *List {
+	l := &List{domains: make(map[string]struct{}, len(domains))}
+	for _, d := range domains {
+		l.domains[d] = struct{}{}
+	}
+	return l
+}
+
+// Len returns the number of domains
+func (l *List) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.domains)
+}
+
+// Match reports whether a referrer URL (or host) is a listed domain or a
+// subdomain of one, returning the listed domain. "semalt.com" matches
+// semalt.com and x.semalt.com but not notsemalt.com.
+func (l *List) Match(referrer string) (string, bool) {
+	if l.Len() == 0 {
+		return "", false
+	}
+	host := hostOf(referrer)
+	for host != "" {
+		if _, ok := l.domains[host]; ok {
+			return host, true
+		}
+		_, parent, found := strings.Cut(host, ".")
+		if !found || !strings.Contains(parent, ".") {
+			return "", false
+		}
+		host = parent
+	}
+	return "", false
+}
@@ -0,0 +1,61 @@
+package spam
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltin(t *testing.T) {
+	domains := Builtin()
+	assert.Greater(t, len(domains), 100)
+	assert.Contains(t, domains, "semalt.com")
+	assert.Contains(t, domains, "buttons-for-website.com")
+}
+
+func TestParseList(t *testing.T) {
+	domains, invalid, err := ParseList(strings.NewReader("# comment\nSemalt.com\n\nwww.darodar.com\nhttp://priceg.com/x\nsemalt.com\nlocalhost\nbad domain.com\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"semalt.com", "darodar.com", "priceg.com"}, domains)
+	assert.Equal(t, []string{"localhost", "bad domain.com"}, invalid)
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	d, err := NormalizeDomain(" WWW.Example-Spam.com. ")
+	require.NoError(t, err)
+	assert.Equal(t, "example-spam.com", d)
+
+	for _, v := range []string{"", "com", ".com", "a..com", "spam_site.com"} {
+		_, err := NormalizeDomain(v)
+		assert.Error(t, err, v)
+	}
+}
+
+func TestListMatch(t *testing.T) {
+	l := NewList([]string{"semalt.com", "offers.bycontext.com"})
+
+	cases := map[string]string{
+		"https://semalt.com/":             "semalt.com",
+		"http://www.semalt.com/page":      "semalt.com",
+		"https://site3.semalt.com/":       "semalt.com",
+		"semalt.com":                      "semalt.com",
+		"https://offers.bycontext.com/x":  "offers.bycontext.com",
+		"https://a.offers.bycontext.com/": "offers.bycontext.com",
+	}
+	for referrer, want := range cases {
+		got, ok := l.Match(referrer)
+		assert.True(t, ok, referrer)
+		assert.Equal(t, want, got, referrer)
+	}
+
+	for _, referrer := range []string{"", "https://notsemalt.com/", "https://semalt.com.example.org/", "https://bycontext.com/", "https://com/"} {
+		_, ok := l.Match(referrer)
+		assert.False(t, ok, referrer)
+	}
+
+	var empty *List
+	_, ok := empty.Match("https://semalt.com/")
+	assert.False(t, ok)
+}
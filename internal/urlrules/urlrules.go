@@ -0,0 +1,233 @@
+// Package urlrules normalizes page URLs at ingest: case and trailing-slash
+// normalization, query parameter allow/deny lists, regex rewrites to templates
+// (/users/8123/settings -> /users/:id/settings) and named content groups.
+package urlrules
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule kinds
+const (
+	KindRewrite = "rewrite"
+	KindGroup   = "group"
+)
+
+// Trailing slash modes
+const (
+	TrailingSlashKeep  = "keep"
+	TrailingSlashStrip = "strip"
+	TrailingSlashAdd   = "add"
+)
+
+// maxGroupLength matches website_event.content_group VARCHAR(100)
+const maxGroupLength = 100
+
+// Settings are the per-website normalization options
+type Settings struct {
+	Lowercase     bool     `json:"lowercase"`
+	TrailingSlash string   `json:"trailing_slash"`
+	QueryAllow    []string `json:"query_allow,omitempty"` // when set, only these parameters are kept
+	QueryDeny     []string `json:"query_deny,omitempty"`  // always removed
+}
+
+// Rule is a rewrite (Pattern -> Value template) or a content group (Pattern -> group name Value).
+// Patterns are regular expressions matched against the normalized path.
+type Rule struct {
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+	Value   string `json:"value"`
+}
+
+// Result is a normalized page URL
+type Result struct {
+	Path         string `json:"path"`
+	Query        string `json:"query,omitempty"`
+	ContentGroup string `json:"content_group,omitempty"`
+	Rewritten    bool   `json:"rewritten"`
+}
+
+type compiledRule struct {
+	re    *regexp.Regexp
+	value string
+}
+
+// Ruleset is a website's compiled settings and rules
+type Ruleset struct {
+	settings Settings
+	allow    map[string]bool
+	deny     map[string]bool
+	rewrites []compiledRule
+	groups   []compiledRule
+}
+
+// ValidateSettings checks the trailing slash mode
+func ValidateSettings(s Settings) error {
+	switch s.TrailingSlash {
+	case "", TrailingSlashKeep, TrailingSlashStrip, TrailingSlashAdd:
+		return nil
+	}
+	return fmt.Errorf("invalid trailing slash mode %q (valid: keep, strip, add)", s.TrailingSlash)
+}
+
+// ValidateRule checks a rule's kind, pattern and value
+func ValidateRule(r Rule) error {
+	if r.Kind != KindRewrite && r.Kind != KindGroup {
+		return fmt.Errorf("invalid rule kind %q (valid: rewrite, group)", r.Kind)
+	}
+	if strings.TrimSpace(r.Pattern) == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+	}
+	value := strings.TrimSpace(r.Value)
+	switch r.Kind {
+	case KindRewrite:
+		if !strings.HasPrefix(value, "/") {
+			return fmt.Errorf("rewrite template %q must start with /", r.Value)
+		}
+	case KindGroup:
+		if value == "" {
+			return fmt.Errorf("content group name is required")
+		}
+		if len(value) > maxGroupLength {
+			return fmt.Errorf("content group name exceeds %d characters", maxGroupLength)
+		}
+	}
+	return nil
+}
+
+// Compile validates and compiles settings and rules; rules keep their order
+// and the first matching rewrite and group win
+func Compile(s Settings, rules []Rule) (*Ruleset, error) {
+	if err := ValidateSettings(s); err != nil {
+		return nil, err
+	}
+	rs := &Ruleset{settings: s, allow: toSet(s.QueryAllow), deny: toSet(s.QueryDeny)}
+	for _, r := range rules {
+		if err := ValidateRule(r); err != nil {
+			return nil, err
+		}
+		c := compiledRule{re: regexp.MustCompile(r.Pattern), value: strings.TrimSpace(r.Value)}
+		if r.Kind == KindRewrite {
+			rs.rewrites = append(rs.rewrites, c)
+		} else {
+			rs.groups = append(rs.groups, c)
+		}
+	}
+	return rs, nil
+}
+
+// Empty reports whether the ruleset leaves URLs unchanged
+func (rs *Ruleset) Empty() bool {
+	return rs == nil || (!rs.settings.Lowercase &&
+		(rs.settings.TrailingSlash == "" || rs.settings.TrailingSlash == TrailingSlashKeep) &&
+		len(rs.allow) == 0 && len(rs.deny) == 0 &&
+		len(rs.rewrites) == 0 && len(rs.groups) == 0)
+}
+
+// Apply normalizes a path and raw query. A nil ruleset returns them unchanged.
+func (rs *Ruleset) Apply(path, rawQuery string) Result {
+	if rs == nil {
+		return Result{Path: path, Query: rawQuery}
+	}
+
+	if rs.settings.Lowercase {
+		path = strings.ToLower(path)
+	}
+	switch rs.settings.TrailingSlash {
+	case TrailingSlashStrip:
+		if len(path) > 1 {
+			path = strings.TrimRight(path, "/")
+			if path == "" {
+				path = "/"
+			}
+		}
+	case TrailingSlashAdd:
+		if !strings.HasSuffix(path, "/") && !hasExtension(path) {
+			path += "/"
+		}
+	}
+
+	result := Result{Path: path, Query: rs.filterQuery(rawQuery)}
+	for _, r := range rs.rewrites {
+		if r.re.MatchString(path) {
+			result.Path = r.re.ReplaceAllString(path, r.value)
+			result.Rewritten = true
+			break
+		}
+	}
+	for _, g := range rs.groups {
+		if g.re.MatchString(result.Path) {
+			result.ContentGroup = g.value
+			break
+		}
+	}
+	return result
+}
+
+// ApplyURL parses a full or relative URL and normalizes its path and query
+func (rs *Ruleset) ApplyURL(raw string) (Result, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Result{}, err
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	return rs.Apply(path, u.RawQuery), nil
+}
+
+func (rs *Ruleset) filterQuery(rawQuery string) string {
+	if rawQuery == "" || (len(rs.allow) == 0 && len(rs.deny) == 0) {
+		return rawQuery
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return rawQuery
+	}
+	for key := range values {
+		name := strings.ToLower(key)
+		if rs.deny[name] || (len(rs.allow) > 0 && !rs.allow[name]) {
+			values.Del(key)
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, key := range keys {
+		for _, v := range values[key] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(key))
+			b.WriteByte('=')
+			b.WriteString(url.QueryEscape(v))
+		}
+	}
+	return b.String()
+}
+
+func hasExtension(path string) bool {
+	last := path[strings.LastIndex(path, "/")+1:]
+	return strings.Contains(last, ".")
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,82 @@
+package urlrules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRewritesAndGroups(t *testing.T) {
+	rs, err := Compile(Settings{Lowercase: true, TrailingSlash: TrailingSlashStrip}, []Rule{
+		{Kind: KindRewrite, Pattern: `^/users/\d+/settings$`, Value: "/users/:id/settings"},
+		{Kind: KindRewrite, Pattern: `^/orders/([a-z]+)-\d+$`, Value: "/orders/$1-:id"},
+		{Kind: KindGroup, Pattern: `^/docs(/|$)`, Value: "Docs"},
+		{Kind: KindGroup, Pattern: `^/(blog|news)/`, Value: "Blog"},
+		{Kind: KindGroup, Pattern: `^/`, Value: "Other"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		path  string
+		want  string
+		group string
+	}{
+		{"/Users/8123/Settings/", "/users/:id/settings", "Other"},
+		{"/orders/abc-123", "/orders/abc-:id", "Other"},
+		{"/docs/", "/docs", "Docs"},
+		{"/blog/hello-world", "/blog/hello-world", "Blog"},
+		{"/", "/", "Other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result := rs.Apply(tt.path, "")
+			assert.Equal(t, tt.want, result.Path)
+			assert.Equal(t, tt.group, result.ContentGroup)
+		})
+	}
+}
+
+func TestApplyTrailingSlashAdd(t *testing.T) {
+	rs, err := Compile(Settings{TrailingSlash: TrailingSlashAdd}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/pricing/", rs.Apply("/pricing", "").Path)
+	assert.Equal(t, "/robots.txt", rs.Apply("/robots.txt", "").Path)
+	assert.Equal(t, "/", rs.Apply("/", "").Path)
+}
+
+func TestApplyQueryLists(t *testing.T) {
+	rs, err := Compile(Settings{QueryDeny: []string{"token", "Session"}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "page=2&q=go", rs.Apply("/search", "q=go&token=secret&session=1&page=2").Query)
+
+	rs, err = Compile(Settings{QueryAllow: []string{"q"}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "q=go", rs.Apply("/search", "q=go&token=secret").Query)
+	assert.Equal(t, "", rs.Apply("/search", "token=secret").Query)
+}
+
+func TestNilRulesetIsIdentity(t *testing.T) {
+	var rs *Ruleset
+	assert.True(t, rs.Empty())
+	assert.Equal(t, Result{Path: "/A/", Query: "x=1"}, rs.Apply("/A/", "x=1"))
+}
+
+func TestApplyURL(t *testing.T) {
+	rs, err := Compile(Settings{}, []Rule{{Kind: KindRewrite, Pattern: `^/p/\d+$`, Value: "/p/:id"}})
+	require.NoError(t, err)
+	result, err := rs.ApplyURL("https://example.com/p/42?ref=x")
+	require.NoError(t, err)
+	assert.Equal(t, "/p/:id", result.Path)
+	assert.Equal(t, "ref=x", result.Query)
+	assert.True(t, result.Rewritten)
+}
+
+func TestValidateRule(t *testing.T) {
+	assert.NoError(t, ValidateRule(Rule{Kind: KindGroup, Pattern: "^/docs", Value: "Docs"}))
+	assert.Error(t, ValidateRule(Rule{Kind: "redirect", Pattern: "^/", Value: "/"}))
+	assert.Error(t, ValidateRule(Rule{Kind: KindRewrite, Pattern: "([", Value: "/x"}))
+	assert.Error(t, ValidateRule(Rule{Kind: KindRewrite, Pattern: "^/x", Value: "x"}))
+	assert.Error(t, ValidateRule(Rule{Kind: KindGroup, Pattern: "^/x", Value: " "}))
+	assert.Error(t, ValidateSettings(Settings{TrailingSlash: "sometimes"}))
+}
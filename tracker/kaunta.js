@@ -64,6 +64,9 @@
   var trackOutbound = dataset.trackOutbound !== 'false';
   var respectDnt = dataset.respectDnt !== 'false';
   var excludeHash = dataset.excludeHash === 'true';
+  var trackErrors = dataset.trackErrors === 'true';
+  var honorOptOut = dataset.honorOptout === 'true';
+  var tag = dataset.tag || '';
   var domain = dataset.domains || '';
   var domains = domain.split(',').map(function(n) {
     return n.trim().toLowerCase().replace(/:\d+$/, '');
@@ -124,12 +127,15 @@
   var utmParams = getUtmParams();
 
   // Static payload fields that don't change per event
-  var staticPayload = Object.freeze({
+  var staticPayload = {
     website: websiteId,
     hostname: hostname,
     screen: screen,
     language: language
-  });
+  };
+  // data-tag labels every event, e.g. the A/B variant served to this visitor
+  if (tag) staticPayload.tag = tag;
+  Object.freeze(staticPayload);
 
   // ============================================================================
   // ENGAGEMENT & SCROLL TRACKING (from Plausible)
@@ -337,9 +343,10 @@
     try {
       // Determine credentials mode:
       // - 'same-origin' for same-origin requests (enables self-tracking with auth)
+      // - 'include' when data-honor-optout="true" (sends the /optout cookie)
       // - 'omit' for cross-origin requests (privacy-first, no cookies)
       var isSameOrigin = endpoint.indexOf(origin) === 0;
-      var credentialsMode = isSameOrigin ? 'same-origin' : 'omit';
+      var credentialsMode = isSameOrigin ? 'same-origin' : (honorOptOut ? 'include' : 'omit');
 
       // Use sendBeacon for better reliability when page is hidden/unloading
       if (navigator.sendBeacon && document.visibilityState === 'hidden') {
@@ -391,6 +398,43 @@
     send(payload, 'event');
   }
 
+  // ============================================================================
+  // ERROR TRACKING (opt-in via data-track-errors="true")
+  // ============================================================================
+
+  var MAX_ERRORS_PER_PAGE = 10;
+  var errorCount = 0;
+
+  function trackError(error, source, line, column) {
+    if (errorCount >= MAX_ERRORS_PER_PAGE) return;
+
+    var message = error && error.message ? error.message : String(error || '');
+    if (!message) return;
+
+    errorCount++;
+
+    var payload = getBasePayload(false);
+    payload.message = (error && error.name && message.indexOf(error.name) !== 0)
+      ? error.name + ': ' + message
+      : message;
+    if (error && error.stack) payload.stack = String(error.stack);
+    if (source) payload.source = source;
+    if (line) payload.line = line;
+    if (column) payload.column = column;
+
+    send(payload, 'error');
+  }
+
+  function onError(event) {
+    // Ignore resource loading errors (img, script tags) - they have no message
+    if (!event.message && !event.error) return;
+    trackError(event.error || event.message, event.filename, event.lineno, event.colno);
+  }
+
+  function onUnhandledRejection(event) {
+    trackError(event.reason);
+  }
+
   // ============================================================================
   // AUTO-TRACKING: SPA NAVIGATION (from both Umami & Plausible)
   // ============================================================================
@@ -514,6 +558,12 @@
     if (trackOutbound) {
       document.addEventListener('click', onLinkClick, true);
     }
+
+    // Report uncaught errors and unhandled promise rejections
+    if (trackErrors) {
+      window.addEventListener('error', onError);
+      window.addEventListener('unhandledrejection', onUnhandledRejection);
+    }
   }
 
   // ============================================================================
@@ -534,6 +584,9 @@
     // Clear pending pageview
     clearTimeout(pendingPageview);
 
+    window.removeEventListener('error', onError);
+    window.removeEventListener('unhandledrejection', onUnhandledRejection);
+
     // Reset state
     initialized = false;
     engagementListening = false;
@@ -545,6 +598,7 @@
     window.kaunta = {
       track: track,
       trackPageview: trackPageview,
+      trackError: trackError,
       destroy: destroy
     };
   }